@@ -10,3 +10,6 @@ set -o pipefail # prevents errors in a pipeline from being masked
 MODULE_VERSION=${MODULE_VERSION?"define MODULE_VERSION env"} # module version used to set common labels
 
 yq --inplace ".commonLabels.version=\"${MODULE_VERSION}\" | .commonLabels.\"app.kubernetes.io/version\"=\"${MODULE_VERSION}\"" ./config/docker-registry/values.yaml
+
+# pin the pruning job image used when the operator doesn't pass its own image
+yq --inplace ".images.operator.version=\"${MODULE_VERSION}\"" ./config/docker-registry/values.yaml
@@ -0,0 +1,43 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1beta1"
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+)
+
+var _ conversion.Convertible = &DockerRegistry{}
+
+// ConvertTo converts this DockerRegistry to the Hub version (v1beta1)
+func (s *DockerRegistry) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.DockerRegistry)
+	dst.ObjectMeta = *s.ObjectMeta.DeepCopy()
+
+	if err := convertFields(&s.Spec, &dst.Spec); err != nil {
+		return errors.Wrap(err, "while converting spec to v1beta1")
+	}
+	return errors.Wrap(convertFields(&s.Status, &dst.Status), "while converting status to v1beta1")
+}
+
+// ConvertFrom converts the Hub version (v1beta1) to this DockerRegistry
+func (s *DockerRegistry) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.DockerRegistry)
+	s.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	if err := convertFields(&src.Spec, &s.Spec); err != nil {
+		return errors.Wrap(err, "while converting spec from v1beta1")
+	}
+	return errors.Wrap(convertFields(&src.Status, &s.Status), "while converting status from v1beta1")
+}
+
+// convertFields copies fields with the same JSON names between versions,
+// v1beta1 graduates v1alpha1 without schema changes so no field needs to be renamed
+func convertFields(src, dst interface{}) error {
+	data, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
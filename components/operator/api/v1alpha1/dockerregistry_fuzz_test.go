@@ -0,0 +1,70 @@
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// FuzzValidateDockerRegistry decodes random DockerRegistry manifests and checks the validator never panics,
+// rejects objects with the Invalid API error only and accepts valid objects again after the JSON round trip
+func FuzzValidateDockerRegistry(f *testing.F) {
+	seeds := []string{
+		`{}`,
+		`{"spec":{"storage":{"filesystem":{}}}}`,
+		`{"spec":{"storage":{"filesystem":{},"azure":{"secretName":"azure"}}}}`,
+		`{"spec":{"storage":{"s3":{"bucket":"registry","region":"us-east-1","kmsKeyID":"key"}}}}`,
+		`{"spec":{"storage":{"gcs":{"bucket":"registry"},"btpObjectStore":{"secretName":"btp"},"pvc":{"name":"pvc"}}}}`,
+		`{"spec":{"storage":{"deleteEnabled":true},"backup":{"enabled":true}}}`,
+		`{"spec":{"syncPeriod":"1s","pruning":{"enabled":true,"keepTags":0}}}`,
+		`{"spec":{"auth":{"credentialRotation":{"enabled":true}},"proxy":{"enabled":true}}}`,
+		`{"spec":{"autoscaling":{"enabled":true,"minReplicas":3,"maxReplicas":1}}}`,
+		`{"spec":{"resources":{"requests":{"cpu":"2"},"limits":{"cpu":"1"}}}}`,
+		`{"spec":{"extraVolumes":[{"name":"data"}],"extraVolumeMounts":[{"name":"other","mountPath":"/data"}]}}`,
+		`{"spec":{"commonLabels":{"-invalid":"value"},"commonAnnotations":{"example.com/owner":"team-a"}}}`,
+		`{"spec":{"podSecurityContext":{"runAsNonRoot":true}}}`,
+	}
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+
+	validator := &dockerRegistryValidator{client: fake.NewClientBuilder().Build()}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		dr := &DockerRegistry{}
+		if err := json.Unmarshal(data, dr); err != nil {
+			t.Skip()
+		}
+
+		_, err := validator.ValidateCreate(context.Background(), dr)
+		if err != nil {
+			if !apierrors.IsInvalid(err) {
+				t.Fatalf("expected Invalid error, got %T: %v", err, err)
+			}
+			if dr.Spec.Storage != nil && len(dr.Spec.Storage.ConfiguredBackends()) > 1 &&
+				!strings.Contains(err.Error(), "only one storage option can be used") {
+				t.Fatalf("expected storage options to be mutually exclusive, got: %v", err)
+			}
+			return
+		}
+
+		if dr.Spec.Storage != nil && len(dr.Spec.Storage.ConfiguredBackends()) > 1 {
+			t.Fatalf("accepted storage with backends %v", dr.Spec.Storage.ConfiguredBackends())
+		}
+
+		encoded, err := json.Marshal(dr)
+		if err != nil {
+			t.Fatalf("while encoding valid dockerregistry: %v", err)
+		}
+		decoded := &DockerRegistry{}
+		if err := json.Unmarshal(encoded, decoded); err != nil {
+			t.Fatalf("while decoding valid dockerregistry: %v", err)
+		}
+		if _, err := validator.ValidateCreate(context.Background(), decoded); err != nil {
+			t.Fatalf("rejected valid dockerregistry after round trip: %v", err)
+		}
+	})
+}
@@ -17,17 +17,590 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // DockerRegistrySpec defines the desired state of DockerRegistry
 type DockerRegistrySpec struct {
-	// Storage defines the storage configuration ( filesystem / s3 / azure / gcs / btpObjectStore ).
+	// Storage defines the storage configuration ( filesystem / s3 / azure / gcs / btpObjectStore / pvc ).
 	Storage *Storage `json:"storage,omitempty"`
 
 	// ExternalAccess defines the external access configuration.
 	ExternalAccess *ExternalAccess `json:"externalAccess,omitempty"`
+
+	// SyncPeriod defines how often the DockerRegistry is reconciled when nothing changes.
+	// default: 30m
+	SyncPeriod *metav1.Duration `json:"syncPeriod,omitempty"`
+
+	// Auth defines the registry authentication configuration.
+	Auth *Auth `json:"auth,omitempty"`
+
+	// Proxy configures the registry as a pull-through cache of the remote registry.
+	Proxy *Proxy `json:"proxy,omitempty"`
+
+	// TLS defines the certificate used by the registry to serve HTTPS.
+	TLS *TLS `json:"tls,omitempty"`
+
+	// ReadOnly indicates whether the registry rejects all pushes and deletions.
+	// default: false
+	ReadOnly bool `json:"readOnly,omitempty"`
+
+	// GarbageCollection defines the periodic garbage collection of the registry storage,
+	// it requires ReadOnly unless it's a dry run because it deletes blobs of the uploads pushed while it runs.
+	GarbageCollection *GarbageCollection `json:"garbageCollection,omitempty"`
+
+	// Backup defines the periodic VolumeSnapshots of the registry PVC, it's supported only by the filesystem and pvc storage.
+	Backup *Backup `json:"backup,omitempty"`
+
+	// Pruning defines the periodic removal of old images from the registry, it requires storage.deleteEnabled.
+	// Only manifests are deleted, the garbage collection releases the storage used by their layers.
+	Pruning *Pruning `json:"pruning,omitempty"`
+
+	// Replication defines periodic copying of the registry images to other registries, e.g. the registries of other clusters.
+	Replication *Replication `json:"replication,omitempty"`
+
+	// Replicas defines the static number of the registry replicas, it's ignored when Autoscaling is set.
+	// default: 1
+	// +kubebuilder:validation:Minimum=1
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Autoscaling enables the HorizontalPodAutoscaler scaling the registry deployment.
+	Autoscaling *Autoscaling `json:"autoscaling,omitempty"`
+
+	// PodDisruptionBudget configures the PodDisruptionBudget created when the registry runs more than one replica.
+	PodDisruptionBudget *PodDisruptionBudget `json:"podDisruptionBudget,omitempty"`
+
+	// Resources defines the compute resources of the registry container.
+	// default: requests cpu 10m and memory 300Mi, limits cpu 400m and memory 800Mi (used only if neither requests nor limits are set)
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// OverrideImage replaces the registry container image shipped with the chart, e.g. to use a mirror in air-gapped environments.
+	OverrideImage *OverrideImage `json:"overrideImage,omitempty"`
+
+	// ImagePullSecrets defines secrets of the kubernetes.io/dockerconfigjson type used to pull the registry images,
+	// the secrets must exist in the DockerRegistry CR namespace
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// Probes tunes the liveness and readiness probes of the registry container, e.g. for slow environments.
+	Probes *Probes `json:"probes,omitempty"`
+
+	// Log defines the logging configuration of the registry container (not the operator).
+	Log *RegistryLog `json:"log,omitempty"`
+
+	// ExtraEnvVars defines additional environment variables of the registry container, e.g. the registry settings not modeled in the spec,
+	// variables set by the operator can't be overridden
+	ExtraEnvVars []corev1.EnvVar `json:"extraEnvVars,omitempty"`
+
+	// Sidecars defines additional containers of the registry pods, e.g. log shippers,
+	// the registry container name docker-registry can't be used
+	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+
+	// ExtraVolumes defines additional volumes of the registry pods, e.g. with CA certificates or the notifications config
+	ExtraVolumes []corev1.Volume `json:"extraVolumes,omitempty"`
+
+	// ExtraVolumeMounts defines additional mounts of the registry container,
+	// every mount must reference a volume from ExtraVolumes or one of the volumes created by the operator
+	ExtraVolumeMounts []corev1.VolumeMount `json:"extraVolumeMounts,omitempty"`
+
+	// Scheduling defines where the registry pods can be scheduled, e.g. on the dedicated infrastructure node pool.
+	Scheduling *Scheduling `json:"scheduling,omitempty"`
+
+	// TopologySpreadConstraints defines how the registry pods are spread across the cluster topology domains.
+	// default: spread across zones with maxSkew 1 and whenUnsatisfiable ScheduleAnyway (used only if the registry runs more than one replica)
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// PriorityClassName defines the PriorityClass of the registry pods, the previous one is kept if it doesn't exist.
+	// default: dockerregistry-priority created by the operator
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// PodSecurityContext defines the security context of the registry pods merged on top of the chart defaults,
+	// runAsNonRoot can't be set for the whole pod because the init container preparing the storage runs as root
+	PodSecurityContext *corev1.PodSecurityContext `json:"podSecurityContext,omitempty"`
+
+	// ContainerSecurityContext defines the security context of the registry container merged on top of the chart defaults,
+	// the operator mounts an emptyDir volume at /tmp when readOnlyRootFilesystem is set
+	ContainerSecurityContext *corev1.SecurityContext `json:"containerSecurityContext,omitempty"`
+
+	// Istio configures the Istio service mesh resources of the registry.
+	Istio *Istio `json:"istio,omitempty"`
+
+	// NetworkPolicy restricts the ingress traffic to the registry port in clusters without Istio.
+	NetworkPolicy *NetworkPolicy `json:"networkPolicy,omitempty"`
+
+	// Monitoring enables the Prometheus Operator ServiceMonitor scraping the registry metrics.
+	Monitoring *Monitoring `json:"monitoring,omitempty"`
+
+	// Notifications defines endpoints notified about the registry push and pull events.
+	Notifications []RegistryNotification `json:"notifications,omitempty"`
+
+	// ExtraConfig defines the custom registry configuration merged on top of the configuration generated by the operator.
+	ExtraConfig *ExtraConfig `json:"extraConfig,omitempty"`
+
+	// SecretPropagation selects namespaces which receive the registry pull secrets.
+	// default: all namespaces not excluded by the operator
+	SecretPropagation *SecretPropagation `json:"secretPropagation,omitempty"`
+
+	// Hooks define scripts run as Jobs before the registry chart is applied and after the registry is ready.
+	Hooks *Hooks `json:"hooks,omitempty"`
+
+	// CommonLabels defines labels added to the metadata of all resources managed by the operator, e.g. the cost center,
+	// labels set by the operator take precedence
+	CommonLabels map[string]string `json:"commonLabels,omitempty"`
+
+	// CommonAnnotations defines annotations added to the metadata of all resources managed by the operator,
+	// annotations set by the operator take precedence
+	CommonAnnotations map[string]string `json:"commonAnnotations,omitempty"`
+}
+
+type RegistryNotification struct {
+	// Name defines the name of the endpoint
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// URL defines the address the events are sent to
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*(:[0-9]+)?(/[a-z0-9]+(([._]|__|-+)[a-z0-9]+)*)*$`
+	URL string `json:"url"`
+
+	// HeadersSecretName defines the name of the Secret in the DockerRegistry CR namespace,
+	// every key of the Secret is sent as the request header with the key value
+	HeadersSecretName string `json:"headersSecretName,omitempty"`
+
+	// Timeout defines how long to wait for the endpoint response
+	// default: 1s
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Threshold defines how many failures are tolerated before the endpoint is backed off
+	// default: 10
+	// +kubebuilder:validation:Minimum=1
+	Threshold *int32 `json:"threshold,omitempty"`
+
+	// Backoff defines how long to wait before retrying the failed endpoint
+	// default: 1s
+	Backoff *metav1.Duration `json:"backoff,omitempty"`
+}
+
+type ExtraConfig struct {
+	// ConfigMapName defines the name of the ConfigMap with the registry configuration snippet under the config.yml key,
+	// the ConfigMap must exist in the DockerRegistry CR namespace
+	// +kubebuilder:validation:MinLength=1
+	ConfigMapName string `json:"configMapName"`
+}
+
+type NetworkPolicy struct {
+	// Enabled indicates whether the operator creates the NetworkPolicy restricting the ingress traffic to the registry
+	// default: false
+	Enabled bool `json:"enabled,omitempty"`
+
+	// IngressFrom defines peers allowed to reach the registry port
+	// default: all pods from the DockerRegistry CR namespace
+	IngressFrom []networkingv1.NetworkPolicyPeer `json:"ingressFrom,omitempty"`
+}
+
+type Monitoring struct {
+	// Enabled indicates whether the operator creates the ServiceMonitor of the registry metrics service
+	// default: false
+	Enabled bool `json:"enabled,omitempty"`
+
+	// ScrapeInterval defines how often Prometheus scrapes the registry metrics
+	// default: 30s
+	ScrapeInterval *metav1.Duration `json:"scrapeInterval,omitempty"`
+}
+
+type Istio struct {
+	// Enabled indicates whether the operator manages the Istio resources of the registry
+	// default: false
+	Enabled bool `json:"enabled,omitempty"`
+
+	// MTLS defines the mutual TLS mode enforced for the registry workload
+	MTLS *IstioMTLS `json:"mtls,omitempty"`
+
+	// AuthorizationPolicy restricts workloads allowed to reach the registry
+	AuthorizationPolicy *IstioAuthorizationPolicy `json:"authorizationPolicy,omitempty"`
+
+	// VirtualService tunes the HTTP route of the VirtualService created for the external access
+	VirtualService *IstioVirtualService `json:"virtualService,omitempty"`
+
+	// Gateway defines the Istio Gateway created for the external access of the registry
+	Gateway *IstioGateway `json:"gateway,omitempty"`
+}
+
+// +kubebuilder:validation:Enum=STRICT;PERMISSIVE
+type IstioMTLSMode string
+
+const (
+	IstioMTLSModeStrict     IstioMTLSMode = "STRICT"
+	IstioMTLSModePermissive IstioMTLSMode = "PERMISSIVE"
+)
+
+type IstioMTLS struct {
+	// Mode defines the mutual TLS mode, the PeerAuthentication is created only for the STRICT mode
+	// default: PERMISSIVE
+	Mode IstioMTLSMode `json:"mode,omitempty"`
+}
+
+type IstioAuthorizationPolicy struct {
+	// AllowedPrincipals defines Istio principals (SPIFFE identities) allowed to reach the registry port,
+	// the AuthorizationPolicy is not created if the list is empty
+	AllowedPrincipals []string `json:"allowedPrincipals,omitempty"`
+}
+
+type IstioVirtualService struct {
+	// Timeout defines the timeout of the registry HTTP route
+	// default: Istio default
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// Retries defines the retry policy of the registry HTTP route
+	Retries *IstioRetries `json:"retries,omitempty"`
+}
+
+type IstioRetries struct {
+	// Attempts defines the number of retries for a request
+	// +kubebuilder:validation:Minimum=0
+	Attempts int32 `json:"attempts"`
+
+	// PerTryTimeout defines the timeout of every attempt
+	PerTryTimeout *metav1.Duration `json:"perTryTimeout,omitempty"`
+}
+
+type IstioGateway struct {
+	// Create indicates whether the operator creates the Gateway used by the external access
+	// default: false
+	Create bool `json:"create,omitempty"`
+
+	// Selector defines labels of the Istio ingress gateway pods the Gateway is applied to
+	// default: istio=ingressgateway
+	Selector map[string]string `json:"selector,omitempty"`
+
+	// Servers defines the list of servers exposed by the Gateway
+	Servers []IstioGatewayServer `json:"servers,omitempty"`
+}
+
+type IstioGatewayServer struct {
+	// Port defines the port on which the Gateway listens
+	Port IstioGatewayPort `json:"port"`
+
+	// Hosts defines hosts exposed by the Gateway
+	// +kubebuilder:validation:MinItems=1
+	Hosts []string `json:"hosts"`
+
+	// TLS defines the TLS settings of the server
+	TLS *IstioGatewayTLS `json:"tls,omitempty"`
+}
+
+type IstioGatewayPort struct {
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Number uint32 `json:"number"`
+
+	// +kubebuilder:validation:Enum=HTTP;HTTPS;HTTP2;GRPC;TCP;TLS
+	Protocol string `json:"protocol"`
+
+	Name string `json:"name"`
+}
+
+type IstioGatewayTLS struct {
+	// +kubebuilder:validation:Enum=SIMPLE;MUTUAL;PASSTHROUGH;ISTIO_MUTUAL
+	Mode string `json:"mode,omitempty"`
+
+	// CredentialName defines the name of the Secret with the server certificate in the ingress gateway namespace
+	CredentialName string `json:"credentialName,omitempty"`
+}
+
+type RegistryLog struct {
+	// Level defines the registry log level
+	// default: info
+	// +kubebuilder:validation:Enum=debug;info;warn;error
+	Level string `json:"level,omitempty"`
+
+	// Formatter defines the registry log format, e.g. json for the log aggregators parsing the structured logs
+	// default: json
+	// +kubebuilder:validation:Enum=json;text
+	Formatter string `json:"formatter,omitempty"`
+}
+
+type Probes struct {
+	// Readiness defines the timing of the registry readiness probe
+	Readiness *ProbeTiming `json:"readiness,omitempty"`
+
+	// Liveness defines the timing of the registry liveness probe
+	Liveness *ProbeTiming `json:"liveness,omitempty"`
+}
+
+// ProbeTiming defines the probe settings, the Kubernetes defaults are used for the fields which are not set
+type ProbeTiming struct {
+	// +kubebuilder:validation:Minimum=0
+	InitialDelaySeconds *int32 `json:"initialDelaySeconds,omitempty"`
+
+	// +kubebuilder:validation:Minimum=1
+	PeriodSeconds *int32 `json:"periodSeconds,omitempty"`
+
+	// +kubebuilder:validation:Minimum=1
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+
+	// +kubebuilder:validation:Minimum=1
+	FailureThreshold *int32 `json:"failureThreshold,omitempty"`
+}
+
+type Scheduling struct {
+	// NodeSelector defines labels of nodes the registry pods can run on
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations defines taints tolerated by the registry pods
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity defines the node and pod affinity rules of the registry pods
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+}
+
+type OverrideImage struct {
+	// Repository defines the registry image repository, e.g. my-mirror.local/library/registry
+	// +kubebuilder:validation:MinLength=1
+	Repository string `json:"repository"`
+
+	// Tag defines the registry image tag
+	// default: the registry version shipped with the chart
+	Tag string `json:"tag,omitempty"`
+
+	// PullPolicy defines the pull policy of the registry image
+	// default: IfNotPresent
+	// +kubebuilder:validation:Enum=Always;Never;IfNotPresent
+	PullPolicy corev1.PullPolicy `json:"pullPolicy,omitempty"`
+}
+
+type PodDisruptionBudget struct {
+	// MinAvailable defines the number of the registry pods which must stay available during voluntary disruptions
+	// default: 1
+	// +kubebuilder:validation:Minimum=1
+	MinAvailable *int32 `json:"minAvailable,omitempty"`
+}
+
+type Autoscaling struct {
+	// MinReplicas defines the lower limit of the registry replicas
+	// default: 1
+	// +kubebuilder:validation:Minimum=1
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// MaxReplicas defines the upper limit of the registry replicas
+	// +kubebuilder:validation:Minimum=1
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// TargetCPUUtilizationPercentage defines the average CPU utilization of the registry pods the autoscaler keeps
+	// default: 80
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+}
+
+type Auth struct {
+	// HtpasswdSecretName defines the name of the Secret with the `htpasswd` file of additional registry users
+	HtpasswdSecretName string `json:"htpasswdSecretName,omitempty"`
+
+	// TokenAuth replaces the default htpasswd authentication with an external token server
+	TokenAuth *TokenAuth `json:"tokenAuth,omitempty"`
+
+	// CredentialRotation enables the periodic regeneration of the internal registry credentials
+	CredentialRotation *CredentialRotation `json:"credentialRotation,omitempty"`
+}
+
+type CredentialRotation struct {
+	// Interval defines how often the internal registry credentials are regenerated
+	Interval *metav1.Duration `json:"interval"`
+
+	// GracePeriodSeconds defines how long the previous credentials are still accepted after the rotation
+	// default: 300
+	// +kubebuilder:validation:Minimum=0
+	GracePeriodSeconds *int64 `json:"gracePeriodSeconds,omitempty"`
+}
+
+type TokenAuth struct {
+	// Realm defines the URL of the token server which issues the tokens
+	Realm string `json:"realm,omitempty"`
+
+	// Service defines the name of the registry sent to the token server
+	Service string `json:"service,omitempty"`
+
+	// Issuer defines the issuer of the tokens accepted by the registry
+	Issuer string `json:"issuer,omitempty"`
+
+	// RootCertBundleSecretName defines the name of the Secret with the `ca.crt` bundle used to verify signatures of the tokens
+	RootCertBundleSecretName string `json:"rootCertBundleSecretName,omitempty"`
+}
+
+type Proxy struct {
+	// RemoteURL defines the URL of the cached registry (e.g. https://registry-1.docker.io)
+	RemoteURL string `json:"remoteURL"`
+
+	// Username defines the user used to authenticate to the remote registry
+	Username string `json:"username,omitempty"`
+
+	// PasswordSecretRef references the Secret key with the password of the remote registry user
+	PasswordSecretRef *SecretKeyRef `json:"passwordSecretRef,omitempty"`
+
+	// CASecretName defines the name of the Secret with the `ca.crt` PEM certificate trusted when connecting to the remote registry
+	CASecretName string `json:"caSecretName,omitempty"`
+}
+
+type SecretKeyRef struct {
+	// Name defines the name of the Secret in the DockerRegistry namespace
+	Name string `json:"name"`
+
+	// Key defines the key of the Secret
+	// default: password
+	Key string `json:"key,omitempty"`
+}
+
+type TLS struct {
+	// SecretName defines the name of the kubernetes.io/tls Secret (in the DockerRegistry namespace) mounted to the registry
+	SecretName string `json:"secretName,omitempty"`
+
+	// CertManager defines the cert-manager configuration used to provision the registry certificate
+	CertManager *TLSCertManager `json:"certManager,omitempty"`
+}
+
+type TLSCertManager struct {
+	// IssuerRef references the cert-manager Issuer or ClusterIssuer which signs the certificate
+	IssuerRef CertManagerIssuerRef `json:"issuerRef"`
+
+	// Duration defines the requested lifetime of the certificate
+	// default: 2160h
+	Duration *metav1.Duration `json:"duration,omitempty"`
+}
+
+type CertManagerIssuerRef struct {
+	Name string `json:"name"`
+
+	// Kind defines the issuer kind ( Issuer / ClusterIssuer )
+	// default: Issuer
+	Kind string `json:"kind,omitempty"`
+
+	// Group defines the issuer API group
+	// default: cert-manager.io
+	Group string `json:"group,omitempty"`
+}
+
+type GarbageCollection struct {
+	// Schedule defines when the garbage collection runs (in the cron format, e.g. "0 3 * * 0")
+	Schedule string `json:"schedule"`
+
+	// DeleteUntagged indicates whether manifests without any tag are removed as well.
+	// default: false
+	DeleteUntagged bool `json:"deleteUntagged,omitempty"`
+
+	// DryRun indicates whether the garbage collection only reports the blobs eligible for deletion without removing them,
+	// the output of the last run is stored in the dockerregistry.operator.kyma-project.io/last-gc-dry-run annotation.
+	// default: false
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+type Backup struct {
+	// Schedule defines when the snapshot is taken (in the cron format, e.g. "0 2 * * *")
+	Schedule string `json:"schedule"`
+
+	// VolumeSnapshotClassName defines the VolumeSnapshotClass used to snapshot the registry PVC
+	// default: the cluster default VolumeSnapshotClass
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+
+	// RetainCount defines how many latest snapshots are kept, older ones are deleted after each backup
+	// default: 7
+	// +kubebuilder:validation:Minimum=1
+	RetainCount *int32 `json:"retainCount,omitempty"`
+}
+
+type Pruning struct {
+	// Schedule defines when the pruning runs (in the cron format, e.g. "0 1 * * 0")
+	Schedule string `json:"schedule"`
+
+	// MaxAgeDays defines how many days the images are kept after they were built, older ones are deleted
+	// +kubebuilder:validation:Minimum=1
+	MaxAgeDays *int32 `json:"maxAgeDays,omitempty"`
+
+	// MaxTagsPerRepository defines how many most recently built tags are kept in each repository, older ones are deleted
+	// +kubebuilder:validation:Minimum=1
+	MaxTagsPerRepository *int32 `json:"maxTagsPerRepository,omitempty"`
+
+	// JobImage replaces the image of the pruning job, the image must provide the operator binary
+	// default: the operator image shipped with the chart
+	JobImage string `json:"jobImage,omitempty"`
+}
+
+type Replication struct {
+	// Schedule defines when the images are copied to the targets (in the cron format, e.g. "0 * * * *")
+	Schedule string `json:"schedule"`
+
+	// Targets defines the registries the images are copied to, each target is synced by its own CronJob
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=10
+	Targets []ReplicationTarget `json:"targets"`
+
+	// JobImage replaces the image of the replication jobs, the image must provide the skopeo binary
+	// default: the skopeo image shipped with the chart
+	JobImage string `json:"jobImage,omitempty"`
+}
+
+type ReplicationTarget struct {
+	// URL defines the registry host and optional path prefix the images are copied to, e.g. "registry.example.com/mirror"
+	// +kubebuilder:validation:MinLength=1
+	URL string `json:"url"`
+
+	// CredentialSecretName defines the secret of the kubernetes.io/dockerconfigjson type with the target registry credentials,
+	// the secret must exist in the DockerRegistry CR namespace
+	CredentialSecretName string `json:"credentialSecretName"`
+
+	// Repositories defines the repositories copied to the target with all their tags
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:items:Pattern=`^[a-z0-9]+(([._]|__|-+)[a-z0-9]+)*(/[a-z0-9]+(([._]|__|-+)[a-z0-9]+)*)*$`
+	Repositories []string `json:"repositories"`
+}
+
+// +kubebuilder:validation:Enum=AllNamespaces;LabelSelector;AnnotationOptIn
+type SecretPropagationMode string
+
+const (
+	SecretPropagationModeAllNamespaces   SecretPropagationMode = "AllNamespaces"
+	SecretPropagationModeLabelSelector   SecretPropagationMode = "LabelSelector"
+	SecretPropagationModeAnnotationOptIn SecretPropagationMode = "AnnotationOptIn"
+)
+
+type SecretPropagation struct {
+	// Mode defines how the namespaces are selected, AnnotationOptIn selects namespaces
+	// with the dockerregistry.operator.kyma-project.io/inject-secret annotation set to "true"
+	// default: AllNamespaces
+	Mode SecretPropagationMode `json:"mode,omitempty"`
+
+	// NamespaceSelector selects namespaces by their labels, it's required in the LabelSelector mode
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+type Hooks struct {
+	// PreReconcile references scripts run before the registry chart is applied.
+	PreReconcile *Hook `json:"preReconcile,omitempty"`
+
+	// PostReconcile references scripts run after the registry deployment is ready.
+	PostReconcile *Hook `json:"postReconcile,omitempty"`
+
+	// Image of the hook Jobs, it must provide the sh shell.
+	Image string `json:"image"`
+
+	// Timeout limits how long each hook script runs.
+	// default: 5m
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// KeepLastJobs is the number of the most recently completed Jobs of each hook kept in the cluster,
+	// failed Jobs are kept for debugging and must be deleted manually.
+	// default: 3
+	// +kubebuilder:validation:Minimum=0
+	KeepLastJobs *int32 `json:"keepLastJobs,omitempty"`
+}
+
+type Hook struct {
+	// ConfigMapName is the name of the ConfigMap in the DockerRegistry namespace,
+	// each key holds a script run in its own Job in the alphabetical order of the keys.
+	ConfigMapName string `json:"configMapName"`
 }
 
 type ExternalAccess struct {
@@ -45,6 +618,7 @@ type ExternalAccess struct {
 }
 
 type Storage struct {
+	Filesystem     *StorageFilesystem     `json:"filesystem,omitempty"`
 	Azure          *StorageAzure          `json:"azure,omitempty"`
 	S3             *StorageS3             `json:"s3,omitempty"`
 	GCS            *StorageGCS            `json:"gcs,omitempty"`
@@ -53,6 +627,20 @@ type Storage struct {
 	DeleteEnabled  bool                   `json:"deleteEnabled,omitempty"`
 }
 
+type StorageFilesystem struct {
+	// PVCSize defines the size of the PVC created for the registry, it can be only increased
+	PVCSize *resource.Quantity `json:"pvcSize,omitempty"`
+
+	// AlertThresholdPercent defines the PVC usage above which the StoragePressure condition is set
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	AlertThresholdPercent *int32 `json:"alertThresholdPercent,omitempty"`
+
+	// StorageClassName defines the StorageClass of the PVC created for the registry, it can't be changed for the existing PVC
+	// default: the cluster default StorageClass
+	StorageClassName string `json:"storageClassName,omitempty"`
+}
+
 type StorageAzure struct {
 	SecretName string `json:"secretName"`
 }
@@ -81,6 +669,10 @@ type StorageS3 struct {
 	Encrypt        bool   `json:"encrypt,omitempty"`
 	Secure         bool   `json:"secure,omitempty"`
 	SecretName     string `json:"secretName,omitempty"`
+
+	// KMSKeyID is the AWS KMS key ID the objects are encrypted with, it requires Encrypt.
+	// default: the S3 managed key
+	KMSKeyID string `json:"kmsKeyID,omitempty"`
 }
 
 type StorageS3Secrets struct {
@@ -126,9 +718,52 @@ const (
 	// deletion
 	ConditionTypeDeleted = ConditionType("Deleted")
 
+	// storage backend configuration details
+	ConditionTypeStorageReady = ConditionType("StorageReady")
+
+	// cert-manager certificate details
+	ConditionTypeTLSReady = ConditionType("TLSReady")
+
+	// filesystem storage usage details
+	ConditionTypeStoragePressure = ConditionType("StoragePressure")
+
+	// filesystem storage class change details
+	ConditionTypeStorageClassImmutable = ConditionType("StorageClassImmutable")
+
+	// image pull secrets validation details
+	ConditionTypeImagePullSecretMissing = ConditionType("ImagePullSecretMissing")
+
+	// priority class validation details
+	ConditionTypePriorityClassMissing = ConditionType("PriorityClassMissing")
+
+	// sidecar containers compatibility details
+	ConditionTypeSidecarConflict = ConditionType("SidecarConflict")
+
+	// registry deployment rollout blocked by the pod disruption budget
+	ConditionTypeDeploymentUpdateDeferred = ConditionType("DeploymentUpdateDeferred")
+
+	// storage encryption at rest details
+	ConditionTypeEncryptionEnabled = ConditionType("EncryptionEnabled")
+
+	// pre- and post-reconcile hooks details
+	ConditionTypeHooksCompleted = ConditionType("HooksCompleted")
+
+	// chart applied by the previous operator version
+	ConditionTypeChartUpgradePending = ConditionType("ChartUpgradePending")
+
+	// reconciliation phases details
+	ConditionTypeHelmChartApplied = ConditionType("HelmChartApplied")
+	ConditionTypeSecretsReady     = ConditionType("SecretsReady")
+	ConditionTypeDeploymentReady  = ConditionType("DeploymentReady")
+	ConditionTypeNetworkingReady  = ConditionType("NetworkingReady")
+
+	// summary of the reconciliation phases conditions
+	ConditionTypeReady = ConditionType("Ready")
+
 	ConditionReasonConfiguration            = ConditionReason("Configuration")
 	ConditionReasonConfigurationErr         = ConditionReason("ConfigurationErr")
 	ConditionReasonConfigured               = ConditionReason("Configured")
+	ConditionReasonInvalidSpec              = ConditionReason("InvalidSpec")
 	ConditionReasonInstallation             = ConditionReason("Installation")
 	ConditionReasonInstallationErr          = ConditionReason("InstallationErr")
 	ConditionReasonInstalled                = ConditionReason("Installed")
@@ -137,8 +772,55 @@ const (
 	ConditionReasonDeletion                 = ConditionReason("Deletion")
 	ConditionReasonDeletionErr              = ConditionReason("DeletionErr")
 	ConditionReasonDeleted                  = ConditionReason("Deleted")
+	ConditionReasonStorageCleanupErr        = ConditionReason("StorageCleanupErr")
+	ConditionReasonStorageConfigured        = ConditionReason("StorageConfigured")
+	ConditionReasonStorageConfigurationErr  = ConditionReason("StorageConfigurationErr")
+	ConditionReasonStorageSecretMissing     = ConditionReason("StorageSecretMissing")
+	ConditionReasonGCSSecretMissing         = ConditionReason("GCSSecretMissing")
+	ConditionReasonStorageUsageHigh         = ConditionReason("StorageUsageHigh")
+	ConditionReasonStorageUsageNormal       = ConditionReason("StorageUsageNormal")
+	ConditionReasonStorageUsageUnknown      = ConditionReason("StorageUsageUnknown")
+	ConditionReasonStorageClassChanged      = ConditionReason("StorageClassChanged")
+	ConditionReasonStorageEncrypted         = ConditionReason("StorageEncrypted")
+	ConditionReasonStorageNotEncrypted      = ConditionReason("StorageNotEncrypted")
+	ConditionReasonProxyConflict            = ConditionReason("ProxyConflict")
+	ConditionReasonImagePullSecretsFound    = ConditionReason("ImagePullSecretsFound")
+	ConditionReasonImagePullSecretNotFound  = ConditionReason("ImagePullSecretNotFound")
+	ConditionReasonImagePullSecretInvalid   = ConditionReason("ImagePullSecretInvalid")
+	ConditionReasonPriorityClassNotFound    = ConditionReason("PriorityClassNotFound")
+	ConditionReasonIstioProxyPortConflict   = ConditionReason("IstioProxyPortConflict")
+	ConditionReasonChartApplied             = ConditionReason("ChartApplied")
+	ConditionReasonChartApplyErr            = ConditionReason("ChartApplyErr")
+	ConditionReasonNewChartVersion          = ConditionReason("NewChartVersion")
+	ConditionReasonHookRunning              = ConditionReason("HookRunning")
+	ConditionReasonHookFailed               = ConditionReason("HookFailed")
+	ConditionReasonHooksSucceeded           = ConditionReason("HooksSucceeded")
+	ConditionReasonSecretsCreated           = ConditionReason("SecretsCreated")
+	ConditionReasonSecretsMissing           = ConditionReason("SecretsMissing")
+	ConditionReasonDeploymentAvailable      = ConditionReason("DeploymentAvailable")
+	ConditionReasonDeploymentProgressing    = ConditionReason("DeploymentProgressing")
+	ConditionReasonDeploymentErr            = ConditionReason("DeploymentErr")
+	ConditionReasonNetworkingConfigured     = ConditionReason("NetworkingConfigured")
+	ConditionReasonNetworkingErr            = ConditionReason("NetworkingErr")
+	ConditionReasonReady                    = ConditionReason("Ready")
+	ConditionReasonNotReady                 = ConditionReason("NotReady")
+	ConditionReasonPaused                   = ConditionReason("Paused")
+	ConditionReasonDisruptionsNotAllowed    = ConditionReason("DisruptionsNotAllowed")
+	ConditionReasonCertificateIssued        = ConditionReason("CertificateIssued")
+	ConditionReasonCertificatePending       = ConditionReason("CertificatePending")
+	ConditionReasonCertificateErr           = ConditionReason("CertificateErr")
 
 	Finalizer = "dockerregistry-operator.kyma-project.io/deletion-hook"
+	// LastGCDryRunAnnotation stores the output of the last garbage collection dry run
+	LastGCDryRunAnnotation = "dockerregistry.operator.kyma-project.io/last-gc-dry-run"
+	// PausedAnnotation set to "true" stops the reconciliation of the DockerRegistry until it's removed
+	PausedAnnotation = "dockerregistry.operator.kyma-project.io/paused"
+	// CleanupFinalizer is registered after the first successful installation and guards removal of the registry storage
+	CleanupFinalizer = "dockerregistry.operator.kyma-project.io/cleanup"
+	// InjectSecretAnnotation set to "true" on the namespace opts it in to the pull secrets in the AnnotationOptIn mode
+	InjectSecretAnnotation = "dockerregistry.operator.kyma-project.io/inject-secret"
+	// ForceDeleteAnnotation set to "true" allows the deletion of the DockerRegistry still used by pods
+	ForceDeleteAnnotation = "dockerregistry.operator.kyma-project.io/force-delete"
 )
 
 type ExternalNetworkAccess struct {
@@ -186,10 +868,77 @@ type DockerRegistryStatus struct {
 	// +kubebuilder:validation:Enum=True;False
 	Served Served `json:"served"`
 
+	// OperatorVersion signifies the version of the operator which reconciled the DockerRegistry.
+	OperatorVersion string `json:"operatorVersion,omitempty"`
+
+	// ChartVersion signifies the version of the docker-registry chart applied successfully most recently.
+	ChartVersion string `json:"chartVersion,omitempty"`
+
+	// ObservedGeneration is the .metadata.generation of the DockerRegistry reconciled successfully most recently.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// LastAppliedHash is the SHA-256 hash of the chart version and values applied most recently.
+	LastAppliedHash string `json:"lastAppliedHash,omitempty"`
+
+	// LastAppliedTime is the time the chart was applied most recently, without the apply grace period it changes
+	// only when the chart values change.
+	LastAppliedTime *metav1.Time `json:"lastAppliedTime,omitempty"`
+
+	// Replication contains the result of the most recent image copying to each replication target.
+	Replication []ReplicationStatus `json:"replication,omitempty"`
+
+	// SecretSyncStatus contains the state of the namespaces the pull secrets propagation failed in, the key is
+	// the namespace name. At most MaxSecretSyncStatusNamespaces namespaces are listed.
+	SecretSyncStatus map[string]SecretSyncStatus `json:"secretSyncStatus,omitempty"`
+
+	// SecretSyncSummary counts the namespaces of the most recent pull secrets propagation.
+	SecretSyncSummary *SecretSyncSummary `json:"secretSyncSummary,omitempty"`
+
 	// Conditions associated with CustomStatus.
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+type SecretSyncState string
+
+const (
+	SecretSyncStateSynced SecretSyncState = "Synced"
+	SecretSyncStateFailed SecretSyncState = "Failed"
+)
+
+// MaxSecretSyncStatusNamespaces limits the failed namespaces listed in the status to keep the CR size bounded
+const MaxSecretSyncStatusNamespaces = 50
+
+type SecretSyncSummary struct {
+	// SyncedNamespaces is the number of namespaces all pull secrets were copied to.
+	SyncedNamespaces int32 `json:"syncedNamespaces"`
+
+	// FailedNamespaces is the number of namespaces the propagation failed in, including the ones not listed in SecretSyncStatus.
+	FailedNamespaces int32 `json:"failedNamespaces"`
+}
+
+type SecretSyncStatus struct {
+	// State signifies that the pull secrets couldn't be copied to the namespace during the most recent propagation.
+	// +kubebuilder:validation:Enum=Synced;Failed
+	State SecretSyncState `json:"state"`
+
+	// Error contains the reason the most recent propagation failed, it's retried with the backoff.
+	Error string `json:"error,omitempty"`
+
+	// LastTransitionTime is the time the state changed most recently.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+}
+
+type ReplicationStatus struct {
+	// URL identifies the replication target.
+	URL string `json:"url"`
+
+	// LastSyncTime is the time the images were copied to the target successfully most recently.
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+
+	// Error contains the reason the most recent copying failed, it's empty if the copying succeeded.
+	Error string `json:"error,omitempty"`
+}
+
 // +k8s:deepcopy-gen=true
 
 //+kubebuilder:object:root=true
@@ -0,0 +1,760 @@
+package v1alpha1
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func (s *DockerRegistry) SetupWebhookWithManager(mgr ctrl.Manager, opts ...WebhookOption) error {
+	validator := &dockerRegistryValidator{client: mgr.GetAPIReader()}
+	for _, opt := range opts {
+		opt(validator)
+	}
+
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(s).
+		WithValidator(validator).
+		WithDefaulter(&dockerRegistryDefaulter{}).
+		Complete()
+}
+
+// WebhookOption configures the DockerRegistry validating webhook
+// +kubebuilder:object:generate=false
+type WebhookOption func(*dockerRegistryValidator)
+
+// WithDeletionProtection rejects the deletion of the DockerRegistry while pods from not excluded namespaces
+// reference any of the pull secrets in their imagePullSecrets
+func WithDeletionProtection(pullSecretNames []string, isExcludedNamespace func(namespace string) bool) WebhookOption {
+	return func(v *dockerRegistryValidator) {
+		v.pullSecretNames = pullSecretNames
+		v.isExcludedNamespace = isExcludedNamespace
+	}
+}
+
+//+kubebuilder:webhook:path=/mutate-operator-kyma-project-io-v1alpha1-dockerregistry,mutating=true,failurePolicy=fail,sideEffects=None,groups=operator.kyma-project.io,resources=dockerregistries,verbs=create,versions=v1alpha1,name=mdockerregistry.kyma-project.io,admissionReviewVersions=v1
+
+type dockerRegistryDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &dockerRegistryDefaulter{}
+
+func (d *dockerRegistryDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	dockerRegistry, ok := obj.(*DockerRegistry)
+	if !ok {
+		return fmt.Errorf("expected a DockerRegistry object but got %T", obj)
+	}
+
+	// defaulting updates would add the filesystem storage next to the backend the user switches to
+	if req, err := admission.RequestFromContext(ctx); err == nil && req.Operation != admissionv1.Create {
+		return nil
+	}
+
+	dockerRegistry.Default()
+	return nil
+}
+
+// Default sets the filesystem storage when no other storage backend is configured
+func (s *DockerRegistry) Default() {
+	if s.Spec.Storage == nil {
+		s.Spec.Storage = &Storage{}
+	}
+
+	if len(s.Spec.Storage.ConfiguredBackends()) == 0 {
+		s.Spec.Storage.Filesystem = &StorageFilesystem{}
+	}
+}
+
+//+kubebuilder:webhook:path=/validate-operator-kyma-project-io-v1alpha1-dockerregistry,mutating=false,failurePolicy=fail,sideEffects=None,groups=operator.kyma-project.io,resources=dockerregistries,verbs=create;update;delete,versions=v1alpha1,name=vdockerregistry.kyma-project.io,admissionReviewVersions=v1
+
+type dockerRegistryValidator struct {
+	client              client.Reader
+	pullSecretNames     []string
+	isExcludedNamespace func(namespace string) bool
+}
+
+var _ webhook.CustomValidator = &dockerRegistryValidator{}
+
+func (v *dockerRegistryValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	dockerRegistry, ok := obj.(*DockerRegistry)
+	if !ok {
+		return nil, fmt.Errorf("expected a DockerRegistry object but got %T", obj)
+	}
+
+	return nil, v.validate(ctx, dockerRegistry)
+}
+
+func (v *dockerRegistryValidator) ValidateUpdate(ctx context.Context, _, newObj runtime.Object) (admission.Warnings, error) {
+	dockerRegistry, ok := newObj.(*DockerRegistry)
+	if !ok {
+		return nil, fmt.Errorf("expected a DockerRegistry object but got %T", newObj)
+	}
+
+	return nil, v.validate(ctx, dockerRegistry)
+}
+
+func (v *dockerRegistryValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	dockerRegistry, ok := obj.(*DockerRegistry)
+	if !ok {
+		return nil, fmt.Errorf("expected a DockerRegistry object but got %T", obj)
+	}
+
+	// not served registry doesn't own the pull secrets
+	if len(v.pullSecretNames) == 0 || dockerRegistry.IsForceDeleteRequested() || dockerRegistry.Status.Served == ServedFalse {
+		return nil, nil
+	}
+
+	namespaces, err := v.dependentNamespaces(ctx)
+	if apierrors.IsForbidden(err) {
+		// the operator bound to the watched namespaces can't list pods in the whole cluster
+		return admission.Warnings{fmt.Sprintf("pods using the registry secrets can't be verified, the deletion is allowed: %s", err.Error())}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("while listing pods using the registry: %w", err)
+	}
+	if len(namespaces) == 0 {
+		return nil, nil
+	}
+
+	return nil, apierrors.NewForbidden(GroupVersion.WithResource("dockerregistries").GroupResource(), dockerRegistry.GetName(),
+		fmt.Errorf("pods in namespaces %s pull images using the registry secrets, set the %s annotation to \"true\" to delete it anyway",
+			strings.Join(namespaces, ", "), ForceDeleteAnnotation))
+}
+
+// dependentNamespaces returns sorted namespaces with pods referencing the pull secrets
+func (v *dockerRegistryValidator) dependentNamespaces(ctx context.Context) ([]string, error) {
+	found := map[string]bool{}
+	pods := &corev1.PodList{}
+	opts := &client.ListOptions{Limit: 500}
+	for {
+		if err := v.client.List(ctx, pods, opts); err != nil {
+			return nil, err
+		}
+		for i := range pods.Items {
+			if v.dependsOnPullSecrets(&pods.Items[i]) {
+				found[pods.Items[i].Namespace] = true
+			}
+		}
+		if pods.Continue == "" {
+			break
+		}
+		opts.Continue = pods.Continue
+	}
+
+	namespaces := make([]string, 0, len(found))
+	for namespace := range found {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+func (v *dockerRegistryValidator) dependsOnPullSecrets(pod *corev1.Pod) bool {
+	if v.isExcludedNamespace != nil && v.isExcludedNamespace(pod.Namespace) {
+		return false
+	}
+	for _, secret := range pod.Spec.ImagePullSecrets {
+		for _, name := range v.pullSecretNames {
+			if secret.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validate checks the spec and the content of the referenced secrets which can't be verified by the spec only
+func (v *dockerRegistryValidator) validate(ctx context.Context, dr *DockerRegistry) error {
+	if err := dr.Validate(); err != nil {
+		return err
+	}
+
+	errs := validateProxyCASecret(ctx, v.client, field.NewPath("spec", "proxy", "caSecretName"), dr)
+	errs = append(errs, validatePVCAccessMode(ctx, v.client, field.NewPath("spec", "storage", "pvc", "name"), dr)...)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("DockerRegistry").GroupKind(), dr.GetName(), errs)
+}
+
+func validateProxyCASecret(ctx context.Context, c client.Reader, path *field.Path, dr *DockerRegistry) field.ErrorList {
+	name := dr.GetProxyCASecretName()
+	if name == "" {
+		return nil
+	}
+
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, client.ObjectKey{Name: name, Namespace: dr.GetNamespace()}, secret)
+	if apierrors.IsNotFound(err) {
+		// the secret may be created after the CR, the reconciler reports it then
+		return nil
+	}
+	if err != nil {
+		return field.ErrorList{field.InternalError(path, err)}
+	}
+
+	if err := ValidateCABundle(secret.Data[ProxyCASecretKey]); err != nil {
+		return field.ErrorList{field.Invalid(path, name, fmt.Sprintf("%s: %s", ProxyCASecretKey, err.Error()))}
+	}
+	return nil
+}
+
+// ValidateCABundle returns error if data doesn't contain only PEM encoded certificates
+func ValidateCABundle(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("certificate is empty")
+	}
+
+	for rest := data; len(strings.TrimSpace(string(rest))) != 0; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return errors.New("certificate is not PEM encoded")
+		}
+		if block.Type != "CERTIFICATE" {
+			return fmt.Errorf("unexpected PEM block type %s", block.Type)
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("invalid certificate: %s", err.Error())
+		}
+	}
+	return nil
+}
+
+// Validate returns an Invalid error with all problems found in the DockerRegistry spec
+func (s *DockerRegistry) Validate() error {
+	specPath := field.NewPath("spec")
+
+	errs := field.ErrorList{}
+	errs = append(errs, validateStorage(specPath.Child("storage"), s.Spec.Storage)...)
+	errs = append(errs, validateSyncPeriod(specPath.Child("syncPeriod"), s)...)
+	errs = append(errs, validateAuth(specPath.Child("auth"), s.Spec.Auth)...)
+	errs = append(errs, validateCredentialRotation(specPath.Child("auth", "credentialRotation"), s.GetCredentialRotation())...)
+	errs = append(errs, validateProxy(specPath.Child("proxy"), s.Spec.Proxy, s.Spec.Auth)...)
+	errs = append(errs, validateTLS(specPath.Child("tls"), s.Spec.TLS)...)
+	errs = append(errs, validateGarbageCollection(specPath.Child("garbageCollection"), s)...)
+	errs = append(errs, validateBackup(specPath.Child("backup"), s.Spec.Backup, s.Spec.Storage)...)
+	errs = append(errs, validatePruning(specPath.Child("pruning"), s)...)
+	errs = append(errs, validateReplication(specPath.Child("replication"), s)...)
+	errs = append(errs, validateAutoscaling(specPath.Child("autoscaling"), s.Spec.Autoscaling)...)
+	errs = append(errs, validateScaling(specPath, s)...)
+	errs = append(errs, validatePodDisruptionBudget(specPath.Child("podDisruptionBudget", "minAvailable"), s)...)
+	errs = append(errs, validateResources(specPath.Child("resources"), s.Spec.Resources)...)
+	errs = append(errs, validateIstio(specPath.Child("istio"), s.Spec.Istio)...)
+	errs = append(errs, validateExtraEnvVars(specPath.Child("extraEnvVars"), s.Spec.ExtraEnvVars)...)
+	errs = append(errs, validateSidecars(specPath.Child("sidecars"), s.Spec.Sidecars)...)
+	errs = append(errs, validateExtraVolumes(specPath, s.Spec.ExtraVolumes, s.Spec.ExtraVolumeMounts)...)
+	errs = append(errs, validatePodSecurityContext(specPath.Child("podSecurityContext"), s.Spec.PodSecurityContext)...)
+	errs = append(errs, validateSecretPropagation(specPath.Child("secretPropagation"), s.Spec.SecretPropagation)...)
+	errs = append(errs, validateHooks(specPath.Child("hooks"), s.Spec.Hooks)...)
+	errs = append(errs, metav1validation.ValidateLabels(s.Spec.CommonLabels, specPath.Child("commonLabels"))...)
+	errs = append(errs, apivalidation.ValidateAnnotations(s.Spec.CommonAnnotations, specPath.Child("commonAnnotations"))...)
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return apierrors.NewInvalid(GroupVersion.WithKind("DockerRegistry").GroupKind(), s.GetName(), errs)
+}
+
+func validateStorage(path *field.Path, storage *Storage) field.ErrorList {
+	if storage == nil {
+		return nil
+	}
+
+	backends := storage.ConfiguredBackends()
+	if len(backends) > 1 {
+		return field.ErrorList{field.Invalid(path, strings.Join(backends, ", "), "only one storage option can be used")}
+	}
+
+	if storage.S3 != nil && storage.S3.KMSKeyID != "" && !storage.S3.Encrypt {
+		return field.ErrorList{field.Invalid(path.Child("s3", "kmsKeyID"), storage.S3.KMSKeyID, "kmsKeyID requires encrypt")}
+	}
+
+	return nil
+}
+
+func validateSyncPeriod(path *field.Path, s *DockerRegistry) field.ErrorList {
+	if s.Spec.SyncPeriod == nil {
+		return nil
+	}
+
+	if s.Spec.SyncPeriod.Duration < MinSyncPeriod {
+		return field.ErrorList{field.Invalid(path, s.Spec.SyncPeriod.Duration.String(), fmt.Sprintf("must be at least %s", MinSyncPeriod))}
+	}
+
+	return nil
+}
+
+func validateAuth(path *field.Path, auth *Auth) field.ErrorList {
+	// empty tokenAuth is the same as the default htpasswd authentication
+	if auth == nil || auth.TokenAuth == nil || *auth.TokenAuth == (TokenAuth{}) {
+		return nil
+	}
+
+	tokenAuth := auth.TokenAuth
+	tokenAuthPath := path.Child("tokenAuth")
+	errs := field.ErrorList{}
+	if tokenAuth.Realm == "" {
+		errs = append(errs, field.Required(tokenAuthPath.Child("realm"), "realm, service and issuer must be set together"))
+	}
+	if tokenAuth.Service == "" {
+		errs = append(errs, field.Required(tokenAuthPath.Child("service"), "realm, service and issuer must be set together"))
+	}
+	if tokenAuth.Issuer == "" {
+		errs = append(errs, field.Required(tokenAuthPath.Child("issuer"), "realm, service and issuer must be set together"))
+	}
+	if tokenAuth.RootCertBundleSecretName == "" {
+		errs = append(errs, field.Required(tokenAuthPath.Child("rootCertBundleSecretName"), "certificate bundle is required to verify tokens"))
+	}
+
+	return errs
+}
+
+func validateCredentialRotation(path *field.Path, rotation *CredentialRotation) field.ErrorList {
+	if rotation == nil {
+		return nil
+	}
+
+	// the previous credentials must expire before they are replaced again
+	if rotation.Interval.Duration <= rotation.GetGracePeriod() {
+		return field.ErrorList{field.Invalid(path.Child("interval"), rotation.Interval.Duration.String(), fmt.Sprintf("must be longer than the grace period %s", rotation.GetGracePeriod()))}
+	}
+
+	return nil
+}
+
+func validateProxy(path *field.Path, proxy *Proxy, auth *Auth) field.ErrorList {
+	if proxy == nil {
+		return nil
+	}
+
+	errs := field.ErrorList{}
+	if proxy.RemoteURL == "" {
+		errs = append(errs, field.Required(path.Child("remoteURL"), "remote registry URL is required"))
+	}
+	if auth != nil && auth.HtpasswdSecretName != "" {
+		errs = append(errs, field.Forbidden(path, "proxy can't be used together with spec.auth.htpasswdSecretName"))
+	}
+
+	return errs
+}
+
+func validateTLS(path *field.Path, tls *TLS) field.ErrorList {
+	if tls == nil || tls.CertManager == nil {
+		return nil
+	}
+
+	errs := field.ErrorList{}
+	if tls.SecretName != "" {
+		errs = append(errs, field.Forbidden(path.Child("secretName"), "secretName can't be used together with certManager"))
+	}
+	if tls.CertManager.IssuerRef.Name == "" {
+		errs = append(errs, field.Required(path.Child("certManager", "issuerRef", "name"), "issuer name is required"))
+	}
+
+	return errs
+}
+
+func validateGarbageCollection(path *field.Path, s *DockerRegistry) field.ErrorList {
+	gc := s.Spec.GarbageCollection
+	if gc == nil {
+		return nil
+	}
+
+	errs := field.ErrorList{}
+	// the cron expression itself is validated by the api-server when the CronJob is applied
+	if strings.TrimSpace(gc.Schedule) == "" {
+		errs = append(errs, field.Required(path.Child("schedule"), "schedule is required to enable garbage collection"))
+	}
+	// the garbage collection deletes blobs of the uploads pushed while it runs
+	if !gc.DryRun && !s.Spec.ReadOnly {
+		errs = append(errs, field.Forbidden(path, "garbage collection requires spec.readOnly unless dryRun is set"))
+	}
+	// the storage without any backend is an ephemeral volume of the registry pod the job can't mount
+	if s.Spec.Storage != nil && len(s.Spec.Storage.ConfiguredBackends()) == 0 {
+		errs = append(errs, field.Forbidden(path, "garbage collection requires a storage backend set in spec.storage"))
+	}
+	return errs
+}
+
+func validateBackup(path *field.Path, backup *Backup, storage *Storage) field.ErrorList {
+	if backup == nil {
+		return nil
+	}
+
+	errs := field.ErrorList{}
+	if strings.TrimSpace(backup.Schedule) == "" {
+		errs = append(errs, field.Required(path.Child("schedule"), "schedule is required to enable backup"))
+	}
+	// only the filesystem (default) and pvc storage keep the registry data on a PVC which can be snapshotted
+	if storage != nil && storage.Filesystem == nil && storage.PVC == nil && len(storage.ConfiguredBackends()) != 0 {
+		errs = append(errs, field.Invalid(path, strings.Join(storage.ConfiguredBackends(), ", "), "backup is supported only for the filesystem and pvc storage"))
+	}
+
+	return errs
+}
+
+func validateSecretPropagation(path *field.Path, propagation *SecretPropagation) field.ErrorList {
+	if propagation == nil {
+		return nil
+	}
+
+	selectorPath := path.Child("namespaceSelector")
+	if propagation.Mode != SecretPropagationModeLabelSelector {
+		if propagation.NamespaceSelector != nil {
+			return field.ErrorList{field.Forbidden(selectorPath, "namespaceSelector can be used only in the LabelSelector mode")}
+		}
+		return nil
+	}
+	if propagation.NamespaceSelector == nil {
+		return field.ErrorList{field.Required(selectorPath, "namespaceSelector is required in the LabelSelector mode")}
+	}
+	return metav1validation.ValidateLabelSelector(propagation.NamespaceSelector, metav1validation.LabelSelectorValidationOptions{}, selectorPath)
+}
+
+func validateHooks(path *field.Path, hooks *Hooks) field.ErrorList {
+	if hooks == nil {
+		return nil
+	}
+
+	errs := field.ErrorList{}
+	if strings.TrimSpace(hooks.Image) == "" {
+		errs = append(errs, field.Required(path.Child("image"), "image is required to run hooks"))
+	}
+	if hooks.Timeout != nil && hooks.Timeout.Duration <= 0 {
+		errs = append(errs, field.Invalid(path.Child("timeout"), hooks.Timeout.Duration.String(), "must be positive"))
+	}
+	if hooks.PreReconcile != nil && hooks.PreReconcile.ConfigMapName == "" {
+		errs = append(errs, field.Required(path.Child("preReconcile", "configMapName"), "configMapName is required"))
+	}
+	if hooks.PostReconcile != nil && hooks.PostReconcile.ConfigMapName == "" {
+		errs = append(errs, field.Required(path.Child("postReconcile", "configMapName"), "configMapName is required"))
+	}
+	return errs
+}
+
+func validatePruning(path *field.Path, s *DockerRegistry) field.ErrorList {
+	pruning := s.Spec.Pruning
+	if pruning == nil {
+		return nil
+	}
+
+	errs := field.ErrorList{}
+	if strings.TrimSpace(pruning.Schedule) == "" {
+		errs = append(errs, field.Required(path.Child("schedule"), "schedule is required to enable pruning"))
+	}
+	if pruning.MaxAgeDays == nil && pruning.MaxTagsPerRepository == nil {
+		errs = append(errs, field.Required(path, "maxAgeDays or maxTagsPerRepository is required to enable pruning"))
+	}
+	// the registry rejects deleting manifests otherwise
+	if s.Spec.Storage == nil || !s.Spec.Storage.DeleteEnabled {
+		errs = append(errs, field.Forbidden(path, "pruning requires spec.storage.deleteEnabled"))
+	}
+	if s.Spec.ReadOnly {
+		errs = append(errs, field.Forbidden(path, "pruning can't be used together with spec.readOnly"))
+	}
+	// the pruning job authenticates with the internal registry credentials
+	if s.IsTokenAuthEnabled() {
+		errs = append(errs, field.Forbidden(path, "pruning can't be used together with spec.auth.tokenAuth"))
+	}
+
+	return errs
+}
+
+var (
+	// repositoryNameRegexp is the path of the distribution reference grammar, the name of the repository without the domain and tag
+	repositoryNameRegexp = regexp.MustCompile(`^[a-z0-9]+(([._]|__|-+)[a-z0-9]+)*(/[a-z0-9]+(([._]|__|-+)[a-z0-9]+)*)*$`)
+	// registryURLRegexp is host[:port][/path] with the host and the path of the distribution reference grammar
+	registryURLRegexp = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*(:[0-9]+)?(/[a-z0-9]+(([._]|__|-+)[a-z0-9]+)*)*$`)
+)
+
+// validateReplication also guards the replication job script the target urls and repositories are passed to
+func validateReplication(path *field.Path, s *DockerRegistry) field.ErrorList {
+	replication := s.Spec.Replication
+	if replication == nil {
+		return nil
+	}
+
+	errs := field.ErrorList{}
+	if strings.TrimSpace(replication.Schedule) == "" {
+		errs = append(errs, field.Required(path.Child("schedule"), "schedule is required to enable replication"))
+	}
+	// the replication job authenticates with the internal registry credentials
+	if s.IsTokenAuthEnabled() {
+		errs = append(errs, field.Forbidden(path, "replication can't be used together with spec.auth.tokenAuth"))
+	}
+
+	urls := map[string]bool{}
+	for i, target := range replication.Targets {
+		targetPath := path.Child("targets").Index(i)
+		if strings.Contains(target.URL, "://") {
+			errs = append(errs, field.Invalid(targetPath.Child("url"), target.URL, "url must not contain the scheme"))
+		} else if !registryURLRegexp.MatchString(target.URL) {
+			errs = append(errs, field.Invalid(targetPath.Child("url"), target.URL, "url must be a registry host with optional port and path"))
+		}
+		if urls[target.URL] {
+			errs = append(errs, field.Duplicate(targetPath.Child("url"), target.URL))
+		}
+		urls[target.URL] = true
+		if target.CredentialSecretName == "" {
+			errs = append(errs, field.Required(targetPath.Child("credentialSecretName"), "credentialSecretName is required"))
+		}
+		for j, repository := range target.Repositories {
+			if !repositoryNameRegexp.MatchString(repository) {
+				errs = append(errs, field.Invalid(targetPath.Child("repositories").Index(j), repository, "repository must be a name without a tag"))
+			}
+		}
+	}
+
+	return errs
+}
+
+// istioSidecarMissingMsg explains why the registry policies can't be enforced by the Istio sidecar
+const istioSidecarMissingMsg = "the registry pods run without the Istio sidecar because the kubelet pulls images through the NodePort"
+
+func validateIstio(path *field.Path, istio *Istio) field.ErrorList {
+	if istio == nil {
+		return nil
+	}
+
+	errs := field.ErrorList{}
+	if istio.MTLS != nil && istio.MTLS.Mode == IstioMTLSModeStrict {
+		errs = append(errs, field.Forbidden(path.Child("mtls", "mode"), "STRICT mode can't be enforced, "+istioSidecarMissingMsg))
+	}
+	if istio.AuthorizationPolicy != nil && len(istio.AuthorizationPolicy.AllowedPrincipals) != 0 {
+		errs = append(errs, field.Forbidden(path.Child("authorizationPolicy", "allowedPrincipals"), "principals can't be verified, "+istioSidecarMissingMsg))
+	}
+	if istio.Gateway != nil && istio.Gateway.Create && len(istio.Gateway.Servers) == 0 {
+		errs = append(errs, field.Required(path.Child("gateway", "servers"), "at least one server is required to create the gateway"))
+	}
+
+	return errs
+}
+
+// operatorManagedEnvVars are set by the chart from the DockerRegistry spec
+var operatorManagedEnvVars = map[string]bool{
+	"REGISTRY_AUTH":                             true,
+	"REGISTRY_AUTH_HTPASSWD_PATH":               true,
+	"REGISTRY_AUTH_HTPASSWD_REALM":              true,
+	"REGISTRY_AUTH_TOKEN_ISSUER":                true,
+	"REGISTRY_AUTH_TOKEN_REALM":                 true,
+	"REGISTRY_AUTH_TOKEN_ROOTCERTBUNDLE":        true,
+	"REGISTRY_AUTH_TOKEN_SERVICE":               true,
+	"REGISTRY_HTTP_SECRET":                      true,
+	"REGISTRY_HTTP_TLS_CERTIFICATE":             true,
+	"REGISTRY_HTTP_TLS_KEY":                     true,
+	"REGISTRY_PROXY_PASSWORD":                   true,
+	"REGISTRY_PROXY_REMOTEURL":                  true,
+	"REGISTRY_PROXY_USERNAME":                   true,
+	"REGISTRY_STORAGE_AZURE_ACCOUNTKEY":         true,
+	"REGISTRY_STORAGE_AZURE_ACCOUNTNAME":        true,
+	"REGISTRY_STORAGE_AZURE_CONTAINER":          true,
+	"REGISTRY_STORAGE_FILESYSTEM_ROOTDIRECTORY": true,
+	"REGISTRY_STORAGE_GCS_BUCKET":               true,
+	"REGISTRY_STORAGE_GCS_CHUNKSIZE":            true,
+	"REGISTRY_STORAGE_GCS_KEYFILE":              true,
+	"REGISTRY_STORAGE_GCS_ROOTDIRECTORY":        true,
+	"REGISTRY_STORAGE_S3_ACCESSKEY":             true,
+	"REGISTRY_STORAGE_S3_BUCKET":                true,
+	"REGISTRY_STORAGE_S3_ENCRYPT":               true,
+	"REGISTRY_STORAGE_S3_REGION":                true,
+	"REGISTRY_STORAGE_S3_REGIONENDPOINT":        true,
+	"REGISTRY_STORAGE_S3_SECRETKEY":             true,
+	"REGISTRY_STORAGE_S3_SECURE":                true,
+}
+
+func validateExtraEnvVars(path *field.Path, envVars []corev1.EnvVar) field.ErrorList {
+	errs := field.ErrorList{}
+	for i, envVar := range envVars {
+		if strings.HasPrefix(envVar.Name, "REGISTRY_") && operatorManagedEnvVars[envVar.Name] {
+			errs = append(errs, field.Forbidden(path.Index(i).Child("name"), fmt.Sprintf("%s is managed by the operator", envVar.Name)))
+		}
+	}
+
+	return errs
+}
+
+// operatorManagedContainers are the registry pod containers created by the chart
+var operatorManagedContainers = map[string]bool{
+	"docker-registry":   true,
+	"generate-htpasswd": true,
+}
+
+func validateSidecars(path *field.Path, sidecars []corev1.Container) field.ErrorList {
+	errs := field.ErrorList{}
+	for i, sidecar := range sidecars {
+		if operatorManagedContainers[sidecar.Name] {
+			errs = append(errs, field.Forbidden(path.Index(i).Child("name"), fmt.Sprintf("%s is the name of the registry pod container", sidecar.Name)))
+		}
+	}
+
+	return errs
+}
+
+func validatePodSecurityContext(path *field.Path, securityContext *corev1.PodSecurityContext) field.ErrorList {
+	if securityContext == nil || securityContext.RunAsNonRoot == nil || !*securityContext.RunAsNonRoot {
+		return nil
+	}
+	return field.ErrorList{field.Forbidden(path.Child("runAsNonRoot"),
+		"the init container preparing the registry storage runs as root, set runAsNonRoot in containerSecurityContext instead")}
+}
+
+// operatorManagedVolumes are the registry pod volumes created by the chart
+var operatorManagedVolumes = map[string]bool{
+	"data":                  true,
+	"dockerregistry-config": true,
+	"dockerregistry-secret": true,
+	"htpasswd-data":         true,
+	"htpasswd-users":        true,
+	"proxy-ca":              true,
+	"registry-credentials":  true,
+	"registry-tmp":          true,
+	"tls-cert":              true,
+	"token-root-cert":       true,
+}
+
+func validateExtraVolumes(path *field.Path, volumes []corev1.Volume, mounts []corev1.VolumeMount) field.ErrorList {
+	errs := field.ErrorList{}
+	volumeNames := map[string]bool{}
+	for i, volume := range volumes {
+		if operatorManagedVolumes[volume.Name] {
+			errs = append(errs, field.Forbidden(path.Child("extraVolumes").Index(i).Child("name"), fmt.Sprintf("%s is the name of the volume created by the operator", volume.Name)))
+		}
+		volumeNames[volume.Name] = true
+	}
+
+	for i, mount := range mounts {
+		if !volumeNames[mount.Name] && !operatorManagedVolumes[mount.Name] {
+			errs = append(errs, field.Invalid(path.Child("extraVolumeMounts").Index(i).Child("name"), mount.Name, "must reference a volume from spec.extraVolumes or a volume created by the operator"))
+		}
+	}
+
+	return errs
+}
+
+func validateAutoscaling(path *field.Path, autoscaling *Autoscaling) field.ErrorList {
+	if autoscaling == nil {
+		return nil
+	}
+
+	if autoscaling.GetMinReplicas() > autoscaling.MaxReplicas {
+		return field.ErrorList{field.Invalid(path.Child("maxReplicas"), autoscaling.MaxReplicas, "must be greater than or equal to minReplicas")}
+	}
+
+	return nil
+}
+
+// validateScaling rejects more than one replica on the filesystem storage, its ReadWriteOnce volume can't be
+// mounted by pods scheduled to different nodes
+func validateScaling(specPath *field.Path, s *DockerRegistry) field.ErrorList {
+	if !s.usesFilesystemStorage() || !s.hasMultipleReplicas() {
+		return nil
+	}
+
+	path := specPath.Child("replicas")
+	if s.Spec.Autoscaling != nil {
+		path = specPath.Child("autoscaling", "maxReplicas")
+	}
+	return field.ErrorList{field.Forbidden(path, multipleReplicasOnFilesystemMsg)}
+}
+
+// validatePodDisruptionBudget rejects the PodDisruptionBudget which would never allow evicting any registry pod
+func validatePodDisruptionBudget(path *field.Path, s *DockerRegistry) field.ErrorList {
+	replicas := s.GetReplicas()
+	if replicas <= 1 {
+		// the PodDisruptionBudget is created only for many replicas
+		return nil
+	}
+
+	minAvailable := s.GetPodDisruptionBudgetMinAvailable()
+	if minAvailable >= replicas {
+		return field.ErrorList{field.Invalid(path, minAvailable, fmt.Sprintf("must be lower than the %d registry replicas", replicas))}
+	}
+	return nil
+}
+
+const multipleReplicasOnFilesystemMsg = "more than one replica requires the azure, s3, gcs, btpObjectStore or a ReadWriteMany pvc storage"
+
+// usesFilesystemStorage returns true when the registry stores the data on the default filesystem volume
+func (s *DockerRegistry) usesFilesystemStorage() bool {
+	storage := s.Spec.Storage
+	return storage == nil || storage.Filesystem != nil || len(storage.ConfiguredBackends()) == 0
+}
+
+// validatePVCAccessMode rejects more than one replica on the user PVC which can't be mounted by many nodes
+func validatePVCAccessMode(ctx context.Context, c client.Reader, path *field.Path, dr *DockerRegistry) field.ErrorList {
+	storage := dr.Spec.Storage
+	if storage == nil || storage.PVC == nil || !dr.hasMultipleReplicas() {
+		return nil
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	err := c.Get(ctx, client.ObjectKey{Name: storage.PVC.Name, Namespace: dr.GetNamespace()}, pvc)
+	if apierrors.IsNotFound(err) {
+		// the PVC may be created after the CR, the reconciler reports it then
+		return nil
+	}
+	if err != nil {
+		return field.ErrorList{field.InternalError(path, err)}
+	}
+
+	for _, mode := range pvc.Spec.AccessModes {
+		if mode == corev1.ReadWriteMany {
+			return nil
+		}
+	}
+	return field.ErrorList{field.Invalid(path, storage.PVC.Name, "must have the ReadWriteMany access mode to be used by more than one replica")}
+}
+
+func (s *DockerRegistry) hasMultipleReplicas() bool {
+	if s.Spec.Autoscaling != nil {
+		return s.Spec.Autoscaling.MaxReplicas > 1
+	}
+	return s.Spec.Replicas != nil && *s.Spec.Replicas > 1
+}
+
+func validateResources(path *field.Path, resources *corev1.ResourceRequirements) field.ErrorList {
+	if resources == nil {
+		return nil
+	}
+
+	errs := field.ErrorList{}
+	for _, name := range sortedResourceNames(resources.Limits) {
+		limit := resources.Limits[name]
+		request, ok := resources.Requests[name]
+		if ok && limit.Cmp(request) < 0 {
+			errs = append(errs, field.Invalid(path.Child("limits").Key(string(name)), limit.String(),
+				fmt.Sprintf("must be greater than or equal to %s request", name)))
+		}
+	}
+
+	return errs
+}
+
+// sortedResourceNames keeps the order of the validation errors stable
+func sortedResourceNames(list corev1.ResourceList) []corev1.ResourceName {
+	names := make([]corev1.ResourceName, 0, len(list))
+	for name := range list {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
@@ -0,0 +1,807 @@
+package v1alpha1
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestDockerRegistry_Default(t *testing.T) {
+	t.Run("set filesystem storage when storage is empty", func(t *testing.T) {
+		dr := &DockerRegistry{}
+
+		err := (&dockerRegistryDefaulter{}).Default(context.Background(), dr)
+		require.NoError(t, err)
+		require.Equal(t, &Storage{Filesystem: &StorageFilesystem{}}, dr.Spec.Storage)
+	})
+
+	t.Run("set filesystem storage on create", func(t *testing.T) {
+		dr := &DockerRegistry{}
+		ctx := admission.NewContextWithRequest(context.Background(), admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Create},
+		})
+
+		err := (&dockerRegistryDefaulter{}).Default(ctx, dr)
+		require.NoError(t, err)
+		require.Equal(t, &Storage{Filesystem: &StorageFilesystem{}}, dr.Spec.Storage)
+	})
+
+	t.Run("do not default storage on update", func(t *testing.T) {
+		dr := &DockerRegistry{Spec: DockerRegistrySpec{Storage: &Storage{DeleteEnabled: true}}}
+		ctx := admission.NewContextWithRequest(context.Background(), admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Update},
+		})
+
+		err := (&dockerRegistryDefaulter{}).Default(ctx, dr)
+		require.NoError(t, err)
+		require.Equal(t, &Storage{DeleteEnabled: true}, dr.Spec.Storage)
+	})
+
+	t.Run("keep deleteEnabled and set filesystem storage", func(t *testing.T) {
+		dr := &DockerRegistry{Spec: DockerRegistrySpec{Storage: &Storage{DeleteEnabled: true}}}
+
+		dr.Default()
+		require.Equal(t, &Storage{DeleteEnabled: true, Filesystem: &StorageFilesystem{}}, dr.Spec.Storage)
+	})
+
+	t.Run("do not change configured storage", func(t *testing.T) {
+		dr := &DockerRegistry{Spec: DockerRegistrySpec{Storage: &Storage{S3: &StorageS3{Bucket: "bucket"}}}}
+
+		dr.Default()
+		require.Equal(t, &Storage{S3: &StorageS3{Bucket: "bucket"}}, dr.Spec.Storage)
+	})
+}
+
+func TestDockerRegistry_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    DockerRegistrySpec
+		wantErr string
+	}{
+		{
+			name: "empty spec",
+			spec: DockerRegistrySpec{},
+		},
+		{
+			name: "single storage",
+			spec: DockerRegistrySpec{Storage: &Storage{Azure: &StorageAzure{SecretName: "azure"}}},
+		},
+		{
+			name:    "filesystem and s3 storage",
+			spec:    DockerRegistrySpec{Storage: &Storage{Filesystem: &StorageFilesystem{}, S3: &StorageS3{}}},
+			wantErr: "spec.storage: Invalid value: \"filesystem, s3\": only one storage option can be used",
+		},
+		{
+			name: "valid sync period",
+			spec: DockerRegistrySpec{SyncPeriod: &metav1.Duration{Duration: time.Hour}},
+		},
+		{
+			name:    "too short sync period",
+			spec:    DockerRegistrySpec{SyncPeriod: &metav1.Duration{Duration: time.Second}},
+			wantErr: "spec.syncPeriod: Invalid value: \"1s\": must be at least 1m0s",
+		},
+		{
+			name: "token auth",
+			spec: DockerRegistrySpec{Auth: &Auth{TokenAuth: &TokenAuth{
+				Realm:                    "https://auth.example.com/token",
+				Service:                  "registry",
+				Issuer:                   "auth.example.com",
+				RootCertBundleSecretName: "token-ca",
+			}}},
+		},
+		{
+			name: "empty token auth",
+			spec: DockerRegistrySpec{Auth: &Auth{TokenAuth: &TokenAuth{}}},
+		},
+		{
+			name:    "token auth without issuer",
+			spec:    DockerRegistrySpec{Auth: &Auth{TokenAuth: &TokenAuth{Realm: "https://auth.example.com/token", Service: "registry", RootCertBundleSecretName: "token-ca"}}},
+			wantErr: "spec.auth.tokenAuth.issuer: Required value: realm, service and issuer must be set together",
+		},
+		{
+			name:    "token auth without certificate bundle",
+			spec:    DockerRegistrySpec{Auth: &Auth{TokenAuth: &TokenAuth{Realm: "https://auth.example.com/token", Service: "registry", Issuer: "auth.example.com"}}},
+			wantErr: "spec.auth.tokenAuth.rootCertBundleSecretName: Required value",
+		},
+		{
+			name: "credential rotation",
+			spec: DockerRegistrySpec{Auth: &Auth{CredentialRotation: &CredentialRotation{Interval: &metav1.Duration{Duration: 24 * time.Hour}}}},
+		},
+		{
+			name:    "credential rotation interval shorter than grace period",
+			spec:    DockerRegistrySpec{Auth: &Auth{CredentialRotation: &CredentialRotation{Interval: &metav1.Duration{Duration: time.Hour}, GracePeriodSeconds: ptr.To[int64](7200)}}},
+			wantErr: "spec.auth.credentialRotation.interval: Invalid value: \"1h0m0s\": must be longer than the grace period 2h0m0s",
+		},
+		{
+			name: "proxy",
+			spec: DockerRegistrySpec{Proxy: &Proxy{RemoteURL: "https://registry-1.docker.io"}},
+		},
+		{
+			name:    "proxy without remote url",
+			spec:    DockerRegistrySpec{Proxy: &Proxy{Username: "user"}},
+			wantErr: "spec.proxy.remoteURL: Required value",
+		},
+		{
+			name:    "proxy with htpasswd users",
+			spec:    DockerRegistrySpec{Proxy: &Proxy{RemoteURL: "https://registry-1.docker.io"}, Auth: &Auth{HtpasswdSecretName: "users"}},
+			wantErr: "spec.proxy: Forbidden: proxy can't be used together with spec.auth.htpasswdSecretName",
+		},
+		{
+			name: "cert-manager tls",
+			spec: DockerRegistrySpec{TLS: &TLS{CertManager: &TLSCertManager{IssuerRef: CertManagerIssuerRef{Name: "issuer"}}}},
+		},
+		{
+			name:    "cert-manager tls without issuer",
+			spec:    DockerRegistrySpec{TLS: &TLS{CertManager: &TLSCertManager{}}},
+			wantErr: "spec.tls.certManager.issuerRef.name: Required value",
+		},
+		{
+			name:    "cert-manager tls and secret name",
+			spec:    DockerRegistrySpec{TLS: &TLS{SecretName: "tls", CertManager: &TLSCertManager{IssuerRef: CertManagerIssuerRef{Name: "issuer"}}}},
+			wantErr: "spec.tls.secretName: Forbidden: secretName can't be used together with certManager",
+		},
+		{
+			name: "garbage collection with schedule",
+			spec: DockerRegistrySpec{ReadOnly: true, GarbageCollection: &GarbageCollection{Schedule: "0 3 * * 0"}},
+		},
+		{
+			name:    "garbage collection without schedule",
+			spec:    DockerRegistrySpec{ReadOnly: true, GarbageCollection: &GarbageCollection{DeleteUntagged: true}},
+			wantErr: "spec.garbageCollection.schedule: Required value",
+		},
+		{
+			name:    "garbage collection of writable registry",
+			spec:    DockerRegistrySpec{GarbageCollection: &GarbageCollection{Schedule: "0 3 * * 0"}},
+			wantErr: "spec.garbageCollection: Forbidden: garbage collection requires spec.readOnly unless dryRun is set",
+		},
+		{
+			name: "garbage collection dry run of writable registry",
+			spec: DockerRegistrySpec{GarbageCollection: &GarbageCollection{Schedule: "0 3 * * 0", DryRun: true}},
+		},
+		{
+			name:    "garbage collection without storage backend",
+			spec:    DockerRegistrySpec{ReadOnly: true, Storage: &Storage{DeleteEnabled: true}, GarbageCollection: &GarbageCollection{Schedule: "0 3 * * 0"}},
+			wantErr: "spec.garbageCollection: Forbidden: garbage collection requires a storage backend set in spec.storage",
+		},
+		{
+			name: "s3 storage encrypted with kms key",
+			spec: DockerRegistrySpec{Storage: &Storage{S3: &StorageS3{Bucket: "registry", Encrypt: true, KMSKeyID: "key"}}},
+		},
+		{
+			name:    "s3 storage kms key without encryption",
+			spec:    DockerRegistrySpec{Storage: &Storage{S3: &StorageS3{Bucket: "registry", KMSKeyID: "key"}}},
+			wantErr: "spec.storage.s3.kmsKeyID: Invalid value: \"key\": kmsKeyID requires encrypt",
+		},
+		{
+			name: "pruning",
+			spec: DockerRegistrySpec{
+				Storage: &Storage{DeleteEnabled: true},
+				Pruning: &Pruning{Schedule: "0 1 * * 0", MaxAgeDays: ptr.To[int32](30)},
+			},
+		},
+		{
+			name: "pruning without limits",
+			spec: DockerRegistrySpec{
+				Storage: &Storage{DeleteEnabled: true},
+				Pruning: &Pruning{Schedule: "0 1 * * 0"},
+			},
+			wantErr: "spec.pruning: Required value: maxAgeDays or maxTagsPerRepository is required to enable pruning",
+		},
+		{
+			name:    "pruning without delete enabled",
+			spec:    DockerRegistrySpec{Pruning: &Pruning{Schedule: "0 1 * * 0", MaxTagsPerRepository: ptr.To[int32](10)}},
+			wantErr: "spec.pruning: Forbidden: pruning requires spec.storage.deleteEnabled",
+		},
+		{
+			name: "pruning of read-only registry",
+			spec: DockerRegistrySpec{
+				Storage:  &Storage{DeleteEnabled: true},
+				ReadOnly: true,
+				Pruning:  &Pruning{Schedule: "0 1 * * 0", MaxTagsPerRepository: ptr.To[int32](10)},
+			},
+			wantErr: "spec.pruning: Forbidden: pruning can't be used together with spec.readOnly",
+		},
+		{
+			name: "replication",
+			spec: DockerRegistrySpec{Replication: &Replication{Schedule: "0 * * * *", Targets: []ReplicationTarget{
+				{URL: "registry.example.com/mirror", CredentialSecretName: "mirror-creds", Repositories: []string{"app"}},
+			}}},
+		},
+		{
+			name: "replication target with scheme",
+			spec: DockerRegistrySpec{Replication: &Replication{Schedule: "0 * * * *", Targets: []ReplicationTarget{
+				{URL: "https://registry.example.com", CredentialSecretName: "mirror-creds", Repositories: []string{"app"}},
+			}}},
+			wantErr: "spec.replication.targets[0].url: Invalid value: \"https://registry.example.com\": url must not contain the scheme",
+		},
+		{
+			name: "replication of tagged repository",
+			spec: DockerRegistrySpec{Replication: &Replication{Schedule: "0 * * * *", Targets: []ReplicationTarget{
+				{URL: "registry.example.com", CredentialSecretName: "mirror-creds", Repositories: []string{"app:v1"}},
+			}}},
+			wantErr: "spec.replication.targets[0].repositories[0]: Invalid value: \"app:v1\": repository must be a name without a tag",
+		},
+		{
+			name: "replication target with port and path",
+			spec: DockerRegistrySpec{Replication: &Replication{Schedule: "0 * * * *", Targets: []ReplicationTarget{
+				{URL: "registry.example.com:5000/team-a/mirror", CredentialSecretName: "mirror-creds", Repositories: []string{"tools/image-builder", "app_v2"}},
+			}}},
+		},
+		{
+			name: "replication target with shell command",
+			spec: DockerRegistrySpec{Replication: &Replication{Schedule: "0 * * * *", Targets: []ReplicationTarget{
+				{URL: "registry.example.com;id", CredentialSecretName: "mirror-creds", Repositories: []string{"app"}},
+			}}},
+			wantErr: "spec.replication.targets[0].url: Invalid value: \"registry.example.com;id\": url must be a registry host with optional port and path",
+		},
+		{
+			name: "replication of repository with shell command",
+			spec: DockerRegistrySpec{Replication: &Replication{Schedule: "0 * * * *", Targets: []ReplicationTarget{
+				{URL: "registry.example.com", CredentialSecretName: "mirror-creds", Repositories: []string{"app;wget example.com|sh"}},
+			}}},
+			wantErr: "spec.replication.targets[0].repositories[0]: Invalid value: \"app;wget example.com|sh\": repository must be a name without a tag",
+		},
+		{
+			name: "replication to duplicated targets",
+			spec: DockerRegistrySpec{Replication: &Replication{Schedule: "0 * * * *", Targets: []ReplicationTarget{
+				{URL: "registry.example.com", CredentialSecretName: "mirror-creds", Repositories: []string{"app"}},
+				{URL: "registry.example.com", CredentialSecretName: "other-creds", Repositories: []string{"other"}},
+			}}},
+			wantErr: "spec.replication.targets[1].url: Duplicate value: \"registry.example.com\"",
+		},
+		{
+			name: "backup of default storage",
+			spec: DockerRegistrySpec{Backup: &Backup{Schedule: "0 2 * * *"}},
+		},
+		{
+			name: "backup of pvc storage",
+			spec: DockerRegistrySpec{
+				Storage: &Storage{PVC: &StoragePVC{Name: "registry"}},
+				Backup:  &Backup{Schedule: "0 2 * * *", RetainCount: ptr.To[int32](3)},
+			},
+		},
+		{
+			name:    "backup without schedule",
+			spec:    DockerRegistrySpec{Backup: &Backup{}},
+			wantErr: "spec.backup.schedule: Required value",
+		},
+		{
+			name: "backup of object storage",
+			spec: DockerRegistrySpec{
+				Storage: &Storage{S3: &StorageS3{Bucket: "registry"}},
+				Backup:  &Backup{Schedule: "0 2 * * *"},
+			},
+			wantErr: "spec.backup: Invalid value: \"s3\": backup is supported only for the filesystem and pvc storage",
+		},
+		{
+			name: "autoscaling",
+			spec: DockerRegistrySpec{
+				Storage:     &Storage{S3: &StorageS3{Bucket: "registry"}},
+				Autoscaling: &Autoscaling{MinReplicas: ptr.To[int32](2), MaxReplicas: 5},
+			},
+		},
+		{
+			name:    "autoscaling on default filesystem storage",
+			spec:    DockerRegistrySpec{Autoscaling: &Autoscaling{MaxReplicas: 3}},
+			wantErr: "spec.autoscaling.maxReplicas: Forbidden: more than one replica requires",
+		},
+		{
+			name: "autoscaling with one max replica on filesystem storage",
+			spec: DockerRegistrySpec{
+				Storage:     &Storage{Filesystem: &StorageFilesystem{}},
+				Autoscaling: &Autoscaling{MaxReplicas: 1},
+			},
+		},
+		{
+			name:    "replicas on filesystem storage",
+			spec:    DockerRegistrySpec{Storage: &Storage{Filesystem: &StorageFilesystem{}}, Replicas: ptr.To[int32](2)},
+			wantErr: "spec.replicas: Forbidden: more than one replica requires",
+		},
+		{
+			name: "replicas on object storage",
+			spec: DockerRegistrySpec{Storage: &Storage{GCS: &StorageGCS{Bucket: "registry"}}, Replicas: ptr.To[int32](3)},
+		},
+		{
+			name: "replicas on pvc storage",
+			spec: DockerRegistrySpec{Storage: &Storage{PVC: &StoragePVC{Name: "registry"}}, Replicas: ptr.To[int32](3)},
+		},
+		{
+			name: "pod disruption budget allowing evictions",
+			spec: DockerRegistrySpec{
+				Storage:             &Storage{GCS: &StorageGCS{Bucket: "registry"}},
+				Replicas:            ptr.To[int32](3),
+				PodDisruptionBudget: &PodDisruptionBudget{MinAvailable: ptr.To[int32](2)},
+			},
+		},
+		{
+			name: "pod disruption budget with min available equal to replicas",
+			spec: DockerRegistrySpec{
+				Storage:             &Storage{GCS: &StorageGCS{Bucket: "registry"}},
+				Replicas:            ptr.To[int32](3),
+				PodDisruptionBudget: &PodDisruptionBudget{MinAvailable: ptr.To[int32](3)},
+			},
+			wantErr: "spec.podDisruptionBudget.minAvailable: Invalid value: 3: must be lower than the 3 registry replicas",
+		},
+		{
+			name: "pod disruption budget with min available above autoscaling min replicas",
+			spec: DockerRegistrySpec{
+				Storage:             &Storage{S3: &StorageS3{Bucket: "registry"}},
+				Autoscaling:         &Autoscaling{MinReplicas: ptr.To[int32](2), MaxReplicas: 5},
+				PodDisruptionBudget: &PodDisruptionBudget{MinAvailable: ptr.To[int32](4)},
+			},
+			wantErr: "spec.podDisruptionBudget.minAvailable: Invalid value: 4: must be lower than the 2 registry replicas",
+		},
+		{
+			name: "pod disruption budget ignored for single replica",
+			spec: DockerRegistrySpec{PodDisruptionBudget: &PodDisruptionBudget{MinAvailable: ptr.To[int32](3)}},
+		},
+		{
+			name:    "autoscaling with max replicas lower than default min replicas",
+			spec:    DockerRegistrySpec{Autoscaling: &Autoscaling{}},
+			wantErr: "spec.autoscaling.maxReplicas: Invalid value: 0: must be greater than or equal to minReplicas",
+		},
+		{
+			name: "resources",
+			spec: DockerRegistrySpec{Resources: &corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+				Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+			}},
+		},
+		{
+			name: "resources with limit lower than request",
+			spec: DockerRegistrySpec{Resources: &corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+			}},
+			wantErr: "spec.resources.limits[memory]: Invalid value: \"512Mi\": must be greater than or equal to memory request",
+		},
+		{
+			name: "istio gateway with servers",
+			spec: DockerRegistrySpec{Istio: &Istio{Gateway: &IstioGateway{
+				Create:  true,
+				Servers: []IstioGatewayServer{{Port: IstioGatewayPort{Number: 443, Name: "https", Protocol: "HTTPS"}, Hosts: []string{"registry.example.com"}}},
+			}}},
+		},
+		{
+			name:    "istio strict mtls",
+			spec:    DockerRegistrySpec{Istio: &Istio{Enabled: true, MTLS: &IstioMTLS{Mode: IstioMTLSModeStrict}}},
+			wantErr: "spec.istio.mtls.mode: Forbidden: STRICT mode can't be enforced, the registry pods run without the Istio sidecar because the kubelet pulls images through the NodePort",
+		},
+		{
+			name: "istio permissive mtls",
+			spec: DockerRegistrySpec{Istio: &Istio{Enabled: true, MTLS: &IstioMTLS{Mode: IstioMTLSModePermissive}}},
+		},
+		{
+			name: "istio authorization policy",
+			spec: DockerRegistrySpec{Istio: &Istio{Enabled: true, AuthorizationPolicy: &IstioAuthorizationPolicy{
+				AllowedPrincipals: []string{"cluster.local/ns/default/sa/builder"},
+			}}},
+			wantErr: "spec.istio.authorizationPolicy.allowedPrincipals: Forbidden: principals can't be verified, the registry pods run without the Istio sidecar because the kubelet pulls images through the NodePort",
+		},
+		{
+			name:    "istio gateway without servers",
+			spec:    DockerRegistrySpec{Istio: &Istio{Gateway: &IstioGateway{Create: true}}},
+			wantErr: "spec.istio.gateway.servers: Required value: at least one server is required to create the gateway",
+		},
+		{
+			name: "extra env vars",
+			spec: DockerRegistrySpec{ExtraEnvVars: []corev1.EnvVar{{Name: "REGISTRY_LOG_LEVEL", Value: "debug"}, {Name: "HTTP_PROXY", Value: "http://proxy:3128"}}},
+		},
+		{
+			name:    "extra env var managed by the operator",
+			spec:    DockerRegistrySpec{ExtraEnvVars: []corev1.EnvVar{{Name: "REGISTRY_LOG_LEVEL", Value: "debug"}, {Name: "REGISTRY_HTTP_SECRET", Value: "secret"}}},
+			wantErr: "spec.extraEnvVars[1].name: Forbidden: REGISTRY_HTTP_SECRET is managed by the operator",
+		},
+		{
+			name: "sidecars",
+			spec: DockerRegistrySpec{Sidecars: []corev1.Container{{Name: "fluent-bit", Image: "fluent/fluent-bit"}}},
+		},
+		{
+			name:    "sidecar with registry container name",
+			spec:    DockerRegistrySpec{Sidecars: []corev1.Container{{Name: "docker-registry", Image: "fluent/fluent-bit"}}},
+			wantErr: "spec.sidecars[0].name: Forbidden: docker-registry is the name of the registry pod container",
+		},
+		{
+			name: "extra volumes",
+			spec: DockerRegistrySpec{
+				ExtraVolumes:      []corev1.Volume{{Name: "ca", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "ca"}}}}},
+				ExtraVolumeMounts: []corev1.VolumeMount{{Name: "ca", MountPath: "/etc/ca"}, {Name: "tls-cert", MountPath: "/etc/notifications-tls"}},
+			},
+		},
+		{
+			name: "extra volume mount without volume",
+			spec: DockerRegistrySpec{
+				ExtraVolumeMounts: []corev1.VolumeMount{{Name: "ca", MountPath: "/etc/ca"}},
+			},
+			wantErr: "spec.extraVolumeMounts[0].name: Invalid value: \"ca\": must reference a volume from spec.extraVolumes or a volume created by the operator",
+		},
+		{
+			name: "extra volume with operator volume name",
+			spec: DockerRegistrySpec{
+				ExtraVolumes: []corev1.Volume{{Name: "data", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}},
+			},
+			wantErr: "spec.extraVolumes[0].name: Forbidden: data is the name of the volume created by the operator",
+		},
+		{
+			name: "pod and container security context",
+			spec: DockerRegistrySpec{
+				PodSecurityContext:       &corev1.PodSecurityContext{RunAsUser: ptr.To[int64](2000)},
+				ContainerSecurityContext: &corev1.SecurityContext{RunAsNonRoot: ptr.To(true), ReadOnlyRootFilesystem: ptr.To(true)},
+			},
+		},
+		{
+			name:    "pod security context with runAsNonRoot",
+			spec:    DockerRegistrySpec{PodSecurityContext: &corev1.PodSecurityContext{RunAsNonRoot: ptr.To(true)}},
+			wantErr: "spec.podSecurityContext.runAsNonRoot: Forbidden: the init container preparing the registry storage runs as root",
+		},
+		{
+			name: "secret propagation with label selector",
+			spec: DockerRegistrySpec{SecretPropagation: &SecretPropagation{
+				Mode:              SecretPropagationModeLabelSelector,
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+			}},
+		},
+		{
+			name:    "secret propagation with label selector mode without selector",
+			spec:    DockerRegistrySpec{SecretPropagation: &SecretPropagation{Mode: SecretPropagationModeLabelSelector}},
+			wantErr: "spec.secretPropagation.namespaceSelector: Required value: namespaceSelector is required in the LabelSelector mode",
+		},
+		{
+			name: "secret propagation with invalid label selector",
+			spec: DockerRegistrySpec{SecretPropagation: &SecretPropagation{
+				Mode: SecretPropagationModeLabelSelector,
+				NamespaceSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "team", Operator: metav1.LabelSelectorOpIn},
+				}},
+			}},
+			wantErr: "spec.secretPropagation.namespaceSelector.matchExpressions[0].values: Required value",
+		},
+		{
+			name: "secret propagation with selector in annotation opt-in mode",
+			spec: DockerRegistrySpec{SecretPropagation: &SecretPropagation{
+				Mode:              SecretPropagationModeAnnotationOptIn,
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+			}},
+			wantErr: "spec.secretPropagation.namespaceSelector: Forbidden: namespaceSelector can be used only in the LabelSelector mode",
+		},
+		{
+			name: "valid hooks",
+			spec: DockerRegistrySpec{Hooks: &Hooks{
+				PreReconcile:  &Hook{ConfigMapName: "pre-hooks"},
+				PostReconcile: &Hook{ConfigMapName: "post-hooks"},
+				Image:         "alpine:3.20",
+				Timeout:       &metav1.Duration{Duration: time.Minute},
+			}},
+		},
+		{
+			name:    "hooks without image",
+			spec:    DockerRegistrySpec{Hooks: &Hooks{PreReconcile: &Hook{ConfigMapName: "pre-hooks"}}},
+			wantErr: "spec.hooks.image: Required value: image is required to run hooks",
+		},
+		{
+			name: "hooks with non-positive timeout",
+			spec: DockerRegistrySpec{Hooks: &Hooks{
+				Image:   "alpine:3.20",
+				Timeout: &metav1.Duration{},
+			}},
+			wantErr: "spec.hooks.timeout: Invalid value: \"0s\": must be positive",
+		},
+		{
+			name: "hook without config map name",
+			spec: DockerRegistrySpec{Hooks: &Hooks{
+				PostReconcile: &Hook{},
+				Image:         "alpine:3.20",
+			}},
+			wantErr: "spec.hooks.postReconcile.configMapName: Required value: configMapName is required",
+		},
+		{
+			name: "valid common metadata",
+			spec: DockerRegistrySpec{
+				CommonLabels:      map[string]string{"example.com/cost-center": "1234"},
+				CommonAnnotations: map[string]string{"example.com/owner": "team a"},
+			},
+		},
+		{
+			name:    "invalid common label value",
+			spec:    DockerRegistrySpec{CommonLabels: map[string]string{"cost-center": "team a"}},
+			wantErr: "spec.commonLabels: Invalid value: \"team a\"",
+		},
+		{
+			name:    "invalid common annotation key",
+			spec:    DockerRegistrySpec{CommonAnnotations: map[string]string{"owner/": "team a"}},
+			wantErr: "spec.commonAnnotations: Invalid value: \"owner/\"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dr := &DockerRegistry{
+				ObjectMeta: metav1.ObjectMeta{Name: "test"},
+				Spec:       tt.spec,
+			}
+
+			validator := &dockerRegistryValidator{client: fake.NewClientBuilder().Build()}
+			_, err := validator.ValidateCreate(context.Background(), dr)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.True(t, apierrors.IsInvalid(err))
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestDockerRegistryValidator_ProxyCASecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		caData  []byte
+		wantErr string
+	}{
+		{
+			name:   "valid certificate",
+			caData: fixPEMCertificate(t),
+		},
+		{
+			name:    "data is not PEM encoded",
+			caData:  []byte("not a certificate"),
+			wantErr: "spec.proxy.caSecretName: Invalid value: \"proxy-ca\": ca.crt: certificate is not PEM encoded",
+		},
+		{
+			name:    "PEM block is not a certificate",
+			caData:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("key")}),
+			wantErr: "ca.crt: unexpected PEM block type PRIVATE KEY",
+		},
+		{
+			name:    "missing ca.crt key",
+			wantErr: "ca.crt: certificate is empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "proxy-ca", Namespace: "kyma-system"},
+				Data:       map[string][]byte{},
+			}
+			if tt.caData != nil {
+				secret.Data[ProxyCASecretKey] = tt.caData
+			}
+			validator := &dockerRegistryValidator{client: fake.NewClientBuilder().WithObjects(secret).Build()}
+			dr := &DockerRegistry{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "kyma-system"},
+				Spec: DockerRegistrySpec{
+					Proxy: &Proxy{RemoteURL: "https://registry.internal", CASecretName: "proxy-ca"},
+				},
+			}
+
+			_, err := validator.ValidateCreate(context.Background(), dr)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.True(t, apierrors.IsInvalid(err))
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("accept CR created before the secret", func(t *testing.T) {
+		validator := &dockerRegistryValidator{client: fake.NewClientBuilder().Build()}
+		dr := &DockerRegistry{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "kyma-system"},
+			Spec: DockerRegistrySpec{
+				Proxy: &Proxy{RemoteURL: "https://registry.internal", CASecretName: "proxy-ca"},
+			},
+		}
+
+		_, err := validator.ValidateCreate(context.Background(), dr)
+		require.NoError(t, err)
+	})
+}
+
+func TestDockerRegistryValidator_PVCAccessMode(t *testing.T) {
+	tests := []struct {
+		name        string
+		accessModes []corev1.PersistentVolumeAccessMode
+		replicas    int32
+		wantErr     string
+	}{
+		{
+			name:        "many replicas on ReadWriteMany pvc",
+			accessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce, corev1.ReadWriteMany},
+			replicas:    3,
+		},
+		{
+			name:        "many replicas on ReadWriteOnce pvc",
+			accessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			replicas:    3,
+			wantErr:     "spec.storage.pvc.name: Invalid value: \"registry\": must have the ReadWriteMany access mode to be used by more than one replica",
+		},
+		{
+			name:        "one replica on ReadWriteOnce pvc",
+			accessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			replicas:    1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pvc := &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "kyma-system"},
+				Spec:       corev1.PersistentVolumeClaimSpec{AccessModes: tt.accessModes},
+			}
+			validator := &dockerRegistryValidator{client: fake.NewClientBuilder().WithObjects(pvc).Build()}
+			dr := &DockerRegistry{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "kyma-system"},
+				Spec: DockerRegistrySpec{
+					Storage:  &Storage{PVC: &StoragePVC{Name: "registry"}},
+					Replicas: ptr.To(tt.replicas),
+				},
+			}
+
+			_, err := validator.ValidateCreate(context.Background(), dr)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.True(t, apierrors.IsInvalid(err))
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("accept CR created before the pvc", func(t *testing.T) {
+		validator := &dockerRegistryValidator{client: fake.NewClientBuilder().Build()}
+		dr := &DockerRegistry{
+			ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "kyma-system"},
+			Spec: DockerRegistrySpec{
+				Storage:  &Storage{PVC: &StoragePVC{Name: "registry"}},
+				Replicas: ptr.To[int32](3),
+			},
+		}
+
+		_, err := validator.ValidateCreate(context.Background(), dr)
+		require.NoError(t, err)
+	})
+}
+
+func TestDockerRegistryValidator_ValidateDelete(t *testing.T) {
+	isExcluded := func(namespace string) bool { return namespace == "kyma-system" }
+	pods := []client.Object{
+		fixPullingPod("kyma-system", "registry-user", "dockerregistry-config"),
+		fixPullingPod("team-b", "app", "dockerregistry-config-external"),
+		fixPullingPod("team-a", "app", "dockerregistry-config"),
+		fixPullingPod("team-a", "other-app", "dockerregistry-config"),
+		fixPullingPod("team-c", "app", "other-secret"),
+	}
+
+	tests := []struct {
+		name        string
+		pods        []client.Object
+		annotations map[string]string
+		served      Served
+		wantErr     string
+	}{
+		{
+			name:    "reject deletion with dependent pods",
+			pods:    pods,
+			wantErr: "pods in namespaces team-a, team-b pull images using the registry secrets",
+		},
+		{
+			name: "allow deletion without dependent pods",
+			pods: []client.Object{
+				fixPullingPod("kyma-system", "registry-user", "dockerregistry-config"),
+				fixPullingPod("team-c", "app", "other-secret"),
+			},
+		},
+		{
+			name:        "allow forced deletion",
+			pods:        pods,
+			annotations: map[string]string{ForceDeleteAnnotation: "true"},
+		},
+		{
+			name:   "allow deletion of not served registry",
+			pods:   pods,
+			served: ServedFalse,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := &dockerRegistryValidator{
+				client:              fake.NewClientBuilder().WithObjects(tt.pods...).Build(),
+				pullSecretNames:     []string{"dockerregistry-config", "dockerregistry-config-external"},
+				isExcludedNamespace: isExcluded,
+			}
+			dr := &DockerRegistry{
+				ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "kyma-system", Annotations: tt.annotations},
+				Status:     DockerRegistryStatus{Served: tt.served},
+			}
+
+			_, err := validator.ValidateDelete(context.Background(), dr)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.True(t, apierrors.IsForbidden(err))
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("allow deletion with warning when pods can't be listed", func(t *testing.T) {
+		validator := &dockerRegistryValidator{
+			client: fake.NewClientBuilder().WithObjects(pods...).WithInterceptorFuncs(interceptor.Funcs{
+				List: func(_ context.Context, _ client.WithWatch, _ client.ObjectList, _ ...client.ListOption) error {
+					return apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("cluster scope is not allowed"))
+				},
+			}).Build(),
+			pullSecretNames: []string{"dockerregistry-config"},
+		}
+
+		warnings, err := validator.ValidateDelete(context.Background(), &DockerRegistry{})
+		require.NoError(t, err)
+		require.Len(t, warnings, 1)
+		require.Contains(t, warnings[0], "pods using the registry secrets can't be verified")
+	})
+
+	t.Run("reject deletion when pods listing fails", func(t *testing.T) {
+		validator := &dockerRegistryValidator{
+			client: fake.NewClientBuilder().WithObjects(pods...).WithInterceptorFuncs(interceptor.Funcs{
+				List: func(_ context.Context, _ client.WithWatch, _ client.ObjectList, _ ...client.ListOption) error {
+					return errors.New("connection refused")
+				},
+			}).Build(),
+			pullSecretNames: []string{"dockerregistry-config"},
+		}
+
+		_, err := validator.ValidateDelete(context.Background(), &DockerRegistry{})
+		require.ErrorContains(t, err, "while listing pods using the registry: connection refused")
+	})
+
+	t.Run("allow deletion without deletion protection", func(t *testing.T) {
+		validator := &dockerRegistryValidator{client: fake.NewClientBuilder().WithObjects(pods...).Build()}
+
+		_, err := validator.ValidateDelete(context.Background(), &DockerRegistry{})
+		require.NoError(t, err)
+	})
+}
+
+func fixPullingPod(namespace, name, secretName string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: corev1.PodSpec{
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: secretName}},
+		},
+	}
+}
+
+func fixPEMCertificate(t *testing.T) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "registry.internal"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
@@ -1,8 +1,13 @@
 package v1alpha1
 
 import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 func (s *DockerRegistry) IsInState(state State) bool {
@@ -20,7 +25,360 @@ func (s *DockerRegistry) IsConditionTrue(conditionType ConditionType) bool {
 	return condition != nil && condition.Status == metav1.ConditionTrue
 }
 
+// ConfiguredBackends returns names of all storage backends set in the storage configuration
+func (s *Storage) ConfiguredBackends() []string {
+	backends := []string{}
+	if s.Filesystem != nil {
+		backends = append(backends, "filesystem")
+	}
+	if s.Azure != nil {
+		backends = append(backends, "azure")
+	}
+	if s.S3 != nil {
+		backends = append(backends, "s3")
+	}
+	if s.GCS != nil {
+		backends = append(backends, "gcs")
+	}
+	if s.BTPObjectStore != nil {
+		backends = append(backends, "btpObjectStore")
+	}
+	if s.PVC != nil {
+		backends = append(backends, "pvc")
+	}
+	return backends
+}
+
+// GetStorageSecretName returns the name of the secret with the storage backend credentials or empty string
+func (s *DockerRegistry) GetStorageSecretName() string {
+	storage := s.Spec.Storage
+	switch {
+	case storage == nil:
+		return ""
+	case storage.Azure != nil:
+		return storage.Azure.SecretName
+	case storage.S3 != nil:
+		return storage.S3.SecretName
+	case storage.GCS != nil:
+		return storage.GCS.SecretName
+	case storage.BTPObjectStore != nil:
+		return storage.BTPObjectStore.SecretName
+	default:
+		return ""
+	}
+}
+
+// GetSyncPeriod returns the configured sync period or the default one
+func (s *DockerRegistry) GetSyncPeriod() time.Duration {
+	if s.Spec.SyncPeriod == nil {
+		return DefaultSyncPeriod
+	}
+	return s.Spec.SyncPeriod.Duration
+}
+
+// GetStorageAlertThresholdPercent returns the filesystem storage usage alert threshold or nil if it's not configured
+func (s *DockerRegistry) GetStorageAlertThresholdPercent() *int32 {
+	if s.Spec.Storage == nil || s.Spec.Storage.Filesystem == nil {
+		return nil
+	}
+	return s.Spec.Storage.Filesystem.AlertThresholdPercent
+}
+
+// GetTLSSecretName returns the name of the secret with the registry certificate or empty string if TLS is disabled
+func (s *DockerRegistry) GetTLSSecretName() string {
+	if s.Spec.TLS == nil {
+		return ""
+	}
+	if s.Spec.TLS.CertManager != nil {
+		return CertManagerSecretName
+	}
+	return s.Spec.TLS.SecretName
+}
+
+// GetHtpasswdSecretName returns the name of the secret with additional registry users or empty string
+func (s *DockerRegistry) GetHtpasswdSecretName() string {
+	if s.Spec.Auth == nil {
+		return ""
+	}
+	return s.Spec.Auth.HtpasswdSecretName
+}
+
+// GetCredentialRotation returns the internal credentials rotation configuration or nil if the rotation is disabled
+func (s *DockerRegistry) GetCredentialRotation() *CredentialRotation {
+	if s.Spec.Auth == nil || s.Spec.Auth.CredentialRotation == nil || s.Spec.Auth.CredentialRotation.Interval == nil {
+		return nil
+	}
+	return s.Spec.Auth.CredentialRotation
+}
+
+// GetGracePeriod returns how long the previous credentials are accepted after the rotation
+func (r *CredentialRotation) GetGracePeriod() time.Duration {
+	if r.GracePeriodSeconds == nil {
+		return DefaultCredentialRotationGracePeriod
+	}
+	return time.Duration(*r.GracePeriodSeconds) * time.Second
+}
+
+// GetProxyPasswordSecretName returns the name of the secret with the remote registry password or empty string
+func (s *DockerRegistry) GetProxyPasswordSecretName() string {
+	if s.Spec.Proxy == nil || s.Spec.Proxy.PasswordSecretRef == nil {
+		return ""
+	}
+	return s.Spec.Proxy.PasswordSecretRef.Name
+}
+
+// GetProxyCASecretName returns the name of the secret with the remote registry CA certificate or empty string
+func (s *DockerRegistry) GetProxyCASecretName() string {
+	if s.Spec.Proxy == nil {
+		return ""
+	}
+	return s.Spec.Proxy.CASecretName
+}
+
+// UsesImagePullSecret returns true if the secret is one of the registry image pull secrets
+func (s *DockerRegistry) UsesImagePullSecret(name string) bool {
+	for _, secret := range s.Spec.ImagePullSecrets {
+		if secret.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// UsesNotificationHeadersSecret returns true if the secret defines headers of one of the notification endpoints
+func (s *DockerRegistry) UsesNotificationHeadersSecret(name string) bool {
+	for _, notification := range s.Spec.Notifications {
+		if notification.HeadersSecretName != "" && notification.HeadersSecretName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// GetKey returns the referenced key or the default password key
+func (r *SecretKeyRef) GetKey() string {
+	if r.Key == "" {
+		return DefaultPasswordSecretKey
+	}
+	return r.Key
+}
+
+// IsTokenAuthEnabled returns true if the token authentication is configured
+func (s *DockerRegistry) IsTokenAuthEnabled() bool {
+	if s.Spec.Auth == nil || s.Spec.Auth.TokenAuth == nil {
+		return false
+	}
+
+	tokenAuth := s.Spec.Auth.TokenAuth
+	return tokenAuth.Realm != "" && tokenAuth.Service != "" && tokenAuth.Issuer != ""
+}
+
+// IsHTTPSecretRotationRequested returns true if the rotate-http-secret annotation is set to "true"
+func (s *DockerRegistry) IsHTTPSecretRotationRequested() bool {
+	return s.GetAnnotations()[RotateHTTPSecretAnnotation] == "true"
+}
+
+// IsPaused returns true if the paused annotation is set to "true"
+func (s *DockerRegistry) IsPaused() bool {
+	return s.GetAnnotations()[PausedAnnotation] == "true"
+}
+
+// IsForceDeleteRequested returns true if the force-delete annotation is set to "true"
+func (s *DockerRegistry) IsForceDeleteRequested() bool {
+	return s.GetAnnotations()[ForceDeleteAnnotation] == "true"
+}
+
+// GetReplicas returns the lowest number of the registry replicas, it's the autoscaler lower limit when autoscaling is set
+func (s *DockerRegistry) GetReplicas() int32 {
+	if s.Spec.Autoscaling != nil {
+		return s.Spec.Autoscaling.GetMinReplicas()
+	}
+	if s.Spec.Replicas != nil {
+		return *s.Spec.Replicas
+	}
+	return DefaultReplicas
+}
+
+// GetPodDisruptionBudgetMinAvailable returns the number of the registry pods which must stay available or the default 1
+func (s *DockerRegistry) GetPodDisruptionBudgetMinAvailable() int32 {
+	if s.Spec.PodDisruptionBudget == nil || s.Spec.PodDisruptionBudget.MinAvailable == nil {
+		return DefaultPodDisruptionBudgetMinAvailable
+	}
+	return *s.Spec.PodDisruptionBudget.MinAvailable
+}
+
+// GetResources returns the compute resources of the registry container or the default ones if neither requests nor limits are set
+func (s *DockerRegistry) GetResources() corev1.ResourceRequirements {
+	resources := s.Spec.Resources
+	if resources == nil || (len(resources.Requests) == 0 && len(resources.Limits) == 0) {
+		return DefaultResources()
+	}
+	return *resources
+}
+
+// DefaultResources returns the compute resources of the registry container used when none are configured
+func DefaultResources() corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("10m"),
+			corev1.ResourceMemory: resource.MustParse("300Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("400m"),
+			corev1.ResourceMemory: resource.MustParse("800Mi"),
+		},
+	}
+}
+
+// GetMinReplicas returns the lower limit of the registry replicas or the default 1
+func (a *Autoscaling) GetMinReplicas() int32 {
+	if a.MinReplicas == nil {
+		return DefaultAutoscalingMinReplicas
+	}
+	return *a.MinReplicas
+}
+
+// GetTargetCPUUtilizationPercentage returns the CPU utilization kept by the autoscaler or the default 80
+func (a *Autoscaling) GetTargetCPUUtilizationPercentage() int32 {
+	if a.TargetCPUUtilizationPercentage == nil {
+		return DefaultTargetCPUUtilizationPercentage
+	}
+	return *a.TargetCPUUtilizationPercentage
+}
+
+// GetRetainCount returns the number of kept snapshots or the default one
+func (b *Backup) GetRetainCount() int32 {
+	if b.RetainCount == nil {
+		return DefaultBackupRetainCount
+	}
+	return *b.RetainCount
+}
+
+// GetExtraConfigMapName returns name of the ConfigMap with the custom registry configuration or empty string
+func (s *DockerRegistry) GetExtraConfigMapName() string {
+	if s.Spec.ExtraConfig == nil {
+		return ""
+	}
+	return s.Spec.ExtraConfig.ConfigMapName
+}
+
+// IsNetworkPolicyEnabled returns true if the operator restricts the ingress traffic to the registry
+func (s *DockerRegistry) IsNetworkPolicyEnabled() bool {
+	return s.Spec.NetworkPolicy != nil && s.Spec.NetworkPolicy.Enabled
+}
+
+// IsMonitoringEnabled returns true if the operator creates the ServiceMonitor of the registry metrics
+func (s *DockerRegistry) IsMonitoringEnabled() bool {
+	return s.Spec.Monitoring != nil && s.Spec.Monitoring.Enabled
+}
+
+// GetMonitoringScrapeInterval returns the configured metrics scrape interval or the default one
+func (s *DockerRegistry) GetMonitoringScrapeInterval() time.Duration {
+	if s.Spec.Monitoring == nil || s.Spec.Monitoring.ScrapeInterval == nil {
+		return DefaultMonitoringScrapeInterval
+	}
+	return s.Spec.Monitoring.ScrapeInterval.Duration
+}
+
+// IsIstioStrictMTLSEnabled returns true if the registry workload accepts only mutual TLS traffic
+func (s *DockerRegistry) IsIstioStrictMTLSEnabled() bool {
+	istio := s.Spec.Istio
+	return istio != nil && istio.Enabled && istio.MTLS != nil && istio.MTLS.Mode == IstioMTLSModeStrict
+}
+
+// IsIstioGatewayManaged returns true if the operator creates the Gateway for the registry
+func (s *DockerRegistry) IsIstioGatewayManaged() bool {
+	istio := s.Spec.Istio
+	return istio != nil && istio.Enabled && istio.Gateway != nil && istio.Gateway.Create
+}
+
+// GetIstioGatewaySelector returns labels of the ingress gateway pods used by the managed Gateway
+func (s *DockerRegistry) GetIstioGatewaySelector() map[string]string {
+	if !s.IsIstioGatewayManaged() || len(s.Spec.Istio.Gateway.Selector) == 0 {
+		return DefaultIstioGatewaySelector
+	}
+	return s.Spec.Istio.Gateway.Selector
+}
+
+// GetIstioAllowedPrincipals returns principals allowed to reach the registry or nil if Istio is not managed
+func (s *DockerRegistry) GetIstioAllowedPrincipals() []string {
+	istio := s.Spec.Istio
+	if istio == nil || !istio.Enabled || istio.AuthorizationPolicy == nil {
+		return nil
+	}
+	return istio.AuthorizationPolicy.AllowedPrincipals
+}
+
+// PropagatesSecretsTo returns true if the registry pull secrets are copied to the namespace according to the secret propagation mode
+func (s *DockerRegistry) PropagatesSecretsTo(namespace metav1.Object) bool {
+	propagation := s.Spec.SecretPropagation
+	if propagation == nil {
+		return true
+	}
+
+	switch propagation.Mode {
+	case SecretPropagationModeLabelSelector:
+		selector, err := metav1.LabelSelectorAsSelector(propagation.NamespaceSelector)
+		if err != nil {
+			// the webhook rejects invalid selectors, don't propagate secrets anywhere if it's bypassed
+			return false
+		}
+		return selector.Matches(labels.Set(namespace.GetLabels()))
+	case SecretPropagationModeAnnotationOptIn:
+		return namespace.GetAnnotations()[InjectSecretAnnotation] == "true"
+	default:
+		return true
+	}
+}
+
+// GetHookTimeout returns the configured timeout of the hook scripts or the default one
+func (s *DockerRegistry) GetHookTimeout() time.Duration {
+	if s.Spec.Hooks == nil || s.Spec.Hooks.Timeout == nil {
+		return DefaultHookTimeout
+	}
+	return s.Spec.Hooks.Timeout.Duration
+}
+
+// GetHookKeepLastJobs returns the number of the completed Jobs kept for each hook
+func (s *DockerRegistry) GetHookKeepLastJobs() int {
+	if s.Spec.Hooks == nil || s.Spec.Hooks.KeepLastJobs == nil {
+		return DefaultHookKeepLastJobs
+	}
+	return int(*s.Spec.Hooks.KeepLastJobs)
+}
+
+// ExtraConfigKey is the key of the extra config ConfigMap with the registry configuration snippet
+const ExtraConfigKey = "config.yml"
+
+// ProxyCASecretKey is the key of the proxy CA Secret with the PEM encoded certificate
+const ProxyCASecretKey = "ca.crt"
+
+var DefaultIstioGatewaySelector = map[string]string{"istio": "ingressgateway"}
+
 const (
 	DefaultEnableInternal = false
 	EndpointDisabled      = ""
+
+	DefaultSyncPeriod = 30 * time.Minute
+	MinSyncPeriod     = time.Minute
+
+	DefaultCredentialRotationGracePeriod = 5 * time.Minute
+	DefaultMonitoringScrapeInterval      = 30 * time.Second
+	DefaultHookTimeout                   = 5 * time.Minute
+
+	DefaultPasswordSecretKey = "password"
+
+	DefaultReplicas                        = 1
+	DefaultAutoscalingMinReplicas          = 1
+	DefaultTargetCPUUtilizationPercentage  = 80
+	DefaultPodDisruptionBudgetMinAvailable = 1
+	DefaultBackupRetainCount               = 7
+	DefaultHookKeepLastJobs                = 3
+
+	RotateHTTPSecretAnnotation = "dockerregistry.operator.kyma-project.io/rotate-http-secret"
+	ActiveImageAnnotation      = "dockerregistry.operator.kyma-project.io/active-image"
+
+	CertManagerCertificateName = "dockerregistry-tls"
+	CertManagerSecretName      = "dockerregistry-tls"
+	DefaultCertificateDuration = 90 * 24 * time.Hour
 )
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+
+	operatorv1alpha1 "github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+)
+
+var (
+	scheme = runtime.NewScheme()
+)
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+
+	utilruntime.Must(operatorv1alpha1.AddToScheme(scheme))
+}
+
+func main() {
+	streams := genericclioptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+	if err := newRootCommand(streams).Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func newRootCommand(streams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "kubectl-dockerregistry",
+		Short: "Inspect the DockerRegistry custom resources",
+		Annotations: map[string]string{
+			cobra.CommandDisplayNameAnnotation: "kubectl dockerregistry",
+		},
+		SilenceUsage: true,
+	}
+	cmd.SetOut(streams.Out)
+	cmd.SetErr(streams.ErrOut)
+
+	// kubeconfig, context and namespace flags are resolved the same way as in kubectl
+	configFlags := genericclioptions.NewConfigFlags(true)
+	configFlags.AddFlags(cmd.PersistentFlags())
+
+	cmd.AddCommand(newStatusCommand(configFlags, streams))
+	return cmd
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	operatorv1alpha1 "github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	k8s "github.com/kyma-project/docker-registry/components/operator/internal/controllers/kubernetes"
+)
+
+func newStatusCommand(configFlags *genericclioptions.ConfigFlags, streams genericclioptions.IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status <name>",
+		Short: "Show the DockerRegistry conditions and namespaces with the propagated internal access Secret",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			namespace, _, err := configFlags.ToRawKubeConfigLoader().Namespace()
+			if err != nil {
+				return errors.Wrap(err, "while resolving namespace")
+			}
+
+			config, err := configFlags.ToRESTConfig()
+			if err != nil {
+				return errors.Wrap(err, "while loading kubeconfig")
+			}
+
+			c, err := client.New(config, client.Options{Scheme: scheme})
+			if err != nil {
+				return errors.Wrap(err, "while creating kubernetes client")
+			}
+
+			return printStatus(cmd.Context(), c, streams.Out, namespace, args[0])
+		},
+	}
+}
+
+func printStatus(ctx context.Context, c client.Client, out io.Writer, namespace, name string) error {
+	dockerRegistry := operatorv1alpha1.DockerRegistry{}
+	if err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &dockerRegistry); err != nil {
+		return errors.Wrapf(err, "while getting dockerregistry %s/%s", namespace, name)
+	}
+
+	namespaces, err := propagatedSecretNamespaces(ctx, c, &dockerRegistry)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s/%s\n", dockerRegistry.Namespace, dockerRegistry.Name)
+	fmt.Fprintf(w, "State:\t%s\n", dockerRegistry.Status.State)
+	fmt.Fprintf(w, "Served:\t%s\n", dockerRegistry.Status.Served)
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "TYPE\tSTATUS\tREASON\tMESSAGE")
+	for _, condition := range dockerRegistry.Status.Conditions {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", condition.Type, condition.Status, condition.Reason, condition.Message)
+	}
+	fmt.Fprintln(w)
+
+	secretName := dockerRegistry.Status.InternalAccess.SecretName
+	if secretName == "" {
+		fmt.Fprintln(w, "Internal access Secret is not created yet")
+		return w.Flush()
+	}
+
+	fmt.Fprintf(w, "Internal access Secret %s propagated to namespaces:\n", secretName)
+	if len(namespaces) == 0 {
+		fmt.Fprintln(w, "  <none>")
+	}
+	for _, ns := range namespaces {
+		fmt.Fprintf(w, "  %s\n", ns)
+	}
+	return w.Flush()
+}
+
+// propagatedSecretNamespaces returns sorted namespaces with the copy of the internal access secret
+func propagatedSecretNamespaces(ctx context.Context, c client.Client, dockerRegistry *operatorv1alpha1.DockerRegistry) ([]string, error) {
+	secretName := dockerRegistry.Status.InternalAccess.SecretName
+	if secretName == "" {
+		return nil, nil
+	}
+
+	secrets := corev1.SecretList{}
+	if err := c.List(ctx, &secrets, client.MatchingLabels{k8s.ConfigLabel: k8s.CredentialsLabelValue}); err != nil {
+		return nil, errors.Wrap(err, "while listing registry access secrets")
+	}
+
+	namespaces := []string{}
+	for _, secret := range secrets.Items {
+		if secret.Name != secretName || secret.Namespace == dockerRegistry.Namespace {
+			continue
+		}
+		namespaces = append(namespaces, secret.Namespace)
+	}
+
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
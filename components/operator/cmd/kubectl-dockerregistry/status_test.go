@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	operatorv1alpha1 "github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	k8s "github.com/kyma-project/docker-registry/components/operator/internal/controllers/kubernetes"
+)
+
+func Test_printStatus(t *testing.T) {
+	t.Run("print conditions and propagated namespaces", func(t *testing.T) {
+		dockerRegistry := fixDockerRegistry("dockerregistry-config")
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			dockerRegistry,
+			fixAccessSecret("kyma-system", "dockerregistry-config"),
+			fixAccessSecret("team-b", "dockerregistry-config"),
+			fixAccessSecret("team-a", "dockerregistry-config"),
+			fixAccessSecret("team-c", "dockerregistry-config-external"),
+		).Build()
+
+		out := &bytes.Buffer{}
+		err := printStatus(context.Background(), c, out, "kyma-system", "default")
+
+		require.NoError(t, err)
+		require.Equal(t, `Name:     kyma-system/default
+State:    Ready
+Served:   True
+
+TYPE        STATUS   REASON      MESSAGE
+Installed   True     Installed   DockerRegistry installed
+
+Internal access Secret dockerregistry-config propagated to namespaces:
+  team-a
+  team-b
+`, out.String())
+	})
+
+	t.Run("print missing internal access secret", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(fixDockerRegistry("")).Build()
+
+		out := &bytes.Buffer{}
+		err := printStatus(context.Background(), c, out, "kyma-system", "default")
+
+		require.NoError(t, err)
+		require.Contains(t, out.String(), "Internal access Secret is not created yet")
+	})
+
+	t.Run("dockerregistry not found", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+		err := printStatus(context.Background(), c, &bytes.Buffer{}, "kyma-system", "default")
+
+		require.EqualError(t, err, "while getting dockerregistry kyma-system/default: dockerregistries.operator.kyma-project.io \"default\" not found")
+	})
+}
+
+func fixDockerRegistry(secretName string) *operatorv1alpha1.DockerRegistry {
+	return &operatorv1alpha1.DockerRegistry{
+		ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "kyma-system"},
+		Status: operatorv1alpha1.DockerRegistryStatus{
+			State:  operatorv1alpha1.StateReady,
+			Served: operatorv1alpha1.ServedTrue,
+			InternalAccess: operatorv1alpha1.NetworkAccess{
+				SecretName: secretName,
+			},
+			Conditions: []metav1.Condition{
+				{
+					Type:    string(operatorv1alpha1.ConditionTypeInstalled),
+					Status:  metav1.ConditionTrue,
+					Reason:  string(operatorv1alpha1.ConditionReasonInstalled),
+					Message: "DockerRegistry installed",
+				},
+			},
+		},
+	}
+}
+
+func fixAccessSecret(namespace, name string) client.Object {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{k8s.ConfigLabel: k8s.CredentialsLabelValue},
+		},
+	}
+}
@@ -18,8 +18,14 @@ package controllers
 
 import (
 	"context"
+	"time"
 
 	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/audit"
+	internalconfig "github.com/kyma-project/docker-registry/components/operator/internal/config"
+	internalerrors "github.com/kyma-project/docker-registry/components/operator/internal/errors"
+	"github.com/kyma-project/docker-registry/components/operator/internal/events"
+	"github.com/kyma-project/docker-registry/components/operator/internal/metrics"
 	"github.com/kyma-project/docker-registry/components/operator/internal/predicate"
 	"github.com/kyma-project/docker-registry/components/operator/internal/state"
 	"github.com/kyma-project/docker-registry/components/operator/internal/tracing"
@@ -27,38 +33,68 @@ import (
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 )
 
+// OperatorVersion is set during the build with -ldflags "-X <package>.OperatorVersion=<version>"
+var OperatorVersion = "dev"
+
 // dockerRegistryReconciler reconciles a DockerRegistry object
 type dockerRegistryReconciler struct {
-	initStateMachine func(*zap.SugaredLogger) state.StateReconciler
+	initStateMachine func(*zap.SugaredLogger, record.EventRecorder) state.StateReconciler
 	client           client.Client
+	recorder         record.EventRecorder
 	log              *zap.SugaredLogger
+	rateLimiter      workqueue.TypedRateLimiter[ctrl.Request]
+	maxConcurrent    int
 }
 
-func NewDockerRegistryReconciler(client client.Client, config *rest.Config, recorder record.EventRecorder, log *zap.SugaredLogger, chartPath string) *dockerRegistryReconciler {
+func NewDockerRegistryReconciler(client client.Client, config *rest.Config, clientset kubernetes.Interface, recorder record.EventRecorder, log *zap.SugaredLogger, auditLog *audit.Logger, chartPath string, deletionTimeout, syncPeriod, applyGracePeriod time.Duration) *dockerRegistryReconciler {
 	cache := chart.NewSecretManifestCache(client)
 
+	chartVersion, err := internalconfig.GetChartVersion(chartPath)
+	if err != nil {
+		log.Warnf("while reading chart version: %s", err.Error())
+	}
+
 	return &dockerRegistryReconciler{
-		initStateMachine: func(log *zap.SugaredLogger) state.StateReconciler {
-			return state.NewMachine(client, config, recorder, log, cache, chartPath)
+		initStateMachine: func(log *zap.SugaredLogger, recorder record.EventRecorder) state.StateReconciler {
+			return state.NewMachine(client, config, clientset, recorder, log, cache, auditLog, chartPath, OperatorVersion, chartVersion, deletionTimeout, syncPeriod, applyGracePeriod)
 		},
-		client: client,
-		log:    log,
+		client:   client,
+		recorder: recorder,
+		log:      log,
 	}
 }
 
+// WithRateLimiter sets the rate limiter of the controller work queue, the controller-runtime default one is used otherwise
+func (sr *dockerRegistryReconciler) WithRateLimiter(rateLimiter workqueue.TypedRateLimiter[ctrl.Request]) *dockerRegistryReconciler {
+	sr.rateLimiter = rateLimiter
+	return sr
+}
+
+// WithMaxConcurrentReconciles sets how many DockerRegistry CRs are reconciled at once, the default is one
+func (sr *dockerRegistryReconciler) WithMaxConcurrentReconciles(maxConcurrent int) *dockerRegistryReconciler {
+	sr.maxConcurrent = maxConcurrent
+	return sr
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (sr *dockerRegistryReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
+		WithOptions(controller.Options{
+			RateLimiter:             sr.rateLimiter,
+			MaxConcurrentReconciles: sr.maxConcurrent,
+		}).
 		For(&v1alpha1.DockerRegistry{}, builder.WithPredicates(predicate.NoStatusChangePredicate{})).
 		Watches(&v1alpha1.DockerRegistry{}, &handler.Funcs{
 			// retrigger all DockerRegistry CRs reconciliations when one is deleted
@@ -66,25 +102,53 @@ func (sr *dockerRegistryReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			DeleteFunc: sr.retriggerAllDockerRegistryCRs,
 		}).
 		Watches(&corev1.Service{}, tracing.ServiceCollectorWatcher()).
+		// reconcile DockerRegistry CRs when one of the referenced secrets is changed
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(sr.mapReferencedObjectToDockerRegistryCRs(usesSecret))).
+		// reconcile DockerRegistry CRs when the referenced extra config is changed
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(sr.mapReferencedObjectToDockerRegistryCRs(usesConfigMap))).
 		Complete(sr)
 }
 
 func (sr *dockerRegistryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := sr.log.With("request", req)
 	log.Info("reconciliation started")
+	start := time.Now()
 
 	instance, err := state.GetDockerRegistryOrServed(ctx, req, sr.client)
 	if err != nil {
 		log.Warnf("while getting dockerregistry, got error: %s", err.Error())
-		return ctrl.Result{}, errors.Wrap(err, "while fetching dockerregistry instance")
+		metrics.ObserveReconcile(start, metrics.ReasonGetInstanceErr, err)
+		return sr.retryWithBackoff(log, errors.Wrap(err, "while fetching dockerregistry instance"))
 	}
 	if instance == nil {
 		log.Info("Couldn't find proper instance of dockerregistry")
 		return ctrl.Result{}, nil
 	}
 
-	r := sr.initStateMachine(log)
-	return r.Reconcile(ctx, *instance)
+	ctx, span := tracing.StartReconcileSpan(ctx, instance)
+	recorder := tracing.NewEventRecorder(ctx, sr.recorder)
+	r := sr.initStateMachine(log, recorder)
+	result, err := r.Reconcile(ctx, *instance)
+	tracing.EndSpan(span, err)
+	metrics.ObserveReconcile(start, metrics.ReasonReconcileErr, err)
+	if err != nil {
+		events.RecordReconcileError(recorder, instance, err)
+		if internalerrors.IsTerminal(err) {
+			// the status condition tells the user what to fix, the spec change triggers the next reconciliation
+			log.Warnf("reconciliation stopped until the spec is changed: %s", err.Error())
+			return ctrl.Result{}, nil
+		}
+		return sr.retryWithBackoff(log, err)
+	}
+
+	return result, nil
+}
+
+// retryWithBackoff returns the error so the work queue rate limiter retries the failed reconciliation after
+// the exponential delay tracked per CR to not hammer the API server when it returns transient errors
+func (sr *dockerRegistryReconciler) retryWithBackoff(log *zap.SugaredLogger, err error) (ctrl.Result, error) {
+	log.Warnf("reconciliation failed, retrying with backoff: %s", err.Error())
+	return ctrl.Result{}, err
 }
 
 func (sr *dockerRegistryReconciler) retriggerAllDockerRegistryCRs(ctx context.Context, e event.DeleteEvent, q workqueue.TypedRateLimitingInterface[ctrl.Request]) {
@@ -105,3 +169,43 @@ func (sr *dockerRegistryReconciler) retriggerAllDockerRegistryCRs(ctx context.Co
 		}})
 	}
 }
+
+// mapReferencedObjectToDockerRegistryCRs returns requests for CRs from the object namespace which reference the object by name
+func (sr *dockerRegistryReconciler) mapReferencedObjectToDockerRegistryCRs(uses func(*v1alpha1.DockerRegistry, string) bool) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []ctrl.Request {
+		list := &v1alpha1.DockerRegistryList{}
+		err := sr.client.List(ctx, list, client.InNamespace(obj.GetNamespace()))
+		if err != nil {
+			sr.log.Errorf("error listing dockerregistry objects: %s", err.Error())
+			return nil
+		}
+
+		requests := []ctrl.Request{}
+		for _, dr := range list.Items {
+			if !uses(&dr, obj.GetName()) {
+				continue
+			}
+
+			requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKey{
+				Namespace: dr.GetNamespace(),
+				Name:      dr.GetName(),
+			}})
+		}
+
+		return requests
+	}
+}
+
+func usesSecret(dr *v1alpha1.DockerRegistry, name string) bool {
+	return dr.GetTLSSecretName() == name ||
+		dr.GetStorageSecretName() == name ||
+		dr.GetHtpasswdSecretName() == name ||
+		dr.GetProxyPasswordSecretName() == name ||
+		dr.GetProxyCASecretName() == name ||
+		dr.UsesImagePullSecret(name) ||
+		dr.UsesNotificationHeadersSecret(name)
+}
+
+func usesConfigMap(dr *v1alpha1.DockerRegistry, name string) bool {
+	return dr.GetExtraConfigMapName() == name
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_usesSecret(t *testing.T) {
+	tests := map[string]struct {
+		storage    *v1alpha1.Storage
+		secretName string
+		want       bool
+	}{
+		"s3 secret": {
+			storage:    &v1alpha1.Storage{S3: &v1alpha1.StorageS3{SecretName: "s3-secret"}},
+			secretName: "s3-secret",
+			want:       true,
+		},
+		"azure secret": {
+			storage:    &v1alpha1.Storage{Azure: &v1alpha1.StorageAzure{SecretName: "azure-secret"}},
+			secretName: "azure-secret",
+			want:       true,
+		},
+		"gcs secret": {
+			storage:    &v1alpha1.Storage{GCS: &v1alpha1.StorageGCS{SecretName: "gcs-secret"}},
+			secretName: "gcs-secret",
+			want:       true,
+		},
+		"btp object store secret": {
+			storage:    &v1alpha1.Storage{BTPObjectStore: &v1alpha1.StorageBTPObjectStore{SecretName: "btp-secret"}},
+			secretName: "btp-secret",
+			want:       true,
+		},
+		"other secret": {
+			storage:    &v1alpha1.Storage{S3: &v1alpha1.StorageS3{SecretName: "s3-secret"}},
+			secretName: "other-secret",
+			want:       false,
+		},
+		"filesystem storage": {
+			storage:    &v1alpha1.Storage{Filesystem: &v1alpha1.StorageFilesystem{}},
+			secretName: "s3-secret",
+			want:       false,
+		},
+		"no storage": {
+			secretName: "s3-secret",
+			want:       false,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dr := &v1alpha1.DockerRegistry{
+				Spec: v1alpha1.DockerRegistrySpec{Storage: tt.storage},
+			}
+
+			require.Equal(t, tt.want, usesSecret(dr, tt.secretName))
+		})
+	}
+}
@@ -20,10 +20,13 @@ import (
 	"context"
 	"path/filepath"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	uberzap "go.uber.org/zap"
+	istiosecurity "istio.io/client-go/pkg/apis/security/v1beta1"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
@@ -76,6 +79,9 @@ var _ = BeforeSuite(func() {
 	err = operatorv1alpha1.AddToScheme(scheme.Scheme)
 	Expect(err).NotTo(HaveOccurred())
 
+	err = istiosecurity.AddToScheme(scheme.Scheme)
+	Expect(err).NotTo(HaveOccurred())
+
 	//+kubebuilder:scaffold:scheme
 
 	k8sClient, err = client.New(config, client.Options{Scheme: scheme.Scheme})
@@ -95,9 +101,14 @@ var _ = BeforeSuite(func() {
 	err = (NewDockerRegistryReconciler(
 		k8sManager.GetClient(),
 		k8sManager.GetConfig(),
+		kubernetes.NewForConfigOrDie(k8sManager.GetConfig()),
 		record.NewFakeRecorder(100),
 		reconcilerLogger.Sugar(),
-		chartPath)).
+		nil,
+		chartPath,
+		time.Minute,
+		operatorv1alpha1.DefaultSyncPeriod,
+		0)).
 		SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/natefinch/lumberjack.v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	maxFileSizeMB  = 100
+	maxFileBackups = 5
+	maxFileAgeDays = 30
+)
+
+// Record describes one DockerRegistry reconciliation
+type Record struct {
+	Audit              bool               `json:"audit"`
+	Timestamp          time.Time          `json:"timestamp"`
+	Name               string             `json:"name"`
+	Namespace          string             `json:"namespace"`
+	UserAgent          string             `json:"userAgent,omitempty"`
+	PreviousConditions []metav1.Condition `json:"previousConditions"`
+	NewConditions      []metav1.Condition `json:"newConditions"`
+}
+
+// Logger writes audit records as JSON lines, nil Logger drops all records
+type Logger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func New(w io.Writer) *Logger {
+	return &Logger{w: w}
+}
+
+// NewFileWriter returns writer rotating the audit log file at the given path
+func NewFileWriter(path string) io.WriteCloser {
+	return &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    maxFileSizeMB,
+		MaxBackups: maxFileBackups,
+		MaxAge:     maxFileAgeDays,
+	}
+}
+
+// Reconciled records the conditions of obj before and after its reconciliation
+func (l *Logger) Reconciled(obj client.Object, timestamp time.Time, previous, current []metav1.Condition) error {
+	if l == nil {
+		return nil
+	}
+
+	record := Record{
+		Audit:              true,
+		Timestamp:          timestamp.UTC(),
+		Name:               obj.GetName(),
+		Namespace:          obj.GetNamespace(),
+		UserAgent:          lastManager(obj),
+		PreviousConditions: previous,
+		NewConditions:      current,
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "while marshalling audit record")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.w.Write(append(line, '\n'))
+	return errors.Wrap(err, "while writing audit record")
+}
+
+// lastManager returns the field manager of the latest object change,
+// the API server derives it from the user agent of the client which sent the request
+func lastManager(obj client.Object) string {
+	var last *metav1.ManagedFieldsEntry
+	managedFields := obj.GetManagedFields()
+	for i := range managedFields {
+		entry := &managedFields[i]
+		if entry.Subresource != "" || entry.Time == nil {
+			// skip status updates done by the operator itself
+			continue
+		}
+		if last == nil || last.Time.Before(entry.Time) {
+			last = entry
+		}
+	}
+	if last == nil {
+		return ""
+	}
+	return last.Manager
+}
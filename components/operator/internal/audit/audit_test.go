@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLogger_Reconciled(t *testing.T) {
+	timestamp := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	previous := []metav1.Condition{{Type: "Installed", Status: metav1.ConditionUnknown, Reason: "Installation"}}
+	current := []metav1.Condition{{Type: "Installed", Status: metav1.ConditionTrue, Reason: "Installed"}}
+
+	t.Run("write record as JSON line", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		dr := &v1alpha1.DockerRegistry{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "default",
+				Namespace: "kyma-system",
+				ManagedFields: []metav1.ManagedFieldsEntry{
+					{Manager: "kubectl-client-side-apply", Time: &metav1.Time{Time: timestamp.Add(-2 * time.Hour)}},
+					{Manager: "kubectl-edit", Time: &metav1.Time{Time: timestamp.Add(-time.Hour)}},
+					{Manager: "dockerregistry-operator", Subresource: "status", Time: &metav1.Time{Time: timestamp}},
+				},
+			},
+		}
+
+		err := New(buf).Reconciled(dr, timestamp, previous, current)
+		require.NoError(t, err)
+
+		require.Equal(t, byte('\n'), buf.Bytes()[buf.Len()-1])
+		record := Record{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+		require.Equal(t, Record{
+			Audit:              true,
+			Timestamp:          timestamp,
+			Name:               "default",
+			Namespace:          "kyma-system",
+			UserAgent:          "kubectl-edit",
+			PreviousConditions: previous,
+			NewConditions:      current,
+		}, record)
+	})
+
+	t.Run("drop record when audit log is disabled", func(t *testing.T) {
+		var logger *Logger
+
+		err := logger.Reconciled(&v1alpha1.DockerRegistry{}, timestamp, previous, current)
+		require.NoError(t, err)
+	})
+}
@@ -0,0 +1,25 @@
+package backoff
+
+import (
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	DefaultBaseDelay = time.Second
+	DefaultMaxDelay  = 5 * time.Minute
+)
+
+// NewRateLimiter returns the controller work queue rate limiter delaying retries of the failed items exponentially,
+// the delay of the item is reset when it's reconciled successfully
+func NewRateLimiter(baseDelay, maxDelay time.Duration) workqueue.TypedRateLimiter[reconcile.Request] {
+	if baseDelay <= 0 {
+		baseDelay = DefaultBaseDelay
+	}
+	if maxDelay < baseDelay {
+		maxDelay = baseDelay
+	}
+	return workqueue.NewTypedItemExponentialFailureRateLimiter[reconcile.Request](baseDelay, maxDelay)
+}
@@ -0,0 +1,32 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "kyma-system", Name: "default"}}
+
+	t.Run("double delay up to the max delay", func(t *testing.T) {
+		rateLimiter := NewRateLimiter(time.Second, 3*time.Second)
+
+		require.Equal(t, time.Second, rateLimiter.When(request))
+		require.Equal(t, 2*time.Second, rateLimiter.When(request))
+		require.Equal(t, 3*time.Second, rateLimiter.When(request))
+
+		rateLimiter.Forget(request)
+		require.Equal(t, time.Second, rateLimiter.When(request))
+	})
+
+	t.Run("use defaults for invalid delays", func(t *testing.T) {
+		rateLimiter := NewRateLimiter(0, -time.Second)
+
+		require.Equal(t, DefaultBaseDelay, rateLimiter.When(request))
+		require.Equal(t, DefaultBaseDelay, rateLimiter.When(request))
+	})
+}
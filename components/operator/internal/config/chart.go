@@ -0,0 +1,28 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+type chartMetadata struct {
+	Version string `json:"version"`
+}
+
+// GetChartVersion returns the version from the Chart.yaml located in the chartPath
+func GetChartVersion(chartPath string) (string, error) {
+	file, err := os.Open(filepath.Join(chartPath, "Chart.yaml"))
+	if err != nil {
+		return "", errors.Wrap(err, "while opening Chart.yaml")
+	}
+	defer file.Close()
+
+	metadata := chartMetadata{}
+	if err := yaml.NewYAMLOrJSONDecoder(file, 4096).Decode(&metadata); err != nil {
+		return "", errors.Wrap(err, "while parsing Chart.yaml")
+	}
+	return metadata.Version, nil
+}
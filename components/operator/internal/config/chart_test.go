@@ -0,0 +1,26 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetChartVersion(t *testing.T) {
+	t.Run("read version from Chart.yaml", func(t *testing.T) {
+		chartPath := t.TempDir()
+		chart := "apiVersion: v1\nname: docker-registry\nversion: 1.9.1\nappVersion: 2.7.1\n"
+		require.NoError(t, os.WriteFile(filepath.Join(chartPath, "Chart.yaml"), []byte(chart), 0600))
+
+		version, err := GetChartVersion(chartPath)
+		require.NoError(t, err)
+		require.Equal(t, "1.9.1", version)
+	})
+
+	t.Run("missing Chart.yaml", func(t *testing.T) {
+		_, err := GetChartVersion(t.TempDir())
+		require.ErrorContains(t, err, "while opening Chart.yaml")
+	})
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// LoadFlags sets flags from the YAML file with flag names as keys (e.g. metrics-bind-address),
+// flags set on the command line take precedence over the file
+func LoadFlags(fs *flag.FlagSet, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "while reading flags file")
+	}
+
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return errors.Wrap(err, "while parsing flags file")
+	}
+
+	setOnCommandLine := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		setOnCommandLine[f.Name] = true
+	})
+
+	for name, value := range values {
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("unknown flag %s in flags file", name)
+		}
+		if setOnCommandLine[name] {
+			continue
+		}
+		if err := fs.Set(name, fmt.Sprint(value)); err != nil {
+			return errors.Wrapf(err, "while setting flag %s", name)
+		}
+	}
+	return nil
+}
+
+// StringList is the flag value collecting the values of the flag repeated on the command line,
+// comma-separated values are accepted too so the flag can be set from the flags file
+type StringList []string
+
+func (l *StringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *StringList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" && !slices.Contains(*l, item) {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
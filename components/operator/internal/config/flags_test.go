@@ -0,0 +1,93 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFlags(t *testing.T) {
+	newFlagSet := func() (*flag.FlagSet, *string, *time.Duration, *bool, *int) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		metricsAddr := fs.String("metrics-bind-address", ":8080", "")
+		syncPeriod := fs.Duration("sync-period", time.Minute, "")
+		leaderElect := fs.Bool("leader-elect", false, "")
+		maxConcurrent := fs.Int("registry-max-concurrent-reconciles", 1, "")
+		return fs, metricsAddr, syncPeriod, leaderElect, maxConcurrent
+	}
+	writeFile := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "flags.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	t.Run("set flags from file", func(t *testing.T) {
+		fs, metricsAddr, syncPeriod, leaderElect, maxConcurrent := newFlagSet()
+		require.NoError(t, fs.Parse(nil))
+		path := writeFile(t, "metrics-bind-address: \":9090\"\nsync-period: 5m\nleader-elect: true\nregistry-max-concurrent-reconciles: 3\n")
+
+		require.NoError(t, LoadFlags(fs, path))
+		require.Equal(t, ":9090", *metricsAddr)
+		require.Equal(t, 5*time.Minute, *syncPeriod)
+		require.True(t, *leaderElect)
+		require.Equal(t, 3, *maxConcurrent)
+	})
+
+	t.Run("command line flags take precedence", func(t *testing.T) {
+		fs, metricsAddr, syncPeriod, _, _ := newFlagSet()
+		require.NoError(t, fs.Parse([]string{"--metrics-bind-address=:7070"}))
+		path := writeFile(t, "metrics-bind-address: \":9090\"\nsync-period: 5m\n")
+
+		require.NoError(t, LoadFlags(fs, path))
+		require.Equal(t, ":7070", *metricsAddr)
+		require.Equal(t, 5*time.Minute, *syncPeriod)
+	})
+
+	t.Run("unknown flag", func(t *testing.T) {
+		fs, _, _, _, _ := newFlagSet()
+		path := writeFile(t, "unknown: value\n")
+
+		require.EqualError(t, LoadFlags(fs, path), "unknown flag unknown in flags file")
+	})
+
+	t.Run("invalid flag value", func(t *testing.T) {
+		fs, _, _, _, _ := newFlagSet()
+		path := writeFile(t, "sync-period: often\n")
+
+		require.ErrorContains(t, LoadFlags(fs, path), "while setting flag sync-period")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		fs, _, _, _, _ := newFlagSet()
+
+		require.ErrorContains(t, LoadFlags(fs, filepath.Join(t.TempDir(), "missing.yaml")), "while reading flags file")
+	})
+}
+
+func TestStringList(t *testing.T) {
+	t.Run("collect repeated flag", func(t *testing.T) {
+		var namespaces StringList
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.Var(&namespaces, "watch-namespace", "")
+
+		require.NoError(t, fs.Parse([]string{"--watch-namespace=team-a", "--watch-namespace", "team-b,team-c", "--watch-namespace=team-a"}))
+		require.Equal(t, StringList{"team-a", "team-b", "team-c"}, namespaces)
+		require.Equal(t, "team-a,team-b,team-c", namespaces.String())
+	})
+
+	t.Run("set from file", func(t *testing.T) {
+		var namespaces StringList
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.Var(&namespaces, "watch-namespace", "")
+		require.NoError(t, fs.Parse(nil))
+		path := filepath.Join(t.TempDir(), "flags.yaml")
+		require.NoError(t, os.WriteFile(path, []byte("watch-namespace: team-a, team-b\n"), 0600))
+
+		require.NoError(t, LoadFlags(fs, path))
+		require.Equal(t, StringList{"team-a", "team-b"}, namespaces)
+	})
+}
@@ -0,0 +1,193 @@
+package kubernetes
+
+import (
+	"context"
+	"reflect"
+
+	"go.uber.org/zap"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+type ConfigMapReconciler struct {
+	Log           *zap.SugaredLogger
+	client        client.Client
+	config        Config
+	svc           ConfigMapService
+	excluded      *namespaceMatcher
+	rateLimiter   workqueue.TypedRateLimiter[ctrl.Request]
+	maxConcurrent int
+}
+
+func NewConfigMap(client client.Client, log *zap.SugaredLogger, config Config, configMapSvc ConfigMapService) *ConfigMapReconciler {
+	return &ConfigMapReconciler{
+		client: client,
+		Log:    log,
+		config: config,
+		svc:    configMapSvc,
+	}
+}
+
+// WithRateLimiter sets the rate limiter of the controller work queue, the controller-runtime default one is used otherwise
+func (r *ConfigMapReconciler) WithRateLimiter(rateLimiter workqueue.TypedRateLimiter[ctrl.Request]) *ConfigMapReconciler {
+	r.rateLimiter = rateLimiter
+	return r
+}
+
+// WithMaxConcurrentReconciles sets how many config maps are reconciled at once, the default is one
+func (r *ConfigMapReconciler) WithMaxConcurrentReconciles(maxConcurrent int) *ConfigMapReconciler {
+	r.maxConcurrent = maxConcurrent
+	return r
+}
+
+func (r *ConfigMapReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	excluded, err := compileNamespaceSelectors(r.config.ExcludedNamespaces)
+	if err != nil {
+		return err
+	}
+	r.excluded = excluded
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("configmap-controller").
+		WithOptions(controller.Options{
+			RateLimiter:             r.rateLimiter,
+			MaxConcurrentReconciles: r.maxConcurrent,
+		}).
+		For(&corev1.ConfigMap{}, builder.WithPredicates(r.predicate())).
+		// there is no namespace controller for the config maps so the base ones are reconciled on namespace changes
+		Watches(&corev1.Namespace{},
+			handler.EnqueueRequestsFromMapFunc(r.mapNamespaceToBaseConfigMaps),
+			builder.WithPredicates(r.namespacePredicate())).
+		Complete(r)
+}
+
+// mapNamespaceToBaseConfigMaps enqueues base config maps so they are copied to the new namespace
+// and the copies are cleaned up from the deleted one
+func (r *ConfigMapReconciler) mapNamespaceToBaseConfigMaps(ctx context.Context, _ client.Object) []ctrl.Request {
+	bases, err := r.svc.GetBase(ctx)
+	if err != nil {
+		r.Log.Error("while getting base config maps", "error", err)
+		return nil
+	}
+
+	requests := make([]ctrl.Request, 0, len(bases))
+	for _, base := range bases {
+		requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&base)})
+	}
+	return requests
+}
+
+func (r *ConfigMapReconciler) namespacePredicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return !isExcludedNamespace(e.Object.GetName(), r.config.GetBaseNamespaces(), r.excluded)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if e.ObjectOld.GetDeletionTimestamp().IsZero() && !e.ObjectNew.GetDeletionTimestamp().IsZero() {
+				return true
+			}
+			// labels and annotations select namespaces receiving the config maps
+			return !reflect.DeepEqual(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels()) ||
+				!reflect.DeepEqual(e.ObjectOld.GetAnnotations(), e.ObjectNew.GetAnnotations())
+		},
+		GenericFunc: func(event.GenericEvent) bool {
+			return false
+		},
+		DeleteFunc: func(event.DeleteEvent) bool {
+			return true
+		},
+	}
+}
+
+func (r *ConfigMapReconciler) predicate() predicate.Predicate {
+	isBase := func(obj client.Object) bool {
+		configMap, ok := obj.(*corev1.ConfigMap)
+		if !ok {
+			return false
+		}
+		return r.svc.IsBase(configMap)
+	}
+
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return isBase(e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return isBase(e.ObjectNew)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return isBase(e.Object)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return isBase(e.Object)
+		},
+	}
+}
+
+// Reconcile copies the base ConfigMap to all namespaces selected by the served DockerRegistry
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+
+func (r *ConfigMapReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	instance := &corev1.ConfigMap{}
+	if err := r.client.Get(ctx, request.NamespacedName, instance); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	logger := r.Log.With("namespace", instance.GetNamespace(), "name", instance.GetName())
+
+	selected, err := GetNamespaceFilter(ctx, r.client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	namespaces, err := getNamespaces(ctx, r.client, r.config.GetBaseNamespaces(), r.excluded, selected)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.svc.HandleFinalizer(ctx, logger, instance, namespaces); err != nil {
+		return ctrl.Result{}, err
+	}
+	if !instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	if err := r.svc.CleanupOrphanConfigMaps(ctx, logger, selected); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	bases, err := r.svc.GetBase(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !containsConfigMap(bases, instance) {
+		logger.Debug("Skipping ConfigMap shadowed by the ConfigMap from the preceding base namespace")
+		return ctrl.Result{RequeueAfter: r.config.ConfigMapRequeueDuration}, nil
+	}
+
+	for _, namespace := range namespaces {
+		if err = r.svc.UpdateNamespace(ctx, logger, namespace, instance); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: r.config.ConfigMapRequeueDuration}, nil
+}
+
+func containsConfigMap(configMaps []corev1.ConfigMap, configMap *corev1.ConfigMap) bool {
+	for _, item := range configMaps {
+		if item.GetNamespace() == configMap.GetNamespace() && item.GetName() == configMap.GetName() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package kubernetes
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/docker-registry/components/operator/internal/resource"
+)
+
+const cfgConfigMapFinalizerName = "dockerregistry.kyma-project.io/finalizer-propagated-configmap"
+
+type ConfigMapService interface {
+	IsBase(configMap *corev1.ConfigMap) bool
+	GetBase(ctx context.Context) ([]corev1.ConfigMap, error)
+	UpdateNamespace(ctx context.Context, logger *zap.SugaredLogger, namespace string, baseInstance *corev1.ConfigMap) error
+	HandleFinalizer(ctx context.Context, logger *zap.SugaredLogger, configMap *corev1.ConfigMap, namespaces []string) error
+	CleanupOrphanConfigMaps(ctx context.Context, logger *zap.SugaredLogger, selected NamespaceFilter) error
+}
+
+var _ ConfigMapService = &configMapService{}
+
+type configMapService struct {
+	client resource.Client
+	config Config
+}
+
+func NewConfigMapService(client resource.Client, config Config) ConfigMapService {
+	return &configMapService{
+		client: client,
+		config: config,
+	}
+}
+
+// GetBase returns base config maps from all base namespaces, config map from the preceding namespace wins when names are duplicated
+func (r *configMapService) GetBase(ctx context.Context) ([]corev1.ConfigMap, error) {
+	var configMaps []corev1.ConfigMap
+	var errs []error
+	for _, configMapName := range r.config.BaseConfigMapNames {
+		for _, namespace := range r.config.GetBaseNamespaces() {
+			configMap := &corev1.ConfigMap{}
+			err := r.client.Get(ctx, types.NamespacedName{
+				Namespace: namespace,
+				Name:      configMapName,
+			}, configMap)
+			if err == nil {
+				configMaps = append(configMaps, *configMap)
+				break
+			}
+			if client.IgnoreNotFound(err) != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return configMaps, goerrors.Join(errs...)
+}
+
+func (r *configMapService) IsBase(configMap *corev1.ConfigMap) bool {
+	return containsString(r.config.GetBaseNamespaces(), configMap.Namespace) &&
+		containsString(r.config.BaseConfigMapNames, configMap.Name)
+}
+
+func (r *configMapService) UpdateNamespace(ctx context.Context, logger *zap.SugaredLogger, namespace string, baseInstance *corev1.ConfigMap) error {
+	logger.Debug(fmt.Sprintf("Updating ConfigMap '%s/%s'", namespace, baseInstance.GetName()))
+	instance := &corev1.ConfigMap{}
+	if err := r.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: baseInstance.GetName()}, instance); err != nil {
+		if errors.IsNotFound(err) {
+			return r.createConfigMap(ctx, logger, namespace, baseInstance)
+		}
+		logger.Error(err, fmt.Sprintf("Gathering existing ConfigMap '%s/%s' failed", namespace, baseInstance.GetName()))
+		return err
+	}
+	if instance.Labels[FunctionManagedByLabel] == FunctionResourceLabelUserValue {
+		return nil
+	}
+	return r.updateConfigMap(ctx, logger, instance, baseInstance)
+}
+
+func (r *configMapService) HandleFinalizer(ctx context.Context, logger *zap.SugaredLogger, instance *corev1.ConfigMap, namespaces []string) error {
+	if instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		if containsString(instance.ObjectMeta.Finalizers, cfgConfigMapFinalizerName) {
+			return nil
+		}
+		instance.ObjectMeta.Finalizers = append(instance.ObjectMeta.Finalizers, cfgConfigMapFinalizerName)
+		return r.client.Update(ctx, instance)
+	}
+
+	if !containsString(instance.ObjectMeta.Finalizers, cfgConfigMapFinalizerName) {
+		return nil
+	}
+	for _, namespace := range namespaces {
+		logger.Debug(fmt.Sprintf("Deleting ConfigMap '%s/%s'", namespace, instance.Name))
+		if err := r.deleteConfigMap(ctx, logger, namespace, instance.Name); err != nil {
+			return err
+		}
+	}
+	instance.ObjectMeta.Finalizers = removeString(instance.ObjectMeta.Finalizers, cfgConfigMapFinalizerName)
+	return r.client.Update(ctx, instance)
+}
+
+// CleanupOrphanConfigMaps deletes copies of the base config maps from namespaces they are not propagated to anymore,
+// it covers namespaces added to the excluded ones, namespaces not selected by the filter and namespaces being deleted
+func (r *configMapService) CleanupOrphanConfigMaps(ctx context.Context, logger *zap.SugaredLogger, selected NamespaceFilter) error {
+	if len(r.config.BaseConfigMapNames) == 0 {
+		return nil
+	}
+
+	excluded, err := compileNamespaceSelectors(r.config.ExcludedNamespaces)
+	if err != nil {
+		return err
+	}
+
+	namespaces := &corev1.NamespaceList{}
+	if err := r.client.ListByLabel(ctx, "", nil, namespaces); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, namespace := range namespaces.Items {
+		if containsString(r.config.GetBaseNamespaces(), namespace.GetName()) {
+			continue
+		}
+		if !excluded.matches(namespace.GetName()) && namespace.Status.Phase != corev1.NamespaceTerminating &&
+			selected(&namespace) {
+			continue
+		}
+		for _, configMapName := range r.config.BaseConfigMapNames {
+			logger.Debug(fmt.Sprintf("Deleting orphan ConfigMap '%s/%s'", namespace.GetName(), configMapName))
+			if err := r.deleteConfigMap(ctx, logger, namespace.GetName(), configMapName); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return goerrors.Join(errs...)
+}
+
+func (r *configMapService) createConfigMap(ctx context.Context, logger *zap.SugaredLogger, namespace string, baseInstance *corev1.ConfigMap) error {
+	configMap := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        baseInstance.GetName(),
+			Namespace:   namespace,
+			Labels:      baseInstance.Labels,
+			Annotations: baseInstance.Annotations,
+		},
+		Data:       baseInstance.Data,
+		BinaryData: baseInstance.BinaryData,
+	}
+
+	logger.Debug(fmt.Sprintf("Creating ConfigMap '%s/%s'", configMap.GetNamespace(), configMap.GetName()))
+	if err := r.client.Create(ctx, &configMap); err != nil {
+		logger.Error(err, fmt.Sprintf("Creating ConfigMap '%s/%s' failed", configMap.GetNamespace(), configMap.GetName()))
+		return err
+	}
+
+	return nil
+}
+
+func (r *configMapService) updateConfigMap(ctx context.Context, logger *zap.SugaredLogger, instance, baseInstance *corev1.ConfigMap) error {
+	copy := instance.DeepCopy()
+	copy.Annotations = baseInstance.GetAnnotations()
+	copy.Labels = baseInstance.GetLabels()
+	copy.Data = baseInstance.Data
+	copy.BinaryData = baseInstance.BinaryData
+
+	if err := r.client.Update(ctx, copy); err != nil {
+		logger.Error(err, fmt.Sprintf("Updating ConfigMap '%s/%s' failed", copy.GetNamespace(), copy.GetName()))
+		return err
+	}
+
+	return nil
+}
+
+func (r *configMapService) deleteConfigMap(ctx context.Context, logger *zap.SugaredLogger, namespace, baseInstanceName string) error {
+	instance := &corev1.ConfigMap{}
+	if err := r.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: baseInstanceName}, instance); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if instance.Labels[FunctionManagedByLabel] == FunctionResourceLabelUserValue {
+		return nil
+	}
+	if err := r.client.Delete(ctx, instance); err != nil {
+		logger.Error(err, fmt.Sprintf("Deleting ConfigMap '%s/%s' failed", namespace, baseInstanceName))
+		return err
+	}
+
+	return nil
+}
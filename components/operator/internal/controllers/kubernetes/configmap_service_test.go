@@ -0,0 +1,193 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kyma-project/docker-registry/components/operator/internal/resource"
+)
+
+func TestConfigMapService_GetBase(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+
+	t.Run("prefer config map from the preceding base namespace", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+			fixConfigMap("kyma-system", "trust-bundle", nil),
+			fixConfigMap("other-system", "trust-bundle", nil),
+			fixConfigMap("other-system", "mirrors", nil),
+			fixConfigMap("kyma-system", "not-propagated", nil),
+		).Build()
+		svc := NewConfigMapService(resource.New(c, testScheme), Config{
+			BaseNamespaces:     []string{"kyma-system", "other-system"},
+			BaseConfigMapNames: []string{"trust-bundle", "mirrors"},
+		})
+
+		bases, err := svc.GetBase(context.Background())
+		require.NoError(t, err)
+		require.Len(t, bases, 2)
+		require.Equal(t, "kyma-system", bases[0].GetNamespace())
+		require.Equal(t, "trust-bundle", bases[0].GetName())
+		require.Equal(t, "other-system", bases[1].GetNamespace())
+		require.Equal(t, "mirrors", bases[1].GetName())
+	})
+}
+
+func TestConfigMapService_UpdateNamespace(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+	base := fixConfigMap("kyma-system", "trust-bundle", map[string]string{"app": "trust"})
+	base.Data = map[string]string{"ca.crt": "new"}
+
+	testCases := map[string]struct {
+		givenObjects []client.Object
+		expectedData map[string]string
+	}{
+		"create config map copy": {
+			expectedData: map[string]string{"ca.crt": "new"},
+		},
+		"update config map copy": {
+			givenObjects: []client.Object{fixConfigMapWithData("test", "trust-bundle", nil, map[string]string{"ca.crt": "old"})},
+			expectedData: map[string]string{"ca.crt": "new"},
+		},
+		"keep config map managed by user": {
+			givenObjects: []client.Object{fixConfigMapWithData("test", "trust-bundle",
+				map[string]string{FunctionManagedByLabel: FunctionResourceLabelUserValue}, map[string]string{"ca.crt": "old"})},
+			expectedData: map[string]string{"ca.crt": "old"},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(testCase.givenObjects...).Build()
+			svc := NewConfigMapService(resource.New(c, testScheme), Config{})
+
+			err := svc.UpdateNamespace(context.Background(), zap.NewNop().Sugar(), "test", base)
+			require.NoError(t, err)
+
+			configMap := &corev1.ConfigMap{}
+			require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: "trust-bundle"}, configMap))
+			require.Equal(t, testCase.expectedData, configMap.Data)
+		})
+	}
+}
+
+func TestConfigMapService_CleanupOrphanConfigMaps(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+	config := Config{
+		BaseNamespaces:     []string{"kyma-system"},
+		BaseConfigMapNames: []string{"trust-bundle"},
+		ExcludedNamespaces: []NamespaceSelector{
+			{Name: "kyma-system"},
+			{Name: "excluded"},
+			{MatchPattern: "sandbox-.*"},
+		},
+	}
+
+	testCases := map[string]struct {
+		givenNamespace   *corev1.Namespace
+		givenConfigMap   *corev1.ConfigMap
+		notSelected      bool
+		expectDeleted    bool
+		withoutBaseNames bool
+	}{
+		"delete config map from excluded namespace": {
+			givenNamespace: fixNamespace("excluded", corev1.NamespaceActive),
+			givenConfigMap: fixConfigMap("excluded", "trust-bundle", nil),
+			expectDeleted:  true,
+		},
+		"delete config map from namespace matching excluded pattern": {
+			givenNamespace: fixNamespace("sandbox-1", corev1.NamespaceActive),
+			givenConfigMap: fixConfigMap("sandbox-1", "trust-bundle", nil),
+			expectDeleted:  true,
+		},
+		"delete config map from terminating namespace": {
+			givenNamespace: fixNamespace("test", corev1.NamespaceTerminating),
+			givenConfigMap: fixConfigMap("test", "trust-bundle", nil),
+			expectDeleted:  true,
+		},
+		"delete config map from namespace not selected by the filter": {
+			givenNamespace: fixNamespace("test", corev1.NamespaceActive),
+			givenConfigMap: fixConfigMap("test", "trust-bundle", nil),
+			notSelected:    true,
+			expectDeleted:  true,
+		},
+		"keep config map in propagated namespace": {
+			givenNamespace: fixNamespace("test", corev1.NamespaceActive),
+			givenConfigMap: fixConfigMap("test", "trust-bundle", nil),
+			expectDeleted:  false,
+		},
+		"keep base config map": {
+			givenNamespace: fixNamespace("kyma-system", corev1.NamespaceActive),
+			givenConfigMap: fixConfigMap("kyma-system", "trust-bundle", nil),
+			expectDeleted:  false,
+		},
+		"keep config map managed by user": {
+			givenNamespace: fixNamespace("excluded", corev1.NamespaceActive),
+			givenConfigMap: fixConfigMap("excluded", "trust-bundle",
+				map[string]string{FunctionManagedByLabel: FunctionResourceLabelUserValue}),
+			expectDeleted: false,
+		},
+		"keep other config map in excluded namespace": {
+			givenNamespace: fixNamespace("excluded", corev1.NamespaceActive),
+			givenConfigMap: fixConfigMap("excluded", "other", nil),
+			expectDeleted:  false,
+		},
+		"keep config maps without propagated names": {
+			givenNamespace:   fixNamespace("excluded", corev1.NamespaceActive),
+			givenConfigMap:   fixConfigMap("excluded", "trust-bundle", nil),
+			withoutBaseNames: true,
+			expectDeleted:    false,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(testScheme).
+				WithObjects(testCase.givenNamespace, testCase.givenConfigMap).Build()
+			config := config
+			if testCase.withoutBaseNames {
+				config.BaseConfigMapNames = nil
+			}
+			svc := NewConfigMapService(resource.New(c, testScheme), config)
+
+			selected := func(metav1.Object) bool { return !testCase.notSelected }
+
+			err := svc.CleanupOrphanConfigMaps(context.Background(), zap.NewNop().Sugar(), selected)
+			require.NoError(t, err)
+
+			err = c.Get(context.Background(), client.ObjectKeyFromObject(testCase.givenConfigMap), &corev1.ConfigMap{})
+			if testCase.expectDeleted {
+				require.True(t, errors.IsNotFound(err))
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func fixConfigMap(namespace, name string, labels map[string]string) *corev1.ConfigMap {
+	return fixConfigMapWithData(namespace, name, labels, nil)
+}
+
+func fixConfigMapWithData(namespace, name string, labels, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Data: data,
+	}
+}
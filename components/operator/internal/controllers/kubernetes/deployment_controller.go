@@ -0,0 +1,155 @@
+package kubernetes
+
+import (
+	"context"
+	goerrors "errors"
+	"reflect"
+
+	"go.uber.org/zap"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
+	"github.com/kyma-project/docker-registry/components/operator/internal/state"
+	appsv1 "k8s.io/api/apps/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+const (
+	managedByLabel        = "app.kubernetes.io/managed-by"
+	operatorManagedByName = "dockerregistry-operator"
+	registryContainerName = "docker-registry"
+)
+
+// DeploymentReconciler updates the DeploymentReady condition and the active image annotation of DockerRegistry CRs
+// when the registry deployment rollout progresses
+type DeploymentReconciler struct {
+	Log    *zap.SugaredLogger
+	client client.Client
+}
+
+func NewDeployment(client client.Client, log *zap.SugaredLogger) *DeploymentReconciler {
+	return &DeploymentReconciler{
+		client: client,
+		Log:    log,
+	}
+}
+
+func (r *DeploymentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("deployment-controller").
+		For(&appsv1.Deployment{}).
+		WithEventFilter(r.predicate()).
+		Complete(r)
+}
+
+func (r *DeploymentReconciler) predicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return isRegistryDeployment(e.Object)
+		},
+		GenericFunc: func(genericEvent event.GenericEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldDeployment, ok := e.ObjectOld.(*appsv1.Deployment)
+			if !ok {
+				return false
+			}
+			newDeployment, ok := e.ObjectNew.(*appsv1.Deployment)
+			if !ok {
+				return false
+			}
+			return isRegistryDeployment(newDeployment) &&
+				(oldDeployment.Status.AvailableReplicas != newDeployment.Status.AvailableReplicas ||
+					registryImage(oldDeployment) != registryImage(newDeployment))
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+	}
+}
+
+// Reconcile reads the registry Deployment and updates the DeploymentReady condition and the active image annotation of the served DockerRegistry CR
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch
+// +kubebuilder:rbac:groups=operator.kyma-project.io,resources=dockerregistries,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=operator.kyma-project.io,resources=dockerregistries/status,verbs=get;update;patch
+
+func (r *DeploymentReconciler) Reconcile(ctx context.Context, request ctrl.Request) (ctrl.Result, error) {
+	deployment := &appsv1.Deployment{}
+	if err := r.client.Get(ctx, request.NamespacedName, deployment); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	dockerRegistries := &v1alpha1.DockerRegistryList{}
+	if err := r.client.List(ctx, dockerRegistries, client.InNamespace(deployment.GetNamespace())); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var errs []error
+	for i := range dockerRegistries.Items {
+		instance := &dockerRegistries.Items[i]
+		if instance.Status.Served != v1alpha1.ServedTrue || !instance.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+
+		if err := r.updateActiveImage(ctx, instance, registryImage(deployment)); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		// the paused reconciliation keeps the Ready condition until the annotation is removed
+		if instance.IsPaused() {
+			continue
+		}
+
+		status := instance.Status.DeepCopy()
+		state.UpdateDeploymentCondition(instance, deployment)
+		if reflect.DeepEqual(*status, instance.Status) {
+			continue
+		}
+
+		r.Log.With("name", instance.GetName(), "namespace", instance.GetNamespace()).
+			Debugf("Updating DeploymentReady condition, %d replicas available", deployment.Status.AvailableReplicas)
+		if err := r.client.Status().Update(ctx, instance); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return ctrl.Result{}, goerrors.Join(errs...)
+}
+
+// updateActiveImage records the registry image actually in use so it can be audited without reading the Deployment
+func (r *DeploymentReconciler) updateActiveImage(ctx context.Context, instance *v1alpha1.DockerRegistry, image string) error {
+	if image == "" || instance.GetAnnotations()[v1alpha1.ActiveImageAnnotation] == image {
+		return nil
+	}
+
+	annotations := instance.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[v1alpha1.ActiveImageAnnotation] = image
+	instance.SetAnnotations(annotations)
+
+	r.Log.With("name", instance.GetName(), "namespace", instance.GetNamespace()).
+		Debugf("Updating active image annotation to %s", image)
+	return r.client.Update(ctx, instance)
+}
+
+// registryImage returns the image of the registry container
+func registryImage(deployment *appsv1.Deployment) string {
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name == registryContainerName {
+			return container.Image
+		}
+	}
+	return ""
+}
+
+func isRegistryDeployment(obj client.Object) bool {
+	return obj.GetName() == flags.FullnameOverride &&
+		obj.GetLabels()[managedByLabel] == operatorManagedByName
+}
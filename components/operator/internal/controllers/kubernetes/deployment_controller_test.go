@@ -0,0 +1,171 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestDeploymentReconciler_Reconcile(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+	require.NoError(t, v1alpha1.AddToScheme(testScheme))
+
+	testCases := map[string]struct {
+		givenAvailableReplicas int32
+		expectedStatus         metav1.ConditionStatus
+		expectedReason         v1alpha1.ConditionReason
+	}{
+		"set condition true when replicas are available": {
+			givenAvailableReplicas: 1,
+			expectedStatus:         metav1.ConditionTrue,
+			expectedReason:         v1alpha1.ConditionReasonDeploymentAvailable,
+		},
+		"set condition unknown when rollout is in progress": {
+			givenAvailableReplicas: 0,
+			expectedStatus:         metav1.ConditionUnknown,
+			expectedReason:         v1alpha1.ConditionReasonDeploymentProgressing,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			served := fixDockerRegistry("default", v1alpha1.ServedTrue)
+			notServed := fixDockerRegistry("second", v1alpha1.ServedFalse)
+			deployment := fixDeployment(testCase.givenAvailableReplicas)
+			c := fake.NewClientBuilder().
+				WithScheme(testScheme).
+				WithObjects(served, notServed, deployment).
+				WithStatusSubresource(served, notServed).
+				Build()
+			r := NewDeployment(c, zap.NewNop().Sugar())
+
+			_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployment)})
+			require.NoError(t, err)
+
+			instance := &v1alpha1.DockerRegistry{}
+			require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(served), instance))
+			condition := meta.FindStatusCondition(instance.Status.Conditions, string(v1alpha1.ConditionTypeDeploymentReady))
+			require.NotNil(t, condition)
+			require.Equal(t, testCase.expectedStatus, condition.Status)
+			require.Equal(t, string(testCase.expectedReason), condition.Reason)
+			require.NotNil(t, meta.FindStatusCondition(instance.Status.Conditions, string(v1alpha1.ConditionTypeReady)))
+			require.Equal(t, testRegistryImage, instance.GetAnnotations()[v1alpha1.ActiveImageAnnotation])
+
+			require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(notServed), instance))
+			require.Empty(t, instance.Status.Conditions)
+			require.Empty(t, instance.GetAnnotations())
+		})
+	}
+
+	t.Run("keep conditions of paused instance", func(t *testing.T) {
+		served := fixDockerRegistry("default", v1alpha1.ServedTrue)
+		served.SetAnnotations(map[string]string{v1alpha1.PausedAnnotation: "true"})
+		served.UpdateConditionUnknown(
+			v1alpha1.ConditionTypeReady,
+			v1alpha1.ConditionReasonPaused,
+			"Reconciliation paused, remove the paused annotation to resume it",
+		)
+		deployment := fixDeployment(1)
+		c := fake.NewClientBuilder().
+			WithScheme(testScheme).
+			WithObjects(served, deployment).
+			WithStatusSubresource(served).
+			Build()
+		r := NewDeployment(c, zap.NewNop().Sugar())
+
+		_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(deployment)})
+		require.NoError(t, err)
+
+		instance := &v1alpha1.DockerRegistry{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(served), instance))
+		require.Nil(t, meta.FindStatusCondition(instance.Status.Conditions, string(v1alpha1.ConditionTypeDeploymentReady)))
+		condition := meta.FindStatusCondition(instance.Status.Conditions, string(v1alpha1.ConditionTypeReady))
+		require.NotNil(t, condition)
+		require.Equal(t, string(v1alpha1.ConditionReasonPaused), condition.Reason)
+		require.Equal(t, testRegistryImage, instance.GetAnnotations()[v1alpha1.ActiveImageAnnotation])
+	})
+
+	t.Run("ignore missing deployment", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(testScheme).Build()
+		r := NewDeployment(c, zap.NewNop().Sugar())
+
+		_, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(fixDeployment(0))})
+		require.NoError(t, err)
+	})
+}
+
+func TestDeploymentReconciler_predicate(t *testing.T) {
+	p := NewDeployment(nil, zap.NewNop().Sugar()).predicate()
+
+	t.Run("accept available replicas change", func(t *testing.T) {
+		require.True(t, p.Update(event.UpdateEvent{ObjectOld: fixDeployment(0), ObjectNew: fixDeployment(1)}))
+	})
+
+	t.Run("accept registry image change", func(t *testing.T) {
+		deployment := fixDeployment(1)
+		deployment.Spec.Template.Spec.Containers[0].Image = "mirror.local/registry:3.0.1"
+		require.True(t, p.Update(event.UpdateEvent{ObjectOld: fixDeployment(1), ObjectNew: deployment}))
+	})
+
+	t.Run("ignore update without available replicas change", func(t *testing.T) {
+		require.False(t, p.Update(event.UpdateEvent{ObjectOld: fixDeployment(1), ObjectNew: fixDeployment(1)}))
+	})
+
+	t.Run("ignore deployment not managed by the operator", func(t *testing.T) {
+		deployment := fixDeployment(1)
+		deployment.Labels = nil
+		require.False(t, p.Create(event.CreateEvent{Object: deployment}))
+	})
+}
+
+const testRegistryImage = "europe-docker.pkg.dev/kyma-project/prod/external/library/registry:3.0.0"
+
+func fixDockerRegistry(name string, served v1alpha1.Served) *v1alpha1.DockerRegistry {
+	return &v1alpha1.DockerRegistry{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "kyma-system",
+		},
+		Status: v1alpha1.DockerRegistryStatus{
+			Served: served,
+		},
+	}
+}
+
+func fixDeployment(availableReplicas int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dockerregistry",
+			Namespace: "kyma-system",
+			Labels: map[string]string{
+				managedByLabel: operatorManagedByName,
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{Name: "docker-registry", Image: testRegistryImage},
+					},
+				},
+			},
+		},
+		Status: appsv1.DeploymentStatus{
+			AvailableReplicas: availableReplicas,
+		},
+	}
+}
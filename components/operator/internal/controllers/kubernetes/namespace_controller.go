@@ -4,36 +4,66 @@ import (
 	"context"
 	goerrors "errors"
 	"fmt"
+	"reflect"
 
 	"go.uber.org/zap"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
 type NamespaceReconciler struct {
-	Log       *zap.SugaredLogger
-	client    client.Client
-	config    Config
-	secretSvc SecretService
+	Log           *zap.SugaredLogger
+	client        client.Client
+	config        Config
+	secretSvc     SecretService
+	saSvc         ServiceAccountService
+	excluded      *namespaceMatcher
+	rateLimiter   workqueue.TypedRateLimiter[ctrl.Request]
+	maxConcurrent int
 }
 
 func NewNamespace(client client.Client, log *zap.SugaredLogger, config Config,
-	secretSvc SecretService) *NamespaceReconciler {
+	secretSvc SecretService, saSvc ServiceAccountService) *NamespaceReconciler {
 	return &NamespaceReconciler{
 		client:    client,
 		Log:       log,
 		config:    config,
 		secretSvc: secretSvc,
+		saSvc:     saSvc,
 	}
 }
 
+// WithRateLimiter sets the rate limiter of the controller work queue, the controller-runtime default one is used otherwise
+func (r *NamespaceReconciler) WithRateLimiter(rateLimiter workqueue.TypedRateLimiter[ctrl.Request]) *NamespaceReconciler {
+	r.rateLimiter = rateLimiter
+	return r
+}
+
+// WithMaxConcurrentReconciles sets how many namespaces are reconciled at once, the default is one
+func (r *NamespaceReconciler) WithMaxConcurrentReconciles(maxConcurrent int) *NamespaceReconciler {
+	r.maxConcurrent = maxConcurrent
+	return r
+}
+
 func (r *NamespaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	excluded, err := compileNamespaceSelectors(r.config.ExcludedNamespaces)
+	if err != nil {
+		return err
+	}
+	r.excluded = excluded
+
 	return ctrl.NewControllerManagedBy(mgr).
 		Named("namespace-controller").
+		WithOptions(controller.Options{
+			RateLimiter:             r.rateLimiter,
+			MaxConcurrentReconciles: r.maxConcurrent,
+		}).
 		For(&corev1.Namespace{}).
 		WithEventFilter(r.predicate()).
 		Complete(r)
@@ -46,13 +76,18 @@ func (r *NamespaceReconciler) predicate() predicate.Predicate {
 			if !ok {
 				return false
 			}
-			return !isExcludedNamespace(namespace.Name, r.config.BaseNamespace, r.config.ExcludedNamespaces)
+			return !isExcludedNamespace(namespace.Name, r.config.GetBaseNamespaces(), r.excluded)
 		},
 		GenericFunc: func(genericEvent event.GenericEvent) bool {
 			return false
 		},
 		UpdateFunc: func(e event.UpdateEvent) bool {
-			return false
+			// labels and annotations select namespaces receiving the secrets
+			if reflect.DeepEqual(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels()) &&
+				reflect.DeepEqual(e.ObjectOld.GetAnnotations(), e.ObjectNew.GetAnnotations()) {
+				return false
+			}
+			return !isExcludedNamespace(e.ObjectNew.GetName(), r.config.GetBaseNamespaces(), r.excluded)
 		},
 		DeleteFunc: func(e event.DeleteEvent) bool {
 			return false
@@ -72,18 +107,40 @@ func (r *NamespaceReconciler) Reconcile(ctx context.Context, request ctrl.Reques
 
 	logger := r.Log.With("name", instance.GetName())
 
+	selected, err := GetNamespaceFilter(ctx, r.client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !selected(instance) {
+		// the secret controller removes secrets left in the namespace
+		logger.Debug("Skipping namespace not selected by the secret propagation")
+		return ctrl.Result{}, nil
+	}
+
 	logger.Debug(fmt.Sprintf("Updating Secret in namespace '%s'", instance.GetName()))
 	var errs []error
 	secrets, err := r.secretSvc.GetBase(ctx)
 	if err != nil {
 		errs = append(errs, err)
 	}
+	secretNames := []string{}
 	for _, secret := range secrets {
 		err = r.secretSvc.UpdateNamespace(ctx, logger, instance.GetName(), &secret)
 		if err != nil {
 			errs = append(errs, err)
+			continue
 		}
+		secretNames = append(secretNames, secret.GetName())
 	}
 
-	return ctrl.Result{}, goerrors.Join(errs...)
+	logger.Debug(fmt.Sprintf("Updating ServiceAccounts in namespace '%s'", instance.GetName()))
+	if err := r.saSvc.UpdateNamespace(ctx, logger, instance.GetName(), secretNames); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) != 0 {
+		return ctrl.Result{}, goerrors.Join(errs...)
+	}
+	// requeue to restore the secrets removed from the namespace and to update service accounts created later
+	return ctrl.Result{RequeueAfter: min(r.config.SecretRequeueDuration, r.config.ServiceAccountRequeueDuration)}, nil
 }
@@ -2,21 +2,34 @@ package kubernetes
 
 import (
 	"context"
+	"reflect"
+	"sort"
 
 	"go.uber.org/zap"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/workqueue"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/state"
 )
 
 type SecretReconciler struct {
-	Log    *zap.SugaredLogger
-	client client.Client
-	config Config
-	svc    SecretService
+	Log           *zap.SugaredLogger
+	client        client.Client
+	config        Config
+	svc           SecretService
+	excluded      *namespaceMatcher
+	rateLimiter   workqueue.TypedRateLimiter[ctrl.Request]
+	maxConcurrent int
 }
 
 func NewSecret(client client.Client, log *zap.SugaredLogger, config Config, secretSvc SecretService) *SecretReconciler {
@@ -28,14 +41,70 @@ func NewSecret(client client.Client, log *zap.SugaredLogger, config Config, secr
 	}
 }
 
+// WithRateLimiter sets the rate limiter of the controller work queue, the controller-runtime default one is used otherwise
+func (r *SecretReconciler) WithRateLimiter(rateLimiter workqueue.TypedRateLimiter[ctrl.Request]) *SecretReconciler {
+	r.rateLimiter = rateLimiter
+	return r
+}
+
+// WithMaxConcurrentReconciles sets how many secrets are reconciled at once, the default is one
+func (r *SecretReconciler) WithMaxConcurrentReconciles(maxConcurrent int) *SecretReconciler {
+	r.maxConcurrent = maxConcurrent
+	return r
+}
+
 func (r *SecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	excluded, err := compileNamespaceSelectors(r.config.ExcludedNamespaces)
+	if err != nil {
+		return err
+	}
+	r.excluded = excluded
+
 	return ctrl.NewControllerManagedBy(mgr).
 		Named("secret-controller").
-		For(&corev1.Secret{}).
-		WithEventFilter(r.predicate()).
+		WithOptions(controller.Options{
+			RateLimiter:             r.rateLimiter,
+			MaxConcurrentReconciles: r.maxConcurrent,
+		}).
+		For(&corev1.Secret{}, builder.WithPredicates(r.predicate())).
+		Watches(&corev1.Namespace{},
+			handler.EnqueueRequestsFromMapFunc(r.mapNamespaceToBaseSecrets),
+			builder.WithPredicates(r.namespaceDeletionPredicate())).
 		Complete(r)
 }
 
+// mapNamespaceToBaseSecrets enqueues base secrets so the copies from the deleted namespace are cleaned up
+func (r *SecretReconciler) mapNamespaceToBaseSecrets(ctx context.Context, _ client.Object) []ctrl.Request {
+	bases, err := r.svc.GetBase(ctx)
+	if err != nil {
+		r.Log.Error("while getting base secrets", "error", err)
+		return nil
+	}
+
+	requests := make([]ctrl.Request, 0, len(bases))
+	for _, base := range bases {
+		requests = append(requests, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(&base)})
+	}
+	return requests
+}
+
+func (r *SecretReconciler) namespaceDeletionPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return e.ObjectOld.GetDeletionTimestamp().IsZero() && !e.ObjectNew.GetDeletionTimestamp().IsZero()
+		},
+		GenericFunc: func(event.GenericEvent) bool {
+			return false
+		},
+		DeleteFunc: func(event.DeleteEvent) bool {
+			return true
+		},
+	}
+}
+
 func (r *SecretReconciler) predicate() predicate.Predicate {
 	return predicate.Funcs{
 		CreateFunc: func(e event.CreateEvent) bool {
@@ -81,7 +150,12 @@ func (r *SecretReconciler) Reconcile(ctx context.Context, request ctrl.Request)
 
 	logger := r.Log.With("namespace", instance.GetNamespace(), "name", instance.GetName())
 
-	namespaces, err := getNamespaces(ctx, r.client, r.config.BaseNamespace, r.config.ExcludedNamespaces)
+	selected, err := GetNamespaceFilter(ctx, r.client)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	namespaces, err := getNamespaces(ctx, r.client, r.config.GetBaseNamespaces(), r.excluded, selected)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
@@ -93,11 +167,97 @@ func (r *SecretReconciler) Reconcile(ctx context.Context, request ctrl.Request)
 		return ctrl.Result{}, nil
 	}
 
+	if err := r.svc.CleanupOrphanSecrets(ctx, logger, selected); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if !containsString(r.config.GetPropagatedSecretNames(), instance.GetName()) {
+		logger.Debug("Skipping Secret with the disabled propagation")
+		return ctrl.Result{RequeueAfter: r.config.SecretRequeueDuration}, nil
+	}
+
+	bases, err := r.svc.GetBase(ctx)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !containsSecret(bases, instance) {
+		logger.Debug("Skipping Secret shadowed by the Secret from the preceding base namespace")
+		return ctrl.Result{RequeueAfter: r.config.SecretRequeueDuration}, nil
+	}
+
+	retryAfter := r.svc.Sync(ctx, logger, instance, namespaces)
+	if err := r.updateSyncStatus(ctx, namespaces); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if retryAfter > 0 && retryAfter < r.config.SecretRequeueDuration {
+		return ctrl.Result{RequeueAfter: retryAfter}, nil
+	}
+	return ctrl.Result{RequeueAfter: r.config.SecretRequeueDuration}, nil
+}
+
+// updateSyncStatus exposes the propagation state of the namespaces in the served DockerRegistry status,
+// the status is updated only when the state of any namespace changes
+// +kubebuilder:rbac:groups=operator.kyma-project.io,resources=dockerregistries/status,verbs=get;update;patch
+func (r *SecretReconciler) updateSyncStatus(ctx context.Context, namespaces []string) error {
+	instance, err := state.GetServedDockerRegistry(ctx, r.client)
+	if err != nil || instance == nil {
+		return err
+	}
+
+	syncStatus, summary := secretSyncStatus(instance.Status.SecretSyncStatus, namespaces, r.svc.GetSyncFailures(), metav1.Now())
+	if reflect.DeepEqual(syncStatus, instance.Status.SecretSyncStatus) && reflect.DeepEqual(summary, instance.Status.SecretSyncSummary) {
+		return nil
+	}
+
+	instance.Status.SecretSyncStatus = syncStatus
+	instance.Status.SecretSyncSummary = summary
+	return r.client.Status().Update(ctx, instance)
+}
+
+// secretSyncStatus returns the state of the failed namespaces keeping the transition time of the ones which failed
+// before and the number of synced and failed namespaces, only the first failed namespaces in the alphabetical order
+// are listed so the status of the CR doesn't grow with the number of namespaces
+func secretSyncStatus(current map[string]v1alpha1.SecretSyncStatus, namespaces []string, failures map[string]string, now metav1.Time) (map[string]v1alpha1.SecretSyncStatus, *v1alpha1.SecretSyncSummary) {
+	if len(namespaces) == 0 {
+		return nil, nil
+	}
+
+	summary := &v1alpha1.SecretSyncSummary{}
+	failed := []string{}
 	for _, namespace := range namespaces {
-		if err = r.svc.UpdateNamespace(ctx, logger, namespace, instance); err != nil {
-			return ctrl.Result{}, err
+		if _, ok := failures[namespace]; ok {
+			failed = append(failed, namespace)
+			continue
 		}
+		summary.SyncedNamespaces++
+	}
+	summary.FailedNamespaces = int32(len(failed))
+	if len(failed) == 0 {
+		return nil, summary
 	}
 
-	return ctrl.Result{RequeueAfter: r.config.SecretRequeueDuration}, nil
+	sort.Strings(failed)
+	if len(failed) > v1alpha1.MaxSecretSyncStatusNamespaces {
+		failed = failed[:v1alpha1.MaxSecretSyncStatusNamespaces]
+	}
+
+	result := make(map[string]v1alpha1.SecretSyncStatus, len(failed))
+	for _, namespace := range failed {
+		status := v1alpha1.SecretSyncStatus{State: v1alpha1.SecretSyncStateFailed, Error: failures[namespace], LastTransitionTime: now}
+		if previous, ok := current[namespace]; ok && previous.State == status.State {
+			status.LastTransitionTime = previous.LastTransitionTime
+		}
+		result[namespace] = status
+	}
+	return result, summary
+}
+
+func containsSecret(secrets []corev1.Secret, secret *corev1.Secret) bool {
+	for _, item := range secrets {
+		if item.GetNamespace() == secret.GetNamespace() && item.GetName() == secret.GetName() {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,62 @@
+package kubernetes
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+)
+
+func Test_secretSyncStatus(t *testing.T) {
+	before := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	now := metav1.NewTime(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+
+	current := map[string]v1alpha1.SecretSyncStatus{
+		"synced":  {State: v1alpha1.SecretSyncStateSynced, LastTransitionTime: before},
+		"failed":  {State: v1alpha1.SecretSyncStateFailed, Error: "old error", LastTransitionTime: before},
+		"fixed":   {State: v1alpha1.SecretSyncStateFailed, Error: "old error", LastTransitionTime: before},
+		"removed": {State: v1alpha1.SecretSyncStateSynced, LastTransitionTime: before},
+	}
+	failures := map[string]string{
+		"failed": "new error",
+		"broken": "new error",
+	}
+
+	result, summary := secretSyncStatus(current, []string{"synced", "failed", "fixed", "broken", "new"}, failures, now)
+
+	require.Equal(t, map[string]v1alpha1.SecretSyncStatus{
+		"failed": {State: v1alpha1.SecretSyncStateFailed, Error: "new error", LastTransitionTime: before},
+		"broken": {State: v1alpha1.SecretSyncStateFailed, Error: "new error", LastTransitionTime: now},
+	}, result)
+	require.Equal(t, &v1alpha1.SecretSyncSummary{SyncedNamespaces: 3, FailedNamespaces: 2}, summary)
+}
+
+func Test_secretSyncStatus_limitsFailedNamespaces(t *testing.T) {
+	now := metav1.NewTime(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+
+	namespaces := []string{"synced"}
+	failures := map[string]string{}
+	for i := 0; i < v1alpha1.MaxSecretSyncStatusNamespaces+10; i++ {
+		namespace := fmt.Sprintf("failed-%03d", i)
+		namespaces = append(namespaces, namespace)
+		failures[namespace] = "error"
+	}
+
+	result, summary := secretSyncStatus(nil, namespaces, failures, now)
+
+	require.Len(t, result, v1alpha1.MaxSecretSyncStatusNamespaces)
+	require.Contains(t, result, "failed-000")
+	require.NotContains(t, result, fmt.Sprintf("failed-%03d", v1alpha1.MaxSecretSyncStatusNamespaces))
+	require.Equal(t, &v1alpha1.SecretSyncSummary{SyncedNamespaces: 1, FailedNamespaces: int32(v1alpha1.MaxSecretSyncStatusNamespaces + 10)}, summary)
+}
+
+func Test_secretSyncStatus_allSynced(t *testing.T) {
+	result, summary := secretSyncStatus(nil, []string{"default", "team-a"}, nil, metav1.Now())
+
+	require.Nil(t, result)
+	require.Equal(t, &v1alpha1.SecretSyncSummary{SyncedNamespaces: 2}, summary)
+}
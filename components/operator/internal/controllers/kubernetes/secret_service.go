@@ -4,12 +4,17 @@ import (
 	"context"
 	goerrors "errors"
 	"fmt"
+	"sort"
+	"sync"
+	"time"
+
 	"go.uber.org/zap"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/kyma-project/docker-registry/components/operator/internal/resource"
@@ -19,6 +24,11 @@ const (
 	FunctionManagedByLabel         = "dockerregistry.kyma-project.io/managed-by"
 	cfgSecretFinalizerName         = "dockerregistry.kyma-project.io/finalizer-registry-config"
 	FunctionResourceLabelUserValue = "user"
+
+	// secretRetryBaseDelay is the first backoff of the failed propagation, it doubles with each failed attempt
+	// up to the SecretRequeueDuration
+	secretRetryBaseDelay    = 5 * time.Second
+	secretRetryJitterFactor = 0.2
 )
 
 type SecretService interface {
@@ -26,6 +36,9 @@ type SecretService interface {
 	GetBase(ctx context.Context) ([]corev1.Secret, error)
 	UpdateNamespace(ctx context.Context, logger *zap.SugaredLogger, namespace string, baseInstance *corev1.Secret) error
 	HandleFinalizer(ctx context.Context, logger *zap.SugaredLogger, secret *corev1.Secret, namespaces []string) error
+	CleanupOrphanSecrets(ctx context.Context, logger *zap.SugaredLogger, selected NamespaceFilter) error
+	Sync(ctx context.Context, logger *zap.SugaredLogger, baseInstance *corev1.Secret, namespaces []string) time.Duration
+	GetSyncFailures() map[string]string
 }
 
 var _ SecretService = &secretService{}
@@ -33,36 +46,50 @@ var _ SecretService = &secretService{}
 type secretService struct {
 	client resource.Client
 	config Config
+
+	failuresMu sync.Mutex
+	// failures contains namespaces the base secrets couldn't be copied to, by the namespace and the base secret name
+	failures map[string]map[string]*syncFailure
+}
+
+type syncFailure struct {
+	attempts int
+	err      error
 }
 
 func NewSecretService(client resource.Client, config Config) SecretService {
 	return &secretService{
-		client: client,
-		config: config,
+		client:   client,
+		config:   config,
+		failures: map[string]map[string]*syncFailure{},
 	}
 }
 
+// GetBase returns base secrets from all base namespaces, secret from the preceding namespace wins when names are duplicated
 func (r *secretService) GetBase(ctx context.Context) ([]corev1.Secret, error) {
 	var secrets []corev1.Secret
 	var errs []error
-	for _, secretName := range []string{r.config.BaseInternalSecretName, r.config.BaseExternalSecretName} {
-		secret := &corev1.Secret{}
-		err := r.client.Get(ctx, types.NamespacedName{
-			Namespace: r.config.BaseNamespace,
-			Name:      secretName,
-		}, secret)
-		if err == nil {
-			secrets = append(secrets, *secret)
-		}
-		if client.IgnoreNotFound(err) != nil {
-			errs = append(errs, err)
+	for _, secretName := range r.config.GetPropagatedSecretNames() {
+		for _, namespace := range r.config.GetBaseNamespaces() {
+			secret := &corev1.Secret{}
+			err := r.client.Get(ctx, types.NamespacedName{
+				Namespace: namespace,
+				Name:      secretName,
+			}, secret)
+			if err == nil {
+				secrets = append(secrets, *secret)
+				break
+			}
+			if client.IgnoreNotFound(err) != nil {
+				errs = append(errs, err)
+			}
 		}
 	}
 	return secrets, goerrors.Join(errs...)
 }
 
 func (r *secretService) IsBase(secret *corev1.Secret) bool {
-	result := secret.Namespace == r.config.BaseNamespace &&
+	result := containsString(r.config.GetBaseNamespaces(), secret.Namespace) &&
 		(secret.Name == r.config.BaseInternalSecretName ||
 			secret.Name == r.config.BaseExternalSecretName) &&
 		secret.Labels[ConfigLabel] == CredentialsLabelValue
@@ -85,6 +112,113 @@ func (r *secretService) UpdateNamespace(ctx context.Context, logger *zap.Sugared
 	return r.updateSecret(ctx, logger, instance, baseInstance)
 }
 
+// Sync copies the base secret to all namespaces without stopping on the first failure, so the unavailable API server
+// leaves only some namespaces with stale credentials. The failed namespaces are remembered and synced first next time,
+// the returned duration is the jittered backoff of the retry or zero if the secret was copied to all namespaces
+func (r *secretService) Sync(ctx context.Context, logger *zap.SugaredLogger, baseInstance *corev1.Secret, namespaces []string) time.Duration {
+	maxAttempts := 0
+	for _, namespace := range r.failedFirst(baseInstance.GetName(), namespaces) {
+		err := r.UpdateNamespace(ctx, logger, namespace, baseInstance)
+		if err != nil {
+			logger.Warnf("Propagating Secret '%s' to namespace '%s' failed, it will be retried: %s", baseInstance.GetName(), namespace, err)
+		}
+		maxAttempts = max(maxAttempts, r.recordSync(namespace, baseInstance.GetName(), err))
+	}
+	r.forgetFailures(baseInstance.GetName(), namespaces)
+
+	if maxAttempts == 0 {
+		return 0
+	}
+	return secretRetryBackoff(maxAttempts, r.config.SecretRequeueDuration)
+}
+
+// GetSyncFailures returns the propagation errors of all base secrets by the namespace
+func (r *secretService) GetSyncFailures() map[string]string {
+	r.failuresMu.Lock()
+	defer r.failuresMu.Unlock()
+
+	result := map[string]string{}
+	for namespace, secrets := range r.failures {
+		var errs []error
+		for name, failure := range secrets {
+			errs = append(errs, fmt.Errorf("secret %s: %w", name, failure.err))
+		}
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+		result[namespace] = goerrors.Join(errs...).Error()
+	}
+	return result
+}
+
+// failedFirst orders namespaces so the ones the secret failed to be copied to are retried before the others
+func (r *secretService) failedFirst(secretName string, namespaces []string) []string {
+	r.failuresMu.Lock()
+	defer r.failuresMu.Unlock()
+
+	ordered := make([]string, 0, len(namespaces))
+	rest := make([]string, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		if r.failures[namespace][secretName] != nil {
+			ordered = append(ordered, namespace)
+			continue
+		}
+		rest = append(rest, namespace)
+	}
+	return append(ordered, rest...)
+}
+
+// recordSync remembers the failed attempt or forgets the previous failures, it returns the number of failed attempts in a row
+func (r *secretService) recordSync(namespace, secretName string, err error) int {
+	r.failuresMu.Lock()
+	defer r.failuresMu.Unlock()
+
+	if err == nil {
+		r.deleteFailure(namespace, secretName)
+		return 0
+	}
+
+	if r.failures[namespace] == nil {
+		r.failures[namespace] = map[string]*syncFailure{}
+	}
+	failure := r.failures[namespace][secretName]
+	if failure == nil {
+		failure = &syncFailure{}
+		r.failures[namespace][secretName] = failure
+	}
+	failure.attempts++
+	failure.err = err
+	return failure.attempts
+}
+
+// forgetFailures drops failures of the secret in namespaces it's not propagated to anymore
+func (r *secretService) forgetFailures(secretName string, namespaces []string) {
+	r.failuresMu.Lock()
+	defer r.failuresMu.Unlock()
+
+	for namespace := range r.failures {
+		if !containsString(namespaces, namespace) {
+			r.deleteFailure(namespace, secretName)
+		}
+	}
+}
+
+func (r *secretService) deleteFailure(namespace, secretName string) {
+	delete(r.failures[namespace], secretName)
+	if len(r.failures[namespace]) == 0 {
+		delete(r.failures, namespace)
+	}
+}
+
+func secretRetryBackoff(attempts int, limit time.Duration) time.Duration {
+	backoff := secretRetryBaseDelay
+	for i := 1; i < attempts && backoff < limit; i++ {
+		backoff *= 2
+	}
+	if limit > 0 && backoff > limit {
+		backoff = limit
+	}
+	return wait.Jitter(backoff, secretRetryJitterFactor)
+}
+
 func (r *secretService) HandleFinalizer(ctx context.Context, logger *zap.SugaredLogger, instance *corev1.Secret, namespaces []string) error {
 	if instance.ObjectMeta.DeletionTimestamp.IsZero() {
 		if containsString(instance.ObjectMeta.Finalizers, cfgSecretFinalizerName) {
@@ -112,6 +246,48 @@ func (r *secretService) HandleFinalizer(ctx context.Context, logger *zap.Sugared
 	return nil
 }
 
+// CleanupOrphanSecrets deletes copies of the base secrets from namespaces they are not propagated to anymore,
+// it covers namespaces added to the excluded ones, namespaces not selected by the filter, namespaces being deleted
+// and the disabled external secret propagation
+func (r *secretService) CleanupOrphanSecrets(ctx context.Context, logger *zap.SugaredLogger, selected NamespaceFilter) error {
+	excluded, err := compileNamespaceSelectors(r.config.ExcludedNamespaces)
+	if err != nil {
+		return err
+	}
+
+	namespaces := &corev1.NamespaceList{}
+	if err := r.client.ListByLabel(ctx, "", nil, namespaces); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, namespace := range namespaces.Items {
+		if containsString(r.config.GetBaseNamespaces(), namespace.GetName()) {
+			continue
+		}
+		secretNames := []string{r.config.BaseInternalSecretName, r.config.BaseExternalSecretName}
+		if !excluded.matches(namespace.GetName()) && namespace.Status.Phase != corev1.NamespaceTerminating &&
+			selected(&namespace) {
+			secretNames = r.orphanSecretNames()
+		}
+		for _, secretName := range secretNames {
+			logger.Debug(fmt.Sprintf("Deleting orphan Secret '%s/%s'", namespace.GetName(), secretName))
+			if err := r.deleteSecret(ctx, logger, namespace.GetName(), secretName); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return goerrors.Join(errs...)
+}
+
+// orphanSecretNames returns names of the base secrets which must not be present in propagated namespaces
+func (r *secretService) orphanSecretNames() []string {
+	if r.config.PropagateExternalSecret {
+		return nil
+	}
+	return []string{r.config.BaseExternalSecretName}
+}
+
 func (r *secretService) createSecret(ctx context.Context, logger *zap.SugaredLogger, namespace string, baseInstance *corev1.Secret) error {
 	secret := corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
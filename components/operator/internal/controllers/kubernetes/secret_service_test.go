@@ -0,0 +1,217 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/kyma-project/docker-registry/components/operator/internal/resource"
+)
+
+func TestSecretService_CleanupOrphanSecrets(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+	config := Config{
+		BaseNamespaces:         []string{"kyma-system"},
+		BaseInternalSecretName: "dockerregistry-config",
+		BaseExternalSecretName: "dockerregistry-config-external",
+		ExcludedNamespaces: []NamespaceSelector{
+			{Name: "kyma-system"},
+			{Name: "excluded"},
+			{MatchPattern: "sandbox-.*"},
+		},
+	}
+
+	testCases := map[string]struct {
+		givenNamespace          *corev1.Namespace
+		givenSecret             *corev1.Secret
+		propagateExternalSecret bool
+		notSelected             bool
+		expectDeleted           bool
+	}{
+		"delete secret from excluded namespace": {
+			givenNamespace: fixNamespace("excluded", corev1.NamespaceActive),
+			givenSecret:    fixSecret("excluded", "dockerregistry-config", nil),
+			expectDeleted:  true,
+		},
+		"delete secret from namespace matching excluded pattern": {
+			givenNamespace: fixNamespace("sandbox-1", corev1.NamespaceActive),
+			givenSecret:    fixSecret("sandbox-1", "dockerregistry-config-external", nil),
+			expectDeleted:  true,
+		},
+		"delete secret from terminating namespace": {
+			givenNamespace: fixNamespace("test", corev1.NamespaceTerminating),
+			givenSecret:    fixSecret("test", "dockerregistry-config", nil),
+			expectDeleted:  true,
+		},
+		"keep secret in propagated namespace": {
+			givenNamespace: fixNamespace("test", corev1.NamespaceActive),
+			givenSecret:    fixSecret("test", "dockerregistry-config", nil),
+			expectDeleted:  false,
+		},
+		"delete external secret when its propagation is disabled": {
+			givenNamespace: fixNamespace("test", corev1.NamespaceActive),
+			givenSecret:    fixSecret("test", "dockerregistry-config-external", nil),
+			expectDeleted:  true,
+		},
+		"keep external secret when its propagation is enabled": {
+			givenNamespace:          fixNamespace("test", corev1.NamespaceActive),
+			givenSecret:             fixSecret("test", "dockerregistry-config-external", nil),
+			propagateExternalSecret: true,
+			expectDeleted:           false,
+		},
+		"delete secret from namespace not selected by the filter": {
+			givenNamespace: fixNamespace("test", corev1.NamespaceActive),
+			givenSecret:    fixSecret("test", "dockerregistry-config", nil),
+			notSelected:    true,
+			expectDeleted:  true,
+		},
+		"keep base secret": {
+			givenNamespace: fixNamespace("kyma-system", corev1.NamespaceActive),
+			givenSecret:    fixSecret("kyma-system", "dockerregistry-config", nil),
+			expectDeleted:  false,
+		},
+		"keep secret managed by user": {
+			givenNamespace: fixNamespace("excluded", corev1.NamespaceActive),
+			givenSecret: fixSecret("excluded", "dockerregistry-config",
+				map[string]string{FunctionManagedByLabel: FunctionResourceLabelUserValue}),
+			expectDeleted: false,
+		},
+		"keep other secret in excluded namespace": {
+			givenNamespace: fixNamespace("excluded", corev1.NamespaceActive),
+			givenSecret:    fixSecret("excluded", "other", nil),
+			expectDeleted:  false,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(testScheme).
+				WithObjects(testCase.givenNamespace, testCase.givenSecret).Build()
+			config := config
+			config.PropagateExternalSecret = testCase.propagateExternalSecret
+			svc := NewSecretService(resource.New(c, testScheme), config)
+
+			selected := func(metav1.Object) bool { return !testCase.notSelected }
+
+			err := svc.CleanupOrphanSecrets(context.Background(), zap.NewNop().Sugar(), selected)
+			require.NoError(t, err)
+
+			err = c.Get(context.Background(), client.ObjectKeyFromObject(testCase.givenSecret), &corev1.Secret{})
+			if testCase.expectDeleted {
+				require.True(t, errors.IsNotFound(err))
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("invalid excluded namespace pattern", func(t *testing.T) {
+		c := fake.NewClientBuilder().WithScheme(testScheme).Build()
+		svc := NewSecretService(resource.New(c, testScheme), Config{
+			ExcludedNamespaces: []NamespaceSelector{{MatchPattern: "("}},
+		})
+
+		err := svc.CleanupOrphanSecrets(context.Background(), zap.NewNop().Sugar(), func(metav1.Object) bool { return true })
+		require.ErrorContains(t, err, "while compiling excluded namespace pattern")
+	})
+}
+
+func TestSecretService_Sync(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+	base := fixSecret("kyma-system", "dockerregistry-config", nil)
+	base.Data = map[string][]byte{"password": []byte("new")}
+
+	unavailable := true
+	c := fake.NewClientBuilder().WithScheme(testScheme).WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if unavailable && obj.GetNamespace() == "broken" {
+				return errors.NewServiceUnavailable("api server is upgraded")
+			}
+			return c.Create(ctx, obj, opts...)
+		},
+	}).Build()
+	svc := NewSecretService(resource.New(c, testScheme), Config{SecretRequeueDuration: time.Minute}).(*secretService)
+
+	t.Run("copy secret to available namespaces and remember the failed one", func(t *testing.T) {
+		retryAfter := svc.Sync(context.Background(), zap.NewNop().Sugar(), base, []string{"test", "broken"})
+
+		require.InDelta(t, secretRetryBaseDelay, retryAfter, float64(secretRetryBaseDelay)*secretRetryJitterFactor)
+		require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "test", Name: "dockerregistry-config"}, &corev1.Secret{}))
+		require.Equal(t, map[string]string{"broken": "secret dockerregistry-config: api server is upgraded"}, svc.GetSyncFailures())
+		require.Equal(t, []string{"broken", "test", "other"}, svc.failedFirst("dockerregistry-config", []string{"test", "broken", "other"}))
+	})
+
+	t.Run("back off the repeated failure", func(t *testing.T) {
+		retryAfter := svc.Sync(context.Background(), zap.NewNop().Sugar(), base, []string{"test", "broken"})
+
+		require.InDelta(t, 2*secretRetryBaseDelay, retryAfter, float64(2*secretRetryBaseDelay)*secretRetryJitterFactor)
+	})
+
+	t.Run("forget the failure after the successful retry", func(t *testing.T) {
+		unavailable = false
+
+		retryAfter := svc.Sync(context.Background(), zap.NewNop().Sugar(), base, []string{"test", "broken"})
+
+		require.Zero(t, retryAfter)
+		require.Empty(t, svc.GetSyncFailures())
+		require.NoError(t, c.Get(context.Background(), client.ObjectKey{Namespace: "broken", Name: "dockerregistry-config"}, &corev1.Secret{}))
+	})
+
+	t.Run("forget the failure of the namespace not propagated anymore", func(t *testing.T) {
+		svc.recordSync("removed", "dockerregistry-config", errors.NewServiceUnavailable("api server is upgraded"))
+
+		svc.Sync(context.Background(), zap.NewNop().Sugar(), base, []string{"test"})
+
+		require.Empty(t, svc.GetSyncFailures())
+	})
+}
+
+func Test_secretRetryBackoff(t *testing.T) {
+	testCases := map[string]struct {
+		attempts int
+		expected time.Duration
+	}{
+		"first retry":             {attempts: 1, expected: 5 * time.Second},
+		"doubled backoff":         {attempts: 3, expected: 20 * time.Second},
+		"backoff limited by tick": {attempts: 10, expected: time.Minute},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			backoff := secretRetryBackoff(testCase.attempts, time.Minute)
+
+			require.GreaterOrEqual(t, backoff, testCase.expected)
+			require.LessOrEqual(t, backoff, time.Duration(float64(testCase.expected)*(1+secretRetryJitterFactor)))
+		})
+	}
+}
+
+func fixNamespace(name string, phase corev1.NamespacePhase) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     corev1.NamespaceStatus{Phase: phase},
+	}
+}
+
+func fixSecret(namespace, name string, labels map[string]string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+	}
+}
@@ -0,0 +1,82 @@
+package kubernetes
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type ServiceAccountService interface {
+	UpdateNamespace(ctx context.Context, logger *zap.SugaredLogger, namespace string, secretNames []string) error
+}
+
+var _ ServiceAccountService = &serviceAccountService{}
+
+type serviceAccountService struct {
+	client client.Client
+	config Config
+}
+
+func NewServiceAccountService(client client.Client, config Config) ServiceAccountService {
+	return &serviceAccountService{
+		client: client,
+		config: config,
+	}
+}
+
+// UpdateNamespace adds pull secrets to image pull secrets of configured service accounts in the namespace,
+// service accounts which don't exist yet are skipped and updated in the next sync
+func (r *serviceAccountService) UpdateNamespace(ctx context.Context, logger *zap.SugaredLogger, namespace string, secretNames []string) error {
+	var errs []error
+	for _, name := range r.config.GetServiceAccountNames() {
+		if err := r.updateServiceAccount(ctx, logger, namespace, name, secretNames); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return goerrors.Join(errs...)
+}
+
+func (r *serviceAccountService) updateServiceAccount(ctx context.Context, logger *zap.SugaredLogger, namespace, name string, secretNames []string) error {
+	instance := &corev1.ServiceAccount{}
+	if err := r.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, instance); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			logger.Debug(fmt.Sprintf("ServiceAccount '%s/%s' not found", namespace, name))
+			return nil
+		}
+		logger.Error(err, fmt.Sprintf("Gathering existing ServiceAccount '%s/%s' failed", namespace, name))
+		return err
+	}
+	if instance.Labels[FunctionManagedByLabel] == FunctionResourceLabelUserValue {
+		return nil
+	}
+
+	copy := instance.DeepCopy()
+	for _, secretName := range secretNames {
+		if !hasImagePullSecret(copy, secretName) {
+			copy.ImagePullSecrets = append(copy.ImagePullSecrets, corev1.LocalObjectReference{Name: secretName})
+		}
+	}
+	if len(copy.ImagePullSecrets) == len(instance.ImagePullSecrets) {
+		return nil
+	}
+
+	logger.Debug(fmt.Sprintf("Updating ServiceAccount '%s/%s'", namespace, name))
+	if err := r.client.Patch(ctx, copy, client.MergeFrom(instance)); err != nil {
+		logger.Error(err, fmt.Sprintf("Updating ServiceAccount '%s/%s' failed", namespace, name))
+		return err
+	}
+	return nil
+}
+
+func hasImagePullSecret(serviceAccount *corev1.ServiceAccount, name string) bool {
+	for _, secret := range serviceAccount.ImagePullSecrets {
+		if secret.Name == name {
+			return true
+		}
+	}
+	return false
+}
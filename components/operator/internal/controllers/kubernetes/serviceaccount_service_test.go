@@ -0,0 +1,87 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestServiceAccountService_UpdateNamespace(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+	secretNames := []string{"dockerregistry-config", "dockerregistry-config-external"}
+
+	testCases := map[string]struct {
+		givenServiceAccount      *corev1.ServiceAccount
+		expectedImagePullSecrets []corev1.LocalObjectReference
+	}{
+		"add pull secrets to service account": {
+			givenServiceAccount: fixServiceAccount("default", nil, corev1.LocalObjectReference{Name: "user-secret"}),
+			expectedImagePullSecrets: []corev1.LocalObjectReference{
+				{Name: "user-secret"},
+				{Name: "dockerregistry-config"},
+				{Name: "dockerregistry-config-external"},
+			},
+		},
+		"don't duplicate pull secrets": {
+			givenServiceAccount: fixServiceAccount("default", nil, corev1.LocalObjectReference{Name: "dockerregistry-config"}),
+			expectedImagePullSecrets: []corev1.LocalObjectReference{
+				{Name: "dockerregistry-config"},
+				{Name: "dockerregistry-config-external"},
+			},
+		},
+		"skip service account managed by user": {
+			givenServiceAccount: fixServiceAccount("default",
+				map[string]string{FunctionManagedByLabel: FunctionResourceLabelUserValue}),
+			expectedImagePullSecrets: nil,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(testCase.givenServiceAccount).Build()
+			svc := NewServiceAccountService(c, Config{})
+
+			err := svc.UpdateNamespace(context.Background(), zap.NewNop().Sugar(), "test", secretNames)
+			require.NoError(t, err)
+
+			serviceAccount := &corev1.ServiceAccount{}
+			require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(testCase.givenServiceAccount), serviceAccount))
+			require.Equal(t, testCase.expectedImagePullSecrets, serviceAccount.ImagePullSecrets)
+		})
+	}
+
+	t.Run("update configured service accounts", func(t *testing.T) {
+		builder := fixServiceAccount("builder", nil)
+		defaultServiceAccount := fixServiceAccount("default", nil)
+		c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(builder, defaultServiceAccount).Build()
+		svc := NewServiceAccountService(c, Config{ServiceAccountNames: []string{"builder", "missing"}})
+
+		err := svc.UpdateNamespace(context.Background(), zap.NewNop().Sugar(), "test", secretNames)
+		require.NoError(t, err)
+
+		require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(builder), builder))
+		require.Len(t, builder.ImagePullSecrets, 2)
+		require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(defaultServiceAccount), defaultServiceAccount))
+		require.Empty(t, defaultServiceAccount.ImagePullSecrets)
+	})
+}
+
+func fixServiceAccount(name string, labels map[string]string, imagePullSecrets ...corev1.LocalObjectReference) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "test",
+			Labels:    labels,
+		},
+		ImagePullSecrets: imagePullSecrets,
+	}
+}
@@ -2,28 +2,153 @@ package kubernetes
 
 import (
 	"context"
+	"regexp"
 	"time"
 
+	"github.com/pkg/errors"
+	"github.com/vrischmann/envconfig"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/docker-registry/components/operator/internal/state"
 )
 
 const (
 	ConfigLabel           = "dockerregistry.kyma-project.io/config"
 	CredentialsLabelValue = "credentials"
+
+	defaultServiceAccountName = "default"
 )
 
 type Config struct {
-	BaseNamespace                 string        `envconfig:"default=kyma-system"`
-	BaseInternalSecretName        string        `envconfig:"default=dockerregistry-config"`
-	BaseExternalSecretName        string        `envconfig:"default=dockerregistry-config-external"`
-	ExcludedNamespaces            []string      `envconfig:"default=kyma-system"`
-	ConfigMapRequeueDuration      time.Duration `envconfig:"default=1m"`
-	SecretRequeueDuration         time.Duration `envconfig:"default=1m"`
-	ServiceAccountRequeueDuration time.Duration `envconfig:"default=1m"`
+	// Deprecated: use BaseNamespaces, BaseNamespace is kept as an alias of the first base namespace
+	BaseNamespace          string   `envconfig:"optional"`
+	BaseNamespaces         []string `envconfig:"default=kyma-system"`
+	BaseInternalSecretName string   `envconfig:"default=dockerregistry-config"`
+	BaseExternalSecretName string   `envconfig:"default=dockerregistry-config-external"`
+	// BaseConfigMapNames are ConfigMaps from the base namespaces copied to the same namespaces as the secrets
+	BaseConfigMapNames []string `envconfig:"optional"`
+	// ExcludedNamespaces are read from the {name,matchPattern} list, for example {kyma-system,},{,preview-.*}
+	ExcludedNamespaces            []NamespaceSelector `envconfig:"default={kyma-system;}"`
+	ConfigMapRequeueDuration      time.Duration       `envconfig:"default=1m"`
+	SecretRequeueDuration         time.Duration       `envconfig:"default=1m"`
+	ServiceAccountRequeueDuration time.Duration       `envconfig:"default=1m"`
+	ServiceAccountNames           []string            `envconfig:"default=default"`
+	PropagateExternalSecret       bool                `envconfig:"default=true"`
+}
+
+// GetConfig reads the secret propagation config from the environment variables with the given prefix
+func GetConfig(prefix string) (Config, error) {
+	cfg := Config{}
+	err := envconfig.InitWithPrefix(&cfg, prefix)
+	return cfg, err
+}
+
+// GetBaseNamespaces returns namespaces with the base secrets, secrets from the first namespaces take precedence
+func (c Config) GetBaseNamespaces() []string {
+	namespaces := []string{}
+	if c.BaseNamespace != "" {
+		namespaces = append(namespaces, c.BaseNamespace)
+	}
+	for _, namespace := range c.BaseNamespaces {
+		if !containsString(namespaces, namespace) {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
+
+// GetPropagatedSecretNames returns names of the base secrets copied to namespaces, the external one only if its propagation is enabled
+func (c Config) GetPropagatedSecretNames() []string {
+	if !c.PropagateExternalSecret {
+		return []string{c.BaseInternalSecretName}
+	}
+	return []string{c.BaseInternalSecretName, c.BaseExternalSecretName}
+}
+
+// GetServiceAccountNames returns service accounts updated with the pull secrets or the default one
+func (c Config) GetServiceAccountNames() []string {
+	if len(c.ServiceAccountNames) == 0 {
+		return []string{defaultServiceAccountName}
+	}
+	return c.ServiceAccountNames
 }
 
-func getNamespaces(ctx context.Context, client client.Client, base string, excluded []string) ([]string, error) {
+// ExcludedNamespaceMatcher returns the function matching the base namespaces and namespaces selected by the ExcludedNamespaces,
+// the pull secrets are not propagated to them
+func (c Config) ExcludedNamespaceMatcher() (func(namespace string) bool, error) {
+	excluded, err := compileNamespaceSelectors(c.ExcludedNamespaces)
+	if err != nil {
+		return nil, err
+	}
+	bases := c.GetBaseNamespaces()
+	return func(namespace string) bool {
+		return isExcludedNamespace(namespace, bases, excluded)
+	}, nil
+}
+
+// NamespaceSelector selects namespace by the exact Name or by the MatchPattern regexp matching the whole name
+type NamespaceSelector struct {
+	Name         string `json:"name,omitempty"`
+	MatchPattern string `json:"matchPattern,omitempty"`
+}
+
+type namespaceMatcher struct {
+	names    []string
+	patterns []*regexp.Regexp
+}
+
+func compileNamespaceSelectors(selectors []NamespaceSelector) (*namespaceMatcher, error) {
+	matcher := &namespaceMatcher{}
+	for _, selector := range selectors {
+		if selector.Name != "" {
+			matcher.names = append(matcher.names, selector.Name)
+		}
+		if selector.MatchPattern == "" {
+			continue
+		}
+		pattern, err := regexp.Compile("^(?:" + selector.MatchPattern + ")$")
+		if err != nil {
+			return nil, errors.Wrapf(err, "while compiling excluded namespace pattern %s", selector.MatchPattern)
+		}
+		matcher.patterns = append(matcher.patterns, pattern)
+	}
+	return matcher, nil
+}
+
+func (m *namespaceMatcher) matches(name string) bool {
+	if m == nil {
+		return false
+	}
+	if containsString(m.names, name) {
+		return true
+	}
+	for _, pattern := range m.patterns {
+		if pattern.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// NamespaceFilter returns true if the namespace receives the pull secrets
+type NamespaceFilter func(namespace metav1.Object) bool
+
+// GetNamespaceFilter returns the filter of namespaces selected by the secret propagation of the served DockerRegistry CR,
+// all namespaces are selected if no CR is served
+func GetNamespaceFilter(ctx context.Context, c client.Client) (NamespaceFilter, error) {
+	instance, err := state.GetServedDockerRegistry(ctx, c)
+	if err != nil {
+		return nil, errors.Wrap(err, "while getting served dockerregistry")
+	}
+	if instance == nil {
+		return func(metav1.Object) bool { return true }, nil
+	}
+	return instance.PropagatesSecretsTo, nil
+}
+
+func getNamespaces(ctx context.Context, client client.Client, bases []string, excluded *namespaceMatcher, selected NamespaceFilter) ([]string, error) {
 	var namespaces corev1.NamespaceList
 	if err := client.List(ctx, &namespaces); err != nil {
 		return nil, err
@@ -31,7 +156,8 @@ func getNamespaces(ctx context.Context, client client.Client, base string, exclu
 
 	names := make([]string, 0)
 	for _, namespace := range namespaces.Items {
-		if !isExcludedNamespace(namespace.GetName(), base, excluded) && namespace.Status.Phase != corev1.NamespaceTerminating {
+		if !isExcludedNamespace(namespace.GetName(), bases, excluded) && namespace.Status.Phase != corev1.NamespaceTerminating &&
+			selected(&namespace) {
 			names = append(names, namespace.GetName())
 		}
 	}
@@ -39,16 +165,6 @@ func getNamespaces(ctx context.Context, client client.Client, base string, exclu
 	return names, nil
 }
 
-func isExcludedNamespace(name, base string, excluded []string) bool {
-	if name == base {
-		return true
-	}
-
-	for _, namespace := range excluded {
-		if name == namespace {
-			return true
-		}
-	}
-
-	return false
+func isExcludedNamespace(name string, bases []string, excluded *namespaceMatcher) bool {
+	return containsString(bases, name) || excluded.matches(name)
 }
@@ -0,0 +1,179 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+)
+
+func TestGetConfig(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		cfg, err := GetConfig("TEST")
+		require.NoError(t, err)
+		require.Equal(t, Config{
+			BaseNamespaces:                []string{"kyma-system"},
+			BaseInternalSecretName:        "dockerregistry-config",
+			BaseExternalSecretName:        "dockerregistry-config-external",
+			ExcludedNamespaces:            []NamespaceSelector{{Name: "kyma-system"}},
+			ConfigMapRequeueDuration:      time.Minute,
+			SecretRequeueDuration:         time.Minute,
+			ServiceAccountRequeueDuration: time.Minute,
+			ServiceAccountNames:           []string{"default"},
+			PropagateExternalSecret:       true,
+		}, cfg)
+	})
+
+	t.Run("from environment", func(t *testing.T) {
+		t.Setenv("TEST_BASE_NAMESPACES", "kyma-system,registry-config")
+		t.Setenv("TEST_BASE_CONFIG_MAP_NAMES", "trust-bundle")
+		t.Setenv("TEST_SECRET_REQUEUE_DURATION", "5m")
+		t.Setenv("TEST_SERVICE_ACCOUNT_NAMES", "default,builder")
+		t.Setenv("TEST_PROPAGATE_EXTERNAL_SECRET", "false")
+		t.Setenv("TEST_EXCLUDED_NAMESPACES", "{kyma-system,},{,preview-.*}")
+
+		cfg, err := GetConfig("TEST")
+		require.NoError(t, err)
+		require.Equal(t, []string{"kyma-system", "registry-config"}, cfg.GetBaseNamespaces())
+		require.Equal(t, []string{"trust-bundle"}, cfg.BaseConfigMapNames)
+		require.Equal(t, 5*time.Minute, cfg.SecretRequeueDuration)
+		require.Equal(t, []string{"default", "builder"}, cfg.ServiceAccountNames)
+		require.False(t, cfg.PropagateExternalSecret)
+		require.Equal(t, []NamespaceSelector{{Name: "kyma-system"}, {MatchPattern: "preview-.*"}}, cfg.ExcludedNamespaces)
+	})
+}
+
+func Test_isExcludedNamespace(t *testing.T) {
+	excluded, err := compileNamespaceSelectors([]NamespaceSelector{
+		{Name: "istio-system"},
+		{MatchPattern: "preview-.*"},
+	})
+	require.NoError(t, err)
+
+	testCases := map[string]bool{
+		"kyma-system":     true,
+		"istio-system":    true,
+		"preview-123":     true,
+		"not-preview-123": false,
+		"default":         false,
+	}
+
+	for name, expected := range testCases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, expected, isExcludedNamespace(name, []string{"kyma-system"}, excluded))
+		})
+	}
+}
+
+func Test_compileNamespaceSelectors(t *testing.T) {
+	_, err := compileNamespaceSelectors([]NamespaceSelector{{MatchPattern: "preview-("}})
+	require.ErrorContains(t, err, "while compiling excluded namespace pattern preview-(")
+}
+
+func TestConfig_ExcludedNamespaceMatcher(t *testing.T) {
+	config := Config{
+		BaseNamespaces:     []string{"kyma-system"},
+		ExcludedNamespaces: []NamespaceSelector{{MatchPattern: "preview-.*"}},
+	}
+	isExcluded, err := config.ExcludedNamespaceMatcher()
+	require.NoError(t, err)
+
+	require.True(t, isExcluded("kyma-system"))
+	require.True(t, isExcluded("preview-123"))
+	require.False(t, isExcluded("default"))
+
+	config.ExcludedNamespaces = []NamespaceSelector{{MatchPattern: "preview-("}}
+	_, err = config.ExcludedNamespaceMatcher()
+	require.ErrorContains(t, err, "while compiling excluded namespace pattern preview-(")
+}
+
+func TestConfig_GetPropagatedSecretNames(t *testing.T) {
+	config := Config{
+		BaseInternalSecretName: "internal",
+		BaseExternalSecretName: "external",
+	}
+	require.Equal(t, []string{"internal"}, config.GetPropagatedSecretNames())
+
+	config.PropagateExternalSecret = true
+	require.Equal(t, []string{"internal", "external"}, config.GetPropagatedSecretNames())
+}
+
+func TestGetNamespaceFilter(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+	require.NoError(t, v1alpha1.AddToScheme(testScheme))
+
+	labeled := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "labeled",
+		Labels: map[string]string{"team": "a"},
+	}}
+	annotated := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:        "annotated",
+		Annotations: map[string]string{v1alpha1.InjectSecretAnnotation: "true"},
+	}}
+
+	testCases := map[string]struct {
+		givenPropagation  *v1alpha1.SecretPropagation
+		servedRegistry    bool
+		expectedLabeled   bool
+		expectedAnnotated bool
+	}{
+		"no served dockerregistry": {
+			givenPropagation:  &v1alpha1.SecretPropagation{Mode: v1alpha1.SecretPropagationModeAnnotationOptIn},
+			expectedLabeled:   true,
+			expectedAnnotated: true,
+		},
+		"default mode": {
+			servedRegistry:    true,
+			expectedLabeled:   true,
+			expectedAnnotated: true,
+		},
+		"all namespaces mode": {
+			givenPropagation:  &v1alpha1.SecretPropagation{Mode: v1alpha1.SecretPropagationModeAllNamespaces},
+			servedRegistry:    true,
+			expectedLabeled:   true,
+			expectedAnnotated: true,
+		},
+		"label selector mode": {
+			givenPropagation: &v1alpha1.SecretPropagation{
+				Mode:              v1alpha1.SecretPropagationModeLabelSelector,
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+			},
+			servedRegistry:    true,
+			expectedLabeled:   true,
+			expectedAnnotated: false,
+		},
+		"annotation opt-in mode": {
+			givenPropagation:  &v1alpha1.SecretPropagation{Mode: v1alpha1.SecretPropagationModeAnnotationOptIn},
+			servedRegistry:    true,
+			expectedLabeled:   false,
+			expectedAnnotated: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			dockerRegistry := &v1alpha1.DockerRegistry{
+				ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "kyma-system"},
+				Spec:       v1alpha1.DockerRegistrySpec{SecretPropagation: testCase.givenPropagation},
+			}
+			if testCase.servedRegistry {
+				dockerRegistry.Status.Served = v1alpha1.ServedTrue
+			}
+			c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(dockerRegistry).Build()
+
+			selected, err := GetNamespaceFilter(context.Background(), c)
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedLabeled, selected(labeled))
+			require.Equal(t, testCase.expectedAnnotated, selected(annotated))
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package conversion
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/api/v1beta1"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+)
+
+func TestDockerRegistryConversion(t *testing.T) {
+	data, err := os.ReadFile("testdata/dockerregistry_v1alpha1.yaml")
+	require.NoError(t, err)
+
+	original := &v1alpha1.DockerRegistry{}
+	require.NoError(t, yaml.UnmarshalStrict(data, original))
+
+	t.Run("convert every field to v1beta1", func(t *testing.T) {
+		hub := &v1beta1.DockerRegistry{}
+		require.NoError(t, original.DeepCopy().ConvertTo(hub))
+
+		require.Equal(t, original.ObjectMeta, hub.ObjectMeta)
+		requireEqualJSON(t, original.Spec, hub.Spec)
+		requireEqualJSON(t, original.Status, hub.Status)
+	})
+
+	t.Run("round-trip v1alpha1 through v1beta1 without data loss", func(t *testing.T) {
+		hub := &v1beta1.DockerRegistry{}
+		require.NoError(t, original.DeepCopy().ConvertTo(hub))
+
+		converted := &v1alpha1.DockerRegistry{TypeMeta: original.TypeMeta}
+		require.NoError(t, converted.ConvertFrom(hub))
+
+		require.Equal(t, original, converted)
+	})
+}
+
+func requireEqualJSON(t *testing.T, expected, actual interface{}) {
+	expectedJSON, err := json.Marshal(expected)
+	require.NoError(t, err)
+	actualJSON, err := json.Marshal(actual)
+	require.NoError(t, err)
+	require.JSONEq(t, string(expectedJSON), string(actualJSON))
+}
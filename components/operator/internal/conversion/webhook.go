@@ -0,0 +1,15 @@
+package conversion
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+)
+
+// WebhookPath is the path the API server calls to convert DockerRegistry CRs between the served versions
+const WebhookPath = "/convert"
+
+// SetupWebhookWithManager registers the webhook converting every version registered in the manager scheme
+// through its Hub version (v1beta1 for DockerRegistry)
+func SetupWebhookWithManager(mgr manager.Manager) {
+	mgr.GetWebhookServer().Register(WebhookPath, conversion.NewWebhookHandler(mgr.GetScheme()))
+}
@@ -0,0 +1,177 @@
+package dryrun
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+)
+
+// clusterScopedKinds are kinds served by the discovery server without a namespace
+var clusterScopedKinds = map[string]bool{
+	"Namespace":                      true,
+	"Node":                           true,
+	"PersistentVolume":               true,
+	"ClusterRole":                    true,
+	"ClusterRoleBinding":             true,
+	"CustomResourceDefinition":       true,
+	"PriorityClass":                  true,
+	"StorageClass":                   true,
+	"MutatingWebhookConfiguration":   true,
+	"ValidatingWebhookConfiguration": true,
+}
+
+// NewDiscoveryServer serves the version and the discovery API of all types registered in the scheme,
+// helm needs them to render the chart even if nothing is applied
+func NewDiscoveryServer(scheme *runtime.Scheme) *httptest.Server {
+	resources := map[schema.GroupVersion][]metav1.APIResource{}
+	for gvk := range scheme.AllKnownTypes() {
+		if gvk.Version == runtime.APIVersionInternal || !isResourceKind(scheme, gvk) {
+			continue
+		}
+
+		plural, singular := meta.UnsafeGuessKindToResource(gvk)
+		gv := gvk.GroupVersion()
+		resources[gv] = append(resources[gv], metav1.APIResource{
+			Name:         plural.Resource,
+			SingularName: singular.Resource,
+			Kind:         gvk.Kind,
+			Namespaced:   !clusterScopedKinds[gvk.Kind],
+			Verbs:        metav1.Verbs{"get", "list", "create", "update", "patch", "delete"},
+		})
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/version", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, &version.Info{Major: "1", Minor: "35", GitVersion: "v1.35.0"})
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, &metav1.APIVersions{Versions: []string{"v1"}})
+	})
+	mux.HandleFunc("/apis", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, apiGroupList(resources))
+	})
+	mux.HandleFunc("/openapi/v3", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, openAPIRoot(resources))
+	})
+	mux.HandleFunc("/openapi/v3/", func(w http.ResponseWriter, r *http.Request) {
+		gv, ok := groupVersionFromPath(strings.TrimPrefix(r.URL.Path, "/openapi/v3"))
+		if !ok || resources[gv] == nil {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, openAPIGroupVersion(gv, resources[gv]))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		gv, ok := groupVersionFromPath(r.URL.Path)
+		if !ok || resources[gv] == nil {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, &metav1.APIResourceList{GroupVersion: gv.String(), APIResources: resources[gv]})
+	})
+
+	return httptest.NewServer(mux)
+}
+
+func isResourceKind(scheme *runtime.Scheme, gvk schema.GroupVersionKind) bool {
+	obj, err := scheme.New(gvk)
+	if err != nil {
+		return false
+	}
+	_, isObject := obj.(metav1.Object)
+	return isObject && !meta.IsListType(obj)
+}
+
+func apiGroupList(resources map[schema.GroupVersion][]metav1.APIResource) *metav1.APIGroupList {
+	groups := map[string]*metav1.APIGroup{}
+	for gv := range resources {
+		if gv.Group == "" {
+			continue
+		}
+		group, ok := groups[gv.Group]
+		if !ok {
+			group = &metav1.APIGroup{Name: gv.Group}
+			groups[gv.Group] = group
+		}
+		groupVersion := metav1.GroupVersionForDiscovery{GroupVersion: gv.String(), Version: gv.Version}
+		group.Versions = append(group.Versions, groupVersion)
+		group.PreferredVersion = groupVersion
+	}
+
+	list := &metav1.APIGroupList{}
+	for _, group := range groups {
+		sort.Slice(group.Versions, func(i, j int) bool {
+			return group.Versions[i].Version < group.Versions[j].Version
+		})
+		list.Groups = append(list.Groups, *group)
+	}
+	sort.Slice(list.Groups, func(i, j int) bool {
+		return list.Groups[i].Name < list.Groups[j].Name
+	})
+	return list
+}
+
+// openAPIRoot lists OpenAPI V3 documents of all served group versions
+func openAPIRoot(resources map[schema.GroupVersion][]metav1.APIResource) map[string]interface{} {
+	paths := map[string]interface{}{}
+	for gv := range resources {
+		path := groupVersionPath(gv)
+		paths[strings.TrimPrefix(path, "/")] = map[string]string{"serverRelativeURL": "/openapi/v3" + path}
+	}
+	return map[string]interface{}{"paths": paths}
+}
+
+// openAPIGroupVersion describes only the patch operations supporting the server-side field validation,
+// so the helm client skips the client-side validation requiring full schemas
+func openAPIGroupVersion(gv schema.GroupVersion, resources []metav1.APIResource) map[string]interface{} {
+	paths := map[string]interface{}{}
+	for _, resource := range resources {
+		path := groupVersionPath(gv) + "/" + resource.Name + "/{name}"
+		paths[path] = map[string]interface{}{
+			"patch": map[string]interface{}{
+				"x-kubernetes-group-version-kind": map[string]string{
+					"group":   gv.Group,
+					"version": gv.Version,
+					"kind":    resource.Kind,
+				},
+				"parameters": []interface{}{
+					map[string]string{"name": "fieldValidation", "in": "query"},
+				},
+			},
+		}
+	}
+	return map[string]interface{}{"openapi": "3.0.0", "paths": paths}
+}
+
+func groupVersionPath(gv schema.GroupVersion) string {
+	if gv.Group == "" {
+		return "/api/" + gv.Version
+	}
+	return "/apis/" + gv.Group + "/" + gv.Version
+}
+
+// groupVersionFromPath parses the /api/<version> and /apis/<group>/<version> paths
+func groupVersionFromPath(path string) (schema.GroupVersion, bool) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(parts) == 2 && parts[0] == "api":
+		return schema.GroupVersion{Version: parts[1]}, true
+	case len(parts) == 3 && parts[0] == "apis":
+		return schema.GroupVersion{Group: parts[1], Version: parts[2]}, true
+	default:
+		return schema.GroupVersion{}, false
+	}
+}
+
+func writeJSON(w http.ResponseWriter, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(obj)
+}
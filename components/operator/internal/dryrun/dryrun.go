@@ -0,0 +1,276 @@
+package dryrun
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/state"
+	"github.com/kyma-project/manager-toolkit/installation/chart"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/yaml"
+)
+
+const defaultNamespace = "kyma-system"
+
+// Options configure the dry run of the DockerRegistry reconciliation
+type Options struct {
+	// InputPath is the path to the file with the DockerRegistry CR
+	InputPath string
+	// FixturesPath is the path to the file with objects the fake cluster is seeded with, it's optional
+	FixturesPath string
+	ChartPath    string
+	// OperatorVersion and ChartVersion are set in the status of the reconciled CR
+	OperatorVersion string
+	ChartVersion    string
+}
+
+// Run reconciles the DockerRegistry CR against the fake cluster and writes manifests of the created resources to out,
+// the returned error means the CR would not reconcile successfully
+func Run(ctx context.Context, log *zap.SugaredLogger, scheme *runtime.Scheme, opts Options, out io.Writer) error {
+	instance, err := readDockerRegistry(opts.InputPath)
+	if err != nil {
+		return err
+	}
+
+	if err := instance.Validate(); err != nil {
+		return errors.Wrap(err, "invalid dockerregistry")
+	}
+
+	fixtures := []client.Object{}
+	if opts.FixturesPath != "" {
+		fixtures, err = readObjects(opts.FixturesPath)
+		if err != nil {
+			return errors.Wrap(err, "while reading fixtures")
+		}
+	}
+
+	tracker := &objectTracker{keys: map[objectKey]struct{}{}}
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(append(fixtures, instance)...).
+		WithStatusSubresource(&v1alpha1.DockerRegistry{}).
+		WithInterceptorFuncs(tracker.interceptorFuncs()).
+		Build()
+
+	// use the stored CR to get up-to-date resource version
+	if err := fakeClient.Get(ctx, client.ObjectKeyFromObject(instance), instance); err != nil {
+		return errors.Wrap(err, "while getting dockerregistry")
+	}
+
+	discoveryServer := NewDiscoveryServer(scheme)
+	defer discoveryServer.Close()
+
+	config := &rest.Config{Host: discoveryServer.URL}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return errors.Wrap(err, "while creating kubernetes clientset")
+	}
+
+	machine := state.NewMachine(fakeClient, config, clientset, record.NewFakeRecorder(100), log,
+		chart.NewInMemoryManifestCache(), nil, opts.ChartPath, opts.OperatorVersion, opts.ChartVersion, 0, 0, 0)
+	if _, err := machine.Reconcile(ctx, *instance); err != nil {
+		return errors.Wrap(err, "while reconciling dockerregistry")
+	}
+
+	if err := fakeClient.Get(ctx, client.ObjectKeyFromObject(instance), instance); err != nil {
+		return errors.Wrap(err, "while getting reconciled dockerregistry")
+	}
+	if instance.Status.State == v1alpha1.StateError {
+		return fmt.Errorf("dockerregistry reconciled with the %s state", instance.Status.State)
+	}
+	if instance.Status.State == v1alpha1.StateWarning {
+		log.Warnf("dockerregistry reconciled with the %s state", instance.Status.State)
+	}
+
+	return tracker.write(ctx, fakeClient, out)
+}
+
+func readDockerRegistry(path string) (*v1alpha1.DockerRegistry, error) {
+	objs, err := readUnstructured(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "while reading dockerregistry")
+	}
+	if len(objs) != 1 {
+		return nil, fmt.Errorf("expected exactly one dockerregistry in %s, found %d objects", path, len(objs))
+	}
+
+	instance := &v1alpha1.DockerRegistry{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(objs[0].Object, instance); err != nil {
+		return nil, errors.Wrap(err, "while decoding dockerregistry")
+	}
+	// v1beta1 has the same schema so the CR can be given in any served version
+	instance.SetGroupVersionKind(v1alpha1.GroupVersion.WithKind("DockerRegistry"))
+	if instance.GetNamespace() == "" {
+		instance.SetNamespace(defaultNamespace)
+	}
+	return instance, nil
+}
+
+func readObjects(path string) ([]client.Object, error) {
+	objs, err := readUnstructured(path)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]client.Object, 0, len(objs))
+	for i := range objs {
+		if objs[i].GetNamespace() == "" {
+			objs[i].SetNamespace(defaultNamespace)
+		}
+		result = append(result, &objs[i])
+	}
+	return result, nil
+}
+
+// readUnstructured decodes all YAML or JSON documents of the file
+func readUnstructured(path string) ([]unstructured.Unstructured, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	objs := []unstructured.Unstructured{}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(file, 4096)
+	for {
+		u := unstructured.Unstructured{}
+		err := decoder.Decode(&u.Object)
+		if err == io.EOF {
+			return objs, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "while decoding %s", path)
+		}
+		// skip empty documents
+		if len(u.Object) != 0 {
+			objs = append(objs, u)
+		}
+	}
+}
+
+type objectKey struct {
+	gvk schema.GroupVersionKind
+	client.ObjectKey
+}
+
+// objectTracker remembers objects written by the reconciliation
+type objectTracker struct {
+	mu   sync.Mutex
+	keys map[objectKey]struct{}
+}
+
+func (t *objectTracker) interceptorFuncs() interceptor.Funcs {
+	return interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if err := c.Create(ctx, obj, opts...); err != nil {
+				return err
+			}
+			return t.track(c, obj)
+		},
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			if err := c.Update(ctx, obj, opts...); err != nil {
+				return err
+			}
+			return t.track(c, obj)
+		},
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			if err := c.Patch(ctx, obj, patch, opts...); err != nil {
+				return err
+			}
+			return t.track(c, obj)
+		},
+		Apply: func(ctx context.Context, c client.WithWatch, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+			if err := c.Apply(ctx, obj, opts...); err != nil {
+				return err
+			}
+			return t.trackApplyConfiguration(obj)
+		},
+	}
+}
+
+func (t *objectTracker) track(c client.Client, obj client.Object) error {
+	gvk, err := c.GroupVersionKindFor(obj)
+	if err != nil {
+		return err
+	}
+	t.add(objectKey{gvk: gvk, ObjectKey: client.ObjectKeyFromObject(obj)})
+	return nil
+}
+
+func (t *objectTracker) trackApplyConfiguration(obj runtime.ApplyConfiguration) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	u := &unstructured.Unstructured{}
+	if err := u.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	t.add(objectKey{gvk: u.GroupVersionKind(), ObjectKey: client.ObjectKeyFromObject(u)})
+	return nil
+}
+
+func (t *objectTracker) add(key objectKey) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.keys[key] = struct{}{}
+}
+
+// write prints the current state of all tracked objects except the DockerRegistry CR sorted by kind, namespace and name
+func (t *objectTracker) write(ctx context.Context, c client.Client, out io.Writer) error {
+	keys := []objectKey{}
+	for key := range t.keys {
+		if key.gvk.Kind == "DockerRegistry" {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].gvk.Kind != keys[j].gvk.Kind {
+			return keys[i].gvk.Kind < keys[j].gvk.Kind
+		}
+		return keys[i].ObjectKey.String() < keys[j].ObjectKey.String()
+	})
+
+	for _, key := range keys {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(key.gvk)
+		if err := c.Get(ctx, key.ObjectKey, u); err != nil {
+			// the object was removed later in the reconciliation
+			if client.IgnoreNotFound(err) == nil {
+				continue
+			}
+			return errors.Wrapf(err, "while getting %s %s", key.gvk.Kind, key.ObjectKey)
+		}
+		u.SetManagedFields(nil)
+		u.SetResourceVersion("")
+		unstructured.RemoveNestedField(u.Object, "status")
+
+		data, err := yaml.Marshal(u.Object)
+		if err != nil {
+			return errors.Wrapf(err, "while marshalling %s %s", key.gvk.Kind, key.ObjectKey)
+		}
+		if _, err := fmt.Fprintf(out, "---\n%s", data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package dryrun
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	istionetworking "istio.io/client-go/pkg/apis/networking/v1beta1"
+	istiosecurity "istio.io/client-go/pkg/apis/security/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+)
+
+var testChartPath = filepath.Join("..", "..", "..", "..", "config", "docker-registry")
+
+func TestRun(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+	require.NoError(t, v1alpha1.AddToScheme(testScheme))
+	require.NoError(t, istionetworking.AddToScheme(testScheme))
+	require.NoError(t, istiosecurity.AddToScheme(testScheme))
+
+	t.Run("print manifests of reconciled resources", func(t *testing.T) {
+		out := &bytes.Buffer{}
+
+		err := Run(context.Background(), zap.NewNop().Sugar(), testScheme, Options{
+			InputPath:    filepath.Join("testdata", "dockerregistry.yaml"),
+			FixturesPath: filepath.Join("testdata", "fixtures.yaml"),
+			ChartPath:    testChartPath,
+		}, out)
+		require.NoError(t, err)
+		require.Contains(t, out.String(), "kind: Deployment")
+		require.Contains(t, out.String(), "name: dockerregistry-secret")
+		require.Contains(t, out.String(), "value: eu-central-1")
+		require.Contains(t, out.String(), "kind: ServiceAccount")
+		require.Contains(t, out.String(), "automountServiceAccountToken: false")
+		require.NotContains(t, out.String(), "kind: DockerRegistry")
+	})
+
+	t.Run("fail when dockerregistry is invalid", func(t *testing.T) {
+		err := Run(context.Background(), zap.NewNop().Sugar(), testScheme, Options{
+			InputPath: filepath.Join("testdata", "invalid_dockerregistry.yaml"),
+			ChartPath: testChartPath,
+		}, &bytes.Buffer{})
+		require.ErrorContains(t, err, "invalid dockerregistry")
+	})
+}
@@ -0,0 +1,69 @@
+package errors
+
+import (
+	"errors"
+)
+
+// Kind classifies the reconcile errors, the reason names the failed part of the reconciliation in events and metrics
+type Kind struct {
+	reason string
+	// terminal errors don't heal without the user changing the DockerRegistry spec so they are not retried
+	terminal bool
+}
+
+var (
+	ErrHelmRenderFailed    = &Kind{reason: "HelmRenderFailed"}
+	ErrSecretSyncFailed    = &Kind{reason: "SecretSyncFailed"}
+	ErrIstioResourceFailed = &Kind{reason: "IstioResourceFailed"}
+	ErrInvalidSpec         = &Kind{reason: "InvalidSpec", terminal: true}
+)
+
+func (k *Kind) Error() string {
+	return k.reason
+}
+
+// Reason returns the reason used in events and metrics
+func (k *Kind) Reason() string {
+	return k.reason
+}
+
+// Wrap classifies the error keeping its message, nil is returned for the nil error
+func (k *Kind) Wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &reconcileError{kind: k, err: err}
+}
+
+type reconcileError struct {
+	kind *Kind
+	err  error
+}
+
+func (e *reconcileError) Error() string {
+	return e.err.Error()
+}
+
+func (e *reconcileError) Unwrap() error {
+	return e.err
+}
+
+// Is allows checking the kind with errors.Is(err, ErrInvalidSpec)
+func (e *reconcileError) Is(target error) bool {
+	return target == e.kind
+}
+
+// Reason returns the reason of the outermost classified error or an empty string if the error isn't classified
+func Reason(err error) string {
+	var reconcileErr *reconcileError
+	if errors.As(err, &reconcileErr) {
+		return reconcileErr.kind.reason
+	}
+	return ""
+}
+
+// IsTerminal returns true if retrying the reconciliation doesn't fix the error
+func IsTerminal(err error) bool {
+	var reconcileErr *reconcileError
+	return errors.As(err, &reconcileErr) && reconcileErr.kind.terminal
+}
@@ -0,0 +1,60 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKind_Wrap(t *testing.T) {
+	t.Run("keep message and cause", func(t *testing.T) {
+		cause := errors.New("template failed")
+
+		err := ErrHelmRenderFailed.Wrap(pkgerrors.Wrap(cause, "while rendering chart"))
+
+		require.EqualError(t, err, "while rendering chart: template failed")
+		require.ErrorIs(t, err, cause)
+		require.ErrorIs(t, err, ErrHelmRenderFailed)
+		require.NotErrorIs(t, err, ErrInvalidSpec)
+	})
+
+	t.Run("nil error", func(t *testing.T) {
+		require.NoError(t, ErrInvalidSpec.Wrap(nil))
+	})
+}
+
+func TestReason(t *testing.T) {
+	testCases := map[string]struct {
+		err            error
+		expectedReason string
+		expectTerminal bool
+	}{
+		"plain error": {
+			err: errors.New("test error"),
+		},
+		"transient error": {
+			err:            fmt.Errorf("while reconciling: %w", ErrIstioResourceFailed.Wrap(errors.New("conflict"))),
+			expectedReason: "IstioResourceFailed",
+		},
+		"terminal error": {
+			err:            pkgerrors.Wrap(ErrInvalidSpec.Wrap(errors.New("invalid storage")), "while validating"),
+			expectedReason: "InvalidSpec",
+			expectTerminal: true,
+		},
+		"outermost kind wins": {
+			err:            ErrSecretSyncFailed.Wrap(ErrInvalidSpec.Wrap(errors.New("invalid secret"))),
+			expectedReason: "SecretSyncFailed",
+			expectTerminal: false,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			require.Equal(t, testCase.expectedReason, Reason(testCase.err))
+			require.Equal(t, testCase.expectTerminal, IsTerminal(testCase.err))
+		})
+	}
+}
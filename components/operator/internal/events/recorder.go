@@ -0,0 +1,108 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	internalerrors "github.com/kyma-project/docker-registry/components/operator/internal/errors"
+	"golang.org/x/time/rate"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// DefaultWarningInterval limits warnings with the same reason to one per interval
+	DefaultWarningInterval = 30 * time.Second
+
+	maxMessageBytes = 1024
+)
+
+type rateLimitedRecorder struct {
+	record.EventRecorder
+	interval time.Duration
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// NewRateLimitedRecorder returns recorder dropping Warning events recorded more often than once per interval
+// for the same reason, a flood of warnings during API server problems may run the operator out of memory
+func NewRateLimitedRecorder(recorder record.EventRecorder, interval time.Duration) record.EventRecorder {
+	return &rateLimitedRecorder{
+		EventRecorder: recorder,
+		interval:      interval,
+		limiters:      map[string]*rate.Limiter{},
+	}
+}
+
+func (r *rateLimitedRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if r.allow(eventtype, reason) {
+		r.EventRecorder.Event(object, eventtype, reason, message)
+	}
+}
+
+func (r *rateLimitedRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	if r.allow(eventtype, reason) {
+		r.EventRecorder.Eventf(object, eventtype, reason, messageFmt, args...)
+	}
+}
+
+func (r *rateLimitedRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	if r.allow(eventtype, reason) {
+		r.EventRecorder.AnnotatedEventf(object, annotations, eventtype, reason, messageFmt, args...)
+	}
+}
+
+func (r *rateLimitedRecorder) allow(eventtype, reason string) bool {
+	if eventtype != corev1.EventTypeWarning {
+		return true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	limiter, ok := r.limiters[reason]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Every(r.interval), 1)
+		r.limiters[reason] = limiter
+	}
+	return limiter.Allow()
+}
+
+// RecordReconcileError records the Warning event with the reason derived from the error kind or type
+func RecordReconcileError(recorder record.EventRecorder, object runtime.Object, err error) {
+	recorder.Event(object, corev1.EventTypeWarning, reconcileErrorReason(err), truncate(err.Error(), maxMessageBytes))
+}
+
+func reconcileErrorReason(err error) string {
+	if reason := internalerrors.Reason(err); reason != "" {
+		return reason
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "ReconcileTimeout"
+	}
+	if reason := k8serrors.ReasonForError(err); reason != "" {
+		return fmt.Sprintf("Reconcile%s", reason)
+	}
+	return "ReconcileError"
+}
+
+func truncate(message string, maxBytes int) string {
+	if len(message) <= maxBytes {
+		return message
+	}
+
+	const suffix = "..."
+	cut := maxBytes - len(suffix)
+	// don't split multi-byte characters
+	for cut > 0 && !utf8.RuneStart(message[cut]) {
+		cut--
+	}
+	return message[:cut] + suffix
+}
@@ -0,0 +1,74 @@
+package events
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	internalerrors "github.com/kyma-project/docker-registry/components/operator/internal/errors"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRateLimitedRecorder(t *testing.T) {
+	t.Run("drop warnings with the same reason within the interval", func(t *testing.T) {
+		fakeRecorder := record.NewFakeRecorder(10)
+		recorder := NewRateLimitedRecorder(fakeRecorder, DefaultWarningInterval)
+
+		recorder.Event(&corev1.Pod{}, corev1.EventTypeWarning, "ReconcileConflict", "first")
+		recorder.Event(&corev1.Pod{}, corev1.EventTypeWarning, "ReconcileConflict", "second")
+		recorder.Eventf(&corev1.Pod{}, corev1.EventTypeWarning, "ReconcileError", "%s", "other reason")
+
+		require.Len(t, fakeRecorder.Events, 2)
+		require.Equal(t, "Warning ReconcileConflict first", <-fakeRecorder.Events)
+		require.Equal(t, "Warning ReconcileError other reason", <-fakeRecorder.Events)
+	})
+
+	t.Run("record all normal events", func(t *testing.T) {
+		fakeRecorder := record.NewFakeRecorder(10)
+		recorder := NewRateLimitedRecorder(fakeRecorder, DefaultWarningInterval)
+
+		recorder.Event(&corev1.Pod{}, corev1.EventTypeNormal, "CredentialsRotated", "first")
+		recorder.Event(&corev1.Pod{}, corev1.EventTypeNormal, "CredentialsRotated", "second")
+
+		require.Len(t, fakeRecorder.Events, 2)
+	})
+}
+
+func TestRecordReconcileError(t *testing.T) {
+	testCases := map[string]struct {
+		err             error
+		expectedMessage string
+	}{
+		"derive reason from API status": {
+			err:             errors.Wrap(k8serrors.NewConflict(schema.GroupResource{Resource: "dockerregistries"}, "default", errors.New("modified")), "while updating status"),
+			expectedMessage: "Warning ReconcileConflict while updating status: Operation cannot be fulfilled on dockerregistries \"default\": modified",
+		},
+		"timeout": {
+			err:             errors.Wrap(context.DeadlineExceeded, "while installing chart"),
+			expectedMessage: "Warning ReconcileTimeout while installing chart: context deadline exceeded",
+		},
+		"prefer reason of the error kind": {
+			err:             errors.Wrap(internalerrors.ErrHelmRenderFailed.Wrap(context.DeadlineExceeded), "while installing chart"),
+			expectedMessage: "Warning HelmRenderFailed while installing chart: context deadline exceeded",
+		},
+		"cap long message": {
+			err:             errors.New(strings.Repeat("a", 2000)),
+			expectedMessage: "Warning ReconcileError " + strings.Repeat("a", maxMessageBytes-3) + "...",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			fakeRecorder := record.NewFakeRecorder(1)
+
+			RecordReconcileError(fakeRecorder, &corev1.Pod{}, testCase.err)
+
+			require.Equal(t, testCase.expectedMessage, <-fakeRecorder.Events)
+		})
+	}
+}
@@ -1,11 +1,15 @@
 package flags
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
 	"github.com/kyma-project/manager-toolkit/installation/chart"
+	corev1 "k8s.io/api/core/v1"
 )
 
 const (
@@ -15,14 +19,43 @@ const (
 type Builder struct {
 	chart.FlagsBuilder
 	rollmeValues []string
+	// values which can't be expressed as flags without losing their types, e.g. lists of structs
+	values map[string]interface{}
 }
 
 func NewBuilder() *Builder {
 	return &Builder{
 		FlagsBuilder: chart.NewFlagsBuilder(),
+		values:       map[string]interface{}{},
 	}
 }
 
+// Build constructs the chart flags and sets the structured values on top of them
+func (fb *Builder) Build() (map[string]interface{}, error) {
+	flags, err := fb.FlagsBuilder.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range fb.values {
+		setNestedValue(flags, strings.Split(key, "."), value)
+	}
+	return flags, nil
+}
+
+// setNestedValue sets the value under the dot separated path creating missing tables on the way
+func setNestedValue(table map[string]interface{}, path []string, value interface{}) {
+	for _, key := range path[:len(path)-1] {
+		next, ok := table[key].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			table[key] = next
+		}
+		table = next
+	}
+	table[path[len(path)-1]] = value
+}
+
 func (fb *Builder) WithFullname(fullname string) *Builder {
 	_ = fb.With("FullnameOverride", fullname)
 	return fb
@@ -34,6 +67,20 @@ func (fb *Builder) WithRegistryCredentials(username, password string) *Builder {
 	return fb
 }
 
+// WithCredentialRotation keeps the previous credentials valid until the grace period ends
+// and restarts the registry to regenerate the htpasswd file after every rotation step
+func (fb *Builder) WithCredentialRotation(rotatedAt time.Time, previousUsername, previousPassword string) *Builder {
+	timestamp := rotatedAt.UTC().Format(time.RFC3339)
+	_ = fb.With("dockerRegistry.rotatedAt", timestamp)
+	if previousUsername == "" {
+		return fb.withRollme(fmt.Sprintf("credentialsRotatedAt=%s", timestamp))
+	}
+
+	_ = fb.With("dockerRegistry.previousUsername", previousUsername)
+	_ = fb.With("dockerRegistry.previousPassword", previousPassword)
+	return fb.withRollme(fmt.Sprintf("credentialsRotatedAt=%s-grace", timestamp))
+}
+
 func (fb *Builder) WithRegistryHttpSecret(httpSecret string) *Builder {
 	_ = fb.With("registryHTTPSecret", httpSecret)
 	return fb
@@ -52,6 +99,19 @@ func (fb *Builder) WithVirtualService(host, gateway string) *Builder {
 	return fb
 }
 
+func (fb *Builder) WithVirtualServiceTimeout(timeout time.Duration) *Builder {
+	_ = fb.With("virtualService.timeout", istioDuration(timeout))
+	return fb
+}
+
+func (fb *Builder) WithVirtualServiceRetries(attempts int32, perTryTimeout *time.Duration) *Builder {
+	_ = fb.With("virtualService.retries.attempts", attempts)
+	if perTryTimeout != nil {
+		_ = fb.With("virtualService.retries.perTryTimeout", istioDuration(*perTryTimeout))
+	}
+	return fb
+}
+
 func (fb *Builder) WithNodePort(nodePort int64) *Builder {
 	_ = fb.With("registryNodePort", nodePort)
 	return fb
@@ -81,6 +141,10 @@ func (fb *Builder) WithS3(config *v1alpha1.StorageS3, secret *v1alpha1.StorageS3
 		_ = fb.With("s3.regionEndpoint", config.RegionEndpoint)
 	}
 
+	if config.KMSKeyID != "" {
+		_ = fb.With("s3.keyID", config.KMSKeyID)
+	}
+
 	if secret != nil {
 		_ = fb.With("secrets.s3.accessKey", secret.AccessKey)
 		_ = fb.With("secrets.s3.secretKey", secret.SecretKey)
@@ -96,12 +160,29 @@ func (fb *Builder) WithDeleteEnabled(enabled bool) *Builder {
 	return fb.withRollme(fmt.Sprintf("configData.storage.delete.enabled=%t", enabled))
 }
 
+func (fb *Builder) WithReadOnly() *Builder {
+	_ = fb.With("readOnly", true)
+	_ = fb.With("configData.storage.maintenance.readonly.enabled", true)
+	// restart registry to fetch new configuration from configmap
+	return fb.withRollme("configData.storage.maintenance.readonly.enabled=true")
+}
+
 func (fb *Builder) WithFilesystem() *Builder {
 	_ = fb.With("storage", "filesystem")
 	_ = fb.With("configData.storage.filesystem.rootdirectory", "/var/lib/registry")
 	return fb
 }
 
+func (fb *Builder) WithPVCSize(size string) *Builder {
+	_ = fb.With("persistence.size", size)
+	return fb
+}
+
+func (fb *Builder) WithPVCStorageClass(storageClassName string) *Builder {
+	_ = fb.With("persistence.storageClass", storageClassName)
+	return fb
+}
+
 func (fb *Builder) WithPVC(config *v1alpha1.StoragePVC) *Builder {
 	_ = fb.With("persistence.enabled", true)
 	_ = fb.With("persistence.existingClaim", config.Name)
@@ -127,6 +208,229 @@ func (fb *Builder) WithGCS(config *v1alpha1.StorageGCS, secret *v1alpha1.Storage
 	return fb
 }
 
+func (fb *Builder) WithHtpasswd(htpasswd, checksum string) *Builder {
+	_ = fb.With("secrets.htpasswd", escape(htpasswd))
+	// the htpasswd file is generated by the init container so the registry must be restarted to load new users
+	return fb.withRollme(fmt.Sprintf("htpasswdChecksum=%s", checksum))
+}
+
+func (fb *Builder) WithProxy(proxy *v1alpha1.Proxy, passwordKey, checksum string) *Builder {
+	_ = fb.With("proxy.enabled", true)
+	_ = fb.With("proxy.remoteURL", escape(proxy.RemoteURL))
+	if proxy.Username != "" {
+		_ = fb.With("proxy.username", escape(proxy.Username))
+	}
+	if proxy.PasswordSecretRef != nil {
+		_ = fb.With("proxy.passwordSecretName", proxy.PasswordSecretRef.Name)
+		_ = fb.With("proxy.passwordSecretKey", passwordKey)
+	}
+	// restart registry to read new remote credentials
+	return fb.withRollme(fmt.Sprintf("proxyChecksum=%s", checksum))
+}
+
+func (fb *Builder) WithTokenAuth(tokenAuth *v1alpha1.TokenAuth) *Builder {
+	_ = fb.With("auth.token.enabled", true)
+	_ = fb.With("auth.token.realm", escape(tokenAuth.Realm))
+	_ = fb.With("auth.token.service", escape(tokenAuth.Service))
+	_ = fb.With("auth.token.issuer", escape(tokenAuth.Issuer))
+	_ = fb.With("auth.token.rootCertBundleSecretName", tokenAuth.RootCertBundleSecretName)
+	return fb
+}
+
+func (fb *Builder) WithTLS(secretName, checksum string) *Builder {
+	_ = fb.With("tlsSecretName", secretName)
+	// restart registry deployment to load the new certificate when the secret content changes
+	return fb.withRollme(fmt.Sprintf("tlsSecretChecksum=%s", checksum))
+}
+
+func (fb *Builder) WithGarbageCollection(schedule string, deleteUntagged, dryRun bool) *Builder {
+	_ = fb.With("garbageCollection.enabled", true)
+	_ = fb.With("garbageCollection.schedule", escape(schedule))
+	_ = fb.With("garbageCollection.deleteUntagged", deleteUntagged)
+	if dryRun {
+		_ = fb.With("garbageCollection.dryRun", true)
+	}
+	return fb
+}
+
+func (fb *Builder) WithBackup(schedule, volumeSnapshotClassName string, retainCount int32) *Builder {
+	_ = fb.With("backup.enabled", true)
+	_ = fb.With("backup.schedule", escape(schedule))
+	_ = fb.With("backup.retainCount", retainCount)
+	if volumeSnapshotClassName != "" {
+		_ = fb.With("backup.volumeSnapshotClassName", volumeSnapshotClassName)
+	}
+	return fb
+}
+
+func (fb *Builder) WithPruning(schedule, jobImage string, maxAgeDays, maxTagsPerRepository int32) *Builder {
+	_ = fb.With("pruning.enabled", true)
+	_ = fb.With("pruning.schedule", escape(schedule))
+	if jobImage != "" {
+		_ = fb.With("pruning.jobImage", escape(jobImage))
+	}
+	if maxAgeDays != 0 {
+		_ = fb.With("pruning.maxAgeDays", maxAgeDays)
+	}
+	if maxTagsPerRepository != 0 {
+		_ = fb.With("pruning.maxTagsPerRepository", maxTagsPerRepository)
+	}
+	return fb
+}
+
+func (fb *Builder) WithReplication(schedule, jobImage string, targets []v1alpha1.ReplicationTarget) *Builder {
+	_ = fb.With("replication.enabled", true)
+	_ = fb.With("replication.schedule", escape(schedule))
+	if jobImage != "" {
+		_ = fb.With("replication.jobImage", escape(jobImage))
+	}
+	fb.withValue("replication.targets", targets)
+	return fb
+}
+
+func (fb *Builder) WithReplicas(replicas int32) *Builder {
+	_ = fb.With("replicaCount", replicas)
+	return fb
+}
+
+func (fb *Builder) WithAutoscaling(minReplicas, maxReplicas, targetCPUUtilizationPercentage int32) *Builder {
+	_ = fb.With("autoscaling.enabled", true)
+	_ = fb.With("autoscaling.minReplicas", minReplicas)
+	_ = fb.With("autoscaling.maxReplicas", maxReplicas)
+	_ = fb.With("autoscaling.targetCPUUtilizationPercentage", targetCPUUtilizationPercentage)
+	return fb
+}
+
+func (fb *Builder) WithResources(resources corev1.ResourceRequirements) *Builder {
+	for name, quantity := range resources.Requests {
+		_ = fb.With("resources.requests."+escapeKey(string(name)), quantity.String())
+	}
+	for name, quantity := range resources.Limits {
+		_ = fb.With("resources.limits."+escapeKey(string(name)), quantity.String())
+	}
+	return fb
+}
+
+func (fb *Builder) WithLog(level, formatter string) *Builder {
+	if level != "" {
+		_ = fb.With("configData.log.level", level)
+		// restart registry to fetch new configuration from configmap
+		_ = fb.withRollme(fmt.Sprintf("configData.log.level=%s", level))
+	}
+	if formatter != "" {
+		_ = fb.With("configData.log.formatter", formatter)
+		_ = fb.withRollme(fmt.Sprintf("configData.log.formatter=%s", formatter))
+	}
+	return fb
+}
+
+func (fb *Builder) WithProbe(probe string, timing *v1alpha1.ProbeTiming) *Builder {
+	fields := map[string]*int32{
+		"initialDelaySeconds": timing.InitialDelaySeconds,
+		"periodSeconds":       timing.PeriodSeconds,
+		"timeoutSeconds":      timing.TimeoutSeconds,
+		"failureThreshold":    timing.FailureThreshold,
+	}
+	for name, value := range fields {
+		if value != nil {
+			_ = fb.With(fmt.Sprintf("%s.%s", probe, name), *value)
+		}
+	}
+	return fb
+}
+
+func (fb *Builder) WithImage(repository, tag string, pullPolicy corev1.PullPolicy) *Builder {
+	_ = fb.With("image.repository", repository)
+	if tag != "" {
+		_ = fb.With("image.tag", tag)
+	}
+	if pullPolicy != "" {
+		_ = fb.With("image.pullPolicy", string(pullPolicy))
+	}
+	return fb
+}
+
+func (fb *Builder) WithImagePullSecrets(secrets []corev1.LocalObjectReference) *Builder {
+	for i, secret := range secrets {
+		_ = fb.With(fmt.Sprintf("imagePullSecrets[%d].name", i), secret.Name)
+	}
+	return fb
+}
+
+// WithoutRegistryAPINetworkPolicy removes the chart policy allowing all traffic to the registry port
+// so only the traffic allowed by the NetworkPolicy managed by the operator can reach it
+func (fb *Builder) WithoutRegistryAPINetworkPolicy() *Builder {
+	_ = fb.With("networkPolicy.allowRegistryAPI", false)
+	return fb
+}
+
+func (fb *Builder) WithNotifications(endpoints []map[string]interface{}, checksum string) *Builder {
+	// the endpoints are passed to the registry from a Secret because the headers can contain credentials
+	fb.withValue("notifications.endpoints", endpoints)
+	// restart registry to read the new endpoints
+	return fb.withRollme(fmt.Sprintf("notificationsChecksum=%s", checksum))
+}
+
+func (fb *Builder) WithExtraConfig(config map[string]interface{}, checksum string) *Builder {
+	fb.withValue("extraConfigData", config)
+	// restart registry to read the new configuration
+	return fb.withRollme(fmt.Sprintf("extraConfigChecksum=%s", checksum))
+}
+
+func (fb *Builder) WithExtraEnvVars(envVars []corev1.EnvVar) *Builder {
+	fb.withValue("extraEnvVars", envVars)
+	return fb
+}
+
+func (fb *Builder) WithSidecars(sidecars []corev1.Container) *Builder {
+	fb.withValue("sidecars", sidecars)
+	return fb
+}
+
+func (fb *Builder) WithExtraVolumes(volumes []corev1.Volume, mounts []corev1.VolumeMount) *Builder {
+	if len(volumes) != 0 {
+		fb.withValue("customVolumes", volumes)
+	}
+	if len(mounts) != 0 {
+		fb.withValue("customVolumeMounts", mounts)
+	}
+	return fb
+}
+
+func (fb *Builder) WithScheduling(scheduling *v1alpha1.Scheduling) *Builder {
+	if len(scheduling.NodeSelector) != 0 {
+		fb.withValue("nodeSelector", scheduling.NodeSelector)
+	}
+	if len(scheduling.Tolerations) != 0 {
+		fb.withValue("tolerations", scheduling.Tolerations)
+	}
+	if scheduling.Affinity != nil {
+		fb.withValue("affinity", scheduling.Affinity)
+	}
+	return fb
+}
+
+func (fb *Builder) WithPriorityClassName(name string) *Builder {
+	_ = fb.With("priorityClassName", name)
+	return fb
+}
+
+func (fb *Builder) WithTopologySpreadConstraints(constraints []corev1.TopologySpreadConstraint) *Builder {
+	fb.withValue("topologySpreadConstraints", constraints)
+	return fb
+}
+
+// WithSecurityContext sets the security context of the registry pods and containers, helm merges it with the chart defaults
+func (fb *Builder) WithSecurityContext(pod *corev1.PodSecurityContext, container *corev1.SecurityContext) *Builder {
+	if pod != nil {
+		fb.withValue("pod.securityContext", pod)
+	}
+	if container != nil {
+		fb.withValue("containers.securityContext", container)
+	}
+	return fb
+}
+
 func (fb *Builder) WithManagedByLabel(managedBy string) *Builder {
 	_ = fb.With("commonLabels.app\\.kubernetes\\.io/managed-by", managedBy)
 	return fb
@@ -139,3 +443,32 @@ func (fb *Builder) withRollme(value string) *Builder {
 	_ = fb.With("rollme", strings.Join(fb.rollmeValues, "\\,"))
 	return fb
 }
+
+// withValue sets the chart value under the dot separated key converted to its json representation
+func (fb *Builder) withValue(key string, value interface{}) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+
+	var converted interface{}
+	if err := json.Unmarshal(raw, &converted); err != nil {
+		return
+	}
+	fb.values[key] = converted
+}
+
+// escapeKey makes sure dots in keys (e.g. nvidia.com/gpu) are not treated as nested values separators
+func escapeKey(key string) string {
+	return strings.ReplaceAll(key, ".", "\\.")
+}
+
+// escape makes sure commas in user provided values are not treated as flags separators
+func escape(value string) string {
+	return strings.ReplaceAll(value, ",", "\\,")
+}
+
+// istioDuration formats the duration in seconds as expected by the Istio protobuf duration fields
+func istioDuration(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
@@ -3,6 +3,7 @@ package flags
 import (
 	"testing"
 
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
 	"github.com/kyma-project/manager-toolkit/installation/chart"
 	"github.com/stretchr/testify/require"
 )
@@ -53,6 +54,61 @@ func Test_flagsBuilder_Build(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, expectedFlags, flags)
 	})
+
+	t.Run("build nested structured values", func(t *testing.T) {
+		expectedFlags := map[string]interface{}{
+			"configData": map[string]interface{}{
+				"http": map[string]interface{}{
+					"addr": ":5000",
+				},
+			},
+			"notifications": map[string]interface{}{
+				"endpoints": []interface{}{
+					map[string]interface{}{"name": "listener", "url": "https://listener.local"},
+				},
+			},
+			"rollme": "notificationsChecksum=abc",
+			"service": map[string]interface{}{
+				"port": int64(5000),
+			},
+		}
+
+		flags, err := NewBuilder().
+			WithServicePort(5000).
+			WithNotifications([]map[string]interface{}{{"name": "listener", "url": "https://listener.local"}}, "abc").
+			Build()
+
+		require.NoError(t, err)
+		require.Equal(t, expectedFlags, flags)
+	})
+}
+
+func Test_flagsBuilder_WithTokenAuth(t *testing.T) {
+	t.Run("escape commas in values", func(t *testing.T) {
+		expectedFlags := map[string]interface{}{
+			"auth": map[string]interface{}{
+				"token": map[string]interface{}{
+					"enabled":                  true,
+					"realm":                    "https://auth.example.com/token?scope=a,b",
+					"service":                  "registry",
+					"issuer":                   "auth.example.com",
+					"rootCertBundleSecretName": "token-ca",
+				},
+			},
+		}
+
+		flags, err := NewBuilder().
+			WithTokenAuth(&v1alpha1.TokenAuth{
+				Realm:                    "https://auth.example.com/token?scope=a,b",
+				Service:                  "registry",
+				Issuer:                   "auth.example.com",
+				RootCertBundleSecretName: "token-ca",
+			}).
+			Build()
+
+		require.NoError(t, err)
+		require.Equal(t, expectedFlags, flags)
+	})
 }
 
 func Test_flagsBuilder_withRollme(t *testing.T) {
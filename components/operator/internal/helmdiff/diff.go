@@ -0,0 +1,322 @@
+package helmdiff
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	truncatedMarker = "\n... (truncated)"
+	redactedValue   = "<redacted>"
+)
+
+// Diff collects human-readable changes the apply of the rendered chart makes to the live resources
+type Diff struct {
+	previous map[string]unstructured.Unstructured
+	rendered map[string]bool
+	changes  []string
+}
+
+// New returns the Diff against the previously rendered manifest, it's the original configuration of the three-way merge
+// and may be empty which only disables the detection of removed fields and resources
+func New(previousManifest string) (*Diff, error) {
+	previous := map[string]unstructured.Unstructured{}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(strings.NewReader(previousManifest), 4096)
+	for {
+		u := unstructured.Unstructured{}
+		err := decoder.Decode(&u.Object)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "while decoding previous manifest")
+		}
+		// skip empty documents
+		if len(u.Object) != 0 {
+			previous[objectID(&u)] = u
+		}
+	}
+
+	return &Diff{
+		previous: previous,
+		rendered: map[string]bool{},
+	}, nil
+}
+
+// Add records changes of the rendered resource, current is the live resource or nil if it doesn't exist
+func (d *Diff) Add(rendered, current *unstructured.Unstructured) error {
+	id := objectID(rendered)
+	d.rendered[id] = true
+	if current == nil {
+		d.changes = append(d.changes, "+ "+id)
+		return nil
+	}
+
+	original := rendered
+	if previous, ok := d.previous[id]; ok {
+		original = &previous
+	}
+
+	// the diff is published in events so the secret values are replaced by the names of the changed keys
+	changedKeys := []string{}
+	if isSecret(rendered) {
+		changedKeys = changedSecretKeys(original, rendered, current)
+		original, rendered, current = withoutSecretData(original), withoutSecretData(rendered), withoutSecretData(current)
+	}
+	if isConfigMap(rendered) {
+		original, rendered, current = withoutNotificationHeaders(original), withoutNotificationHeaders(rendered), withoutNotificationHeaders(current)
+	}
+
+	patch, err := threeWayPatch(original, rendered, current)
+	if err != nil {
+		return errors.Wrapf(err, "while computing patch of %s", id)
+	}
+	patch, err = redactEnvValues(patch)
+	if err != nil {
+		return errors.Wrapf(err, "while redacting patch of %s", id)
+	}
+	if string(patch) == "{}" && len(changedKeys) == 0 {
+		return nil
+	}
+
+	change := "~ " + id
+	if len(changedKeys) != 0 {
+		change += "\n" + indent("changed keys: "+strings.Join(changedKeys, ", "))
+	}
+	if string(patch) != "{}" {
+		changes, err := yaml.JSONToYAML(patch)
+		if err != nil {
+			return errors.Wrapf(err, "while converting patch of %s", id)
+		}
+		change += "\n" + indent(string(changes))
+	}
+	d.changes = append(d.changes, change)
+	return nil
+}
+
+// String returns changed resources in the order they were added followed by resources removed from the chart,
+// it's empty when the apply changes nothing
+func (d *Diff) String() string {
+	removed := []string{}
+	for id := range d.previous {
+		if !d.rendered[id] {
+			removed = append(removed, "- "+id)
+		}
+	}
+	sort.Strings(removed)
+	return strings.Join(append(append([]string{}, d.changes...), removed...), "\n")
+}
+
+// Truncate shortens the diff to at most limit bytes without splitting multi-byte characters
+func Truncate(diff string, limit int) string {
+	if len(diff) <= limit {
+		return diff
+	}
+	end := limit - len(truncatedMarker)
+	if end < 0 {
+		end = 0
+	}
+	for end > 0 && !utf8.RuneStart(diff[end]) {
+		end--
+	}
+	return diff[:end] + truncatedMarker
+}
+
+// threeWayPatch uses the strategic merge patch for built-in kinds so list items are matched by their merge keys,
+// other kinds fall back to the JSON merge patch replacing whole lists
+func threeWayPatch(original, modified, current *unstructured.Unstructured) ([]byte, error) {
+	originalJSON, err := json.Marshal(original.Object)
+	if err != nil {
+		return nil, err
+	}
+	modifiedJSON, err := json.Marshal(modified.Object)
+	if err != nil {
+		return nil, err
+	}
+	currentJSON, err := json.Marshal(current.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := scheme.Scheme.New(modified.GroupVersionKind())
+	if err != nil {
+		return jsonmergepatch.CreateThreeWayJSONMergePatch(originalJSON, modifiedJSON, currentJSON)
+	}
+	patchMeta, err := strategicpatch.NewPatchMetaFromStruct(obj)
+	if err != nil {
+		return nil, err
+	}
+	return strategicpatch.CreateThreeWayMergePatch(originalJSON, modifiedJSON, currentJSON, patchMeta, true)
+}
+
+func isSecret(u *unstructured.Unstructured) bool {
+	gvk := u.GroupVersionKind()
+	return gvk.Group == "" && gvk.Kind == "Secret"
+}
+
+// changedSecretKeys returns sorted keys the apply sets to a new value or removes from the live secret
+func changedSecretKeys(original, rendered, current *unstructured.Unstructured) []string {
+	renderedData := secretData(rendered)
+	currentData := secretData(current)
+
+	changed := map[string]bool{}
+	for key, value := range renderedData {
+		if currentValue, ok := currentData[key]; !ok || currentValue != value {
+			changed[key] = true
+		}
+	}
+	for key := range secretData(original) {
+		if _, ok := renderedData[key]; !ok {
+			if _, ok := currentData[key]; ok {
+				changed[key] = true
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(changed))
+	for key := range changed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// secretData returns the base64 encoded values of data and stringData, stringData takes precedence like in the API server
+func secretData(u *unstructured.Unstructured) map[string]string {
+	data, _, _ := unstructured.NestedStringMap(u.Object, "data")
+	if data == nil {
+		data = map[string]string{}
+	}
+	stringData, _, _ := unstructured.NestedStringMap(u.Object, "stringData")
+	for key, value := range stringData {
+		data[key] = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+	return data
+}
+
+func withoutSecretData(u *unstructured.Unstructured) *unstructured.Unstructured {
+	stripped := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	for key, value := range u.Object {
+		if key != "data" && key != "stringData" {
+			stripped.Object[key] = value
+		}
+	}
+	return stripped
+}
+
+func isConfigMap(u *unstructured.Unstructured) bool {
+	gvk := u.GroupVersionKind()
+	return gvk.Group == "" && gvk.Kind == "ConfigMap"
+}
+
+// withoutNotificationHeaders masks header values of the notification endpoints in the registry configuration files,
+// the headers usually hold the endpoint credentials
+func withoutNotificationHeaders(u *unstructured.Unstructured) *unstructured.Unstructured {
+	data, _, _ := unstructured.NestedStringMap(u.Object, "data")
+	redactedData := map[string]interface{}{}
+	redacted := false
+	for key, value := range data {
+		redactedData[key] = value
+
+		config := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(value), &config); err != nil {
+			continue
+		}
+		endpoints, _, _ := unstructured.NestedSlice(config, "notifications", "endpoints")
+		if !redactHeaders(endpoints) {
+			continue
+		}
+		_ = unstructured.SetNestedSlice(config, endpoints, "notifications", "endpoints")
+		if raw, err := yaml.Marshal(config); err == nil {
+			redactedData[key] = string(raw)
+			redacted = true
+		}
+	}
+	if !redacted {
+		return u
+	}
+
+	stripped := u.DeepCopy()
+	stripped.Object["data"] = redactedData
+	return stripped
+}
+
+func redactHeaders(endpoints []interface{}) bool {
+	redacted := false
+	for _, endpoint := range endpoints {
+		headers, ok := endpoint.(map[string]interface{})["headers"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for name := range headers {
+			headers[name] = []interface{}{redactedValue}
+			redacted = true
+		}
+	}
+	return redacted
+}
+
+// sensitiveEnvName matches names of environment variables which likely hold credentials
+var sensitiveEnvName = regexp.MustCompile(`(?i)(password|secret|token|key|credential)`)
+
+// redactEnvValues masks values of the env entries with sensitive names in the patch
+func redactEnvValues(patch []byte) ([]byte, error) {
+	var obj interface{}
+	if err := json.Unmarshal(patch, &obj); err != nil {
+		return nil, err
+	}
+	if !redactEnv(obj) {
+		return patch, nil
+	}
+	return json.Marshal(obj)
+}
+
+func redactEnv(value interface{}) bool {
+	redacted := false
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		if name, ok := typed["name"].(string); ok && sensitiveEnvName.MatchString(name) {
+			if _, ok := typed["value"]; ok {
+				typed["value"] = redactedValue
+				redacted = true
+			}
+		}
+		for _, item := range typed {
+			redacted = redactEnv(item) || redacted
+		}
+	case []interface{}:
+		for _, item := range typed {
+			redacted = redactEnv(item) || redacted
+		}
+	}
+	return redacted
+}
+
+func objectID(u *unstructured.Unstructured) string {
+	if u.GetNamespace() == "" {
+		return fmt.Sprintf("%s %s", u.GetKind(), u.GetName())
+	}
+	return fmt.Sprintf("%s %s/%s", u.GetKind(), u.GetNamespace(), u.GetName())
+}
+
+func indent(text string) string {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i := range lines {
+		lines[i] = "    " + lines[i]
+	}
+	return strings.Join(lines, "\n")
+}
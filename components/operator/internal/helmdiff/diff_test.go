@@ -0,0 +1,298 @@
+package helmdiff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+const previousManifest = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: registry
+  namespace: kyma-system
+  labels:
+    tier: backend
+spec:
+  template:
+    spec:
+      containers:
+      - name: registry
+        image: registry:2.8.2
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: old-config
+  namespace: kyma-system
+`
+
+func TestDiff(t *testing.T) {
+	rendered := fixObject(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: registry
+  namespace: kyma-system
+spec:
+  template:
+    spec:
+      containers:
+      - name: registry
+        image: registry:2.8.3
+`)
+
+	t.Run("changed, removed and created resources", func(t *testing.T) {
+		diff, err := New(previousManifest)
+		require.NoError(t, err)
+
+		live := fixObject(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: registry
+  namespace: kyma-system
+  labels:
+    tier: backend
+  resourceVersion: "12"
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: registry
+        image: registry:2.8.2
+        imagePullPolicy: IfNotPresent
+status:
+  readyReplicas: 1
+`)
+		require.NoError(t, diff.Add(rendered, live))
+		require.NoError(t, diff.Add(fixObject(t, `
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: registry
+`), nil))
+
+		require.Equal(t, `~ Deployment kyma-system/registry
+    metadata:
+      labels: null
+    spec:
+      template:
+        spec:
+          $setElementOrder/containers:
+          - name: registry
+          containers:
+          - image: registry:2.8.3
+            name: registry
++ ClusterRole registry
+- ConfigMap kyma-system/old-config`, diff.String())
+	})
+
+	t.Run("unchanged resource", func(t *testing.T) {
+		diff, err := New("")
+		require.NoError(t, err)
+
+		live := rendered.DeepCopy()
+		live.SetResourceVersion("12")
+		require.NoError(t, diff.Add(rendered, live))
+		require.Empty(t, diff.String())
+	})
+
+	t.Run("custom resource", func(t *testing.T) {
+		diff, err := New("")
+		require.NoError(t, err)
+
+		monitor := fixObject(t, `
+apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: registry
+  namespace: kyma-system
+spec:
+  endpoints:
+  - port: http-metrics
+    interval: 30s
+`)
+		live := monitor.DeepCopy()
+		require.NoError(t, unstructured.SetNestedSlice(live.Object, []interface{}{
+			map[string]interface{}{"port": "http-metrics", "interval": "1m"},
+		}, "spec", "endpoints"))
+
+		require.NoError(t, diff.Add(monitor, live))
+		require.Equal(t, `~ ServiceMonitor kyma-system/registry
+    spec:
+      endpoints:
+      - interval: 30s
+        port: http-metrics`, diff.String())
+	})
+
+	t.Run("list changed secret keys without values", func(t *testing.T) {
+		diff, err := New(`
+apiVersion: v1
+kind: Secret
+metadata:
+  name: registry-config
+  namespace: kyma-system
+data:
+  username: dXNlcg==
+  password: b2xkLXBhc3N3b3Jk
+  previousPassword: b2xkZXI=
+`)
+		require.NoError(t, err)
+
+		secret := fixObject(t, `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: registry-config
+  namespace: kyma-system
+  labels:
+    tier: backend
+data:
+  username: dXNlcg==
+  password: bmV3LXBhc3N3b3Jk
+stringData:
+  .dockerconfigjson: '{"auths":{"registry":{"password":"new-password"}}}'
+`)
+		live := fixObject(t, `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: registry-config
+  namespace: kyma-system
+data:
+  username: dXNlcg==
+  password: b2xkLXBhc3N3b3Jk
+  previousPassword: b2xkZXI=
+`)
+
+		require.NoError(t, diff.Add(secret, live))
+		require.Equal(t, `~ Secret kyma-system/registry-config
+    changed keys: .dockerconfigjson, password, previousPassword
+    metadata:
+      labels:
+        tier: backend`, diff.String())
+	})
+
+	t.Run("redact notification headers", func(t *testing.T) {
+		diff, err := New("")
+		require.NoError(t, err)
+
+		configMap := fixObject(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: registry-config
+  namespace: kyma-system
+data:
+  config.yml: |-
+    notifications:
+      endpoints:
+      - name: listener
+        url: https://listener.local
+        headers:
+          Authorization: [Bearer new-token]
+`)
+		live := fixObject(t, `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: registry-config
+  namespace: kyma-system
+data:
+  config.yml: |-
+    notifications:
+      endpoints:
+      - name: listener
+        url: https://old-listener.local
+        headers:
+          Authorization: [Bearer old-token]
+`)
+
+		require.NoError(t, diff.Add(configMap, live))
+		require.NotContains(t, diff.String(), "new-token")
+		require.NotContains(t, diff.String(), "old-token")
+		require.Contains(t, diff.String(), "<redacted>")
+		require.Contains(t, diff.String(), "https://listener.local")
+	})
+
+	t.Run("redact sensitive env values", func(t *testing.T) {
+		diff, err := New("")
+		require.NoError(t, err)
+
+		deployment := fixObject(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: registry
+  namespace: kyma-system
+spec:
+  template:
+    spec:
+      containers:
+      - name: registry
+        env:
+        - name: REGISTRY_STORAGE_S3_SECRETKEY
+          value: new-secret
+        - name: REGISTRY_LOG_LEVEL
+          value: debug
+`)
+		live := fixObject(t, `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: registry
+  namespace: kyma-system
+spec:
+  template:
+    spec:
+      containers:
+      - name: registry
+        env:
+        - name: REGISTRY_STORAGE_S3_SECRETKEY
+          value: old-secret
+        - name: REGISTRY_LOG_LEVEL
+          value: info
+`)
+
+		require.NoError(t, diff.Add(deployment, live))
+		require.NotContains(t, diff.String(), "new-secret")
+		require.Contains(t, diff.String(), "value: <redacted>")
+		require.Contains(t, diff.String(), "value: debug")
+	})
+
+	t.Run("invalid previous manifest", func(t *testing.T) {
+		_, err := New("\t")
+		require.ErrorContains(t, err, "while decoding previous manifest")
+	})
+}
+
+func TestTruncate(t *testing.T) {
+	t.Run("keep short diff", func(t *testing.T) {
+		require.Equal(t, "+ ClusterRole registry", Truncate("+ ClusterRole registry", 4096))
+	})
+
+	t.Run("truncate long diff", func(t *testing.T) {
+		truncated := Truncate(strings.Repeat("a", 5000), 4096)
+		require.Len(t, truncated, 4096)
+		require.True(t, strings.HasSuffix(truncated, truncatedMarker))
+	})
+
+	t.Run("don't split multi-byte characters", func(t *testing.T) {
+		truncated := Truncate(strings.Repeat("ż", 100), 50)
+		require.LessOrEqual(t, len(truncated), 50)
+		require.True(t, strings.HasSuffix(truncated, truncatedMarker))
+		require.Equal(t, strings.Repeat("ż", 17)+truncatedMarker, truncated)
+	})
+}
+
+func fixObject(t *testing.T, manifest string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	require.NoError(t, yaml.Unmarshal([]byte(manifest), &u.Object))
+	return u
+}
@@ -0,0 +1,156 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+var (
+	cacheListDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "dockerregistry_cache_list_duration_seconds",
+			Help:    "Duration of the object lists served by the operator cache",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"group", "version", "kind"},
+	)
+	cacheObjects = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "dockerregistry_cache_objects",
+			Help: "Number of objects kept in the operator cache",
+		},
+		[]string{"group", "version", "kind"},
+	)
+)
+
+// NewCache is the manager NewCache function returning the cache which records the list duration and the number
+// of cached objects of each cached kind, kinds read by the client from the API server, like secrets and configmaps,
+// are counted only for the informers of the controller watches
+func NewCache(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+	c, err := cache.New(config, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := opts.Scheme
+	if scheme == nil {
+		scheme = clientgoscheme.Scheme
+	}
+	return &instrumentedCache{
+		Cache:   c,
+		scheme:  scheme,
+		counted: map[schema.GroupVersionKind]bool{},
+	}, nil
+}
+
+type instrumentedCache struct {
+	cache.Cache
+	scheme *runtime.Scheme
+
+	mu      sync.Mutex
+	counted map[schema.GroupVersionKind]bool
+}
+
+func (c *instrumentedCache) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if err := c.Cache.Get(ctx, key, obj, opts...); err != nil {
+		return err
+	}
+	c.countObjects(ctx, obj)
+	return nil
+}
+
+func (c *instrumentedCache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	start := time.Now()
+	err := c.Cache.List(ctx, list, opts...)
+
+	gvk, gvkErr := apiutil.GVKForObject(list, c.scheme)
+	if gvkErr != nil {
+		return err
+	}
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	cacheListDuration.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind).Observe(time.Since(start).Seconds())
+	if err == nil {
+		c.countKind(ctx, gvk)
+	}
+	return err
+}
+
+func (c *instrumentedCache) GetInformer(ctx context.Context, obj client.Object, opts ...cache.InformerGetOption) (cache.Informer, error) {
+	informer, err := c.Cache.GetInformer(ctx, obj, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if gvk, err := apiutil.GVKForObject(obj, c.scheme); err == nil {
+		c.count(gvk, informer)
+	}
+	return informer, nil
+}
+
+func (c *instrumentedCache) GetInformerForKind(ctx context.Context, gvk schema.GroupVersionKind, opts ...cache.InformerGetOption) (cache.Informer, error) {
+	informer, err := c.Cache.GetInformerForKind(ctx, gvk, opts...)
+	if err != nil {
+		return nil, err
+	}
+	c.count(gvk, informer)
+	return informer, nil
+}
+
+// countObjects starts counting objects of the kind served by the cache, the informer created by the get or list
+// is not returned by the wrapped cache
+func (c *instrumentedCache) countObjects(ctx context.Context, obj client.Object) {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return
+	}
+	c.countKind(ctx, gvk)
+}
+
+func (c *instrumentedCache) countKind(ctx context.Context, gvk schema.GroupVersionKind) {
+	if c.isCounted(gvk) {
+		return
+	}
+	// the informer already exists so the call doesn't block
+	informer, err := c.Cache.GetInformerForKind(ctx, gvk, cache.BlockUntilSynced(false))
+	if err != nil {
+		return
+	}
+	c.count(gvk, informer)
+}
+
+func (c *instrumentedCache) isCounted(gvk schema.GroupVersionKind) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counted[gvk]
+}
+
+// count registers the handler keeping the number of objects in the informer, the handler receives the add
+// notifications of objects the informer received before
+func (c *instrumentedCache) count(gvk schema.GroupVersionKind, informer cache.Informer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counted[gvk] {
+		return
+	}
+
+	gauge := cacheObjects.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind)
+	_, err := informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc:    func(interface{}) { gauge.Inc() },
+		DeleteFunc: func(interface{}) { gauge.Dec() },
+	})
+	if err != nil {
+		return
+	}
+	c.counted[gvk] = true
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestInstrumentedCache(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	t.Run("count objects of informers", func(t *testing.T) {
+		cacheObjects.Reset()
+		fakeInformers := &informertest.FakeInformers{Scheme: scheme}
+		c := &instrumentedCache{Cache: fakeInformers, scheme: scheme, counted: map[schema.GroupVersionKind]bool{}}
+
+		_, err := c.GetInformer(context.Background(), &corev1.Namespace{})
+		require.NoError(t, err)
+		// the handler is registered once
+		_, err = c.GetInformer(context.Background(), &corev1.Namespace{})
+		require.NoError(t, err)
+
+		informer, err := fakeInformers.FakeInformerFor(context.Background(), &corev1.Namespace{})
+		require.NoError(t, err)
+		informer.Add(&metav1.ObjectMeta{Name: "first"})
+		informer.Add(&metav1.ObjectMeta{Name: "second"})
+		informer.Delete(&metav1.ObjectMeta{Name: "first"})
+
+		require.Equal(t, float64(1), testutil.ToFloat64(cacheObjects.WithLabelValues("", "v1", "Namespace")))
+	})
+
+	t.Run("count kind of get objects", func(t *testing.T) {
+		cacheObjects.Reset()
+		fakeInformers := &informertest.FakeInformers{Scheme: scheme}
+		c := &instrumentedCache{Cache: fakeInformers, scheme: scheme, counted: map[schema.GroupVersionKind]bool{}}
+
+		key := client.ObjectKey{Namespace: "kyma-system", Name: "dockerregistry"}
+		require.NoError(t, c.Get(context.Background(), key, &corev1.Service{}))
+		// the handler is registered once
+		require.NoError(t, c.Get(context.Background(), key, &corev1.Service{}))
+
+		informer, err := fakeInformers.FakeInformerFor(context.Background(), &corev1.Service{})
+		require.NoError(t, err)
+		informer.Add(&metav1.ObjectMeta{Name: "dockerregistry"})
+
+		require.Equal(t, float64(1), testutil.ToFloat64(cacheObjects.WithLabelValues("", "v1", "Service")))
+	})
+
+	t.Run("observe list duration and count listed kind", func(t *testing.T) {
+		cacheListDuration.Reset()
+		cacheObjects.Reset()
+		fakeInformers := &informertest.FakeInformers{Scheme: scheme}
+		c := &instrumentedCache{Cache: fakeInformers, scheme: scheme, counted: map[schema.GroupVersionKind]bool{}}
+
+		require.NoError(t, c.List(context.Background(), &v1alpha1.DockerRegistryList{}))
+
+		require.Equal(t, 1, testutil.CollectAndCount(cacheListDuration))
+		informer, err := fakeInformers.FakeInformerFor(context.Background(), &v1alpha1.DockerRegistry{})
+		require.NoError(t, err)
+		informer.Add(&metav1.ObjectMeta{Name: "default"})
+
+		require.Equal(t, float64(1), testutil.ToFloat64(cacheObjects.WithLabelValues(v1alpha1.GroupVersion.Group, "v1alpha1", "DockerRegistry")))
+	})
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"time"
+
+	internalerrors "github.com/kyma-project/docker-registry/components/operator/internal/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
+
+	ReasonGetInstanceErr = "GetInstanceErr"
+	ReasonReconcileErr   = "ReconcileErr"
+)
+
+var (
+	reconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "dockerregistry_reconcile_duration_seconds",
+			Help:    "Duration of the DockerRegistry reconciliation",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"result"},
+	)
+	reconcileErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "dockerregistry_reconcile_errors_total",
+			Help: "Number of failed DockerRegistry reconciliations",
+		},
+		[]string{"reason"},
+	)
+)
+
+// Register registers the reconciliation and cache metrics and the given collectors in the controller-runtime metrics registry
+func Register(collectors ...prometheus.Collector) {
+	ctrlmetrics.Registry.MustRegister(reconcileDuration, reconcileErrors, cacheListDuration, cacheObjects)
+	ctrlmetrics.Registry.MustRegister(collectors...)
+}
+
+// ObserveReconcile records duration of the reconciliation started at the start time and counts the error if it's not nil
+func ObserveReconcile(start time.Time, reason string, err error) {
+	if err == nil {
+		reconcileDuration.WithLabelValues(ResultSuccess).Observe(time.Since(start).Seconds())
+		return
+	}
+
+	reconcileDuration.WithLabelValues(ResultError).Observe(time.Since(start).Seconds())
+	reconcileErrors.WithLabelValues(errorReason(reason, err)).Inc()
+}
+
+// errorReason returns the kubernetes API status reason if it's known, the reason of the classified error or the given
+// reason, the API reason keeps the breakdown of the API errors returned by the classified steps
+func errorReason(reason string, err error) string {
+	if apiReason := k8serrors.ReasonForError(err); apiReason != "" {
+		return string(apiReason)
+	}
+	if kindReason := internalerrors.Reason(err); kindReason != "" {
+		return kindReason
+	}
+	return reason
+}
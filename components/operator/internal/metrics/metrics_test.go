@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	internalerrors "github.com/kyma-project/docker-registry/components/operator/internal/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestObserveReconcile(t *testing.T) {
+	reconcileDuration.Reset()
+	reconcileErrors.Reset()
+
+	ObserveReconcile(time.Now(), ReasonReconcileErr, nil)
+	ObserveReconcile(time.Now(), ReasonReconcileErr, errors.New("test error"))
+	ObserveReconcile(time.Now(), ReasonReconcileErr, k8serrors.NewConflict(schema.GroupResource{}, "test", errors.New("test error")))
+
+	ObserveReconcile(time.Now(), ReasonReconcileErr, internalerrors.ErrIstioResourceFailed.Wrap(errors.New("test error")))
+	ObserveReconcile(time.Now(), ReasonReconcileErr, internalerrors.ErrSecretSyncFailed.Wrap(
+		k8serrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "test", errors.New("test error"))))
+
+	require.Equal(t, 2, testutil.CollectAndCount(reconcileDuration))
+	require.Equal(t, float64(1), testutil.ToFloat64(reconcileErrors.WithLabelValues(ReasonReconcileErr)))
+	require.Equal(t, float64(1), testutil.ToFloat64(reconcileErrors.WithLabelValues("Conflict")))
+	require.Equal(t, float64(1), testutil.ToFloat64(reconcileErrors.WithLabelValues("IstioResourceFailed")))
+	require.Equal(t, float64(1), testutil.ToFloat64(reconcileErrors.WithLabelValues("Forbidden")))
+	require.Equal(t, float64(0), testutil.ToFloat64(reconcileErrors.WithLabelValues("SecretSyncFailed")))
+}
@@ -1,11 +1,12 @@
 package predicate
 
 import (
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 )
 
-// this predicate allows not reacting on status changes
+// this predicate allows reacting only on spec changes, status and metadata updates and cache resyncs are skipped
 type NoStatusChangePredicate struct {
 	predicate.Funcs
 }
@@ -15,14 +16,34 @@ func (p NoStatusChangePredicate) Update(e event.UpdateEvent) bool {
 		return false
 	}
 
-	// first resource version (after apply)
+	// the cache resync doesn't change the object,
+	// the periodic reconciliation is scheduled by the reconciler according to the sync period instead
 	if e.ObjectOld.GetResourceVersion() == e.ObjectNew.GetResourceVersion() {
+		return false
+	}
+
+	// pausing and resuming doesn't change the generation but has to be handled right away
+	if isPausedAnnotationUpdate(e) {
+		return true
+	}
+
+	// the HTTP secret rotation is requested by the annotation only, its removal by the reconciler is skipped
+	if isRotateHTTPSecretRequest(e) {
 		return true
 	}
 
 	return !isStatusUpdate(e)
 }
 
+func isPausedAnnotationUpdate(e event.UpdateEvent) bool {
+	return e.ObjectOld.GetAnnotations()[v1alpha1.PausedAnnotation] != e.ObjectNew.GetAnnotations()[v1alpha1.PausedAnnotation]
+}
+
+func isRotateHTTPSecretRequest(e event.UpdateEvent) bool {
+	requested, ok := e.ObjectNew.GetAnnotations()[v1alpha1.RotateHTTPSecretAnnotation]
+	return ok && requested != e.ObjectOld.GetAnnotations()[v1alpha1.RotateHTTPSecretAnnotation]
+}
+
 func isStatusUpdate(e event.UpdateEvent) bool {
 	if e.ObjectOld.GetGeneration() == e.ObjectNew.GetGeneration() &&
 		e.ObjectOld.GetResourceVersion() != e.ObjectNew.GetResourceVersion() {
@@ -27,7 +27,7 @@ func TestNoStatusChangePredicate_Update(t *testing.T) {
 			want: false,
 		},
 		{
-			name: "first obj iteration",
+			name: "cache resync",
 			args: args{
 				e: event.UpdateEvent{
 					ObjectOld: func() *unstructured.Unstructured {
@@ -44,7 +44,7 @@ func TestNoStatusChangePredicate_Update(t *testing.T) {
 					}(),
 				},
 			},
-			want: true,
+			want: false,
 		},
 		{
 			name: "status update",
@@ -86,6 +86,96 @@ func TestNoStatusChangePredicate_Update(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "annotation update",
+			args: args{
+				e: event.UpdateEvent{
+					ObjectOld: func() *unstructured.Unstructured {
+						u := &unstructured.Unstructured{}
+						u.SetGeneration(1)
+						u.SetResourceVersion("560")
+						return u
+					}(),
+					ObjectNew: func() *unstructured.Unstructured {
+						u := &unstructured.Unstructured{}
+						u.SetGeneration(1)
+						u.SetResourceVersion("600")
+						u.SetAnnotations(map[string]string{"example.com/owner": "team"})
+						return u
+					}(),
+				},
+			},
+			want: false,
+		},
+		{
+			name: "paused annotation removed",
+			args: args{
+				e: event.UpdateEvent{
+					ObjectOld: func() *unstructured.Unstructured {
+						u := &unstructured.Unstructured{}
+						u.SetGeneration(1)
+						u.SetResourceVersion("560")
+						u.SetAnnotations(map[string]string{
+							"dockerregistry.operator.kyma-project.io/paused": "true",
+						})
+						return u
+					}(),
+					ObjectNew: func() *unstructured.Unstructured {
+						u := &unstructured.Unstructured{}
+						u.SetGeneration(1)
+						u.SetResourceVersion("600")
+						return u
+					}(),
+				},
+			},
+			want: true,
+		},
+		{
+			name: "rotate http secret annotation added",
+			args: args{
+				e: event.UpdateEvent{
+					ObjectOld: func() *unstructured.Unstructured {
+						u := &unstructured.Unstructured{}
+						u.SetGeneration(1)
+						u.SetResourceVersion("560")
+						return u
+					}(),
+					ObjectNew: func() *unstructured.Unstructured {
+						u := &unstructured.Unstructured{}
+						u.SetGeneration(1)
+						u.SetResourceVersion("600")
+						u.SetAnnotations(map[string]string{
+							"dockerregistry.operator.kyma-project.io/rotate-http-secret": "true",
+						})
+						return u
+					}(),
+				},
+			},
+			want: true,
+		},
+		{
+			name: "rotate http secret annotation removed",
+			args: args{
+				e: event.UpdateEvent{
+					ObjectOld: func() *unstructured.Unstructured {
+						u := &unstructured.Unstructured{}
+						u.SetGeneration(1)
+						u.SetResourceVersion("560")
+						u.SetAnnotations(map[string]string{
+							"dockerregistry.operator.kyma-project.io/rotate-http-secret": "true",
+						})
+						return u
+					}(),
+					ObjectNew: func() *unstructured.Unstructured {
+						u := &unstructured.Unstructured{}
+						u.SetGeneration(1)
+						u.SetResourceVersion("600")
+						return u
+					}(),
+				},
+			},
+			want: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,259 @@
+package pruning
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+
+	// pageSize is the number of repositories and tags requested at once
+	pageSize = 100
+)
+
+var (
+	manifestMediaTypes = []string{
+		mediaTypeDockerManifest,
+		mediaTypeDockerManifestList,
+		mediaTypeOCIManifest,
+		mediaTypeOCIIndex,
+	}
+
+	nextLinkRegexp = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+)
+
+// Image is the tagged manifest in the repository
+type Image struct {
+	Tag    string
+	Digest string
+	// Created is the time the image was built, it's zero when the image config doesn't contain it
+	Created time.Time
+}
+
+// Client reads and deletes registry content using the registry HTTP API V2
+type Client struct {
+	baseURL    *url.URL
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+func NewClient(baseURL, username, password string, httpClient *http.Client) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "while parsing registry url")
+	}
+	return &Client{
+		baseURL:    u,
+		username:   username,
+		password:   password,
+		httpClient: httpClient,
+	}, nil
+}
+
+type catalogResponse struct {
+	Repositories []string `json:"repositories"`
+}
+
+type tagsResponse struct {
+	Tags []string `json:"tags"`
+}
+
+type manifest struct {
+	MediaType string `json:"mediaType"`
+	Config    struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Manifests []struct {
+		Digest string `json:"digest"`
+	} `json:"manifests"`
+}
+
+type imageConfig struct {
+	Created *time.Time `json:"created"`
+}
+
+// Repositories returns names of all repositories in the registry catalog
+func (c *Client) Repositories(ctx context.Context) ([]string, error) {
+	repositories := []string{}
+	next := fmt.Sprintf("/v2/_catalog?n=%d", pageSize)
+	for next != "" {
+		page := catalogResponse{}
+		var err error
+		next, err = c.getPage(ctx, next, &page)
+		if err != nil {
+			return nil, errors.Wrap(err, "while listing repositories")
+		}
+		repositories = append(repositories, page.Repositories...)
+	}
+	return repositories, nil
+}
+
+// Tags returns all tags of the repository, the repository without tags or removed one has none
+func (c *Client) Tags(ctx context.Context, repository string) ([]string, error) {
+	tags := []string{}
+	next := fmt.Sprintf("/v2/%s/tags/list?n=%d", repository, pageSize)
+	for next != "" {
+		page := tagsResponse{}
+		var err error
+		next, err = c.getPage(ctx, next, &page)
+		if isNotFound(err) {
+			return tags, nil
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "while listing tags of %s", repository)
+		}
+		tags = append(tags, page.Tags...)
+	}
+	return tags, nil
+}
+
+// Image returns the digest of the tagged manifest and the time the image was built,
+// the build time of the multi-platform image is read from its first manifest
+func (c *Client) Image(ctx context.Context, repository, tag string) (Image, error) {
+	m, digest, err := c.getManifest(ctx, repository, tag)
+	if err != nil {
+		return Image{}, errors.Wrapf(err, "while getting manifest %s:%s", repository, tag)
+	}
+
+	if len(m.Manifests) != 0 {
+		m, _, err = c.getManifest(ctx, repository, m.Manifests[0].Digest)
+		if err != nil {
+			return Image{}, errors.Wrapf(err, "while getting platform manifest of %s:%s", repository, tag)
+		}
+	}
+
+	image := Image{Tag: tag, Digest: digest}
+	if m.Config.Digest == "" {
+		return image, nil
+	}
+
+	config := imageConfig{}
+	resp, err := c.do(ctx, http.MethodGet, fmt.Sprintf("/v2/%s/blobs/%s", repository, m.Config.Digest), nil)
+	if err != nil {
+		return Image{}, errors.Wrapf(err, "while getting config of %s:%s", repository, tag)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(&config); err != nil {
+		return Image{}, errors.Wrapf(err, "while decoding config of %s:%s", repository, tag)
+	}
+	if config.Created != nil {
+		image.Created = *config.Created
+	}
+	return image, nil
+}
+
+// DeleteManifest deletes the manifest with all its tags, the manifest deleted in the meantime is ignored
+func (c *Client) DeleteManifest(ctx context.Context, repository, digest string) error {
+	resp, err := c.do(ctx, http.MethodDelete, fmt.Sprintf("/v2/%s/manifests/%s", repository, digest), nil)
+	if isNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "while deleting manifest %s@%s", repository, digest)
+	}
+	return resp.Body.Close()
+}
+
+func (c *Client) getManifest(ctx context.Context, repository, reference string) (*manifest, string, error) {
+	header := http.Header{}
+	for _, mediaType := range manifestMediaTypes {
+		header.Add("Accept", mediaType)
+	}
+
+	resp, err := c.do(ctx, http.MethodGet, fmt.Sprintf("/v2/%s/manifests/%s", repository, reference), header)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	m := &manifest{}
+	if err := json.Unmarshal(body, m); err != nil {
+		return nil, "", errors.Wrap(err, "while decoding manifest")
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		digest = fmt.Sprintf("sha256:%x", sha256.Sum256(body))
+	}
+	return m, digest, nil
+}
+
+// getPage decodes the paginated response and returns the path of the next page or empty string if it's the last one
+func (c *Client) getPage(ctx context.Context, path string, page interface{}) (string, error) {
+	resp, err := c.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(page); err != nil {
+		return "", err
+	}
+
+	match := nextLinkRegexp.FindStringSubmatch(resp.Header.Get("Link"))
+	if match == nil {
+		return "", nil
+	}
+	return match[1], nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, header http.Header) (*http.Response, error) {
+	ref, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL.ResolveReference(ref).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		resp.Body.Close()
+		return nil, &statusError{method: method, path: ref.Path, statusCode: resp.StatusCode}
+	}
+	return resp, nil
+}
+
+type statusError struct {
+	method     string
+	path       string
+	statusCode int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s %s returned %d %s", e.method, e.path, e.statusCode, http.StatusText(e.statusCode))
+}
+
+func isNotFound(err error) bool {
+	statusErr := &statusError{}
+	return errors.As(err, &statusErr) && statusErr.statusCode == http.StatusNotFound
+}
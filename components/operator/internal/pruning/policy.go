@@ -0,0 +1,54 @@
+package pruning
+
+import (
+	"sort"
+	"time"
+)
+
+// Policy selects images deleted from the repository
+type Policy struct {
+	// MaxAge is how long images are kept after they were built, zero means no limit
+	MaxAge time.Duration
+	// MaxTags is how many most recently built tags are kept in the repository, zero means no limit
+	MaxTags int
+}
+
+// digestsToDelete returns digests of manifests selected by the policy, deleting the manifest removes all its tags
+// so the manifest is kept when any of its tags is kept, images without the build time are always kept
+func (p Policy) digestsToDelete(images []Image, now time.Time) []string {
+	sorted := make([]Image, len(images))
+	copy(sorted, images)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Created.After(sorted[j].Created)
+	})
+
+	kept := map[string]bool{}
+	selected := []string{}
+	counted := 0
+	for _, image := range sorted {
+		if image.Created.IsZero() {
+			kept[image.Digest] = true
+			continue
+		}
+
+		counted++
+		tooMany := p.MaxTags > 0 && counted > p.MaxTags
+		tooOld := p.MaxAge > 0 && now.Sub(image.Created) > p.MaxAge
+		if !tooMany && !tooOld {
+			kept[image.Digest] = true
+			continue
+		}
+		selected = append(selected, image.Digest)
+	}
+
+	digests := []string{}
+	seen := map[string]bool{}
+	for _, digest := range selected {
+		if kept[digest] || seen[digest] {
+			continue
+		}
+		seen[digest] = true
+		digests = append(digests, digest)
+	}
+	return digests
+}
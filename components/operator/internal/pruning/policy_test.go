@@ -0,0 +1,56 @@
+package pruning
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy_digestsToDelete(t *testing.T) {
+	now := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	images := []Image{
+		{Tag: "v1", Digest: "sha256:1", Created: now.AddDate(0, 0, -30)},
+		{Tag: "v2", Digest: "sha256:2", Created: now.AddDate(0, 0, -20)},
+		{Tag: "v3", Digest: "sha256:3", Created: now.AddDate(0, 0, -10)},
+		{Tag: "latest", Digest: "sha256:3", Created: now.AddDate(0, 0, -10)},
+		{Tag: "v4", Digest: "sha256:4", Created: now.AddDate(0, 0, -1)},
+		{Tag: "unknown", Digest: "sha256:5"},
+	}
+
+	testCases := map[string]struct {
+		policy   Policy
+		expected []string
+	}{
+		"no limits": {
+			policy:   Policy{},
+			expected: []string{},
+		},
+		"delete old images": {
+			policy:   Policy{MaxAge: 15 * 24 * time.Hour},
+			expected: []string{"sha256:2", "sha256:1"},
+		},
+		"keep most recent tags": {
+			policy:   Policy{MaxTags: 4},
+			expected: []string{"sha256:1"},
+		},
+		"keep manifest with any kept tag": {
+			policy:   Policy{MaxTags: 2},
+			expected: []string{"sha256:2", "sha256:1"},
+		},
+		"apply both limits": {
+			policy:   Policy{MaxAge: 25 * 24 * time.Hour, MaxTags: 2},
+			expected: []string{"sha256:2", "sha256:1"},
+		},
+		"keep images without build time": {
+			policy:   Policy{MaxTags: 1},
+			expected: []string{"sha256:3", "sha256:2", "sha256:1"},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, testCase.expected, testCase.policy.digestsToDelete(images, now))
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package pruning
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	goerrors "errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const requestTimeout = 30 * time.Second
+
+// Options configure the pruning job
+type Options struct {
+	// RegistryURL is the address of the registry API, e.g. http://dockerregistry.kyma-system.svc.cluster.local:5000
+	RegistryURL string
+	Username    string
+	Password    string
+	// CAFile is the path to the PEM encoded certificate the registry serving certificate is verified with, it's optional
+	CAFile string
+	Policy Policy
+}
+
+// Run deletes manifests selected by the policy from all registry repositories,
+// the repository which can't be pruned doesn't stop pruning of the other ones
+func Run(ctx context.Context, log *zap.SugaredLogger, opts Options) error {
+	httpClient, err := newHTTPClient(opts.CAFile)
+	if err != nil {
+		return err
+	}
+
+	client, err := NewClient(opts.RegistryURL, opts.Username, opts.Password, httpClient)
+	if err != nil {
+		return err
+	}
+
+	repositories, err := client.Repositories(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	deleted := 0
+	var errs []error
+	for _, repository := range repositories {
+		count, err := pruneRepository(ctx, log, client, opts.Policy, repository, now)
+		deleted += count
+		if err != nil {
+			log.Warnf("while pruning repository %s: %s", repository, err.Error())
+			errs = append(errs, err)
+		}
+	}
+
+	log.Infof("deleted %d manifests from %d repositories", deleted, len(repositories))
+	return goerrors.Join(errs...)
+}
+
+func pruneRepository(ctx context.Context, log *zap.SugaredLogger, client *Client, policy Policy, repository string, now time.Time) (int, error) {
+	tags, err := client.Tags(ctx, repository)
+	if err != nil {
+		return 0, err
+	}
+
+	images := make([]Image, 0, len(tags))
+	for _, tag := range tags {
+		image, err := client.Image(ctx, repository, tag)
+		if err != nil {
+			return 0, err
+		}
+		images = append(images, image)
+	}
+
+	deleted := 0
+	for _, digest := range policy.digestsToDelete(images, now) {
+		log.Infof("deleting manifest %s@%s", repository, digest)
+		if err := client.DeleteManifest(ctx, repository, digest); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+func newHTTPClient(caFile string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if caFile != "" {
+		ca, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "while reading registry CA certificate")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.Errorf("no certificate found in %s", caFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &http.Client{Transport: transport, Timeout: requestTimeout}, nil
+}
@@ -0,0 +1,238 @@
+package pruning
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRun(t *testing.T) {
+	now := time.Now()
+
+	t.Run("delete images selected by policy", func(t *testing.T) {
+		registry := newFakeRegistry(t)
+		registry.push("team/app", "v1", now.AddDate(0, 0, -40))
+		registry.push("team/app", "v2", now.AddDate(0, 0, -5))
+		registry.pushIndex("team/app", "multi-arch", now.AddDate(0, 0, -50))
+		registry.push("tools", "old", now.AddDate(0, 0, -60))
+		registry.push("tools", "latest", now.AddDate(0, 0, -60))
+
+		err := Run(context.Background(), zap.NewNop().Sugar(), Options{
+			RegistryURL: registry.URL,
+			Username:    "user",
+			Password:    "pass",
+			Policy:      Policy{MaxAge: 30 * 24 * time.Hour},
+		})
+		require.NoError(t, err)
+
+		require.Equal(t, map[string][]string{
+			"team/app": {"v2"},
+			"tools":    {},
+		}, registry.tags())
+	})
+
+	t.Run("continue with next repository", func(t *testing.T) {
+		registry := newFakeRegistry(t)
+		registry.push("broken", "v1", now.AddDate(0, 0, -40))
+		registry.push("broken", "v2", now.AddDate(0, 0, -5))
+		registry.push("app", "v1", now.AddDate(0, 0, -40))
+		registry.push("app", "v2", now.AddDate(0, 0, -5))
+		registry.failDelete("broken")
+
+		err := Run(context.Background(), zap.NewNop().Sugar(), Options{
+			RegistryURL: registry.URL,
+			Username:    "user",
+			Password:    "pass",
+			Policy:      Policy{MaxTags: 1},
+		})
+		require.ErrorContains(t, err, "DELETE /v2/broken/manifests/")
+
+		require.Equal(t, []string{"v2"}, registry.tags()["app"])
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		registry := newFakeRegistry(t)
+
+		err := Run(context.Background(), zap.NewNop().Sugar(), Options{
+			RegistryURL: registry.URL,
+			Username:    "user",
+			Password:    "wrong",
+			Policy:      Policy{MaxTags: 1},
+		})
+		require.ErrorContains(t, err, "GET /v2/_catalog returned 401 Unauthorized")
+	})
+}
+
+// fakeRegistry serves the subset of the registry API returning one repository or tag per page
+type fakeRegistry struct {
+	*httptest.Server
+
+	mu             sync.Mutex
+	repositories   map[string]map[string]string
+	blobs          map[string]string
+	failingDeletes map[string]bool
+}
+
+func newFakeRegistry(t *testing.T) *fakeRegistry {
+	r := &fakeRegistry{
+		repositories:   map[string]map[string]string{},
+		blobs:          map[string]string{},
+		failingDeletes: map[string]bool{},
+	}
+	r.Server = httptest.NewServer(http.HandlerFunc(r.serve))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *fakeRegistry) push(repository, tag string, created time.Time) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	configDigest := r.addBlob(fmt.Sprintf(`{"created":%q}`, created.Format(time.RFC3339)))
+	manifestDigest := r.addBlob(fmt.Sprintf(`{"mediaType":%q,"config":{"digest":%q}}`, mediaTypeOCIManifest, configDigest))
+	r.tag(repository, tag, manifestDigest)
+	return manifestDigest
+}
+
+func (r *fakeRegistry) pushIndex(repository, tag string, created time.Time) {
+	platformDigest := r.push(repository, "platform", created)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.repositories[repository], "platform")
+	indexDigest := r.addBlob(fmt.Sprintf(`{"mediaType":%q,"manifests":[{"digest":%q}]}`, mediaTypeOCIIndex, platformDigest))
+	r.tag(repository, tag, indexDigest)
+}
+
+func (r *fakeRegistry) failDelete(repository string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failingDeletes[repository] = true
+}
+
+func (r *fakeRegistry) tags() map[string][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := map[string][]string{}
+	for repository, tags := range r.repositories {
+		result[repository] = sortedKeys(tags)
+	}
+	return result
+}
+
+func (r *fakeRegistry) addBlob(content string) string {
+	digest := fmt.Sprintf("sha256:%x", len(r.blobs)+1)
+	r.blobs[digest] = content
+	return digest
+}
+
+func (r *fakeRegistry) tag(repository, tag, digest string) {
+	if r.repositories[repository] == nil {
+		r.repositories[repository] = map[string]string{}
+	}
+	r.repositories[repository][tag] = digest
+}
+
+func (r *fakeRegistry) serve(w http.ResponseWriter, req *http.Request) {
+	if username, password, ok := req.BasicAuth(); !ok || username != "user" || password != "pass" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	switch {
+	case path == "_catalog":
+		r.servePage(w, req, sortedKeys(r.repositories), func(items []string) interface{} {
+			return catalogResponse{Repositories: items}
+		})
+	case strings.HasSuffix(path, "/tags/list"):
+		tags, ok := r.repositories[strings.TrimSuffix(path, "/tags/list")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		r.servePage(w, req, sortedKeys(tags), func(items []string) interface{} {
+			return tagsResponse{Tags: items}
+		})
+	case strings.Contains(path, "/manifests/"):
+		parts := strings.SplitN(path, "/manifests/", 2)
+		r.serveManifest(w, req, parts[0], parts[1])
+	case strings.Contains(path, "/blobs/"):
+		content, ok := r.blobs[path[strings.Index(path, "/blobs/")+len("/blobs/"):]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (r *fakeRegistry) serveManifest(w http.ResponseWriter, req *http.Request, repository, reference string) {
+	tags := r.repositories[repository]
+	digest, ok := tags[reference]
+	if !ok {
+		digest = reference
+	}
+	content, ok := r.blobs[digest]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if req.Method == http.MethodDelete {
+		if r.failingDeletes[repository] {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		for tag, tagDigest := range tags {
+			if tagDigest == digest {
+				delete(tags, tag)
+			}
+		}
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	w.Header().Set("Docker-Content-Digest", digest)
+	_, _ = w.Write([]byte(content))
+}
+
+func (r *fakeRegistry) servePage(w http.ResponseWriter, req *http.Request, items []string, response func([]string) interface{}) {
+	start := 0
+	if last := req.URL.Query().Get("last"); last != "" {
+		start = sort.SearchStrings(items, last) + 1
+	}
+	page := []string{}
+	if start < len(items) {
+		page = items[start : start+1]
+	}
+	if start+1 < len(items) {
+		w.Header().Set("Link", fmt.Sprintf(`<%s?last=%s&n=1>; rel="next"`, req.URL.Path, page[0]))
+	}
+	_ = json.NewEncoder(w).Encode(response(page))
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,99 @@
+package registry
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const APICheckTimeout = 3 * time.Second
+
+var apiUpDesc = prometheus.NewDesc(
+	"dockerregistry_registry_api_up",
+	"Whether the API of the served registry responds, 1 when it does and 0 otherwise",
+	[]string{"namespace", "name"}, nil,
+)
+
+type apiCollector struct {
+	client     client.Reader
+	httpClient *http.Client
+	timeout    time.Duration
+}
+
+// NewAPICollector returns the metrics collector calling the API of the served registry on every scrape, the registry
+// availability is reported as a metric so that it doesn't affect the readiness of the operator and its webhook
+func NewAPICollector(c client.Reader, timeout time.Duration) prometheus.Collector {
+	return &apiCollector{
+		client:  c,
+		timeout: timeout,
+		httpClient: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				// only the reachability is verified here, the certificate may be issued for the external host
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // #nosec G402
+			},
+		},
+	}
+}
+
+func (c *apiCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- apiUpDesc
+}
+
+func (c *apiCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	list := &v1alpha1.DockerRegistryList{}
+	if err := c.client.List(ctx, list); err != nil {
+		ch <- prometheus.NewInvalidMetric(apiUpDesc, errors.Wrap(err, "while listing dockerregistry objects"))
+		return
+	}
+
+	for i := range list.Items {
+		dr := &list.Items[i]
+		if dr.Status.Served != v1alpha1.ServedTrue || dr.Status.InternalAccess.PushAddress == "" {
+			continue
+		}
+
+		up := 1.0
+		if err := checkRegistryAPI(ctx, c.httpClient, registryAPIURL(dr)); err != nil {
+			up = 0
+		}
+		ch <- prometheus.MustNewConstMetric(apiUpDesc, prometheus.GaugeValue, up, dr.GetNamespace(), dr.GetName())
+	}
+}
+
+func registryAPIURL(dr *v1alpha1.DockerRegistry) string {
+	scheme := "http"
+	if dr.GetTLSSecretName() != "" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/v2/", scheme, dr.Status.InternalAccess.PushAddress)
+}
+
+func checkRegistryAPI(ctx context.Context, httpClient *http.Client, url string) error {
+	apiReq, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return errors.Wrap(err, "while creating registry API request")
+	}
+
+	resp, err := httpClient.Do(apiReq)
+	if err != nil {
+		return errors.Wrapf(err, "while calling registry API %s", url)
+	}
+	defer resp.Body.Close()
+
+	// unauthorized response means the registry is up and requires credentials
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusUnauthorized {
+		return fmt.Errorf("registry API %s responded with status %d", url, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNewAPICollector(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(testScheme))
+
+	testCases := map[string]struct {
+		givenStatusCode int
+		givenServed     v1alpha1.Served
+		expectedMetrics string
+	}{
+		"up when registry responds with OK": {
+			givenStatusCode: http.StatusOK,
+			givenServed:     v1alpha1.ServedTrue,
+			expectedMetrics: fixAPIUpMetric(1),
+		},
+		"up when registry requires credentials": {
+			givenStatusCode: http.StatusUnauthorized,
+			givenServed:     v1alpha1.ServedTrue,
+			expectedMetrics: fixAPIUpMetric(1),
+		},
+		"down when registry responds with unexpected status": {
+			givenStatusCode: http.StatusServiceUnavailable,
+			givenServed:     v1alpha1.ServedTrue,
+			expectedMetrics: fixAPIUpMetric(0),
+		},
+		"no metric when no registry is served": {
+			givenStatusCode: http.StatusServiceUnavailable,
+			givenServed:     v1alpha1.ServedFalse,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			//GIVEN
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/v2/", r.URL.Path)
+				w.WriteHeader(testCase.givenStatusCode)
+			}))
+			defer server.Close()
+
+			k8sClient := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+				fixAPICheckDockerRegistry(testCase.givenServed, strings.TrimPrefix(server.URL, "http://")),
+			).Build()
+			collector := NewAPICollector(k8sClient, APICheckTimeout)
+
+			//WHEN
+			err := testutil.CollectAndCompare(collector, strings.NewReader(testCase.expectedMetrics))
+
+			//THEN
+			require.NoError(t, err)
+		})
+	}
+}
+
+func fixAPIUpMetric(value int) string {
+	return `# HELP dockerregistry_registry_api_up Whether the API of the served registry responds, 1 when it does and 0 otherwise
+# TYPE dockerregistry_registry_api_up gauge
+dockerregistry_registry_api_up{name="default",namespace="kyma-system"} ` + fmt.Sprintf("%d\n", value)
+}
+
+func fixAPICheckDockerRegistry(served v1alpha1.Served, pushAddress string) client.Object {
+	return &v1alpha1.DockerRegistry{
+		ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: kymaNamespace},
+		Status: v1alpha1.DockerRegistryStatus{
+			Served:         served,
+			InternalAccess: v1alpha1.NetworkAccess{PushAddress: pushAddress},
+		},
+	}
+}
@@ -0,0 +1,73 @@
+package registry
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	garbageCollectionInstanceLabel = "dockerregistry-garbage-collection"
+	garbageCollectionContainerName = "garbage-collection"
+)
+
+type GCLogReader interface {
+	GetLastGCLogs(ctx context.Context, c client.Client, namespace string) (string, error)
+}
+
+type podLogsFetcher func(ctx context.Context, namespace, podName, container string) ([]byte, error)
+
+type gcLogReader struct {
+	fetchPodLogs podLogsFetcher
+}
+
+func NewGCLogReader(clientset kubernetes.Interface) GCLogReader {
+	return &gcLogReader{
+		fetchPodLogs: apiServerPodLogsFetcher(clientset),
+	}
+}
+
+// GetLastGCLogs returns logs of the most recently finished garbage collection job pod
+// or empty string if no garbage collection has finished yet
+func (r *gcLogReader) GetLastGCLogs(ctx context.Context, c client.Client, namespace string) (string, error) {
+	pods := corev1.PodList{}
+	err := c.List(ctx, &pods,
+		client.InNamespace(namespace),
+		client.MatchingLabels{"app.kubernetes.io/instance": garbageCollectionInstanceLabel},
+	)
+	if err != nil {
+		return "", errors.Wrap(err, "while listing garbage collection pods")
+	}
+
+	var last *corev1.Pod
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			continue
+		}
+		if last == nil || last.CreationTimestamp.Before(&pod.CreationTimestamp) {
+			last = pod
+		}
+	}
+	if last == nil {
+		return "", nil
+	}
+
+	raw, err := r.fetchPodLogs(ctx, namespace, last.Name, garbageCollectionContainerName)
+	if err != nil {
+		return "", errors.Wrapf(err, "while fetching logs of the garbage collection pod %s", last.Name)
+	}
+
+	return string(raw), nil
+}
+
+func apiServerPodLogsFetcher(clientset kubernetes.Interface) podLogsFetcher {
+	return func(ctx context.Context, namespace, podName, container string) ([]byte, error) {
+		return clientset.CoreV1().Pods(namespace).
+			GetLogs(podName, &corev1.PodLogOptions{Container: container}).
+			DoRaw(ctx)
+	}
+}
@@ -0,0 +1,85 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestGCLogReader_GetLastGCLogs(t *testing.T) {
+	testCases := map[string]struct {
+		givenPods    []client.Object
+		givenLogs    string
+		givenLogsErr error
+		expectedPod  string
+		expectedLogs string
+		expectedErr  string
+	}{
+		"return logs of the last finished pod": {
+			givenPods: []client.Object{
+				fixGCPod("gc-1", corev1.PodSucceeded, 1),
+				fixGCPod("gc-2", corev1.PodFailed, 2),
+				fixGCPod("gc-3", corev1.PodRunning, 3),
+			},
+			givenLogs:    "2 blobs eligible for deletion",
+			expectedPod:  "gc-2",
+			expectedLogs: "2 blobs eligible for deletion",
+		},
+		"return empty logs when no pod has finished": {
+			givenPods: []client.Object{fixGCPod("gc-1", corev1.PodRunning, 1)},
+		},
+		"return error when logs can't be fetched": {
+			givenPods:    []client.Object{fixGCPod("gc-1", corev1.PodSucceeded, 1)},
+			givenLogsErr: errors.New("forbidden"),
+			expectedPod:  "gc-1",
+			expectedErr:  "while fetching logs of the garbage collection pod gc-1: forbidden",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			//GIVEN
+			k8sClient := fake.NewClientBuilder().WithObjects(testCase.givenPods...).Build()
+			reader := &gcLogReader{
+				fetchPodLogs: func(_ context.Context, namespace, podName, container string) ([]byte, error) {
+					require.Equal(t, kymaNamespace, namespace)
+					require.Equal(t, testCase.expectedPod, podName)
+					require.Equal(t, garbageCollectionContainerName, container)
+					return []byte(testCase.givenLogs), testCase.givenLogsErr
+				},
+			}
+
+			//WHEN
+			logs, err := reader.GetLastGCLogs(context.TODO(), k8sClient, kymaNamespace)
+
+			//THEN
+			if testCase.expectedErr != "" {
+				require.EqualError(t, err, testCase.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedLogs, logs)
+		})
+	}
+}
+
+func fixGCPod(name string, phase corev1.PodPhase, createdHour int) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         kymaNamespace,
+			CreationTimestamp: metav1.NewTime(time.Date(2024, 1, 1, createdHour, 0, 0, 0, time.UTC)),
+			Labels:            map[string]string{"app.kubernetes.io/instance": garbageCollectionInstanceLabel},
+		},
+		Status: corev1.PodStatus{
+			Phase: phase,
+		},
+	}
+}
@@ -53,6 +53,22 @@ func AdjustDockerRegToClusterPVCSize(ctx context.Context, c client.Client, obj u
 	return unstructured.Unstructured{Object: out}, nil
 }
 
+// GetDockerRegistryPVC returns the PVC created by the chart or nil if it doesn't exist yet
+func GetDockerRegistryPVC(ctx context.Context, c client.Client, namespace string) (*corev1.PersistentVolumeClaim, error) {
+	pvc := corev1.PersistentVolumeClaim{}
+	objKey := client.ObjectKey{
+		Namespace: namespace,
+		Name:      dockerRegistryPVCName,
+	}
+	if err := c.Get(ctx, objKey, &pvc); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "while getting pvc from cluster")
+	}
+	return &pvc, nil
+}
+
 func IsPVC(objKind schema.GroupVersionKind) bool {
 	expected := schema.GroupVersionKind{
 		Group:   pvcGroup,
@@ -2,6 +2,9 @@ package registry
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"math/big"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -15,6 +18,13 @@ const (
 	LabelConfigVal           = "credentials"
 	DeploymentName           = "dockerregistry"
 	HttpEnvKey               = "REGISTRY_HTTP_SECRET"
+
+	// CredentialsRotatedAtAnnotation stores the time of the last internal credentials rotation
+	CredentialsRotatedAtAnnotation = "dockerregistry.operator.kyma-project.io/credentials-rotated-at"
+	PreviousUsernameKey            = "previousUsername"
+	PreviousPasswordKey            = "previousPassword"
+
+	credentialsAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 )
 
 func GetDockerRegistryInternalRegistrySecret(ctx context.Context, c client.Client, namespace string) (*corev1.Secret, error) {
@@ -35,6 +45,40 @@ func GetDockerRegistryInternalRegistrySecret(ctx context.Context, c client.Clien
 	return &secret, nil
 }
 
+// GenerateHTTPSecret returns new random value for the REGISTRY_HTTP_SECRET env
+func GenerateHTTPSecret() (string, error) {
+	value := make([]byte, 16)
+	if _, err := rand.Read(value); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(value), nil
+}
+
+// GenerateCredentials returns new random internal registry credentials of the same length as the chart generates
+func GenerateCredentials() (string, string, error) {
+	username, err := randAlphaNum(20)
+	if err != nil {
+		return "", "", err
+	}
+	password, err := randAlphaNum(40)
+	if err != nil {
+		return "", "", err
+	}
+	return username, password, nil
+}
+
+func randAlphaNum(length int) (string, error) {
+	value := make([]byte, length)
+	for i := range value {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(credentialsAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		value[i] = credentialsAlphabet[n.Int64()]
+	}
+	return string(value), nil
+}
+
 func GetRegistryHTTPSecretEnvValue(ctx context.Context, c client.Client, namespace string) (string, error) {
 	deployment := appsv1.Deployment{}
 	key := client.ObjectKey{
@@ -0,0 +1,150 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type VolumeUsage struct {
+	UsedBytes     uint64
+	CapacityBytes uint64
+}
+
+// Percent returns the used part of the volume capacity in percents
+func (u *VolumeUsage) Percent() int32 {
+	if u.CapacityBytes == 0 {
+		return 0
+	}
+	return int32(u.UsedBytes * 100 / u.CapacityBytes)
+}
+
+type VolumeUsageReader interface {
+	GetPVCUsage(ctx context.Context, c client.Client, namespace, pvcName string) (*VolumeUsage, error)
+}
+
+// GetDockerRegistryPVCUsage returns usage of the PVC created by the chart
+func GetDockerRegistryPVCUsage(ctx context.Context, c client.Client, reader VolumeUsageReader, namespace string) (*VolumeUsage, error) {
+	return reader.GetPVCUsage(ctx, c, namespace, dockerRegistryPVCName)
+}
+
+type podCommandExecutor func(ctx context.Context, namespace, podName, container string, command []string) ([]byte, error)
+
+type volumeUsageReader struct {
+	execInPod podCommandExecutor
+}
+
+func NewVolumeUsageReader(config *rest.Config, clientset kubernetes.Interface) VolumeUsageReader {
+	return &volumeUsageReader{
+		execInPod: apiServerPodCommandExecutor(config, clientset),
+	}
+}
+
+// GetPVCUsage returns usage of the PVC reported by df in the container which mounts the PVC
+// or nil if the PVC is not mounted by any running pod
+func (r *volumeUsageReader) GetPVCUsage(ctx context.Context, c client.Client, namespace, pvcName string) (*VolumeUsage, error) {
+	podName, container, mountPath, err := findPVCMount(ctx, c, namespace, pvcName)
+	if err != nil || podName == "" {
+		return nil, err
+	}
+
+	out, err := r.execInPod(ctx, namespace, podName, container, []string{"df", "-Pk", mountPath})
+	if err != nil {
+		return nil, errors.Wrapf(err, "while reading filesystem usage in pod %s", podName)
+	}
+
+	usage, err := parseDiskFree(out)
+	return usage, errors.Wrapf(err, "while parsing filesystem usage in pod %s", podName)
+}
+
+// findPVCMount returns the running pod, its container and the path the PVC is mounted at
+func findPVCMount(ctx context.Context, c client.Client, namespace, pvcName string) (string, string, string, error) {
+	pods := corev1.PodList{}
+	if err := c.List(ctx, &pods, client.InNamespace(namespace)); err != nil {
+		return "", "", "", errors.Wrap(err, "while listing pods")
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim == nil || volume.PersistentVolumeClaim.ClaimName != pvcName {
+				continue
+			}
+			for _, container := range pod.Spec.Containers {
+				for _, mount := range container.VolumeMounts {
+					if mount.Name == volume.Name {
+						return pod.Name, container.Name, mount.MountPath, nil
+					}
+				}
+			}
+		}
+	}
+
+	return "", "", "", nil
+}
+
+// parseDiskFree reads the POSIX output of df -Pk, sizes are in 1024-byte blocks
+func parseDiskFree(out []byte) (*VolumeUsage, error) {
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) < 2 {
+		return nil, errors.Errorf("unexpected df output %q", string(out))
+	}
+
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 6 {
+		return nil, errors.Errorf("unexpected df output %q", string(out))
+	}
+	capacity, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "while parsing capacity")
+	}
+	used, err := strconv.ParseUint(fields[2], 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "while parsing used size")
+	}
+
+	return &VolumeUsage{
+		UsedBytes:     used * 1024,
+		CapacityBytes: capacity * 1024,
+	}, nil
+}
+
+func apiServerPodCommandExecutor(config *rest.Config, clientset kubernetes.Interface) podCommandExecutor {
+	return func(ctx context.Context, namespace, podName, container string, command []string) ([]byte, error) {
+		request := clientset.CoreV1().RESTClient().Post().
+			Resource("pods").
+			Namespace(namespace).
+			Name(podName).
+			SubResource("exec").
+			VersionedParams(&corev1.PodExecOptions{
+				Container: container,
+				Command:   command,
+				Stdout:    true,
+				Stderr:    true,
+			}, scheme.ParameterCodec)
+
+		executor, err := remotecommand.NewSPDYExecutor(config, http.MethodPost, request.URL())
+		if err != nil {
+			return nil, errors.Wrap(err, "while creating pod command executor")
+		}
+
+		stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+		err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{Stdout: &stdout, Stderr: &stderr})
+		if err != nil {
+			return nil, errors.Wrapf(err, "while running %s: %s", strings.Join(command, " "), strings.TrimSpace(stderr.String()))
+		}
+		return stdout.Bytes(), nil
+	}
+}
@@ -0,0 +1,108 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const fixDiskFree = `Filesystem           1024-blocks    Used Available Capacity Mounted on
+/dev/sdb                   1000     850       150  85% /var/lib/registry
+`
+
+func TestVolumeUsageReader_GetPVCUsage(t *testing.T) {
+	testCases := map[string]struct {
+		givenPods     []client.Object
+		givenOutput   string
+		givenExecErr  error
+		expectedUsage *VolumeUsage
+		expectedErr   string
+	}{
+		"return usage reported by df": {
+			givenPods:     []client.Object{fixRegistryPod(corev1.PodRunning)},
+			givenOutput:   fixDiskFree,
+			expectedUsage: &VolumeUsage{UsedBytes: 850 * 1024, CapacityBytes: 1000 * 1024},
+		},
+		"return nil when pvc is not mounted by running pod": {
+			givenPods: []client.Object{fixRegistryPod(corev1.PodPending)},
+		},
+		"return error when df output can't be parsed": {
+			givenPods:   []client.Object{fixRegistryPod(corev1.PodRunning)},
+			givenOutput: "df: /var/lib/registry: No such file or directory",
+			expectedErr: "while parsing filesystem usage in pod dockerregistry-abc: unexpected df output \"df: /var/lib/registry: No such file or directory\"",
+		},
+		"return error when command can't be run": {
+			givenPods:    []client.Object{fixRegistryPod(corev1.PodRunning)},
+			givenExecErr: errors.New("forbidden"),
+			expectedErr:  "while reading filesystem usage in pod dockerregistry-abc: forbidden",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			//GIVEN
+			k8sClient := fake.NewClientBuilder().WithObjects(testCase.givenPods...).Build()
+			reader := &volumeUsageReader{
+				execInPod: func(_ context.Context, namespace, podName, container string, command []string) ([]byte, error) {
+					require.Equal(t, kymaNamespace, namespace)
+					require.Equal(t, "dockerregistry-abc", podName)
+					require.Equal(t, "registry", container)
+					require.Equal(t, []string{"df", "-Pk", "/var/lib/registry/"}, command)
+					return []byte(testCase.givenOutput), testCase.givenExecErr
+				},
+			}
+
+			//WHEN
+			usage, err := reader.GetPVCUsage(context.TODO(), k8sClient, kymaNamespace, dockerRegistryPVCName)
+
+			//THEN
+			if testCase.expectedErr != "" {
+				require.EqualError(t, err, testCase.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedUsage, usage)
+		})
+	}
+}
+
+func TestVolumeUsage_Percent(t *testing.T) {
+	require.Equal(t, int32(85), (&VolumeUsage{UsedBytes: 850, CapacityBytes: 1000}).Percent())
+	require.Equal(t, int32(0), (&VolumeUsage{UsedBytes: 850}).Percent())
+}
+
+func fixRegistryPod(phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dockerregistry-abc",
+			Namespace: kymaNamespace,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:         "registry",
+					VolumeMounts: []corev1.VolumeMount{{Name: "data", MountPath: "/var/lib/registry/"}},
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "data",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+							ClaimName: dockerRegistryPVCName,
+						},
+					},
+				},
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: phase,
+		},
+	}
+}
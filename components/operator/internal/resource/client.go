@@ -2,18 +2,34 @@ package resource
 
 import (
 	"context"
+	"encoding/json"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	apilabels "k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/csaupgrade"
 	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
+const (
+	// FieldManager is the name of the server-side apply field manager of resources managed by the operator
+	FieldManager = "docker-registry-operator"
+	// legacyFieldManager is the field manager of resources created and updated by older operator versions,
+	// it's the default one derived from the operator binary name
+	legacyFieldManager = "operator"
+)
+
 //go:generate mockery --name=Client --output=automock --outpkg=automock --case=underscore
 type Client interface {
 	Create(ctx context.Context, object Object) error
 	CreateWithReference(ctx context.Context, parent Object, object Object) error
+	UpsertWithReference(ctx context.Context, parent Object, object Object, mutate func() error) error
 	Update(ctx context.Context, object Object) error
 	Get(ctx context.Context, key ctrlclient.ObjectKey, object Object) error
 	ListByLabel(ctx context.Context, namespace string, labels map[string]string, object ctrlclient.ObjectList) error
@@ -26,6 +42,7 @@ type Client interface {
 type K8sClient interface {
 	Create(context.Context, ctrlclient.Object, ...ctrlclient.CreateOption) error
 	Update(ctx context.Context, obj ctrlclient.Object, opts ...ctrlclient.UpdateOption) error
+	Patch(ctx context.Context, obj ctrlclient.Object, patch ctrlclient.Patch, opts ...ctrlclient.PatchOption) error
 	Get(ctx context.Context, key ctrlclient.ObjectKey, obj ctrlclient.Object, opts ...ctrlclient.GetOption) error
 	List(context.Context, ctrlclient.ObjectList, ...ctrlclient.ListOption) error
 	DeleteAllOf(context.Context, ctrlclient.Object, ...ctrlclient.DeleteAllOfOption) error
@@ -41,8 +58,22 @@ type Object interface {
 var _ Client = &client{}
 
 type client struct {
-	k8sClient K8sClient
-	schema    *runtime.Scheme
+	k8sClient   K8sClient
+	schema      *runtime.Scheme
+	labels      map[string]string
+	annotations map[string]string
+}
+
+// Option configures the Client
+type Option func(*client)
+
+// WithCommonMetadata merges the labels and annotations into the metadata of created and upserted objects,
+// labels and annotations set on the object take precedence
+func WithCommonMetadata(labels, annotations map[string]string) Option {
+	return func(c *client) {
+		c.labels = labels
+		c.annotations = annotations
+	}
 }
 
 func (c *client) Delete(ctx context.Context, obj Object) error {
@@ -52,11 +83,15 @@ func (c *client) Delete(ctx context.Context, obj Object) error {
 	})
 }
 
-func New(k8sClient K8sClient, schema *runtime.Scheme) Client {
-	return &client{
+func New(k8sClient K8sClient, schema *runtime.Scheme, opts ...Option) Client {
+	c := &client{
 		k8sClient: k8sClient,
 		schema:    schema,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 func (c *client) Create(ctx context.Context, object Object) error {
@@ -64,6 +99,7 @@ func (c *client) Create(ctx context.Context, object Object) error {
 }
 
 func (c *client) CreateWithReference(ctx context.Context, parent, object Object) error {
+	c.setCommonMetadata(object)
 	if parent != nil {
 		if err := controllerutil.SetControllerReference(parent, object, c.schema); err != nil {
 			return err
@@ -73,6 +109,120 @@ func (c *client) CreateWithReference(ctx context.Context, parent, object Object)
 	return c.k8sClient.Create(ctx, object)
 }
 
+// UpsertWithReference applies the desired state set by mutate with the server-side apply,
+// fields of the object set by other managers (for example, injected annotations) are kept
+func (c *client) UpsertWithReference(ctx context.Context, parent, object Object, mutate func() error) error {
+	if err := mutate(); err != nil {
+		return err
+	}
+	c.setCommonMetadata(object)
+	if parent != nil {
+		if err := controllerutil.SetControllerReference(parent, object, c.schema); err != nil {
+			return err
+		}
+	}
+
+	desired, err := c.applyObject(object)
+	if err != nil {
+		return err
+	}
+
+	if err := c.upgradeManagedFields(ctx, desired); err != nil {
+		return err
+	}
+
+	// without forced ownership the apply fails on conflicts with fields owned by other managers
+	err = c.k8sClient.Patch(ctx, desired, ctrlclient.Apply, ctrlclient.FieldOwner(FieldManager))
+	if err != nil {
+		return err
+	}
+
+	return fromUnstructured(desired, object)
+}
+
+func (c *client) setCommonMetadata(object Object) {
+	SetCommonMetadata(object, c.labels, c.annotations)
+}
+
+// SetCommonMetadata merges the common labels and annotations into the object metadata,
+// labels and annotations set on the object take precedence
+func SetCommonMetadata(object metav1.Object, labels, annotations map[string]string) {
+	if merged := mergeMetadata(labels, object.GetLabels()); merged != nil {
+		object.SetLabels(merged)
+	}
+	if merged := mergeMetadata(annotations, object.GetAnnotations()); merged != nil {
+		object.SetAnnotations(merged)
+	}
+}
+
+// mergeMetadata returns the common entries overridden by the object ones or nil if there are no common entries
+func mergeMetadata(common, object map[string]string) map[string]string {
+	if len(common) == 0 {
+		return nil
+	}
+
+	merged := make(map[string]string, len(common)+len(object))
+	for key, value := range common {
+		merged[key] = value
+	}
+	for key, value := range object {
+		merged[key] = value
+	}
+	return merged
+}
+
+// applyObject returns the object as the apply configuration without fields which are never set by the operator
+func (c *client) applyObject(object Object) (*unstructured.Unstructured, error) {
+	gvk, err := apiutil.GVKForObject(object, c.schema)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	desired := &unstructured.Unstructured{}
+	if err := json.Unmarshal(data, &desired.Object); err != nil {
+		return nil, err
+	}
+
+	desired.SetGroupVersionKind(gvk)
+	desired.SetResourceVersion("")
+	desired.SetManagedFields(nil)
+	unstructured.RemoveNestedField(desired.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(desired.Object, "status")
+	return desired, nil
+}
+
+// upgradeManagedFields moves fields of the object updated by previous operator versions to the apply field manager,
+// otherwise changing them would conflict with the old update field manager
+func (c *client) upgradeManagedFields(ctx context.Context, desired *unstructured.Unstructured) error {
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(desired.GroupVersionKind())
+	err := c.k8sClient.Get(ctx, ctrlclient.ObjectKeyFromObject(desired), current)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	patch, err := csaupgrade.UpgradeManagedFieldsPatch(current, sets.New(legacyFieldManager), FieldManager)
+	if err != nil || patch == nil {
+		return err
+	}
+	return c.k8sClient.Patch(ctx, current, ctrlclient.RawPatch(types.JSONPatchType, patch))
+}
+
+func fromUnstructured(u *unstructured.Unstructured, object Object) error {
+	data, err := json.Marshal(u.Object)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, object)
+}
+
 func (c *client) Update(ctx context.Context, object Object) error {
 	return c.k8sClient.Update(ctx, object)
 }
@@ -0,0 +1,151 @@
+package resource
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestClient_UpsertWithReference(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+	serviceKey := ctrlclient.ObjectKey{Name: "registry", Namespace: "kyma-system"}
+
+	t.Run("keep fields owned by other managers", func(t *testing.T) {
+		k8sClient := fake.NewClientBuilder().WithScheme(testScheme).WithReturnManagedFields().Build()
+		c := New(k8sClient, testScheme)
+
+		require.NoError(t, upsertService(c, "registry-v1", nil))
+		require.NoError(t, k8sClient.Patch(context.Background(), fixServiceApplyConfiguration(map[string]interface{}{
+			"annotations": map[string]interface{}{"sidecar.istio.io/inject": "false"},
+		}), ctrlclient.Apply, ctrlclient.FieldOwner("istio")))
+
+		require.NoError(t, upsertService(c, "registry-v2", nil))
+
+		service := &corev1.Service{}
+		require.NoError(t, k8sClient.Get(context.Background(), serviceKey, service))
+		require.Equal(t, "registry-v2", service.Spec.Selector["app"])
+		require.Equal(t, "false", service.GetAnnotations()["sidecar.istio.io/inject"])
+		requireFieldManager(t, service, FieldManager)
+	})
+
+	t.Run("don't force ownership of fields owned by other managers", func(t *testing.T) {
+		k8sClient := fake.NewClientBuilder().WithScheme(testScheme).WithReturnManagedFields().Build()
+		c := New(k8sClient, testScheme)
+
+		require.NoError(t, upsertService(c, "registry", map[string]string{"owner": "operator"}))
+		require.NoError(t, k8sClient.Patch(context.Background(), fixServiceApplyConfiguration(map[string]interface{}{
+			"labels": map[string]interface{}{"owner": "user"},
+		}), ctrlclient.Apply, ctrlclient.FieldOwner("user"), ctrlclient.ForceOwnership))
+
+		err := upsertService(c, "registry", map[string]string{"owner": "operator"})
+		require.True(t, apierrors.IsConflict(err))
+	})
+
+	t.Run("take over fields updated by previous operator versions", func(t *testing.T) {
+		k8sClient := fake.NewClientBuilder().WithScheme(testScheme).WithReturnManagedFields().Build()
+		c := New(k8sClient, testScheme)
+		require.NoError(t, k8sClient.Create(context.Background(), &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: serviceKey.Name, Namespace: serviceKey.Namespace},
+			Spec: corev1.ServiceSpec{
+				Selector: map[string]string{"app": "registry-v1"},
+				Ports:    []corev1.ServicePort{{Name: "http", Port: 5000}},
+			},
+		}, ctrlclient.FieldOwner(legacyFieldManager)))
+
+		require.NoError(t, upsertService(c, "registry-v2", nil))
+
+		service := &corev1.Service{}
+		require.NoError(t, k8sClient.Get(context.Background(), serviceKey, service))
+		require.Equal(t, "registry-v2", service.Spec.Selector["app"])
+		for _, entry := range service.GetManagedFields() {
+			require.NotEqual(t, legacyFieldManager, entry.Manager)
+		}
+	})
+}
+
+func TestClient_WithCommonMetadata(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+	serviceKey := ctrlclient.ObjectKey{Name: "registry", Namespace: "kyma-system"}
+	commonLabels := map[string]string{"cost-center": "1234", "owner": "team-a"}
+	commonAnnotations := map[string]string{"compliance.example.com/level": "high"}
+
+	t.Run("merge common metadata on upsert", func(t *testing.T) {
+		k8sClient := fake.NewClientBuilder().WithScheme(testScheme).Build()
+		c := New(k8sClient, testScheme, WithCommonMetadata(commonLabels, commonAnnotations))
+
+		require.NoError(t, upsertService(c, "registry", map[string]string{"owner": "operator"}))
+
+		service := &corev1.Service{}
+		require.NoError(t, k8sClient.Get(context.Background(), serviceKey, service))
+		require.Equal(t, map[string]string{"cost-center": "1234", "owner": "operator"}, service.GetLabels())
+		require.Equal(t, commonAnnotations, service.GetAnnotations())
+	})
+
+	t.Run("remove common metadata dropped from the configuration", func(t *testing.T) {
+		k8sClient := fake.NewClientBuilder().WithScheme(testScheme).Build()
+		require.NoError(t, upsertService(New(k8sClient, testScheme, WithCommonMetadata(commonLabels, commonAnnotations)), "registry", nil))
+
+		require.NoError(t, upsertService(New(k8sClient, testScheme), "registry", nil))
+
+		service := &corev1.Service{}
+		require.NoError(t, k8sClient.Get(context.Background(), serviceKey, service))
+		require.Empty(t, service.GetLabels())
+		require.Empty(t, service.GetAnnotations())
+	})
+
+	t.Run("merge common metadata on create", func(t *testing.T) {
+		k8sClient := fake.NewClientBuilder().WithScheme(testScheme).Build()
+		c := New(k8sClient, testScheme, WithCommonMetadata(commonLabels, nil))
+
+		require.NoError(t, c.Create(context.Background(), &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "kyma-system"},
+		}))
+
+		configMap := &corev1.ConfigMap{}
+		require.NoError(t, k8sClient.Get(context.Background(), serviceKey, configMap))
+		require.Equal(t, commonLabels, configMap.GetLabels())
+		require.Empty(t, configMap.GetAnnotations())
+	})
+}
+
+func upsertService(c Client, app string, labels map[string]string) error {
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "registry", Namespace: "kyma-system"},
+	}
+	return c.UpsertWithReference(context.Background(), nil, service, func() error {
+		service.SetLabels(labels)
+		service.Spec.Selector = map[string]string{"app": app}
+		service.Spec.Ports = []corev1.ServicePort{{Name: "http", Port: 5000}}
+		return nil
+	})
+}
+
+func fixServiceApplyConfiguration(metadata map[string]interface{}) *unstructured.Unstructured {
+	metadata["name"] = "registry"
+	metadata["namespace"] = "kyma-system"
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   metadata,
+	}}
+}
+
+func requireFieldManager(t *testing.T, obj metav1.Object, manager string) {
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Manager == manager && entry.Operation == metav1.ManagedFieldsOperationApply {
+			return
+		}
+	}
+	t.Fatalf("field manager %s not found", manager)
+}
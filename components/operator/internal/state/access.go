@@ -3,30 +3,51 @@ package state
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	internalerrors "github.com/kyma-project/docker-registry/components/operator/internal/errors"
 	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
 	"github.com/kyma-project/docker-registry/components/operator/internal/registry"
+	"github.com/kyma-project/docker-registry/components/operator/internal/tracing"
 	"github.com/pkg/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
+var errProxyWithLocalUsers = errors.New("proxy can't be used together with local registry users from spec.auth.htpasswdSecretName")
+
 func sFnAccessConfiguration(ctx context.Context, r *reconciler, s *systemState) (stateFn, *ctrl.Result, error) {
 	err := setAccessConfig(ctx, r, s)
 	if err != nil {
+		reason := v1alpha1.ConditionReasonConfigurationErr
+		if errors.Is(err, errProxyWithLocalUsers) {
+			reason = v1alpha1.ConditionReasonProxyConflict
+		}
 		s.warningBuilder.With("failed to set access configuration: " + err.Error())
-		s.instance.UpdateConditionFalse(
-			v1alpha1.ConditionTypeConfigured,
-			v1alpha1.ConditionReasonConfigurationErr,
-			err,
-		)
+		s.setConfigurationFailed(reason, err)
+		// the chart rendered without the current internal credentials would rotate them
+		if errors.Is(err, internalerrors.ErrSecretSyncFailed) {
+			s.setState(v1alpha1.StateError)
+			return stopWithEventualError(err)
+		}
 	}
 
-	return nextState(sFnStorageConfiguration)
+	return nextState(sFnTLSConfiguration)
 }
 
 func setAccessConfig(ctx context.Context, r *reconciler, s *systemState) error {
-	if err := setInternalAccessConfig(ctx, r, s); err != nil {
+	syncCtx, span := tracing.StartSpan(ctx, "SecretSync")
+	err := internalerrors.ErrSecretSyncFailed.Wrap(setInternalAccessConfig(syncCtx, r, s))
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return err
+	}
+
+	if err := setAuthConfig(ctx, r, s); err != nil {
+		return err
+	}
+
+	if err := setProxyConfig(ctx, r, s); err != nil {
 		return err
 	}
 
@@ -54,6 +75,19 @@ func setInternalAccessConfig(ctx context.Context, r *reconciler, s *systemState)
 			)
 	}
 
+	if err := prepareCredentialRotation(r, s, existingIntRegSecret, time.Now()); err != nil {
+		return err
+	}
+
+	if s.instance.IsHTTPSecretRotationRequested() {
+		httpSecret, genErr := registry.GenerateHTTPSecret()
+		if genErr != nil {
+			return errors.Wrap(genErr, "while generating new registry http secret")
+		}
+		r.log.Info("rotating docker registry http secret")
+		s.flagsBuilder.WithRegistryHttpSecret(httpSecret)
+	}
+
 	nodePort, err := s.nodePortResolver.GetNodePort(ctx, r.client, s.instance.Namespace)
 	if err != nil {
 		return errors.Wrap(err, "while resolving registry node port")
@@ -65,6 +99,97 @@ func setInternalAccessConfig(ctx context.Context, r *reconciler, s *systemState)
 	return nil
 }
 
+func setAuthConfig(ctx context.Context, r *reconciler, s *systemState) error {
+	if err := setHtpasswdConfig(ctx, r, s); err != nil {
+		return err
+	}
+
+	return setTokenAuthConfig(ctx, r, s)
+}
+
+func setHtpasswdConfig(ctx context.Context, r *reconciler, s *systemState) error {
+	secretName := s.instance.GetHtpasswdSecretName()
+	if secretName == "" {
+		return nil
+	}
+
+	htpasswdSecret, err := registry.GetSecret(ctx, r.client, secretName, s.instance.Namespace)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("while fetching htpasswd secret from %s", s.instance.Namespace))
+	}
+	if err := requireSecretKeys(htpasswdSecret, "htpasswd"); err != nil {
+		return errors.Wrap(err, "while validating htpasswd secret")
+	}
+
+	s.flagsBuilder.WithHtpasswd(
+		string(htpasswdSecret.Data["htpasswd"]),
+		secretChecksum(htpasswdSecret, "htpasswd"),
+	)
+	return nil
+}
+
+func setTokenAuthConfig(ctx context.Context, r *reconciler, s *systemState) error {
+	if !s.instance.IsTokenAuthEnabled() {
+		return nil
+	}
+
+	tokenAuth := s.instance.Spec.Auth.TokenAuth
+	bundleSecret, err := registry.GetSecret(ctx, r.client, tokenAuth.RootCertBundleSecretName, s.instance.Namespace)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("while fetching token auth certificate bundle secret from %s", s.instance.Namespace))
+	}
+	if err := requireSecretKeys(bundleSecret, "ca.crt"); err != nil {
+		return errors.Wrap(err, "while validating token auth certificate bundle secret")
+	}
+
+	s.flagsBuilder.WithTokenAuth(tokenAuth)
+	return nil
+}
+
+func setProxyConfig(ctx context.Context, r *reconciler, s *systemState) error {
+	proxy := s.instance.Spec.Proxy
+	if proxy == nil {
+		return nil
+	}
+
+	if s.instance.GetHtpasswdSecretName() != "" {
+		// pull-through cache doesn't accept pushes so local users would be misleading
+		return errProxyWithLocalUsers
+	}
+
+	passwordKey := ""
+	checksum := proxy.RemoteURL
+	if proxy.PasswordSecretRef != nil {
+		passwordKey = proxy.PasswordSecretRef.GetKey()
+		passwordSecret, err := registry.GetSecret(ctx, r.client, proxy.PasswordSecretRef.Name, s.instance.Namespace)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("while fetching proxy password secret from %s", s.instance.Namespace))
+		}
+		if err := requireSecretKeys(passwordSecret, passwordKey); err != nil {
+			return errors.Wrap(err, "while validating proxy password secret")
+		}
+		checksum = secretChecksum(passwordSecret, passwordKey)
+	}
+
+	if proxy.CASecretName != "" {
+		caSecret, err := registry.GetSecret(ctx, r.client, proxy.CASecretName, s.instance.Namespace)
+		if err != nil {
+			return errors.Wrap(err, fmt.Sprintf("while fetching proxy CA secret from %s", s.instance.Namespace))
+		}
+		if err := requireSecretKeys(caSecret, v1alpha1.ProxyCASecretKey); err != nil {
+			return errors.Wrap(err, "while validating proxy CA secret")
+		}
+		if err := v1alpha1.ValidateCABundle(caSecret.Data[v1alpha1.ProxyCASecretKey]); err != nil {
+			return errors.Wrap(err, "while validating proxy CA secret")
+		}
+		// restart registry to trust the new CA
+		checksum = fmt.Sprintf("%s-%s", checksum, secretChecksum(caSecret, v1alpha1.ProxyCASecretKey))
+	}
+
+	s.flagsBuilder.WithProxy(proxy, passwordKey, checksum)
+	return nil
+}
+
 func setExternalAccessConfig(ctx context.Context, r *reconciler, s *systemState) error {
 	spec := s.instance.Spec
 	externalConfigured := spec.ExternalAccess != nil && spec.ExternalAccess.Enabled != nil
@@ -74,6 +199,19 @@ func setExternalAccessConfig(ctx context.Context, r *reconciler, s *systemState)
 		return nil
 	}
 
+	if s.instance.IsIstioGatewayManaged() && spec.ExternalAccess.Gateway == nil {
+		// the gateway is created later in the istio state so there is nothing to resolve yet
+		if spec.ExternalAccess.Host == nil {
+			msg := ".spec.externalAccess.enabled is true but .spec.externalAccess.host is required to use the gateway created from .spec.istio.gateway"
+			s.warningBuilder.With(msg)
+			r.log.Warnf(msg)
+			return nil
+		}
+		s.flagsBuilder.WithVirtualService(*spec.ExternalAccess.Host, managedGatewayReference(s))
+		setVirtualServiceRoute(s)
+		return nil
+	}
+
 	resolvedAccess, err := s.gatewayHostResolver.Do(ctx, r.client, *spec.ExternalAccess)
 	if err != nil {
 		// set warning and continue reconciliation because external access is optional
@@ -87,6 +225,26 @@ func setExternalAccessConfig(ctx context.Context, r *reconciler, s *systemState)
 		resolvedAccess.Host,
 		resolvedAccess.Gateway,
 	)
+	setVirtualServiceRoute(s)
 
 	return nil
 }
+
+func setVirtualServiceRoute(s *systemState) {
+	istio := s.instance.Spec.Istio
+	if istio == nil || istio.VirtualService == nil {
+		return
+	}
+
+	route := istio.VirtualService
+	if route.Timeout != nil {
+		s.flagsBuilder.WithVirtualServiceTimeout(route.Timeout.Duration)
+	}
+	if route.Retries != nil {
+		var perTryTimeout *time.Duration
+		if route.Retries.PerTryTimeout != nil {
+			perTryTimeout = &route.Retries.PerTryTimeout.Duration
+		}
+		s.flagsBuilder.WithVirtualServiceRetries(route.Retries.Attempts, perTryTimeout)
+	}
+}
@@ -3,6 +3,7 @@ package state
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
 	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
@@ -50,7 +51,7 @@ func Test_sFnAccessConfiguration(t *testing.T) {
 		next, result, err := sFnAccessConfiguration(context.Background(), r, s)
 		require.NoError(t, err)
 		require.Nil(t, result)
-		requireEqualFunc(t, sFnStorageConfiguration, next)
+		requireEqualFunc(t, sFnTLSConfiguration, next)
 
 		flags, err := s.flagsBuilder.Build()
 		require.NoError(t, err)
@@ -58,6 +59,59 @@ func Test_sFnAccessConfiguration(t *testing.T) {
 		require.EqualValues(t, expectedFlags, flags)
 	})
 
+	t.Run("generate new http secret when rotation is requested", func(t *testing.T) {
+		registryDeploy := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      registry.DeploymentName,
+				Namespace: "kyma",
+			},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{
+								Env: []corev1.EnvVar{
+									{
+										Name:  registry.HttpEnvKey,
+										Value: "httpEnvKeyVal",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "kyma",
+					Annotations: map[string]string{
+						v1alpha1.RotateHTTPSecretAnnotation: "true",
+					},
+				},
+			},
+			statusSnapshot:   v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:     flags.NewBuilder(),
+			nodePortResolver: registry.NewNodePortResolver(registry.RandomNodePort),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().WithObjects(registryDeploy).Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		next, result, err := sFnAccessConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnTLSConfiguration, next)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.NotEmpty(t, flags["registryHTTPSecret"])
+		require.NotEqual(t, "httpEnvKeyVal", flags["registryHTTPSecret"])
+	})
+
 	t.Run("setup node port and use existing username and password", func(t *testing.T) {
 		registrySecret := &corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
@@ -131,7 +185,7 @@ func Test_sFnAccessConfiguration(t *testing.T) {
 		next, result, err := sFnAccessConfiguration(context.Background(), r, s)
 		require.NoError(t, err)
 		require.Nil(t, result)
-		requireEqualFunc(t, sFnStorageConfiguration, next)
+		requireEqualFunc(t, sFnTLSConfiguration, next)
 
 		flags, err := s.flagsBuilder.Build()
 		require.NoError(t, err)
@@ -200,7 +254,7 @@ func Test_sFnAccessConfiguration(t *testing.T) {
 		next, result, err := sFnAccessConfiguration(context.Background(), r, s)
 		require.NoError(t, err)
 		require.Nil(t, result)
-		requireEqualFunc(t, sFnStorageConfiguration, next)
+		requireEqualFunc(t, sFnTLSConfiguration, next)
 
 		flags, err := s.flagsBuilder.Build()
 		require.NoError(t, err)
@@ -208,6 +262,113 @@ func Test_sFnAccessConfiguration(t *testing.T) {
 		require.EqualValues(t, expectedFlags, flags)
 	})
 
+	t.Run("setup external access route timeout and retries", func(t *testing.T) {
+		testScheme := runtime.NewScheme()
+		require.NoError(t, istiov1beta1.AddToScheme(testScheme))
+		require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+
+		testGateway := &istiov1beta1.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "kyma-gateway",
+				Namespace: "kyma-system",
+			},
+			Spec: networkingv1beta1.Gateway{
+				Servers: []*networkingv1beta1.Server{
+					{
+						Hosts: []string{"*.cluster.local"},
+					},
+				},
+			},
+		}
+
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-name",
+					Namespace: "test-namespace",
+				},
+				Spec: v1alpha1.DockerRegistrySpec{
+					ExternalAccess: &v1alpha1.ExternalAccess{
+						Enabled: ptr.To(true),
+					},
+					Istio: &v1alpha1.Istio{
+						VirtualService: &v1alpha1.IstioVirtualService{
+							Timeout: &metav1.Duration{Duration: 10 * time.Minute},
+							Retries: &v1alpha1.IstioRetries{
+								Attempts:      3,
+								PerTryTimeout: &metav1.Duration{Duration: 1500 * time.Millisecond},
+							},
+						},
+					},
+				},
+			},
+			statusSnapshot:      v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:        flags.NewBuilder(),
+			nodePortResolver:    registry.NewNodePortResolver(registry.RandomNodePort),
+			gatewayHostResolver: registry.NewExternalAccessResolver("registry-test-name-test-namespace"),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(testGateway).Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		_, _, err := sFnAccessConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+
+		virtualService := flags["virtualService"].(map[string]interface{})
+		require.Equal(t, "600s", virtualService["timeout"])
+		require.EqualValues(t, map[string]interface{}{
+			"attempts":      int64(3),
+			"perTryTimeout": "1.5s",
+		}, virtualService["retries"])
+	})
+
+	t.Run("setup external access with gateway created by the operator", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-name",
+					Namespace: "test-namespace",
+				},
+				Spec: v1alpha1.DockerRegistrySpec{
+					ExternalAccess: &v1alpha1.ExternalAccess{
+						Enabled: ptr.To(true),
+						Host:    ptr.To("registry.example.com"),
+					},
+					Istio: &v1alpha1.Istio{
+						Enabled: true,
+						Gateway: &v1alpha1.IstioGateway{Create: true},
+					},
+				},
+			},
+			statusSnapshot:      v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:        flags.NewBuilder(),
+			nodePortResolver:    registry.NewNodePortResolver(registry.RandomNodePort),
+			warningBuilder:      warning.NewBuilder(),
+			gatewayHostResolver: registry.NewExternalAccessResolver("registry-test-name-test-namespace"),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		_, _, err := sFnAccessConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Empty(t, s.warningBuilder.Build())
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+
+		require.EqualValues(t, map[string]interface{}{
+			"enabled": true,
+			"gateway": "test-namespace/dockerregistry",
+			"host":    "registry.example.com",
+		}, flags["virtualService"])
+	})
+
 	t.Run("external access gateway not found error", func(t *testing.T) {
 		testScheme := runtime.NewScheme()
 		require.NoError(t, istiov1beta1.AddToScheme(testScheme))
@@ -248,7 +409,7 @@ func Test_sFnAccessConfiguration(t *testing.T) {
 		next, result, err := sFnAccessConfiguration(context.Background(), r, s)
 		require.NoError(t, err)
 		require.Nil(t, result)
-		requireEqualFunc(t, sFnStorageConfiguration, next)
+		requireEqualFunc(t, sFnTLSConfiguration, next)
 
 		flags, err := s.flagsBuilder.Build()
 		require.NoError(t, err)
@@ -257,4 +418,330 @@ func Test_sFnAccessConfiguration(t *testing.T) {
 
 		require.Equal(t, "Warning: .spec.externalAccess.enabled is true but got error: while getting Gateway kyma-gateway in namespace kyma-system: gatewaies.networking.istio.io \"kyma-gateway\" not found", s.warningBuilder.Build())
 	})
+
+	t.Run("append users from htpasswd secret", func(t *testing.T) {
+		htpasswdSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "registry-users",
+				Namespace: "kyma",
+			},
+			Data: map[string][]byte{
+				"htpasswd": []byte("ci:$2y$05$hash\npuller:$2y$05$hash"),
+			},
+		}
+
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "kyma",
+				},
+				Spec: v1alpha1.DockerRegistrySpec{
+					Auth: &v1alpha1.Auth{
+						HtpasswdSecretName: "registry-users",
+					},
+				},
+			},
+			statusSnapshot:   v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:     flags.NewBuilder(),
+			nodePortResolver: registry.NewNodePortResolver(registry.RandomNodePort),
+			warningBuilder:   warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().WithObjects(htpasswdSecret).Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		next, result, err := sFnAccessConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnTLSConfiguration, next)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			"htpasswd": "ci:$2y$05$hash\npuller:$2y$05$hash",
+		}, flags["secrets"])
+		require.Contains(t, flags["rollme"], "htpasswdChecksum=")
+	})
+
+	t.Run("htpasswd secret without htpasswd key", func(t *testing.T) {
+		htpasswdSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "registry-users",
+				Namespace: "kyma",
+			},
+		}
+
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "kyma",
+				},
+				Spec: v1alpha1.DockerRegistrySpec{
+					Auth: &v1alpha1.Auth{
+						HtpasswdSecretName: "registry-users",
+					},
+				},
+			},
+			statusSnapshot:   v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:     flags.NewBuilder(),
+			nodePortResolver: registry.NewNodePortResolver(registry.RandomNodePort),
+			warningBuilder:   warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().WithObjects(htpasswdSecret).Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		_, _, err := sFnAccessConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeConfigured,
+			metav1.ConditionFalse,
+			v1alpha1.ConditionReasonConfigurationErr,
+			"while validating htpasswd secret: secret kyma/registry-users is missing keys: htpasswd",
+		)
+	})
+
+	t.Run("configure token auth", func(t *testing.T) {
+		bundleSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "token-ca",
+				Namespace: "kyma",
+			},
+			Data: map[string][]byte{
+				"ca.crt": []byte("cert"),
+			},
+		}
+
+		s := &systemState{
+			instance:         fixTokenAuthDockerRegistry(),
+			statusSnapshot:   v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:     flags.NewBuilder(),
+			nodePortResolver: registry.NewNodePortResolver(registry.RandomNodePort),
+			warningBuilder:   warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().WithObjects(bundleSecret).Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		next, result, err := sFnAccessConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnTLSConfiguration, next)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			"token": map[string]interface{}{
+				"enabled":                  true,
+				"realm":                    "https://auth.example.com/token",
+				"service":                  "registry",
+				"issuer":                   "auth.example.com",
+				"rootCertBundleSecretName": "token-ca",
+			},
+		}, flags["auth"])
+	})
+
+	t.Run("token auth certificate bundle secret not found", func(t *testing.T) {
+		s := &systemState{
+			instance:         fixTokenAuthDockerRegistry(),
+			statusSnapshot:   v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:     flags.NewBuilder(),
+			nodePortResolver: registry.NewNodePortResolver(registry.RandomNodePort),
+			warningBuilder:   warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		next, result, err := sFnAccessConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnTLSConfiguration, next)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeConfigured,
+			metav1.ConditionFalse,
+			v1alpha1.ConditionReasonConfigurationErr,
+			"while fetching token auth certificate bundle secret from kyma: secrets \"token-ca\" not found",
+		)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.NotContains(t, flags, "auth")
+	})
+
+	t.Run("configure proxy with password secret", func(t *testing.T) {
+		passwordSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "dockerhub",
+				Namespace: "kyma",
+			},
+			Data: map[string][]byte{
+				"token": []byte("secret"),
+			},
+		}
+
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "kyma",
+				},
+				Spec: v1alpha1.DockerRegistrySpec{
+					Proxy: &v1alpha1.Proxy{
+						RemoteURL: "https://registry-1.docker.io",
+						Username:  "user",
+						PasswordSecretRef: &v1alpha1.SecretKeyRef{
+							Name: "dockerhub",
+							Key:  "token",
+						},
+					},
+				},
+			},
+			statusSnapshot:   v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:     flags.NewBuilder(),
+			nodePortResolver: registry.NewNodePortResolver(registry.RandomNodePort),
+			warningBuilder:   warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().WithObjects(passwordSecret).Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		next, result, err := sFnAccessConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnTLSConfiguration, next)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			"enabled":            true,
+			"remoteURL":          "https://registry-1.docker.io",
+			"username":           "user",
+			"passwordSecretName": "dockerhub",
+			"passwordSecretKey":  "token",
+		}, flags["proxy"])
+		require.Contains(t, flags["rollme"], "proxyChecksum=")
+	})
+
+	t.Run("proxy conflicts with htpasswd users", func(t *testing.T) {
+		htpasswdSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "registry-users",
+				Namespace: "kyma",
+			},
+			Data: map[string][]byte{
+				"htpasswd": []byte("ci:$2y$05$hash"),
+			},
+		}
+
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "kyma",
+				},
+				Spec: v1alpha1.DockerRegistrySpec{
+					Auth: &v1alpha1.Auth{
+						HtpasswdSecretName: "registry-users",
+					},
+					Proxy: &v1alpha1.Proxy{
+						RemoteURL: "https://registry-1.docker.io",
+					},
+				},
+			},
+			statusSnapshot:   v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:     flags.NewBuilder(),
+			nodePortResolver: registry.NewNodePortResolver(registry.RandomNodePort),
+			warningBuilder:   warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().WithObjects(htpasswdSecret).Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		next, result, err := sFnAccessConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnTLSConfiguration, next)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeConfigured,
+			metav1.ConditionFalse,
+			v1alpha1.ConditionReasonProxyConflict,
+			"proxy can't be used together with local registry users from spec.auth.htpasswdSecretName",
+		)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.NotContains(t, flags, "proxy")
+	})
+
+	t.Run("reject proxy CA secret without PEM certificate", func(t *testing.T) {
+		caSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "proxy-ca",
+				Namespace: "kyma",
+			},
+			Data: map[string][]byte{
+				"ca.crt": []byte("not a certificate"),
+			},
+		}
+
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "kyma",
+				},
+				Spec: v1alpha1.DockerRegistrySpec{
+					Proxy: &v1alpha1.Proxy{
+						RemoteURL:    "https://registry.internal",
+						CASecretName: "proxy-ca",
+					},
+				},
+			},
+			statusSnapshot:   v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:     flags.NewBuilder(),
+			nodePortResolver: registry.NewNodePortResolver(registry.RandomNodePort),
+			warningBuilder:   warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().WithObjects(caSecret).Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		next, result, err := sFnAccessConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnTLSConfiguration, next)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeConfigured,
+			metav1.ConditionFalse,
+			v1alpha1.ConditionReasonConfigurationErr,
+			"while validating proxy CA secret: certificate is not PEM encoded",
+		)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.NotContains(t, flags, "proxy")
+	})
+}
+
+func fixTokenAuthDockerRegistry() v1alpha1.DockerRegistry {
+	return v1alpha1.DockerRegistry{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "kyma",
+		},
+		Spec: v1alpha1.DockerRegistrySpec{
+			Auth: &v1alpha1.Auth{
+				TokenAuth: &v1alpha1.TokenAuth{
+					Realm:                    "https://auth.example.com/token",
+					Service:                  "registry",
+					Issuer:                   "auth.example.com",
+					RootCertBundleSecretName: "token-ca",
+				},
+			},
+		},
+	}
 }
@@ -3,6 +3,8 @@ package state
 import (
 	"context"
 
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/pkg/errors"
 	controllerruntime "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
@@ -12,7 +14,11 @@ func sFnAddFinalizer(ctx context.Context, r *reconciler, s *systemState) (stateF
 	instanceHasFinalizer := controllerutil.ContainsFinalizer(&s.instance, r.finalizer)
 	if !instanceHasFinalizer {
 		// in case instance has no finalizer and instance is being deleted - end reconciliation
+		// unless the registry storage still has to be cleaned up
 		if instanceIsBeingDeleted {
+			if controllerutil.ContainsFinalizer(&s.instance, v1alpha1.CleanupFinalizer) {
+				return nextState(sFnInitialize)
+			}
 			// stop state machine
 			return stop()
 		}
@@ -30,3 +36,18 @@ func addFinalizer(ctx context.Context, r *reconciler, s *systemState) error {
 	controllerutil.AddFinalizer(&s.instance, r.finalizer)
 	return updateDockerRegistryWithoutStatus(ctx, r, s)
 }
+
+// addCleanupFinalizer registers the storage cleanup finalizer after the registry is installed successfully
+func addCleanupFinalizer(ctx context.Context, r *reconciler, s *systemState) error {
+	if !controllerutil.AddFinalizer(&s.instance, v1alpha1.CleanupFinalizer) {
+		return nil
+	}
+
+	// keep calculated status, update overrides it with the one from the cluster
+	status := s.instance.Status.DeepCopy()
+	if err := updateDockerRegistryWithoutStatus(ctx, r, s); err != nil {
+		return errors.Wrap(err, "while adding cleanup finalizer")
+	}
+	s.instance.Status = *status
+	return nil
+}
@@ -81,4 +81,26 @@ func Test_sFnAddFinalizer(t *testing.T) {
 		require.Nil(t, result)
 		require.Nil(t, next)
 	})
+	t.Run("continue deletion when only cleanup finalizer is left", func(t *testing.T) {
+		r := &reconciler{
+			cfg: cfg{
+				finalizer: v1alpha1.Finalizer,
+			},
+		}
+
+		metaTimeNow := v1.Now()
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				ObjectMeta: v1.ObjectMeta{
+					DeletionTimestamp: &metaTimeNow,
+					Finalizers:        []string{v1alpha1.CleanupFinalizer},
+				},
+			},
+		}
+
+		next, result, err := sFnAddFinalizer(context.Background(), r, s)
+		require.Nil(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnInitialize, next)
+	})
 }
@@ -2,13 +2,22 @@ package state
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
 
 	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	internalerrors "github.com/kyma-project/docker-registry/components/operator/internal/errors"
 	"github.com/kyma-project/docker-registry/components/operator/internal/registry"
+	"github.com/kyma-project/docker-registry/components/operator/internal/tracing"
 	"github.com/kyma-project/manager-toolkit/installation/base/resource"
 	"github.com/kyma-project/manager-toolkit/installation/chart"
 	"github.com/kyma-project/manager-toolkit/installation/chart/action"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -24,7 +33,9 @@ func sFnApplyResources(ctx context.Context, r *reconciler, s *systemState) (stat
 	s.flagsBuilder.WithManagedByLabel("dockerregistry-operator")
 
 	// install component
-	err := install(ctx, r, s)
+	installCtx, span := tracing.StartSpan(ctx, "HelmRender")
+	err := install(installCtx, r, s)
+	tracing.EndSpan(span, err)
 	if err != nil {
 		r.log.Warnf("error while installing resource %s: %s",
 			client.ObjectKeyFromObject(&s.instance), err.Error())
@@ -34,11 +45,44 @@ func sFnApplyResources(ctx context.Context, r *reconciler, s *systemState) (stat
 			v1alpha1.ConditionReasonInstallationErr,
 			err,
 		)
+		s.instance.UpdateConditionFalse(
+			v1alpha1.ConditionTypeHelmChartApplied,
+			v1alpha1.ConditionReasonChartApplyErr,
+			err,
+		)
+		updateReadyCondition(s)
 		return stopWithEventualError(err)
 	}
 
-	// switch state verify
-	return nextState(sFnVerifyResources)
+	s.instance.UpdateConditionTrue(
+		v1alpha1.ConditionTypeHelmChartApplied,
+		v1alpha1.ConditionReasonChartApplied,
+		"Chart applied",
+	)
+	completeChartUpgrade(r, s)
+	updateDeploymentUpdateDeferredCondition(s)
+
+	if s.instance.IsHTTPSecretRotationRequested() {
+		if err := finishHTTPSecretRotation(ctx, r, s); err != nil {
+			return stopWithEventualError(err)
+		}
+	}
+
+	return nextState(sFnPodDisruptionBudget)
+}
+
+// finishHTTPSecretRotation removes the rotation annotation so the http secret is not regenerated again
+func finishHTTPSecretRotation(ctx context.Context, r *reconciler, s *systemState) error {
+	// update returns the instance with the stored status which would drop not yet saved conditions
+	status := s.instance.Status.DeepCopy()
+	delete(s.instance.Annotations, v1alpha1.RotateHTTPSecretAnnotation)
+	if err := updateDockerRegistryWithoutStatus(ctx, r, s); err != nil {
+		return errors.Wrap(err, "while removing http secret rotation annotation")
+	}
+	s.instance.Status = *status
+
+	r.Event(&s.instance, "Normal", "HTTPSecretRotated", "Registry HTTP secret rotated")
+	return nil
 }
 
 func install(ctx context.Context, r *reconciler, s *systemState) error {
@@ -47,15 +91,106 @@ func install(ctx context.Context, r *reconciler, s *systemState) error {
 		return err
 	}
 
-	return chart.Install(s.chartConfig, &chart.InstallOpts{
+	spec := s.instance.Spec
+	hash, err := chartValuesHash(r.chartVersion, flags, spec.CommonLabels, spec.CommonAnnotations)
+	if err != nil {
+		return errors.Wrap(err, "while computing chart values hash")
+	}
+	if skipChartApply(r, s, hash) {
+		r.log.Debug("skipping chart apply, chart values didn't change")
+		return nil
+	}
+
+	diff := newChartDiff(ctx, s)
+	rendered := false
+	err = chart.Install(s.chartConfig, &chart.InstallOpts{
 		CustomFlags: flags,
 		PreActions: []action.PreApply{
+			renderedPreApplyAction(&rendered),
 			action.PreApplyWithPredicate(
 				adjustPVCPreApplyAction(ctx, r.client),
 				resource.HasKind("PersistentVolumeClaim"),
 			),
+			action.PreApplyWithPredicate(
+				deferRolloutPreApplyAction(ctx, r, s),
+				resource.HasKind("Deployment"),
+			),
+			commonMetadataPreApplyAction(s),
+			// the last action sees the resources as they are applied
+			diffPreApplyAction(ctx, r, diff),
 		},
 	})
+	if err != nil && !rendered {
+		return internalerrors.ErrHelmRenderFailed.Wrap(err)
+	}
+	if err != nil {
+		return err
+	}
+	emitChartDiff(r, s, diff)
+
+	// the apply time is used only by the grace period, updating it on every apply would change the status
+	// in every reconciliation
+	if r.applyGracePeriod > 0 || s.instance.Status.LastAppliedHash != hash {
+		s.instance.Status.LastAppliedTime = ptr.To(metav1.Now())
+	}
+	s.instance.Status.LastAppliedHash = hash
+	return nil
+}
+
+// chartValuesHash identifies the applied manifest, the same chart renders the same manifest from the same values
+// and the common metadata is added to the rendered resources
+func chartValuesHash(chartVersion string, flags map[string]interface{}, commonMetadata ...map[string]string) (string, error) {
+	// maps are marshalled with sorted keys so the output is stable
+	values, err := json.Marshal(flags)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(chartVersion))
+	hash.Write(values)
+	// the hash of the values without the common metadata stays the same
+	if hasCommonMetadata(commonMetadata) {
+		metadata, err := json.Marshal(commonMetadata)
+		if err != nil {
+			return "", err
+		}
+		hash.Write(metadata)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func hasCommonMetadata(commonMetadata []map[string]string) bool {
+	for _, metadata := range commonMetadata {
+		if len(metadata) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// skipChartApply returns true if the same chart values were applied within the grace period,
+// the chart is still re-applied afterwards to revert manual changes of the registry resources
+func skipChartApply(r *reconciler, s *systemState, hash string) bool {
+	status := s.instance.Status
+	if r.applyGracePeriod <= 0 || status.LastAppliedHash != hash || status.LastAppliedTime == nil {
+		return false
+	}
+	// deferred rollout is retried only by the apply
+	if !s.instance.IsConditionTrue(v1alpha1.ConditionTypeHelmChartApplied) ||
+		s.instance.IsCondition(v1alpha1.ConditionTypeDeploymentUpdateDeferred) {
+		return false
+	}
+	return time.Since(status.LastAppliedTime.Time) < r.applyGracePeriod
+}
+
+// renderedPreApplyAction marks the chart as rendered, the chart install runs the actions only for the rendered
+// resources so the later errors are the apply errors
+func renderedPreApplyAction(rendered *bool) action.PreApply {
+	return func(*unstructured.Unstructured) error {
+		*rendered = true
+		return nil
+	}
 }
 
 func adjustPVCPreApplyAction(ctx context.Context, c client.Client) action.PreApply {
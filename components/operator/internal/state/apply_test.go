@@ -3,15 +3,21 @@ package state
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	internalerrors "github.com/kyma-project/docker-registry/components/operator/internal/errors"
 	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
 
 	"github.com/kyma-project/manager-toolkit/installation/chart"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func Test_buildSFnApplyResources(t *testing.T) {
@@ -35,7 +41,7 @@ func Test_buildSFnApplyResources(t *testing.T) {
 		next, result, err := sFnApplyResources(context.Background(), &reconciler{}, s)
 		require.Nil(t, err)
 		require.Nil(t, result)
-		requireEqualFunc(t, sFnVerifyResources, next)
+		requireEqualFunc(t, sFnPodDisruptionBudget, next)
 
 		expectedFlags := map[string]interface{}{
 			"commonLabels": map[string]interface{}{
@@ -72,13 +78,146 @@ func Test_buildSFnApplyResources(t *testing.T) {
 			},
 			flagsBuilder: flags.NewBuilder(),
 		}
-		r := &reconciler{}
+		r := &reconciler{
+			cfg: cfg{chartVersion: "1.9.1"},
+		}
 
 		// run installation process and return verificating state
 		next, result, err := sFnApplyResources(context.Background(), r, s)
 		require.Nil(t, err)
 		require.Nil(t, result)
-		requireEqualFunc(t, sFnVerifyResources, next)
+		requireEqualFunc(t, sFnPodDisruptionBudget, next)
+		require.NotEmpty(t, s.instance.Status.LastAppliedHash)
+		require.NotNil(t, s.instance.Status.LastAppliedTime)
+		require.Equal(t, "1.9.1", s.instance.Status.ChartVersion)
+	})
+
+	t.Run("keep applied time of unchanged chart values without grace period", func(t *testing.T) {
+		appliedFlags := flags.NewBuilder()
+		appliedFlags.WithManagedByLabel("dockerregistry-operator")
+		values, err := appliedFlags.Build()
+		require.NoError(t, err)
+		hash, err := chartValuesHash("1.9.1", values)
+		require.NoError(t, err)
+		appliedAt := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+
+		instance := testInstalledDockerRegistry.DeepCopy()
+		instance.Status.LastAppliedHash = hash
+		instance.Status.LastAppliedTime = &appliedAt
+		s := &systemState{
+			instance: *instance,
+			chartConfig: &chart.Config{
+				Cache: fixEmptyManifestCache(),
+				CacheKey: types.NamespacedName{
+					Name:      testInstalledDockerRegistry.GetName(),
+					Namespace: testInstalledDockerRegistry.GetNamespace(),
+				},
+				Release: chart.Release{
+					Name:      testInstalledDockerRegistry.GetName(),
+					Namespace: testInstalledDockerRegistry.GetNamespace(),
+				},
+			},
+			flagsBuilder: flags.NewBuilder(),
+		}
+		r := &reconciler{
+			cfg: cfg{chartVersion: "1.9.1"},
+		}
+
+		_, _, err = sFnApplyResources(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Equal(t, hash, s.instance.Status.LastAppliedHash)
+		require.Equal(t, &appliedAt, s.instance.Status.LastAppliedTime)
+	})
+
+	t.Run("skip apply of unchanged chart values within grace period", func(t *testing.T) {
+		s := fixAppliedChartState(t, time.Now().Add(-time.Minute))
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			cfg: cfg{applyGracePeriod: time.Hour},
+		}
+
+		// the broken manifest cache fails the apply if it's not skipped
+		next, result, err := sFnApplyResources(context.Background(), r, s)
+		require.Nil(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnPodDisruptionBudget, next)
+	})
+
+	t.Run("skip apply of unchanged chart values with grace period of the machine", func(t *testing.T) {
+		s := fixAppliedChartState(t, time.Now().Add(-time.Minute))
+		r := NewMachine(nil, nil, nil, nil, zap.NewNop().Sugar(), nil, nil, "", "", "", 0, 0, time.Hour).(*reconciler)
+
+		next, result, err := sFnApplyResources(context.Background(), r, s)
+		require.Nil(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnPodDisruptionBudget, next)
+	})
+
+	t.Run("apply unchanged chart values after grace period", func(t *testing.T) {
+		s := fixAppliedChartState(t, time.Now().Add(-2*time.Hour))
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			cfg: cfg{applyGracePeriod: time.Hour},
+		}
+
+		_, _, err := sFnApplyResources(context.Background(), r, s)
+		require.ErrorContains(t, err, "could not parse chart manifest")
+	})
+
+	t.Run("apply unchanged chart values without grace period", func(t *testing.T) {
+		s := fixAppliedChartState(t, time.Now().Add(-time.Minute))
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+		}
+
+		_, _, err := sFnApplyResources(context.Background(), r, s)
+		require.ErrorContains(t, err, "could not parse chart manifest")
+	})
+
+	t.Run("remove http secret rotation annotation after apply", func(t *testing.T) {
+		scheme := runtime.NewScheme()
+		require.NoError(t, v1alpha1.AddToScheme(scheme))
+
+		instance := testInstalledDockerRegistry.DeepCopy()
+		instance.Annotations = map[string]string{
+			v1alpha1.RotateHTTPSecretAnnotation: "true",
+		}
+		s := &systemState{
+			chartConfig: &chart.Config{
+				Cache: fixEmptyManifestCache(),
+				CacheKey: types.NamespacedName{
+					Name:      testInstalledDockerRegistry.GetName(),
+					Namespace: testInstalledDockerRegistry.GetNamespace(),
+				},
+				Release: chart.Release{
+					Name:      testInstalledDockerRegistry.GetName(),
+					Namespace: testInstalledDockerRegistry.GetNamespace(),
+				},
+			},
+			flagsBuilder: flags.NewBuilder(),
+		}
+		eventRecorder := record.NewFakeRecorder(5)
+		r := &reconciler{
+			k8s: k8s{
+				client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(instance).Build(),
+				EventRecorder: eventRecorder,
+			},
+		}
+
+		// use stored instance to get up-to-date resource version
+		require.NoError(t, r.client.Get(context.Background(), client.ObjectKeyFromObject(instance), &s.instance))
+
+		next, result, err := sFnApplyResources(context.Background(), r, s)
+		require.Nil(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnPodDisruptionBudget, next)
+		require.False(t, s.instance.IsHTTPSecretRotationRequested())
+		require.Equal(t, instance.Status.State, s.instance.Status.State)
+		require.Equal(t, "Normal HTTPSecretRotated Registry HTTP secret rotated", <-eventRecorder.Events)
+
+		storedInstance := &v1alpha1.DockerRegistry{}
+		require.NoError(t, r.client.Get(context.Background(), client.ObjectKeyFromObject(instance), storedInstance))
+		require.NotContains(t, storedInstance.GetAnnotations(), v1alpha1.RotateHTTPSecretAnnotation)
 	})
 
 	t.Run("install chart error", func(t *testing.T) {
@@ -100,6 +239,7 @@ func Test_buildSFnApplyResources(t *testing.T) {
 		// handle error and return update condition state
 		next, result, err := sFnApplyResources(context.Background(), r, s)
 		require.EqualError(t, err, "could not parse chart manifest: yaml: found character that cannot start any token")
+		require.ErrorIs(t, err, internalerrors.ErrHelmRenderFailed)
 		require.Nil(t, result)
 		require.Nil(t, next)
 
@@ -113,3 +253,29 @@ func Test_buildSFnApplyResources(t *testing.T) {
 		)
 	})
 }
+
+// fixAppliedChartState returns the state with the chart values applied at the given time and the broken manifest cache
+func fixAppliedChartState(t *testing.T, appliedAt time.Time) *systemState {
+	appliedFlags := flags.NewBuilder()
+	appliedFlags.WithManagedByLabel("dockerregistry-operator")
+	values, err := appliedFlags.Build()
+	require.NoError(t, err)
+	hash, err := chartValuesHash("", values)
+	require.NoError(t, err)
+
+	instance := testInstalledDockerRegistry.DeepCopy()
+	instance.Status.LastAppliedHash = hash
+	instance.Status.LastAppliedTime = &metav1.Time{Time: appliedAt}
+	instance.UpdateConditionTrue(v1alpha1.ConditionTypeHelmChartApplied, v1alpha1.ConditionReasonChartApplied, "Chart applied")
+	return &systemState{
+		instance: *instance,
+		chartConfig: &chart.Config{
+			Cache: fixManifestCache("\t"),
+			CacheKey: types.NamespacedName{
+				Name:      testInstalledDockerRegistry.GetName(),
+				Namespace: testInstalledDockerRegistry.GetNamespace(),
+			},
+		},
+		flagsBuilder: flags.NewBuilder(),
+	}
+}
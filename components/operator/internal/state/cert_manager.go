@@ -0,0 +1,136 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
+	"github.com/kyma-project/docker-registry/components/operator/internal/registry"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+var certificateGVK = schema.GroupVersionKind{
+	Group:   "cert-manager.io",
+	Version: "v1",
+	Kind:    "Certificate",
+}
+
+// create or update cert-manager Certificate and wait until the certificate is issued
+func sFnCertManagerConfiguration(ctx context.Context, r *reconciler, s *systemState) (stateFn, *ctrl.Result, error) {
+	certificate, err := applyCertificate(ctx, r, s)
+	if err != nil {
+		s.setState(v1alpha1.StateError)
+		s.instance.UpdateConditionFalse(
+			v1alpha1.ConditionTypeTLSReady,
+			v1alpha1.ConditionReasonCertificateErr,
+			err,
+		)
+		return stopWithEventualError(err)
+	}
+
+	if !isCertificateReady(certificate) {
+		// don't install the registry until it can be served with the issued certificate
+		s.setState(v1alpha1.StateProcessing)
+		s.instance.UpdateConditionUnknown(
+			v1alpha1.ConditionTypeTLSReady,
+			v1alpha1.ConditionReasonCertificatePending,
+			"Waiting for cert-manager to issue the certificate",
+		)
+		return requeueAfter(requeueDuration)
+	}
+
+	tlsSecret, err := registry.GetSecret(ctx, r.client, v1alpha1.CertManagerSecretName, s.instance.Namespace)
+	if err != nil {
+		err = errors.Wrap(err, fmt.Sprintf("while fetching issued tls secret from %s", s.instance.Namespace))
+		s.setState(v1alpha1.StateError)
+		s.instance.UpdateConditionFalse(
+			v1alpha1.ConditionTypeTLSReady,
+			v1alpha1.ConditionReasonCertificateErr,
+			err,
+		)
+		return stopWithEventualError(err)
+	}
+
+	s.flagsBuilder.WithTLS(v1alpha1.CertManagerSecretName, secretChecksum(tlsSecret, corev1.TLSCertKey, corev1.TLSPrivateKeyKey))
+	s.instance.UpdateConditionTrue(
+		v1alpha1.ConditionTypeTLSReady,
+		v1alpha1.ConditionReasonCertificateIssued,
+		"Certificate issued",
+	)
+	return nextState(sFnStorageConfiguration)
+}
+
+func applyCertificate(ctx context.Context, r *reconciler, s *systemState) (*unstructured.Unstructured, error) {
+	certManager := s.instance.Spec.TLS.CertManager
+
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(certificateGVK)
+	certificate.SetName(v1alpha1.CertManagerCertificateName)
+	certificate.SetNamespace(s.instance.Namespace)
+
+	err := newResourceClient(r, s).UpsertWithReference(ctx, &s.instance, certificate, func() error {
+		spec := map[string]interface{}{
+			"secretName": v1alpha1.CertManagerSecretName,
+			"duration":   certificateDuration(certManager).String(),
+			"dnsNames":   certificateDNSNames(s.instance.Namespace),
+			"issuerRef":  certificateIssuerRef(certManager.IssuerRef),
+		}
+		return unstructured.SetNestedMap(certificate.Object, spec, "spec")
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "while applying cert-manager certificate")
+	}
+
+	return certificate, nil
+}
+
+func certificateDuration(certManager *v1alpha1.TLSCertManager) time.Duration {
+	if certManager.Duration == nil {
+		return v1alpha1.DefaultCertificateDuration
+	}
+	return certManager.Duration.Duration
+}
+
+// certificateDNSNames returns names under which the registry service is reachable inside the cluster
+func certificateDNSNames(namespace string) []interface{} {
+	return []interface{}{
+		flags.FullnameOverride,
+		fmt.Sprintf("%s.%s", flags.FullnameOverride, namespace),
+		fmt.Sprintf("%s.%s.svc", flags.FullnameOverride, namespace),
+		fmt.Sprintf("%s.%s.svc.cluster.local", flags.FullnameOverride, namespace),
+		// kubelet pulls images through the node port on localhost
+		"localhost",
+	}
+}
+
+func certificateIssuerRef(issuerRef v1alpha1.CertManagerIssuerRef) map[string]interface{} {
+	ref := map[string]interface{}{
+		"name":  issuerRef.Name,
+		"kind":  "Issuer",
+		"group": certificateGVK.Group,
+	}
+	if issuerRef.Kind != "" {
+		ref["kind"] = issuerRef.Kind
+	}
+	if issuerRef.Group != "" {
+		ref["group"] = issuerRef.Group
+	}
+	return ref
+}
+
+func isCertificateReady(certificate *unstructured.Unstructured) bool {
+	conditions, _, _ := unstructured.NestedSlice(certificate.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if ok && condition["type"] == "Ready" && condition["status"] == "True" {
+			return true
+		}
+	}
+	return false
+}
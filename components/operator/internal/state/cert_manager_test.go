@@ -0,0 +1,172 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
+	"github.com/kyma-project/docker-registry/components/operator/internal/warning"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_sFnCertManagerConfiguration(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+	require.NoError(t, v1alpha1.AddToScheme(testScheme))
+
+	t.Run("create certificate and wait until it is issued", func(t *testing.T) {
+		s := &systemState{
+			instance:       fixCertManagerDockerRegistry(),
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		c := fake.NewClientBuilder().WithScheme(testScheme).Build()
+		r := &reconciler{
+			k8s: k8s{client: c},
+			log: zap.NewNop().Sugar(),
+		}
+
+		next, result, err := sFnCertManagerConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, next)
+		require.Equal(t, &ctrl.Result{RequeueAfter: requeueDuration}, result)
+		require.Equal(t, v1alpha1.StateProcessing, s.instance.Status.State)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeTLSReady,
+			metav1.ConditionUnknown,
+			v1alpha1.ConditionReasonCertificatePending,
+			"Waiting for cert-manager to issue the certificate",
+		)
+
+		certificate := fixEmptyCertificate()
+		require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(certificate), certificate))
+		spec, _, _ := unstructured.NestedMap(certificate.Object, "spec")
+		require.Equal(t, map[string]interface{}{
+			"secretName": "dockerregistry-tls",
+			"duration":   "1h0m0s",
+			"dnsNames": []interface{}{
+				"dockerregistry",
+				"dockerregistry.kyma-system",
+				"dockerregistry.kyma-system.svc",
+				"dockerregistry.kyma-system.svc.cluster.local",
+				"localhost",
+			},
+			"issuerRef": map[string]interface{}{
+				"name":  "ca-issuer",
+				"kind":  "ClusterIssuer",
+				"group": "cert-manager.io",
+			},
+		}, spec)
+		require.Len(t, certificate.GetOwnerReferences(), 1)
+		require.Equal(t, "test", certificate.GetOwnerReferences()[0].Name)
+	})
+
+	t.Run("add common metadata to certificate", func(t *testing.T) {
+		instance := fixCertManagerDockerRegistry()
+		instance.Spec.CommonLabels = map[string]string{"team": "platform"}
+		instance.Spec.CommonAnnotations = map[string]string{"example.com/owner": "platform"}
+		s := &systemState{
+			instance:       instance,
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		c := fake.NewClientBuilder().WithScheme(testScheme).Build()
+		r := &reconciler{
+			k8s: k8s{client: c},
+			log: zap.NewNop().Sugar(),
+		}
+
+		_, _, err := sFnCertManagerConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+
+		certificate := fixEmptyCertificate()
+		require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(certificate), certificate))
+		require.Equal(t, map[string]string{"team": "platform"}, certificate.GetLabels())
+		require.Equal(t, map[string]string{"example.com/owner": "platform"}, certificate.GetAnnotations())
+	})
+
+	t.Run("mount issued certificate", func(t *testing.T) {
+		s := &systemState{
+			instance:       fixCertManagerDockerRegistry(),
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		certificate := fixEmptyCertificate()
+		require.NoError(t, unstructured.SetNestedSlice(certificate.Object, []interface{}{
+			map[string]interface{}{"type": "Ready", "status": "True"},
+		}, "status", "conditions"))
+		issuedSecret := fixTLSSecret("cert", "key")
+		issuedSecret.Name = v1alpha1.CertManagerSecretName
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(certificate, issuedSecret).Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		next, result, err := sFnCertManagerConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnStorageConfiguration, next)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeTLSReady,
+			metav1.ConditionTrue,
+			v1alpha1.ConditionReasonCertificateIssued,
+			"Certificate issued",
+		)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Equal(t, "dockerregistry-tls", flags["tlsSecretName"])
+	})
+
+	t.Run("tls configuration redirects to cert-manager", func(t *testing.T) {
+		s := &systemState{
+			instance:       fixCertManagerDockerRegistry(),
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+
+		next, result, err := sFnTLSConfiguration(context.Background(), nil, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnCertManagerConfiguration, next)
+	})
+}
+
+func fixCertManagerDockerRegistry() v1alpha1.DockerRegistry {
+	return v1alpha1.DockerRegistry{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "kyma-system",
+			UID:       "test-uid",
+		},
+		Spec: v1alpha1.DockerRegistrySpec{
+			TLS: &v1alpha1.TLS{
+				CertManager: &v1alpha1.TLSCertManager{
+					IssuerRef: v1alpha1.CertManagerIssuerRef{
+						Name: "ca-issuer",
+						Kind: "ClusterIssuer",
+					},
+					Duration: &metav1.Duration{Duration: time.Hour},
+				},
+			},
+		},
+	}
+}
+
+func fixEmptyCertificate() *unstructured.Unstructured {
+	certificate := &unstructured.Unstructured{}
+	certificate.SetGroupVersionKind(certificateGVK)
+	certificate.SetName(v1alpha1.CertManagerCertificateName)
+	certificate.SetNamespace("kyma-system")
+	return certificate
+}
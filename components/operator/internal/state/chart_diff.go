@@ -0,0 +1,58 @@
+package state
+
+import (
+	"context"
+
+	"github.com/kyma-project/docker-registry/components/operator/internal/helmdiff"
+	"github.com/kyma-project/manager-toolkit/installation/chart/action"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// maxChartDiffEventSize keeps the event message readable in kubectl describe
+const maxChartDiffEventSize = 4096
+
+// newChartDiff returns the diff against the cached manifest, without it only removed fields and resources are not detected
+func newChartDiff(ctx context.Context, s *systemState) *helmdiff.Diff {
+	previousManifest := ""
+	if cached, err := s.chartConfig.Cache.Get(ctx, s.chartConfig.CacheKey); err == nil {
+		previousManifest = cached.Manifest
+	}
+
+	diff, err := helmdiff.New(previousManifest)
+	if err != nil {
+		// the broken manifest fails the apply anyway
+		diff, _ = helmdiff.New("")
+	}
+	return diff
+}
+
+// diffPreApplyAction compares the rendered resource with the live one, the diff is informational so it never fails the apply
+func diffPreApplyAction(ctx context.Context, r *reconciler, diff *helmdiff.Diff) action.PreApply {
+	return func(u *unstructured.Unstructured) error {
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(u.GroupVersionKind())
+		err := r.client.Get(ctx, client.ObjectKeyFromObject(u), current)
+		if k8serrors.IsNotFound(err) {
+			current = nil
+		} else if err != nil {
+			r.log.Debugf("skipping diff of %s %s: %s", u.GetKind(), client.ObjectKeyFromObject(u), err)
+			return nil
+		}
+
+		if err := diff.Add(u, current); err != nil {
+			r.log.Debugf("skipping diff of %s %s: %s", u.GetKind(), client.ObjectKeyFromObject(u), err)
+		}
+		return nil
+	}
+}
+
+// emitChartDiff emits changes of the applied chart so admins see them without reading the operator logs
+func emitChartDiff(r *reconciler, s *systemState, diff *helmdiff.Diff) {
+	changes := diff.String()
+	if changes == "" {
+		return
+	}
+	r.Event(&s.instance, "Normal", "ChartChanged", helmdiff.Truncate(changes, maxChartDiffEventSize))
+}
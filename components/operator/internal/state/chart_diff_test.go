@@ -0,0 +1,134 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/helmdiff"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func Test_diffPreApplyAction(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+
+	t.Run("diff changed resource", func(t *testing.T) {
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+				fixRolloutDeployment("registry:2.8.2"),
+			).Build()},
+		}
+		diff, err := helmdiff.New("")
+		require.NoError(t, err)
+
+		require.NoError(t, diffPreApplyAction(context.Background(), r, diff)(fixRolloutUnstructuredDeployment(t, "registry:3.0.0")))
+		require.Contains(t, diff.String(), "~ Deployment kyma-system/dockerregistry")
+		require.Contains(t, diff.String(), "image: registry:3.0.0")
+	})
+
+	t.Run("skip unchanged resource", func(t *testing.T) {
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+				fixRolloutDeployment("registry:2.8.2"),
+			).Build()},
+		}
+		diff, err := helmdiff.New("")
+		require.NoError(t, err)
+
+		require.NoError(t, diffPreApplyAction(context.Background(), r, diff)(fixRolloutUnstructuredDeployment(t, "registry:2.8.2")))
+		require.Empty(t, diff.String())
+	})
+
+	t.Run("diff created resource", func(t *testing.T) {
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).Build()},
+		}
+		diff, err := helmdiff.New("")
+		require.NoError(t, err)
+
+		require.NoError(t, diffPreApplyAction(context.Background(), r, diff)(fixRolloutUnstructuredDeployment(t, "registry:3.0.0")))
+		require.Equal(t, "+ Deployment kyma-system/dockerregistry", diff.String())
+	})
+
+	t.Run("skip resource failed to get", func(t *testing.T) {
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithInterceptorFuncs(interceptor.Funcs{
+				Get: func(context.Context, client.WithWatch, client.ObjectKey, client.Object, ...client.GetOption) error {
+					return errors.New("test error")
+				},
+			}).Build()},
+		}
+		diff, err := helmdiff.New("")
+		require.NoError(t, err)
+
+		require.NoError(t, diffPreApplyAction(context.Background(), r, diff)(fixRolloutUnstructuredDeployment(t, "registry:3.0.0")))
+		require.Empty(t, diff.String())
+	})
+}
+
+func Test_emitChartDiff(t *testing.T) {
+	t.Run("emit changes", func(t *testing.T) {
+		eventRecorder := record.NewFakeRecorder(5)
+		r := &reconciler{k8s: k8s{EventRecorder: eventRecorder}}
+		diff, err := helmdiff.New("")
+		require.NoError(t, err)
+		require.NoError(t, diff.Add(fixRolloutUnstructuredDeployment(t, "registry:3.0.0"), nil))
+
+		emitChartDiff(r, &systemState{instance: v1alpha1.DockerRegistry{}}, diff)
+		require.Equal(t, "Normal ChartChanged + Deployment kyma-system/dockerregistry", <-eventRecorder.Events)
+	})
+
+	t.Run("don't publish secret values", func(t *testing.T) {
+		eventRecorder := record.NewFakeRecorder(5)
+		r := &reconciler{k8s: k8s{EventRecorder: eventRecorder}}
+		diff, err := helmdiff.New("")
+		require.NoError(t, err)
+		rendered := fixChartDiffSecret("bmV3LXBhc3N3b3Jk")
+		require.NoError(t, diff.Add(rendered, fixChartDiffSecret("b2xkLXBhc3N3b3Jk")))
+
+		emitChartDiff(r, &systemState{instance: v1alpha1.DockerRegistry{}}, diff)
+		event := <-eventRecorder.Events
+		require.Equal(t, "Normal ChartChanged ~ Secret kyma-system/dockerregistry-config\n    changed keys: password", event)
+		require.NotContains(t, event, "bmV3LXBhc3N3b3Jk")
+		require.NotContains(t, event, "b2xkLXBhc3N3b3Jk")
+	})
+
+	t.Run("don't emit empty diff", func(t *testing.T) {
+		eventRecorder := record.NewFakeRecorder(5)
+		r := &reconciler{k8s: k8s{EventRecorder: eventRecorder}}
+		diff, err := helmdiff.New("")
+		require.NoError(t, err)
+
+		emitChartDiff(r, &systemState{instance: v1alpha1.DockerRegistry{}}, diff)
+		require.Empty(t, eventRecorder.Events)
+	})
+}
+
+func fixChartDiffSecret(password string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]interface{}{
+			"name":      "dockerregistry-config",
+			"namespace": "kyma-system",
+		},
+		"data": map[string]interface{}{
+			"username": "dXNlcg==",
+			"password": password,
+		},
+	}}
+}
@@ -0,0 +1,38 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+)
+
+// startChartUpgrade marks the CR whose resources were applied from an older chart,
+// the status keeps the applied version until the new chart is applied successfully
+func startChartUpgrade(r *reconciler, s *systemState) {
+	appliedVersion := s.instance.Status.ChartVersion
+	if appliedVersion == "" || appliedVersion == r.chartVersion {
+		return
+	}
+
+	// the failed upgrade is retried without emitting the event again
+	if !s.instance.IsConditionTrue(v1alpha1.ConditionTypeChartUpgradePending) {
+		r.Event(&s.instance, "Normal", "ChartUpgradeStarted",
+			fmt.Sprintf("Upgrading chart from %s to %s", appliedVersion, r.chartVersion))
+	}
+	s.instance.UpdateConditionTrue(
+		v1alpha1.ConditionTypeChartUpgradePending,
+		v1alpha1.ConditionReasonNewChartVersion,
+		fmt.Sprintf("Chart %s not applied yet, applied chart version is %s", r.chartVersion, appliedVersion),
+	)
+}
+
+// completeChartUpgrade records the version of the successfully applied chart
+func completeChartUpgrade(r *reconciler, s *systemState) {
+	s.instance.Status.ChartVersion = r.chartVersion
+	if !s.instance.IsCondition(v1alpha1.ConditionTypeChartUpgradePending) {
+		return
+	}
+
+	s.instance.RemoveCondition(v1alpha1.ConditionTypeChartUpgradePending)
+	r.Event(&s.instance, "Normal", "ChartUpgradeComplete", fmt.Sprintf("Chart upgraded to %s", r.chartVersion))
+}
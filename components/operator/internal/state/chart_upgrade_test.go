@@ -0,0 +1,99 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func Test_startChartUpgrade(t *testing.T) {
+	t.Run("mark chart upgrade", func(t *testing.T) {
+		eventRecorder := record.NewFakeRecorder(5)
+		r := &reconciler{
+			cfg: cfg{chartVersion: "1.10.0"},
+			k8s: k8s{EventRecorder: eventRecorder},
+		}
+		s := &systemState{instance: v1alpha1.DockerRegistry{
+			Status: v1alpha1.DockerRegistryStatus{ChartVersion: "1.9.1"},
+		}}
+
+		startChartUpgrade(r, s)
+		require.Equal(t, "Normal ChartUpgradeStarted Upgrading chart from 1.9.1 to 1.10.0", <-eventRecorder.Events)
+		require.Equal(t, "1.9.1", s.instance.Status.ChartVersion)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeChartUpgradePending,
+			metav1.ConditionTrue,
+			v1alpha1.ConditionReasonNewChartVersion,
+			"Chart 1.10.0 not applied yet, applied chart version is 1.9.1",
+		)
+	})
+
+	t.Run("don't emit event again when upgrade is retried", func(t *testing.T) {
+		eventRecorder := record.NewFakeRecorder(5)
+		r := &reconciler{
+			cfg: cfg{chartVersion: "1.10.0"},
+			k8s: k8s{EventRecorder: eventRecorder},
+		}
+		s := &systemState{instance: v1alpha1.DockerRegistry{
+			Status: v1alpha1.DockerRegistryStatus{ChartVersion: "1.9.1"},
+		}}
+		s.instance.UpdateConditionTrue(v1alpha1.ConditionTypeChartUpgradePending, v1alpha1.ConditionReasonNewChartVersion, "")
+
+		startChartUpgrade(r, s)
+		require.Empty(t, eventRecorder.Events)
+		require.True(t, s.instance.IsConditionTrue(v1alpha1.ConditionTypeChartUpgradePending))
+	})
+
+	t.Run("skip current chart version", func(t *testing.T) {
+		r := &reconciler{cfg: cfg{chartVersion: "1.10.0"}}
+		s := &systemState{instance: v1alpha1.DockerRegistry{
+			Status: v1alpha1.DockerRegistryStatus{ChartVersion: "1.10.0"},
+		}}
+
+		startChartUpgrade(r, s)
+		require.False(t, s.instance.IsCondition(v1alpha1.ConditionTypeChartUpgradePending))
+	})
+
+	t.Run("skip first installation", func(t *testing.T) {
+		r := &reconciler{cfg: cfg{chartVersion: "1.10.0"}}
+		s := &systemState{}
+
+		startChartUpgrade(r, s)
+		require.False(t, s.instance.IsCondition(v1alpha1.ConditionTypeChartUpgradePending))
+	})
+}
+
+func Test_completeChartUpgrade(t *testing.T) {
+	t.Run("complete chart upgrade", func(t *testing.T) {
+		eventRecorder := record.NewFakeRecorder(5)
+		r := &reconciler{
+			cfg: cfg{chartVersion: "1.10.0"},
+			k8s: k8s{EventRecorder: eventRecorder},
+		}
+		s := &systemState{instance: v1alpha1.DockerRegistry{
+			Status: v1alpha1.DockerRegistryStatus{ChartVersion: "1.9.1"},
+		}}
+		s.instance.UpdateConditionTrue(v1alpha1.ConditionTypeChartUpgradePending, v1alpha1.ConditionReasonNewChartVersion, "")
+
+		completeChartUpgrade(r, s)
+		require.Equal(t, "Normal ChartUpgradeComplete Chart upgraded to 1.10.0", <-eventRecorder.Events)
+		require.Equal(t, "1.10.0", s.instance.Status.ChartVersion)
+		require.False(t, s.instance.IsCondition(v1alpha1.ConditionTypeChartUpgradePending))
+	})
+
+	t.Run("set chart version of first installation", func(t *testing.T) {
+		eventRecorder := record.NewFakeRecorder(5)
+		r := &reconciler{
+			cfg: cfg{chartVersion: "1.10.0"},
+			k8s: k8s{EventRecorder: eventRecorder},
+		}
+		s := &systemState{}
+
+		completeChartUpgrade(r, s)
+		require.Empty(t, eventRecorder.Events)
+		require.Equal(t, "1.10.0", s.instance.Status.ChartVersion)
+	})
+}
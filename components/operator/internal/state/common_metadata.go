@@ -0,0 +1,25 @@
+package state
+
+import (
+	internalresource "github.com/kyma-project/docker-registry/components/operator/internal/resource"
+	"github.com/kyma-project/manager-toolkit/installation/chart/action"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// newResourceClient returns the client of the resources managed outside of the chart
+// labeled and annotated with the common metadata from the DockerRegistry CR
+func newResourceClient(r *reconciler, s *systemState) internalresource.Client {
+	return internalresource.New(r.client, r.client.Scheme(), internalresource.WithCommonMetadata(
+		s.instance.Spec.CommonLabels,
+		s.instance.Spec.CommonAnnotations,
+	))
+}
+
+// commonMetadataPreApplyAction adds the common metadata to the top-level metadata of the chart resources only,
+// changing the pod template would roll out the registry on every change of the common metadata
+func commonMetadataPreApplyAction(s *systemState) action.PreApply {
+	return func(u *unstructured.Unstructured) error {
+		internalresource.SetCommonMetadata(u, s.instance.Spec.CommonLabels, s.instance.Spec.CommonAnnotations)
+		return nil
+	}
+}
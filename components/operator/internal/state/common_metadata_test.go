@@ -0,0 +1,82 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_commonMetadataPreApplyAction(t *testing.T) {
+	t.Run("add common metadata to top-level metadata only", func(t *testing.T) {
+		s := fixPDBSystemState(v1alpha1.DockerRegistrySpec{
+			CommonLabels:      map[string]string{"cost-center": "1234", "app": "other"},
+			CommonAnnotations: map[string]string{"example.com/owner": "team-a"},
+		})
+		u := &unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": map[string]interface{}{"app": "docker-registry"},
+			},
+			"spec": map[string]interface{}{
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"labels": map[string]interface{}{"app": "docker-registry"},
+					},
+				},
+			},
+		}}
+
+		require.NoError(t, commonMetadataPreApplyAction(s)(u))
+
+		require.Equal(t, map[string]string{"app": "docker-registry", "cost-center": "1234"}, u.GetLabels())
+		require.Equal(t, map[string]string{"example.com/owner": "team-a"}, u.GetAnnotations())
+		templateLabels, _, err := unstructured.NestedStringMap(u.Object, "spec", "template", "metadata", "labels")
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"app": "docker-registry"}, templateLabels)
+	})
+
+	t.Run("keep metadata without common metadata", func(t *testing.T) {
+		s := fixPDBSystemState(v1alpha1.DockerRegistrySpec{})
+		u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+
+		require.NoError(t, commonMetadataPreApplyAction(s)(u))
+
+		require.Nil(t, u.GetLabels())
+		require.Nil(t, u.GetAnnotations())
+	})
+}
+
+func Test_newResourceClient(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+	require.NoError(t, v1alpha1.AddToScheme(testScheme))
+
+	t.Run("label managed resources with common metadata", func(t *testing.T) {
+		s := fixPDBSystemState(v1alpha1.DockerRegistrySpec{
+			NetworkPolicy:     &v1alpha1.NetworkPolicy{Enabled: true},
+			CommonLabels:      map[string]string{"cost-center": "1234"},
+			CommonAnnotations: map[string]string{"example.com/owner": "team-a"},
+		})
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).Build()},
+		}
+
+		_, _, err := sFnNetworkPolicy(context.Background(), r, s)
+		require.NoError(t, err)
+
+		networkPolicy := &networkingv1.NetworkPolicy{}
+		require.NoError(t, r.client.Get(context.Background(),
+			client.ObjectKey{Name: networkPolicyName, Namespace: "kyma-system"}, networkPolicy))
+		require.Equal(t, "1234", networkPolicy.Labels["cost-center"])
+		require.Equal(t, "team-a", networkPolicy.Annotations["example.com/owner"])
+	})
+}
@@ -0,0 +1,136 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/registry"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// phaseConditions are the conditions describing the reconciliation phases summarized by the Ready condition
+var phaseConditions = []v1alpha1.ConditionType{
+	v1alpha1.ConditionTypeHelmChartApplied,
+	v1alpha1.ConditionTypeSecretsReady,
+	v1alpha1.ConditionTypeDeploymentReady,
+	v1alpha1.ConditionTypeNetworkingReady,
+}
+
+func updateSecretsCondition(ctx context.Context, r *reconciler, s *systemState) {
+	secretNames := []string{registry.InternalAccessSecretName}
+	if isExternalAccessEnabled(s.instance.Spec) {
+		secretNames = append(secretNames, registry.ExternalAccessSecretName)
+	}
+
+	missing := []string{}
+	for _, name := range secretNames {
+		if _, err := registry.GetSecret(ctx, r.client, name, s.instance.Namespace); err != nil {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(missing) != 0 {
+		err := fmt.Errorf("registry access secrets not found: %s", strings.Join(missing, ", "))
+		s.warningBuilder.With(err.Error())
+		s.instance.UpdateConditionFalse(
+			v1alpha1.ConditionTypeSecretsReady,
+			v1alpha1.ConditionReasonSecretsMissing,
+			err,
+		)
+		return
+	}
+
+	s.instance.UpdateConditionTrue(
+		v1alpha1.ConditionTypeSecretsReady,
+		v1alpha1.ConditionReasonSecretsCreated,
+		"Registry access secrets created",
+	)
+}
+
+func updateNetworkingCondition(ctx context.Context, r *reconciler, s *systemState) {
+	if !isExternalAccessEnabled(s.instance.Spec) {
+		s.instance.UpdateConditionTrue(
+			v1alpha1.ConditionTypeNetworkingReady,
+			v1alpha1.ConditionReasonNetworkingConfigured,
+			"External access disabled",
+		)
+		return
+	}
+
+	resolvedAccess, err := s.gatewayHostResolver.Do(ctx, r.client, *s.instance.Spec.ExternalAccess)
+	if err != nil {
+		s.instance.UpdateConditionFalse(
+			v1alpha1.ConditionTypeNetworkingReady,
+			v1alpha1.ConditionReasonNetworkingErr,
+			errors.Wrap(err, "while resolving external access"),
+		)
+		return
+	}
+
+	s.instance.UpdateConditionTrue(
+		v1alpha1.ConditionTypeNetworkingReady,
+		v1alpha1.ConditionReasonNetworkingConfigured,
+		fmt.Sprintf("Registry exposed on %s", resolvedAccess.Host),
+	)
+}
+
+// updateReadyCondition sets the Ready condition to true only when all phase conditions are true
+func updateReadyCondition(s *systemState) {
+	setReadyCondition(&s.instance)
+}
+
+// UpdateDeploymentCondition sets the DeploymentReady condition based on the available replicas of the registry deployment
+// and recalculates the Ready condition
+func UpdateDeploymentCondition(instance *v1alpha1.DockerRegistry, deployment *appsv1.Deployment) {
+	desiredReplicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		desiredReplicas = *deployment.Spec.Replicas
+	}
+
+	if deployment.Status.AvailableReplicas >= desiredReplicas {
+		instance.UpdateConditionTrue(
+			v1alpha1.ConditionTypeDeploymentReady,
+			v1alpha1.ConditionReasonDeploymentAvailable,
+			"Registry deployment available",
+		)
+	} else {
+		instance.UpdateConditionUnknown(
+			v1alpha1.ConditionTypeDeploymentReady,
+			v1alpha1.ConditionReasonDeploymentProgressing,
+			"Waiting for the registry deployment rollout",
+		)
+	}
+
+	setReadyCondition(instance)
+}
+
+func setReadyCondition(instance *v1alpha1.DockerRegistry) {
+	notReady := []string{}
+	for _, conditionType := range phaseConditions {
+		if !instance.IsConditionTrue(conditionType) {
+			notReady = append(notReady, string(conditionType))
+		}
+	}
+
+	if len(notReady) != 0 {
+		instance.UpdateConditionFalse(
+			v1alpha1.ConditionTypeReady,
+			v1alpha1.ConditionReasonNotReady,
+			fmt.Errorf("conditions not ready: %s", strings.Join(notReady, ", ")),
+		)
+		return
+	}
+
+	instance.UpdateConditionTrue(
+		v1alpha1.ConditionTypeReady,
+		v1alpha1.ConditionReasonReady,
+		"All reconciliation phases succeeded",
+	)
+}
+
+func isExternalAccessEnabled(spec v1alpha1.DockerRegistrySpec) bool {
+	return spec.ExternalAccess != nil && spec.ExternalAccess.Enabled != nil && *spec.ExternalAccess.Enabled
+}
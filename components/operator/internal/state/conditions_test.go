@@ -0,0 +1,210 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/registry"
+	"github.com/kyma-project/docker-registry/components/operator/internal/warning"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_updateSecretsCondition(t *testing.T) {
+	t.Run("secrets created", func(t *testing.T) {
+		s := &systemState{instance: fixConditionsDockerRegistry(false)}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().WithObjects(fixAccessSecret("kyma-system", registry.InternalAccessSecretName)).Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		updateSecretsCondition(context.Background(), r, s)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeSecretsReady,
+			metav1.ConditionTrue,
+			v1alpha1.ConditionReasonSecretsCreated,
+			"Registry access secrets created",
+		)
+	})
+
+	t.Run("external access secret missing", func(t *testing.T) {
+		s := &systemState{
+			instance:       fixConditionsDockerRegistry(true),
+			warningBuilder: warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().WithObjects(fixAccessSecret("kyma-system", registry.InternalAccessSecretName)).Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		updateSecretsCondition(context.Background(), r, s)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeSecretsReady,
+			metav1.ConditionFalse,
+			v1alpha1.ConditionReasonSecretsMissing,
+			"registry access secrets not found: dockerregistry-config-external",
+		)
+		require.Equal(t, "Warning: registry access secrets not found: dockerregistry-config-external", s.warningBuilder.Build())
+	})
+}
+
+func Test_updateNetworkingCondition(t *testing.T) {
+	t.Run("external access disabled", func(t *testing.T) {
+		s := &systemState{instance: fixConditionsDockerRegistry(false)}
+
+		updateNetworkingCondition(context.Background(), &reconciler{}, s)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeNetworkingReady,
+			metav1.ConditionTrue,
+			v1alpha1.ConditionReasonNetworkingConfigured,
+			"External access disabled",
+		)
+	})
+
+	t.Run("external access resolved", func(t *testing.T) {
+		s := &systemState{
+			instance: fixConditionsDockerRegistry(true),
+			gatewayHostResolver: &testExternalAddressResolver{expectedAccess: &registry.ResolvedAccess{
+				Host: "registry.cluster.local",
+			}},
+		}
+
+		updateNetworkingCondition(context.Background(), &reconciler{}, s)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeNetworkingReady,
+			metav1.ConditionTrue,
+			v1alpha1.ConditionReasonNetworkingConfigured,
+			"Registry exposed on registry.cluster.local",
+		)
+	})
+
+	t.Run("gateway not operational", func(t *testing.T) {
+		s := &systemState{
+			instance:            fixConditionsDockerRegistry(true),
+			gatewayHostResolver: &testExternalAddressResolver{expectedError: errors.New("test-error")},
+		}
+
+		updateNetworkingCondition(context.Background(), &reconciler{}, s)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeNetworkingReady,
+			metav1.ConditionFalse,
+			v1alpha1.ConditionReasonNetworkingErr,
+			"while resolving external access: test-error",
+		)
+	})
+}
+
+func Test_updateReadyCondition(t *testing.T) {
+	t.Run("all phases ready", func(t *testing.T) {
+		s := &systemState{instance: fixConditionsDockerRegistry(false)}
+		for _, conditionType := range phaseConditions {
+			s.instance.UpdateConditionTrue(conditionType, v1alpha1.ConditionReasonReady, "")
+		}
+
+		updateReadyCondition(s)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeReady,
+			metav1.ConditionTrue,
+			v1alpha1.ConditionReasonReady,
+			"All reconciliation phases succeeded",
+		)
+	})
+
+	t.Run("some phases not ready", func(t *testing.T) {
+		s := &systemState{instance: fixConditionsDockerRegistry(false)}
+		s.instance.UpdateConditionTrue(v1alpha1.ConditionTypeHelmChartApplied, v1alpha1.ConditionReasonChartApplied, "")
+		s.instance.UpdateConditionUnknown(v1alpha1.ConditionTypeDeploymentReady, v1alpha1.ConditionReasonDeploymentProgressing, "")
+
+		updateReadyCondition(s)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeReady,
+			metav1.ConditionFalse,
+			v1alpha1.ConditionReasonNotReady,
+			"conditions not ready: SecretsReady, DeploymentReady, NetworkingReady",
+		)
+	})
+}
+
+func TestUpdateDeploymentCondition(t *testing.T) {
+	t.Run("deployment available", func(t *testing.T) {
+		instance := fixConditionsDockerRegistry(false)
+		for _, conditionType := range phaseConditions {
+			instance.UpdateConditionTrue(conditionType, v1alpha1.ConditionReasonReady, "")
+		}
+
+		UpdateDeploymentCondition(&instance, fixRegistryDeployment(2, 2))
+		requireContainsCondition(t, instance.Status,
+			v1alpha1.ConditionTypeDeploymentReady,
+			metav1.ConditionTrue,
+			v1alpha1.ConditionReasonDeploymentAvailable,
+			"Registry deployment available",
+		)
+		requireContainsCondition(t, instance.Status,
+			v1alpha1.ConditionTypeReady,
+			metav1.ConditionTrue,
+			v1alpha1.ConditionReasonReady,
+			"All reconciliation phases succeeded",
+		)
+	})
+
+	t.Run("deployment rollout in progress", func(t *testing.T) {
+		instance := fixConditionsDockerRegistry(false)
+		for _, conditionType := range phaseConditions {
+			instance.UpdateConditionTrue(conditionType, v1alpha1.ConditionReasonReady, "")
+		}
+
+		UpdateDeploymentCondition(&instance, fixRegistryDeployment(2, 1))
+		requireContainsCondition(t, instance.Status,
+			v1alpha1.ConditionTypeDeploymentReady,
+			metav1.ConditionUnknown,
+			v1alpha1.ConditionReasonDeploymentProgressing,
+			"Waiting for the registry deployment rollout",
+		)
+		requireContainsCondition(t, instance.Status,
+			v1alpha1.ConditionTypeReady,
+			metav1.ConditionFalse,
+			v1alpha1.ConditionReasonNotReady,
+			"conditions not ready: DeploymentReady",
+		)
+	})
+}
+
+func fixRegistryDeployment(replicas, availableReplicas int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+		},
+		Status: appsv1.DeploymentStatus{
+			AvailableReplicas: availableReplicas,
+		},
+	}
+}
+
+func fixConditionsDockerRegistry(externalAccess bool) v1alpha1.DockerRegistry {
+	return v1alpha1.DockerRegistry{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "kyma-system",
+		},
+		Spec: v1alpha1.DockerRegistrySpec{
+			ExternalAccess: &v1alpha1.ExternalAccess{
+				Enabled: ptr.To(externalAccess),
+			},
+		},
+	}
+}
+
+func fixAccessSecret(namespace, name string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+}
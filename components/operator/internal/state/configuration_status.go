@@ -7,12 +7,49 @@ import (
 	controllerruntime "sigs.k8s.io/controller-runtime"
 )
 
-func sFnUpdateConfigurationStatus(_ context.Context, _ *reconciler, s *systemState) (stateFn, *controllerruntime.Result, error) {
+func sFnUpdateConfigurationStatus(ctx context.Context, r *reconciler, s *systemState) (stateFn, *controllerruntime.Result, error) {
+	prepareScaling(s)
+	prepareResources(s)
+	prepareProbes(s)
+	prepareLog(s)
+	prepareImage(s)
+	prepareScheduling(s)
+	prepareExtraEnvVars(s)
+	prepareExtraVolumes(s)
+	prepareSecurityContext(s)
+	prepareSidecars(s)
+	prepareNetworkPolicy(s)
+
+	err := prepareImagePullSecrets(ctx, r, s)
+	if err == nil {
+		err = preparePriorityClass(ctx, r, s)
+	}
+	if err == nil {
+		err = prepareNotifications(ctx, r, s)
+	}
+	if err == nil {
+		err = prepareReplication(ctx, r, s)
+	}
+	if err == nil {
+		// must be the last one to compare the extra config with all flags set by the operator
+		err = prepareExtraConfig(ctx, r, s)
+	}
+	if err != nil {
+		s.setState(v1alpha1.StateError)
+		s.setConfigurationFailed(v1alpha1.ConditionReasonConfigurationErr, err)
+		return stopWithEventualError(err)
+	}
+
+	// keep the condition set by the access, tls or storage configuration
+	if s.configurationFailed {
+		return nextState(sFnPreReconcileHook)
+	}
+
 	s.instance.UpdateConditionTrue(
 		v1alpha1.ConditionTypeConfigured,
 		v1alpha1.ConditionReasonConfigured,
 		"Configuration ready",
 	)
 
-	return nextState(sFnApplyResources)
+	return nextState(sFnPreReconcileHook)
 }
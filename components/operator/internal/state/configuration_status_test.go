@@ -2,9 +2,11 @@ package state
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -12,13 +14,14 @@ import (
 func Test_sFnUpdateConfigurationStatus(t *testing.T) {
 	t.Run("update condition configured", func(t *testing.T) {
 		s := &systemState{
-			instance: v1alpha1.DockerRegistry{},
+			instance:     v1alpha1.DockerRegistry{},
+			flagsBuilder: flags.NewBuilder(),
 		}
 
 		next, result, err := sFnUpdateConfigurationStatus(context.Background(), &reconciler{}, s)
 		require.NoError(t, err)
 		require.Nil(t, result)
-		requireEqualFunc(t, sFnApplyResources, next)
+		requireEqualFunc(t, sFnPreReconcileHook, next)
 
 		requireContainsCondition(t, s.instance.Status,
 			v1alpha1.ConditionTypeConfigured,
@@ -27,4 +30,24 @@ func Test_sFnUpdateConfigurationStatus(t *testing.T) {
 			"Configuration ready",
 		)
 	})
+
+	t.Run("keep condition configured set to false by earlier state", func(t *testing.T) {
+		s := &systemState{
+			instance:     v1alpha1.DockerRegistry{},
+			flagsBuilder: flags.NewBuilder(),
+		}
+		s.setConfigurationFailed(v1alpha1.ConditionReasonConfigurationErr, errors.New("test error"))
+
+		next, result, err := sFnUpdateConfigurationStatus(context.Background(), &reconciler{}, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnPreReconcileHook, next)
+
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeConfigured,
+			metav1.ConditionFalse,
+			v1alpha1.ConditionReasonConfigurationErr,
+			"test error",
+		)
+	})
 }
@@ -0,0 +1,105 @@
+package state
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	istioProxyMetricsPort = 15090
+
+	proxyCAVolumeName = "proxy-ca"
+	proxyCAMountPath  = "/etc/docker-registry/proxy-ca"
+	systemCertsDir    = "/etc/ssl/certs"
+
+	tmpVolumeName = "registry-tmp"
+	tmpMountPath  = "/tmp"
+)
+
+func prepareExtraEnvVars(s *systemState) {
+	envVars := s.instance.Spec.ExtraEnvVars
+	if s.instance.GetProxyCASecretName() != "" {
+		// keep the system certificates and read the proxy CA from the mounted secret
+		envVars = append(slices.Clone(envVars), corev1.EnvVar{
+			Name:  "SSL_CERT_DIR",
+			Value: fmt.Sprintf("%s:%s", systemCertsDir, proxyCAMountPath),
+		})
+	}
+
+	if len(envVars) != 0 {
+		s.flagsBuilder.WithExtraEnvVars(envVars)
+	}
+}
+
+func prepareExtraVolumes(s *systemState) {
+	volumes := s.instance.Spec.ExtraVolumes
+	mounts := s.instance.Spec.ExtraVolumeMounts
+	if name := s.instance.GetProxyCASecretName(); name != "" {
+		volumes = append(slices.Clone(volumes), corev1.Volume{
+			Name: proxyCAVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: name,
+					Items:      []corev1.KeyToPath{{Key: v1alpha1.ProxyCASecretKey, Path: v1alpha1.ProxyCASecretKey}},
+				},
+			},
+		})
+		mounts = append(slices.Clone(mounts), corev1.VolumeMount{
+			Name:      proxyCAVolumeName,
+			MountPath: proxyCAMountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	if needsTmpVolume(s.instance.Spec.ContainerSecurityContext, mounts) {
+		// the registry writes temporary files even with the read-only root filesystem
+		volumes = append(slices.Clone(volumes), corev1.Volume{
+			Name:         tmpVolumeName,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		})
+		mounts = append(slices.Clone(mounts), corev1.VolumeMount{
+			Name:      tmpVolumeName,
+			MountPath: tmpMountPath,
+		})
+	}
+
+	s.flagsBuilder.WithExtraVolumes(volumes, mounts)
+}
+
+// needsTmpVolume returns true if the root filesystem is read-only and /tmp is not mounted by the user
+func needsTmpVolume(securityContext *corev1.SecurityContext, mounts []corev1.VolumeMount) bool {
+	if securityContext == nil || securityContext.ReadOnlyRootFilesystem == nil || !*securityContext.ReadOnlyRootFilesystem {
+		return false
+	}
+	return !slices.ContainsFunc(mounts, func(mount corev1.VolumeMount) bool {
+		return mount.MountPath == tmpMountPath
+	})
+}
+
+func prepareSecurityContext(s *systemState) {
+	s.flagsBuilder.WithSecurityContext(s.instance.Spec.PodSecurityContext, s.instance.Spec.ContainerSecurityContext)
+}
+
+// prepareSidecars passes the sidecars to the chart and warns when they run next to the Istio proxy
+func prepareSidecars(s *systemState) {
+	sidecars := s.instance.Spec.Sidecars
+	if len(sidecars) != 0 {
+		s.flagsBuilder.WithSidecars(sidecars)
+	}
+
+	if len(sidecars) == 0 || s.instance.Spec.Istio == nil || !s.instance.Spec.Istio.Enabled {
+		s.instance.RemoveCondition(v1alpha1.ConditionTypeSidecarConflict)
+		return
+	}
+
+	msg := fmt.Sprintf("sidecars may conflict with the Istio proxy on port %d", istioProxyMetricsPort)
+	s.warningBuilder.With(msg)
+	s.instance.UpdateConditionTrue(
+		v1alpha1.ConditionTypeSidecarConflict,
+		v1alpha1.ConditionReasonIstioProxyPortConflict,
+		msg,
+	)
+}
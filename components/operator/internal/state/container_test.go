@@ -0,0 +1,248 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
+	"github.com/kyma-project/docker-registry/components/operator/internal/warning"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+)
+
+func Test_prepareExtraEnvVars(t *testing.T) {
+	t.Run("pass extra env vars to the chart", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				Spec: v1alpha1.DockerRegistrySpec{ExtraEnvVars: []corev1.EnvVar{
+					{Name: "REGISTRY_LOG_LEVEL", Value: "debug"},
+					{Name: "TOKEN", ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "token"}, Key: "token"},
+					}},
+				}},
+			},
+			flagsBuilder: flags.NewBuilder(),
+		}
+
+		prepareExtraEnvVars(s)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{
+			map[string]interface{}{"name": "REGISTRY_LOG_LEVEL", "value": "debug"},
+			map[string]interface{}{"name": "TOKEN", "valueFrom": map[string]interface{}{
+				"secretKeyRef": map[string]interface{}{"name": "token", "key": "token"},
+			}},
+		}, flags["extraEnvVars"])
+	})
+
+	t.Run("skip empty extra env vars", func(t *testing.T) {
+		s := &systemState{flagsBuilder: flags.NewBuilder()}
+
+		prepareExtraEnvVars(s)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.NotContains(t, flags, "extraEnvVars")
+	})
+}
+
+func Test_prepareSidecars(t *testing.T) {
+	sidecars := []corev1.Container{{Name: "fluent-bit", Image: "fluent/fluent-bit:3.0"}}
+
+	t.Run("pass sidecars to the chart", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				Spec: v1alpha1.DockerRegistrySpec{Sidecars: sidecars},
+			},
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+
+		prepareSidecars(s)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{
+			map[string]interface{}{"name": "fluent-bit", "image": "fluent/fluent-bit:3.0", "resources": map[string]interface{}{}},
+		}, flags["sidecars"])
+		require.Nil(t, meta.FindStatusCondition(s.instance.Status.Conditions, string(v1alpha1.ConditionTypeSidecarConflict)))
+		require.Empty(t, s.warningBuilder.Build())
+	})
+
+	t.Run("warn about conflict with the istio proxy", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				Spec: v1alpha1.DockerRegistrySpec{
+					Sidecars: sidecars,
+					Istio:    &v1alpha1.Istio{Enabled: true},
+				},
+			},
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+
+		prepareSidecars(s)
+
+		condition := meta.FindStatusCondition(s.instance.Status.Conditions, string(v1alpha1.ConditionTypeSidecarConflict))
+		require.NotNil(t, condition)
+		require.Equal(t, metav1.ConditionTrue, condition.Status)
+		require.Equal(t, string(v1alpha1.ConditionReasonIstioProxyPortConflict), condition.Reason)
+		require.Equal(t, "Warning: sidecars may conflict with the Istio proxy on port 15090", s.warningBuilder.Build())
+	})
+
+	t.Run("remove condition when sidecars are removed", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				Spec: v1alpha1.DockerRegistrySpec{Istio: &v1alpha1.Istio{Enabled: true}},
+			},
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		s.instance.UpdateConditionTrue(v1alpha1.ConditionTypeSidecarConflict, v1alpha1.ConditionReasonIstioProxyPortConflict, "conflict")
+
+		prepareSidecars(s)
+
+		require.Nil(t, meta.FindStatusCondition(s.instance.Status.Conditions, string(v1alpha1.ConditionTypeSidecarConflict)))
+	})
+}
+
+func Test_prepareExtraVolumes(t *testing.T) {
+	s := &systemState{
+		instance: v1alpha1.DockerRegistry{
+			Spec: v1alpha1.DockerRegistrySpec{
+				ExtraVolumes: []corev1.Volume{
+					{Name: "ca", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "ca"}}}},
+				},
+				ExtraVolumeMounts: []corev1.VolumeMount{{Name: "ca", MountPath: "/etc/ca", ReadOnly: true}},
+			},
+		},
+		flagsBuilder: flags.NewBuilder(),
+	}
+
+	prepareExtraVolumes(s)
+
+	flags, err := s.flagsBuilder.Build()
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"name": "ca", "configMap": map[string]interface{}{"name": "ca"}},
+	}, flags["customVolumes"])
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"name": "ca", "mountPath": "/etc/ca", "readOnly": true},
+	}, flags["customVolumeMounts"])
+}
+
+func Test_prepareTmpVolume(t *testing.T) {
+	t.Run("mount tmp volume with read-only root filesystem", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				Spec: v1alpha1.DockerRegistrySpec{
+					ContainerSecurityContext: &corev1.SecurityContext{ReadOnlyRootFilesystem: ptr.To(true)},
+				},
+			},
+			flagsBuilder: flags.NewBuilder(),
+		}
+
+		prepareExtraVolumes(s)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{
+			map[string]interface{}{"name": "registry-tmp", "emptyDir": map[string]interface{}{}},
+		}, flags["customVolumes"])
+		require.Equal(t, []interface{}{
+			map[string]interface{}{"name": "registry-tmp", "mountPath": "/tmp"},
+		}, flags["customVolumeMounts"])
+	})
+
+	t.Run("keep tmp mounted by user", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				Spec: v1alpha1.DockerRegistrySpec{
+					ContainerSecurityContext: &corev1.SecurityContext{ReadOnlyRootFilesystem: ptr.To(true)},
+					ExtraVolumes:             []corev1.Volume{{Name: "scratch", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}},
+					ExtraVolumeMounts:        []corev1.VolumeMount{{Name: "scratch", MountPath: "/tmp"}},
+				},
+			},
+			flagsBuilder: flags.NewBuilder(),
+		}
+
+		prepareExtraVolumes(s)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Len(t, flags["customVolumes"], 1)
+		require.Len(t, flags["customVolumeMounts"], 1)
+	})
+
+	t.Run("skip tmp volume with writable root filesystem", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				Spec: v1alpha1.DockerRegistrySpec{
+					ContainerSecurityContext: &corev1.SecurityContext{ReadOnlyRootFilesystem: ptr.To(false)},
+				},
+			},
+			flagsBuilder: flags.NewBuilder(),
+		}
+
+		prepareExtraVolumes(s)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.NotContains(t, flags, "customVolumes")
+	})
+}
+
+func Test_prepareSecurityContext(t *testing.T) {
+	s := &systemState{
+		instance: v1alpha1.DockerRegistry{
+			Spec: v1alpha1.DockerRegistrySpec{
+				PodSecurityContext:       &corev1.PodSecurityContext{RunAsUser: ptr.To[int64](2000)},
+				ContainerSecurityContext: &corev1.SecurityContext{RunAsNonRoot: ptr.To(true)},
+			},
+		},
+		flagsBuilder: flags.NewBuilder(),
+	}
+
+	prepareSecurityContext(s)
+
+	flags, err := s.flagsBuilder.Build()
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"securityContext": map[string]interface{}{"runAsUser": float64(2000)}}, flags["pod"])
+	require.Equal(t, map[string]interface{}{"securityContext": map[string]interface{}{"runAsNonRoot": true}}, flags["containers"])
+}
+
+func Test_prepareProxyCA(t *testing.T) {
+	s := &systemState{
+		instance: v1alpha1.DockerRegistry{
+			Spec: v1alpha1.DockerRegistrySpec{
+				Proxy:        &v1alpha1.Proxy{RemoteURL: "https://registry.internal", CASecretName: "internal-ca"},
+				ExtraEnvVars: []corev1.EnvVar{{Name: "REGISTRY_LOG_LEVEL", Value: "debug"}},
+			},
+		},
+		flagsBuilder: flags.NewBuilder(),
+	}
+
+	prepareExtraEnvVars(s)
+	prepareExtraVolumes(s)
+
+	flags, err := s.flagsBuilder.Build()
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"name": "REGISTRY_LOG_LEVEL", "value": "debug"},
+		map[string]interface{}{"name": "SSL_CERT_DIR", "value": "/etc/ssl/certs:/etc/docker-registry/proxy-ca"},
+	}, flags["extraEnvVars"])
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"name": "proxy-ca", "secret": map[string]interface{}{
+			"secretName": "internal-ca",
+			"items":      []interface{}{map[string]interface{}{"key": "ca.crt", "path": "ca.crt"}},
+		}},
+	}, flags["customVolumes"])
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"name": "proxy-ca", "mountPath": "/etc/docker-registry/proxy-ca", "readOnly": true},
+	}, flags["customVolumeMounts"])
+	require.Len(t, s.instance.Spec.ExtraEnvVars, 1)
+}
@@ -0,0 +1,60 @@
+package state
+
+import (
+	"time"
+
+	"github.com/kyma-project/docker-registry/components/operator/internal/registry"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// prepareCredentialRotation regenerates the internal registry credentials when the rotation interval elapses,
+// the previous credentials stay valid until the grace period ends
+func prepareCredentialRotation(r *reconciler, s *systemState, existing *corev1.Secret, now time.Time) error {
+	rotation := s.instance.GetCredentialRotation()
+	if rotation == nil || existing == nil {
+		// the first credentials are generated by the chart
+		return nil
+	}
+
+	rotatedAt := existing.CreationTimestamp.Time
+	if value, ok := existing.Annotations[registry.CredentialsRotatedAtAnnotation]; ok {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			r.log.Warnf("while parsing last credentials rotation time %s: %s", value, err.Error())
+		} else {
+			rotatedAt = parsed
+		}
+	}
+	previousUsername := string(existing.Data[registry.PreviousUsernameKey])
+	previousPassword := string(existing.Data[registry.PreviousPasswordKey])
+
+	nextRotation := rotatedAt.Add(rotation.Interval.Duration)
+	if !now.Before(nextRotation) {
+		username, password, err := registry.GenerateCredentials()
+		if err != nil {
+			return errors.Wrap(err, "while generating new internal registry credentials")
+		}
+
+		r.log.Info("rotating internal docker registry credentials")
+		previousUsername = string(existing.Data["username"])
+		previousPassword = string(existing.Data["password"])
+		rotatedAt = now
+		nextRotation = rotatedAt.Add(rotation.Interval.Duration)
+		s.flagsBuilder.WithRegistryCredentials(username, password)
+		s.credentialsRotated = true
+		r.Event(&s.instance, "Normal", "CredentialsRotated", "Internal registry credentials rotated")
+	}
+
+	nextCheck := nextRotation
+	gracePeriodEnd := rotatedAt.Add(rotation.GetGracePeriod())
+	if now.Before(gracePeriodEnd) {
+		nextCheck = gracePeriodEnd
+	} else {
+		previousUsername, previousPassword = "", ""
+	}
+
+	s.flagsBuilder.WithCredentialRotation(rotatedAt, previousUsername, previousPassword)
+	s.credentialRotationRequeueAfter = nextCheck.Sub(now)
+	return nil
+}
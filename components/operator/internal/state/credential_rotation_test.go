@@ -0,0 +1,123 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
+	"github.com/kyma-project/docker-registry/components/operator/internal/registry"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func Test_prepareCredentialRotation(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("skip when rotation is disabled", func(t *testing.T) {
+		s := fixCredentialRotationState(nil)
+		r := fixCredentialRotationReconciler()
+
+		err := prepareCredentialRotation(r, s, fixInternalAccessSecret(created, nil), created.Add(48*time.Hour))
+
+		require.NoError(t, err)
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Empty(t, flags)
+		require.Zero(t, s.credentialRotationRequeueAfter)
+	})
+
+	t.Run("keep credentials before the interval elapses", func(t *testing.T) {
+		s := fixCredentialRotationState(&v1alpha1.CredentialRotation{Interval: &metav1.Duration{Duration: 24 * time.Hour}})
+		r := fixCredentialRotationReconciler()
+
+		err := prepareCredentialRotation(r, s, fixInternalAccessSecret(created, nil), created.Add(time.Hour))
+
+		require.NoError(t, err)
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"rotatedAt": "2024-01-01T00:00:00Z"}, flags["dockerRegistry"])
+		require.Equal(t, "credentialsRotatedAt=2024-01-01T00:00:00Z", flags["rollme"])
+		require.Equal(t, 23*time.Hour, s.credentialRotationRequeueAfter)
+		require.False(t, s.credentialsRotated)
+		require.Empty(t, r.EventRecorder.(*record.FakeRecorder).Events)
+	})
+
+	t.Run("rotate credentials and keep the previous ones during the grace period", func(t *testing.T) {
+		s := fixCredentialRotationState(&v1alpha1.CredentialRotation{Interval: &metav1.Duration{Duration: 24 * time.Hour}})
+		r := fixCredentialRotationReconciler()
+		now := created.Add(25 * time.Hour)
+
+		err := prepareCredentialRotation(r, s, fixInternalAccessSecret(created, nil), now)
+
+		require.NoError(t, err)
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		dockerRegistry := flags["dockerRegistry"].(map[string]interface{})
+		require.Len(t, dockerRegistry["username"], 20)
+		require.Len(t, dockerRegistry["password"], 40)
+		require.NotEqual(t, "user", dockerRegistry["username"])
+		require.Equal(t, "user", dockerRegistry["previousUsername"])
+		require.Equal(t, "pass", dockerRegistry["previousPassword"])
+		require.Equal(t, "2024-01-02T01:00:00Z", dockerRegistry["rotatedAt"])
+		require.Equal(t, "credentialsRotatedAt=2024-01-02T01:00:00Z-grace", flags["rollme"])
+		require.Equal(t, v1alpha1.DefaultCredentialRotationGracePeriod, s.credentialRotationRequeueAfter)
+		require.True(t, s.credentialsRotated)
+		require.Len(t, r.EventRecorder.(*record.FakeRecorder).Events, 1)
+	})
+
+	t.Run("drop previous credentials after the grace period", func(t *testing.T) {
+		s := fixCredentialRotationState(&v1alpha1.CredentialRotation{Interval: &metav1.Duration{Duration: 24 * time.Hour}})
+		r := fixCredentialRotationReconciler()
+		rotatedAt := created.Add(25 * time.Hour)
+		secret := fixInternalAccessSecret(created, map[string]string{registry.CredentialsRotatedAtAnnotation: rotatedAt.Format(time.RFC3339)})
+		secret.Data[registry.PreviousUsernameKey] = []byte("old-user")
+		secret.Data[registry.PreviousPasswordKey] = []byte("old-pass")
+
+		err := prepareCredentialRotation(r, s, secret, rotatedAt.Add(10*time.Minute))
+
+		require.NoError(t, err)
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"rotatedAt": "2024-01-02T01:00:00Z"}, flags["dockerRegistry"])
+		require.Equal(t, "credentialsRotatedAt=2024-01-02T01:00:00Z", flags["rollme"])
+		require.Equal(t, 24*time.Hour-10*time.Minute, s.credentialRotationRequeueAfter)
+	})
+}
+
+func fixCredentialRotationState(rotation *v1alpha1.CredentialRotation) *systemState {
+	return &systemState{
+		instance: v1alpha1.DockerRegistry{
+			ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "kyma-system"},
+			Spec: v1alpha1.DockerRegistrySpec{
+				Auth: &v1alpha1.Auth{CredentialRotation: rotation},
+			},
+		},
+		flagsBuilder: flags.NewBuilder(),
+	}
+}
+
+func fixCredentialRotationReconciler() *reconciler {
+	return &reconciler{
+		log: zap.NewNop().Sugar(),
+		k8s: k8s{EventRecorder: record.NewFakeRecorder(5)},
+	}
+}
+
+func fixInternalAccessSecret(created time.Time, annotations map[string]string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              registry.InternalAccessSecretName,
+			Namespace:         "kyma-system",
+			CreationTimestamp: metav1.NewTime(created),
+			Annotations:       annotations,
+		},
+		Data: map[string][]byte{
+			"username": []byte("user"),
+			"password": []byte("pass"),
+		},
+	}
+}
@@ -72,8 +72,8 @@ func deleteResourcesWithFilter(ctx context.Context, r *reconciler, s *systemStat
 		"DockerRegistry module deleted",
 	)
 
-	// if resources are ready to be deleted, remove finalizer
-	return nextState(sFnRemoveFinalizer)
+	// if resources are deleted, make sure the registry storage is gone before removing finalizers
+	return nextState(sFnCleanupStorage)
 }
 
 func uninstallResourcesError(r *reconciler, s *systemState, err error) (stateFn, *ctrl.Result, error) {
@@ -107,7 +107,7 @@ func Test_sFnDeleteResources(t *testing.T) {
 		next, result, err := sFnSafeDeletionState(context.TODO(), r, s)
 		require.Nil(t, err)
 		require.Nil(t, result)
-		requireEqualFunc(t, sFnRemoveFinalizer, next)
+		requireEqualFunc(t, sFnCleanupStorage, next)
 
 		status := s.instance.Status
 		require.Equal(t, v1alpha1.StateDeleting, status.State)
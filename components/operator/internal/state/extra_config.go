@@ -0,0 +1,108 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/pkg/errors"
+	"helm.sh/helm/v3/pkg/chartutil"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// prepareExtraConfig passes the custom registry configuration to the chart, keys managed by the operator
+// are not overwritten and reported as a warning
+func prepareExtraConfig(ctx context.Context, r *reconciler, s *systemState) error {
+	name := s.instance.GetExtraConfigMapName()
+	if name == "" {
+		return nil
+	}
+
+	configMap := corev1.ConfigMap{}
+	err := r.client.Get(ctx, client.ObjectKey{Namespace: s.instance.Namespace, Name: name}, &configMap)
+	if err != nil {
+		return errors.Wrapf(err, "while getting extra config map %s", name)
+	}
+
+	raw, ok := configMap.Data[v1alpha1.ExtraConfigKey]
+	if !ok {
+		return errors.Errorf("extra config map %s doesn't contain the %s key", name, v1alpha1.ExtraConfigKey)
+	}
+
+	extraConfig := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(raw), &extraConfig); err != nil {
+		return errors.Wrapf(err, "while parsing extra config map %s", name)
+	}
+
+	managedConfig, err := operatorManagedConfig(s)
+	if err != nil {
+		return err
+	}
+
+	if conflicts := conflictingKeys("", managedConfig, extraConfig); len(conflicts) != 0 {
+		s.warningBuilder.With(fmt.Sprintf("extra config keys %s are managed by the operator and are ignored", strings.Join(conflicts, ", ")))
+	}
+
+	s.flagsBuilder.WithExtraConfig(extraConfig, configMapChecksum(&configMap, v1alpha1.ExtraConfigKey))
+	return nil
+}
+
+// operatorManagedConfig returns the registry configuration from the chart defaults and the operator flags
+func operatorManagedConfig(s *systemState) (map[string]interface{}, error) {
+	values, err := chartutil.ReadValuesFile(filepath.Join(s.chartConfig.Release.ChartPath, "values.yaml"))
+	if err != nil {
+		return nil, errors.Wrap(err, "while reading chart values")
+	}
+
+	flags, err := s.flagsBuilder.Build()
+	if err != nil {
+		return nil, errors.Wrap(err, "while building chart flags")
+	}
+
+	// the notification endpoints are passed to the registry outside of the configuration file
+	managedNotifications := map[string]interface{}{}
+	if endpoints, ok := toTable(flags["notifications"])["endpoints"]; ok {
+		managedNotifications["notifications"] = map[string]interface{}{"endpoints": endpoints}
+	}
+
+	return chartutil.CoalesceTables(
+		chartutil.CoalesceTables(toTable(flags["configData"]), managedNotifications),
+		toTable(values["configData"]),
+	), nil
+}
+
+// conflictingKeys returns sorted paths of the extra keys which are already set in the managed configuration
+func conflictingKeys(prefix string, managed, extra map[string]interface{}) []string {
+	conflicts := []string{}
+	for key, extraValue := range extra {
+		managedValue, ok := managed[key]
+		if !ok {
+			continue
+		}
+
+		path := prefix + key
+		managedTable, managedIsTable := managedValue.(map[string]interface{})
+		extraTable, extraIsTable := extraValue.(map[string]interface{})
+		if managedIsTable && extraIsTable {
+			conflicts = append(conflicts, conflictingKeys(path+".", managedTable, extraTable)...)
+			continue
+		}
+		conflicts = append(conflicts, path)
+	}
+
+	sort.Strings(conflicts)
+	return conflicts
+}
+
+func toTable(value interface{}) map[string]interface{} {
+	table, ok := value.(map[string]interface{})
+	if !ok {
+		return map[string]interface{}{}
+	}
+	return table
+}
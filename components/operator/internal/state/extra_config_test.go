@@ -0,0 +1,95 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
+	"github.com/kyma-project/docker-registry/components/operator/internal/warning"
+	"github.com/kyma-project/manager-toolkit/installation/chart"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_prepareExtraConfig(t *testing.T) {
+	testCases := map[string]struct {
+		givenConfigMaps []client.Object
+		givenExtra      *v1alpha1.ExtraConfig
+		expectedExtra   interface{}
+		expectedWarning string
+		expectedErr     string
+	}{
+		"no extra config": {},
+		"merge extra config": {
+			givenConfigMaps: []client.Object{fixExtraConfigMap("notifications:\n  events:\n    includereferences: true\n")},
+			givenExtra:      &v1alpha1.ExtraConfig{ConfigMapName: "registry-extra"},
+			expectedExtra: map[string]interface{}{
+				"notifications": map[string]interface{}{
+					"events": map[string]interface{}{"includereferences": true},
+				},
+			},
+		},
+		"warn about keys managed by the operator": {
+			givenConfigMaps: []client.Object{fixExtraConfigMap("http:\n  addr: :6000\n  host: https://registry.local\nlog:\n  formatter: text\n  level: debug\n")},
+			givenExtra:      &v1alpha1.ExtraConfig{ConfigMapName: "registry-extra"},
+			expectedExtra: map[string]interface{}{
+				"http": map[string]interface{}{"addr": ":6000", "host": "https://registry.local"},
+				"log":  map[string]interface{}{"formatter": "text", "level": "debug"},
+			},
+			expectedWarning: "Warning: extra config keys http.addr, log.formatter, log.level are managed by the operator and are ignored",
+		},
+		"config map not found": {
+			givenExtra:  &v1alpha1.ExtraConfig{ConfigMapName: "registry-extra"},
+			expectedErr: "while getting extra config map registry-extra: configmaps \"registry-extra\" not found",
+		},
+		"config map without config key": {
+			givenConfigMaps: []client.Object{&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "registry-extra", Namespace: "kyma-system"}}},
+			givenExtra:      &v1alpha1.ExtraConfig{ConfigMapName: "registry-extra"},
+			expectedErr:     "extra config map registry-extra doesn't contain the config.yml key",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			s := &systemState{
+				instance: v1alpha1.DockerRegistry{
+					ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "kyma-system"},
+					Spec:       v1alpha1.DockerRegistrySpec{ExtraConfig: testCase.givenExtra},
+				},
+				chartConfig: &chart.Config{
+					Release: chart.Release{ChartPath: "../../../../config/docker-registry", Name: "dockerregistry", Namespace: "kyma-system"},
+				},
+				flagsBuilder:   flags.NewBuilder().WithServicePort(5000),
+				warningBuilder: warning.NewBuilder(),
+			}
+			r := &reconciler{
+				log: zap.NewNop().Sugar(),
+				k8s: k8s{client: fake.NewClientBuilder().WithObjects(testCase.givenConfigMaps...).Build()},
+			}
+
+			err := prepareExtraConfig(context.Background(), r, s)
+			if testCase.expectedErr != "" {
+				require.EqualError(t, err, testCase.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			flags, err := s.flagsBuilder.Build()
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedExtra, flags["extraConfigData"])
+			require.Equal(t, testCase.expectedWarning, s.warningBuilder.Build())
+		})
+	}
+}
+
+func fixExtraConfigMap(config string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "registry-extra", Namespace: "kyma-system"},
+		Data:       map[string]string{v1alpha1.ExtraConfigKey: config},
+	}
+}
@@ -6,14 +6,17 @@ import (
 	"reflect"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/audit"
 	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
 	"github.com/kyma-project/docker-registry/components/operator/internal/registry"
 	"github.com/kyma-project/docker-registry/components/operator/internal/warning"
 	"github.com/kyma-project/manager-toolkit/installation/chart"
 	"go.uber.org/zap"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -34,6 +37,15 @@ type cfg struct {
 	finalizer     string
 	chartPath     string
 	managerPodUID string
+	// operatorImage is the image of the running operator, it runs the pruning job unless spec.pruning.jobImage is set
+	operatorImage   string
+	operatorVersion string
+	chartVersion    string
+	deletionTimeout time.Duration
+	// syncPeriod is the reconciliation period of CRs without spec.syncPeriod
+	syncPeriod time.Duration
+	// applyGracePeriod is how long the chart isn't re-applied if its values didn't change, zero re-applies it every time
+	applyGracePeriod time.Duration
 }
 
 type systemState struct {
@@ -44,6 +56,16 @@ type systemState struct {
 	flagsBuilder        *flags.Builder
 	nodePortResolver    *registry.NodePortResolver
 	gatewayHostResolver registry.ExternalAccessResolver
+	volumeUsageReader   registry.VolumeUsageReader
+	gcLogReader         registry.GCLogReader
+	// credentialRotationRequeueAfter is the time left to the next credentials rotation step
+	credentialRotationRequeueAfter time.Duration
+	// deploymentUpdateDeferredBy is the name of the PodDisruptionBudget which blocks the registry rollout
+	deploymentUpdateDeferredBy string
+	// credentialsRotated is set when new internal registry credentials are applied in the current reconciliation
+	credentialsRotated bool
+	// configurationFailed is set when a configuration step of the current reconciliation failed
+	configurationFailed bool
 }
 
 func (s *systemState) saveStatusSnapshot() {
@@ -58,6 +80,11 @@ func (s *systemState) setState(state v1alpha1.State) {
 	s.instance.Status.State = state
 }
 
+func (s *systemState) setConfigurationFailed(reason v1alpha1.ConditionReason, err error) {
+	s.configurationFailed = true
+	s.instance.UpdateConditionFalse(v1alpha1.ConditionTypeConfigured, reason, err)
+}
+
 func (s *systemState) setServed(served v1alpha1.Served) {
 	s.instance.Status.Served = served
 }
@@ -83,15 +110,17 @@ func chartConfig(ctx context.Context, r *reconciler, namespace string) *chart.Co
 }
 
 type k8s struct {
-	client client.Client
-	config *rest.Config
+	client    client.Client
+	config    *rest.Config
+	clientset kubernetes.Interface
 	record.EventRecorder
 }
 
 type reconciler struct {
-	fn    stateFn
-	log   *zap.SugaredLogger
-	cache chart.ManifestCache
+	fn       stateFn
+	log      *zap.SugaredLogger
+	cache    chart.ManifestCache
+	auditLog *audit.Logger
 	k8s
 	cfg
 }
@@ -118,8 +147,12 @@ func (m *reconciler) Reconcile(ctx context.Context, v v1alpha1.DockerRegistry) (
 		gatewayHostResolver: registry.NewExternalAccessResolver(
 			fmt.Sprintf("registry-%s-%s", v.GetName(), v.GetNamespace()),
 		),
+		volumeUsageReader: registry.NewVolumeUsageReader(m.config, m.clientset),
+		gcLogReader:       registry.NewGCLogReader(m.clientset),
 	}
 	state.saveStatusSnapshot()
+	startedAt := time.Now()
+	previousConditions := state.statusSnapshot.Conditions
 	var err error
 	var result *ctrl.Result
 loop:
@@ -142,6 +175,10 @@ loop:
 		result = &defaultResult
 	}
 
+	if auditErr := m.auditLog.Reconciled(&state.instance, startedAt, previousConditions, state.instance.Status.Conditions); auditErr != nil {
+		m.log.Warnf("while writing audit record: %s", auditErr.Error())
+	}
+
 	m.log.
 		With("error", err).
 		With("result", result).
@@ -0,0 +1,325 @@
+package state
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	internalresource "github.com/kyma-project/docker-registry/components/operator/internal/resource"
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// hookPhaseLabel marks the hook Jobs with the phase they run in
+	hookPhaseLabel = "dockerregistry.operator.kyma-project.io/hook"
+
+	preReconcileHookPhase  = "pre-reconcile"
+	postReconcileHookPhase = "post-reconcile"
+
+	hookScriptsVolumeName = "scripts"
+	hookScriptsMountPath  = "/hooks"
+
+	// hookServiceAccountName is the ServiceAccount the hook Jobs run with, the operator doesn't grant it any permissions
+	// so the CR editors can't run scripts with the operator or other privileged ServiceAccounts
+	hookServiceAccountName = "dockerregistry-hooks"
+)
+
+// run the pre-reconcile hook scripts before the registry chart is applied
+func sFnPreReconcileHook(ctx context.Context, r *reconciler, s *systemState) (stateFn, *ctrl.Result, error) {
+	var hook *v1alpha1.Hook
+	if s.instance.Spec.Hooks != nil {
+		hook = s.instance.Spec.Hooks.PreReconcile
+	}
+
+	done, err := runHook(ctx, newResourceClient(r, s), s, preReconcileHookPhase, hook)
+	if err != nil {
+		return stopWithHookError(r, s, err)
+	}
+	if !done {
+		return requeueAfter(requeueDuration)
+	}
+
+	return nextState(sFnApplyResources)
+}
+
+// run the post-reconcile hook scripts after the registry deployment is ready
+func sFnPostReconcileHook(ctx context.Context, r *reconciler, s *systemState) (stateFn, *ctrl.Result, error) {
+	var hook *v1alpha1.Hook
+	if s.instance.Spec.Hooks != nil {
+		hook = s.instance.Spec.Hooks.PostReconcile
+	}
+
+	done, err := runHook(ctx, newResourceClient(r, s), s, postReconcileHookPhase, hook)
+	if err != nil {
+		return stopWithHookError(r, s, err)
+	}
+	if !done {
+		return requeueAfter(requeueDuration)
+	}
+
+	if s.instance.Spec.Hooks == nil {
+		s.instance.RemoveCondition(v1alpha1.ConditionTypeHooksCompleted)
+	} else {
+		s.instance.UpdateConditionTrue(
+			v1alpha1.ConditionTypeHooksCompleted,
+			v1alpha1.ConditionReasonHooksSucceeded,
+			"Hooks completed",
+		)
+	}
+
+	return nextState(sFnUpdateFinalStatus)
+}
+
+func stopWithHookError(r *reconciler, s *systemState, err error) (stateFn, *ctrl.Result, error) {
+	r.log.Warnf("error while running hooks %s: %s",
+		client.ObjectKeyFromObject(&s.instance), err.Error())
+	s.setState(v1alpha1.StateError)
+	s.instance.UpdateConditionFalse(
+		v1alpha1.ConditionTypeHooksCompleted,
+		v1alpha1.ConditionReasonHookFailed,
+		err,
+	)
+	return stopWithEventualError(err)
+}
+
+// runHook runs the hook scripts one by one and returns true when all of them completed,
+// scripts run once for each generation of the CR and each change of the script or the hook configuration
+func runHook(ctx context.Context, c internalresource.Client, s *systemState, phase string, hook *v1alpha1.Hook) (bool, error) {
+	jobs, err := hookJobs(ctx, c, s, phase, hook)
+	if err != nil {
+		return false, err
+	}
+
+	// clean up before new jobs are created
+	if err := cleanupHookJobs(ctx, c, s, phase, jobs); err != nil {
+		return false, err
+	}
+
+	if len(jobs) != 0 {
+		if err := applyHookServiceAccount(ctx, c, s); err != nil {
+			return false, err
+		}
+	}
+
+	for _, job := range jobs {
+		done, err := runHookJob(ctx, c, s, phase, job)
+		if err != nil || !done {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func applyHookServiceAccount(ctx context.Context, c internalresource.Client, s *systemState) error {
+	serviceAccount := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      hookServiceAccountName,
+			Namespace: s.instance.GetNamespace(),
+		},
+	}
+	err := c.UpsertWithReference(ctx, &s.instance, serviceAccount, func() error { return nil })
+	return errors.Wrapf(err, "while applying hook service account %s", hookServiceAccountName)
+}
+
+func runHookJob(ctx context.Context, c internalresource.Client, s *systemState, phase string, job *batchv1.Job) (bool, error) {
+	existing := &batchv1.Job{}
+	err := c.Get(ctx, client.ObjectKeyFromObject(job), existing)
+	if k8serrors.IsNotFound(err) {
+		if err := c.CreateWithReference(ctx, &s.instance, job); err != nil {
+			return false, errors.Wrapf(err, "while creating %s hook job %s", phase, job.GetName())
+		}
+		existing = job
+	} else if err != nil {
+		return false, errors.Wrapf(err, "while getting %s hook job %s", phase, job.GetName())
+	}
+
+	for _, condition := range existing.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, nil
+		case batchv1.JobFailed:
+			return false, fmt.Errorf("%s hook job %s failed: %s", phase, job.GetName(), condition.Message)
+		}
+	}
+
+	s.instance.UpdateConditionUnknown(
+		v1alpha1.ConditionTypeHooksCompleted,
+		v1alpha1.ConditionReasonHookRunning,
+		fmt.Sprintf("Waiting for the %s hook job %s", phase, job.GetName()),
+	)
+	return false, nil
+}
+
+// hookJobs returns Jobs of the hook scripts sorted by the ConfigMap keys
+func hookJobs(ctx context.Context, c internalresource.Client, s *systemState, phase string, hook *v1alpha1.Hook) ([]*batchv1.Job, error) {
+	if hook == nil {
+		return nil, nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+	err := c.Get(ctx, client.ObjectKey{Name: hook.ConfigMapName, Namespace: s.instance.GetNamespace()}, configMap)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while getting %s hook config map %s", phase, hook.ConfigMapName)
+	}
+
+	keys := make([]string, 0, len(configMap.Data))
+	for key := range configMap.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	jobs := make([]*batchv1.Job, 0, len(keys))
+	for _, key := range keys {
+		jobs = append(jobs, hookJob(s, phase, configMap.GetName(), key, configMap.Data[key]))
+	}
+	return jobs, nil
+}
+
+func hookJob(s *systemState, phase, configMapName, key, script string) *batchv1.Job {
+	timeout := s.instance.GetHookTimeout()
+	labels := map[string]string{
+		hookPhaseLabel: phase,
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      hookJobName(phase, s.instance.GetGeneration(), s.instance.Spec.Hooks.Image, timeout.String(), key, script),
+			Namespace: s.instance.GetNamespace(),
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			// scripts are not idempotent in general so the failed one is not retried
+			BackoffLimit:          ptr.To[int32](0),
+			ActiveDeadlineSeconds: ptr.To(int64(timeout.Seconds())),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: hookServiceAccountName,
+					Containers: []corev1.Container{
+						{
+							Name:    "hook",
+							Image:   s.instance.Spec.Hooks.Image,
+							Command: []string{"sh", path.Join(hookScriptsMountPath, key)},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: hookScriptsVolumeName, MountPath: hookScriptsMountPath, ReadOnly: true},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: hookScriptsVolumeName,
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{Name: configMapName},
+									Items:                []corev1.KeyToPath{{Key: key, Path: key}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// hookJobName identifies the run of the script, a new Job is created for a new generation of the CR
+// or a changed script, while the existing Job tells the script already ran
+func hookJobName(phase string, generation int64, values ...string) string {
+	hash := sha256.New()
+	hash.Write([]byte(strconv.FormatInt(generation, 10)))
+	for _, value := range values {
+		hash.Write([]byte{0})
+		hash.Write([]byte(value))
+	}
+	return fmt.Sprintf("dockerregistry-%s-%s", phase, hex.EncodeToString(hash.Sum(nil))[:10])
+}
+
+// cleanupHookJobs deletes Jobs of the previous runs of the hook together with their pods, it keeps Jobs of the current run,
+// failed Jobs for debugging and the configured number of the most recently completed ones
+func cleanupHookJobs(ctx context.Context, c internalresource.Client, s *systemState, phase string, current []*batchv1.Job) error {
+	jobs := &batchv1.JobList{}
+	err := c.ListByLabel(ctx, s.instance.GetNamespace(), map[string]string{hookPhaseLabel: phase}, jobs)
+	if err != nil {
+		return errors.Wrapf(err, "while listing %s hook jobs", phase)
+	}
+
+	currentNames := map[string]bool{}
+	for _, job := range current {
+		currentNames[job.GetName()] = true
+	}
+
+	completed := []*batchv1.Job{}
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if !metav1.IsControlledBy(job, &s.instance) {
+			continue
+		}
+		if isHookJobFinished(job, batchv1.JobComplete) {
+			completed = append(completed, job)
+			continue
+		}
+		if currentNames[job.GetName()] || isHookJobFinished(job, batchv1.JobFailed) {
+			continue
+		}
+		// the running job of the previous run is superseded
+		if err := deleteHookJob(ctx, c, phase, job); err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(completed, func(i, j int) bool {
+		return hookJobCompletionTime(completed[i]).After(hookJobCompletionTime(completed[j]))
+	})
+	kept := 0
+	for _, job := range completed {
+		// completed jobs of the current run tell the scripts already ran
+		if currentNames[job.GetName()] || kept < s.instance.GetHookKeepLastJobs() {
+			kept++
+			continue
+		}
+		if err := deleteHookJob(ctx, c, phase, job); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteHookJob(ctx context.Context, c internalresource.Client, phase string, job *batchv1.Job) error {
+	err := c.Delete(ctx, job)
+	return errors.Wrapf(client.IgnoreNotFound(err), "while deleting %s hook job %s", phase, job.GetName())
+}
+
+func isHookJobFinished(job *batchv1.Job, conditionType batchv1.JobConditionType) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == conditionType && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+func hookJobCompletionTime(job *batchv1.Job) time.Time {
+	if job.Status.CompletionTime != nil {
+		return job.Status.CompletionTime.Time
+	}
+	return job.GetCreationTimestamp().Time
+}
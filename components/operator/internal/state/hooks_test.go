@@ -0,0 +1,320 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func Test_sFnPreReconcileHook(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+	require.NoError(t, v1alpha1.AddToScheme(testScheme))
+
+	t.Run("skip when hooks are not configured", func(t *testing.T) {
+		s := &systemState{instance: *testInstalledDockerRegistry.DeepCopy()}
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).Build()},
+		}
+
+		next, result, err := sFnPreReconcileHook(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnApplyResources, next)
+	})
+
+	t.Run("create job of the first script and wait for it", func(t *testing.T) {
+		s := &systemState{instance: *fixHookDockerRegistry()}
+		c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(fixHookConfigMap()).Build()
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: c},
+		}
+
+		next, result, err := sFnPreReconcileHook(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, next)
+		require.Equal(t, requeueDuration, result.RequeueAfter)
+
+		jobs := &batchv1.JobList{}
+		require.NoError(t, c.List(context.Background(), jobs))
+		require.Len(t, jobs.Items, 1)
+		job := jobs.Items[0]
+		require.Equal(t, preReconcileHookPhase, job.GetLabels()[hookPhaseLabel])
+		require.True(t, metav1.IsControlledBy(&job, &s.instance))
+		require.Equal(t, hookServiceAccountName, job.Spec.Template.Spec.ServiceAccountName)
+		serviceAccount := &corev1.ServiceAccount{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: hookServiceAccountName, Namespace: s.instance.GetNamespace()}, serviceAccount))
+		require.True(t, metav1.IsControlledBy(serviceAccount, &s.instance))
+		require.Equal(t, int64(120), *job.Spec.ActiveDeadlineSeconds)
+		container := job.Spec.Template.Spec.Containers[0]
+		require.Equal(t, "alpine:3.20", container.Image)
+		require.Equal(t, []string{"sh", "/hooks/01-notify.sh"}, container.Command)
+
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeHooksCompleted,
+			metav1.ConditionUnknown,
+			v1alpha1.ConditionReasonHookRunning,
+			"Waiting for the pre-reconcile hook job "+job.GetName(),
+		)
+	})
+
+	t.Run("continue when all jobs completed", func(t *testing.T) {
+		s := &systemState{instance: *fixHookDockerRegistry()}
+		c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+			fixHookConfigMap(),
+			fixHookJob(t, testScheme, s, "01-notify.sh", "echo notify", batchv1.JobComplete),
+			fixHookJob(t, testScheme, s, "02-firewall.sh", "echo firewall", batchv1.JobComplete),
+		).Build()
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: c},
+		}
+
+		next, result, err := sFnPreReconcileHook(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnApplyResources, next)
+	})
+
+	t.Run("stop when job failed", func(t *testing.T) {
+		s := &systemState{instance: *fixHookDockerRegistry()}
+		failedJob := fixHookJob(t, testScheme, s, "01-notify.sh", "echo notify", batchv1.JobFailed)
+		c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(fixHookConfigMap(), failedJob).Build()
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: c},
+		}
+
+		next, result, err := sFnPreReconcileHook(context.Background(), r, s)
+		expectedErr := "pre-reconcile hook job " + failedJob.GetName() + " failed: test message"
+		require.EqualError(t, err, expectedErr)
+		require.Nil(t, result)
+		require.Nil(t, next)
+		require.Equal(t, v1alpha1.StateError, s.instance.Status.State)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeHooksCompleted,
+			metav1.ConditionFalse,
+			v1alpha1.ConditionReasonHookFailed,
+			expectedErr,
+		)
+	})
+
+	t.Run("stop when config map is missing", func(t *testing.T) {
+		s := &systemState{instance: *fixHookDockerRegistry()}
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).Build()},
+		}
+
+		_, _, err := sFnPreReconcileHook(context.Background(), r, s)
+		require.ErrorContains(t, err, "while getting pre-reconcile hook config map pre-hooks")
+		require.Equal(t, v1alpha1.StateError, s.instance.Status.State)
+	})
+
+	t.Run("keep the most recently completed jobs of previous generations", func(t *testing.T) {
+		oldJob := fixPreviousHookJob(t, testScheme, 0, batchv1.JobComplete, time.Now().Add(-2*time.Hour))
+		recentJob := fixPreviousHookJob(t, testScheme, 1, batchv1.JobComplete, time.Now().Add(-time.Hour))
+
+		s := &systemState{instance: *fixHookDockerRegistry()}
+		s.instance.Spec.Hooks.KeepLastJobs = ptr.To[int32](1)
+		c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(fixHookConfigMap(), oldJob, recentJob).Build()
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: c},
+		}
+
+		_, _, err := sFnPreReconcileHook(context.Background(), r, s)
+		require.NoError(t, err)
+
+		require.True(t, k8serrors.IsNotFound(c.Get(context.Background(), client.ObjectKeyFromObject(oldJob), &batchv1.Job{})))
+		require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(recentJob), &batchv1.Job{}))
+		jobs := &batchv1.JobList{}
+		require.NoError(t, c.List(context.Background(), jobs))
+		require.Len(t, jobs.Items, 2)
+	})
+
+	t.Run("keep failed jobs of previous generations", func(t *testing.T) {
+		failedJob := fixPreviousHookJob(t, testScheme, 1, batchv1.JobFailed, time.Now())
+
+		s := &systemState{instance: *fixHookDockerRegistry()}
+		s.instance.Spec.Hooks.KeepLastJobs = ptr.To[int32](0)
+		c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(fixHookConfigMap(), failedJob).Build()
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: c},
+		}
+
+		_, _, err := sFnPreReconcileHook(context.Background(), r, s)
+		require.NoError(t, err)
+		require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(failedJob), &batchv1.Job{}))
+	})
+
+	t.Run("delete running jobs of previous generations", func(t *testing.T) {
+		runningJob := fixPreviousHookJob(t, testScheme, 1, "", time.Now())
+
+		s := &systemState{instance: *fixHookDockerRegistry()}
+		c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(fixHookConfigMap(), runningJob).Build()
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: c},
+		}
+
+		_, _, err := sFnPreReconcileHook(context.Background(), r, s)
+		require.NoError(t, err)
+		require.True(t, k8serrors.IsNotFound(c.Get(context.Background(), client.ObjectKeyFromObject(runningJob), &batchv1.Job{})))
+	})
+
+	t.Run("keep completed jobs of the current generation", func(t *testing.T) {
+		s := &systemState{instance: *fixHookDockerRegistry()}
+		s.instance.Spec.Hooks.KeepLastJobs = ptr.To[int32](0)
+		c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+			fixHookConfigMap(),
+			fixHookJob(t, testScheme, s, "01-notify.sh", "echo notify", batchv1.JobComplete),
+			fixHookJob(t, testScheme, s, "02-firewall.sh", "echo firewall", batchv1.JobComplete),
+		).Build()
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: c},
+		}
+
+		next, _, err := sFnPreReconcileHook(context.Background(), r, s)
+		require.NoError(t, err)
+		requireEqualFunc(t, sFnApplyResources, next)
+		jobs := &batchv1.JobList{}
+		require.NoError(t, c.List(context.Background(), jobs))
+		require.Len(t, jobs.Items, 2)
+	})
+}
+
+func Test_sFnPostReconcileHook(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+	require.NoError(t, v1alpha1.AddToScheme(testScheme))
+
+	t.Run("mark hooks completed", func(t *testing.T) {
+		s := &systemState{instance: *fixHookDockerRegistry()}
+		s.instance.Spec.Hooks.PreReconcile = nil
+		s.instance.Spec.Hooks.PostReconcile = &v1alpha1.Hook{ConfigMapName: "pre-hooks"}
+		c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(fixHookConfigMap()).
+			WithStatusSubresource(&batchv1.Job{}).Build()
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: c},
+		}
+
+		jobs := &batchv1.JobList{}
+		_, result, err := sFnPostReconcileHook(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Equal(t, requeueDuration, result.RequeueAfter)
+		require.NoError(t, c.List(context.Background(), jobs))
+		require.Len(t, jobs.Items, 1)
+
+		// complete the first script to start the second one
+		completeHookJobs(t, c, jobs)
+		_, result, err = sFnPostReconcileHook(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Equal(t, requeueDuration, result.RequeueAfter)
+		require.NoError(t, c.List(context.Background(), jobs))
+		require.Len(t, jobs.Items, 2)
+
+		completeHookJobs(t, c, jobs)
+		next, result, err := sFnPostReconcileHook(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnUpdateFinalStatus, next)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeHooksCompleted,
+			metav1.ConditionTrue,
+			v1alpha1.ConditionReasonHooksSucceeded,
+			"Hooks completed",
+		)
+	})
+
+	t.Run("remove condition when hooks are not configured", func(t *testing.T) {
+		s := &systemState{instance: *testInstalledDockerRegistry.DeepCopy()}
+		s.instance.UpdateConditionTrue(v1alpha1.ConditionTypeHooksCompleted, v1alpha1.ConditionReasonHooksSucceeded, "Hooks completed")
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).Build()},
+		}
+
+		next, _, err := sFnPostReconcileHook(context.Background(), r, s)
+		require.NoError(t, err)
+		requireEqualFunc(t, sFnUpdateFinalStatus, next)
+		require.False(t, s.instance.IsCondition(v1alpha1.ConditionTypeHooksCompleted))
+	})
+}
+
+func Test_hookJobName(t *testing.T) {
+	name := hookJobName(preReconcileHookPhase, 2, "alpine:3.20", "echo notify")
+	require.Regexp(t, "^dockerregistry-pre-reconcile-[0-9a-f]{10}$", name)
+	require.Equal(t, name, hookJobName(preReconcileHookPhase, 2, "alpine:3.20", "echo notify"))
+	require.NotEqual(t, name, hookJobName(preReconcileHookPhase, 3, "alpine:3.20", "echo notify"))
+	require.NotEqual(t, name, hookJobName(preReconcileHookPhase, 2, "alpine:3.20", "echo changed"))
+}
+
+// fixPreviousHookJob returns the job of the first script run for the given generation, finished at the given time
+func fixPreviousHookJob(t *testing.T, scheme *runtime.Scheme, generation int64, conditionType batchv1.JobConditionType, finishedAt time.Time) *batchv1.Job {
+	previous := &systemState{instance: *fixHookDockerRegistry()}
+	previous.instance.SetGeneration(generation)
+	job := hookJob(previous, preReconcileHookPhase, "pre-hooks", "01-notify.sh", "echo notify")
+	require.NoError(t, controllerutil.SetControllerReference(&previous.instance, job, scheme))
+	if conditionType != "" {
+		job.Status.Conditions = []batchv1.JobCondition{{Type: conditionType, Status: corev1.ConditionTrue}}
+		job.Status.CompletionTime = &metav1.Time{Time: finishedAt}
+	}
+	return job
+}
+
+func completeHookJobs(t *testing.T, c client.Client, jobs *batchv1.JobList) {
+	for i := range jobs.Items {
+		jobs.Items[i].Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+		require.NoError(t, c.Status().Update(context.Background(), &jobs.Items[i]))
+	}
+}
+
+func fixHookDockerRegistry() *v1alpha1.DockerRegistry {
+	instance := testInstalledDockerRegistry.DeepCopy()
+	instance.SetGeneration(2)
+	instance.Spec.Hooks = &v1alpha1.Hooks{
+		PreReconcile: &v1alpha1.Hook{ConfigMapName: "pre-hooks"},
+		Image:        "alpine:3.20",
+		Timeout:      &metav1.Duration{Duration: 2 * time.Minute},
+	}
+	return instance
+}
+
+func fixHookConfigMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "pre-hooks", Namespace: testInstalledDockerRegistry.GetNamespace()},
+		Data: map[string]string{
+			"02-firewall.sh": "echo firewall",
+			"01-notify.sh":   "echo notify",
+		},
+	}
+}
+
+func fixHookJob(t *testing.T, scheme *runtime.Scheme, s *systemState, key, script string, conditionType batchv1.JobConditionType) client.Object {
+	job := hookJob(s, preReconcileHookPhase, "pre-hooks", key, script)
+	require.NoError(t, controllerutil.SetControllerReference(&s.instance, job, scheme))
+	job.Status.Conditions = []batchv1.JobCondition{
+		{Type: conditionType, Status: corev1.ConditionTrue, Message: "test message"},
+	}
+	return job
+}
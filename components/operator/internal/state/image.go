@@ -0,0 +1,10 @@
+package state
+
+func prepareImage(s *systemState) {
+	overrideImage := s.instance.Spec.OverrideImage
+	if overrideImage == nil {
+		return
+	}
+
+	s.flagsBuilder.WithImage(overrideImage.Repository, overrideImage.Tag, overrideImage.PullPolicy)
+}
@@ -0,0 +1,70 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// prepareImagePullSecrets passes the image pull secrets to the chart and reports the missing ones
+// without failing the reconciliation so the registry can still run on nodes which already have the image
+func prepareImagePullSecrets(ctx context.Context, r *reconciler, s *systemState) error {
+	secrets := s.instance.Spec.ImagePullSecrets
+	if len(secrets) == 0 {
+		s.instance.RemoveCondition(v1alpha1.ConditionTypeImagePullSecretMissing)
+		return nil
+	}
+
+	s.flagsBuilder.WithImagePullSecrets(secrets)
+
+	var missing, invalid []string
+	for _, ref := range secrets {
+		secret := corev1.Secret{}
+		err := r.client.Get(ctx, client.ObjectKey{Namespace: s.instance.Namespace, Name: ref.Name}, &secret)
+		if k8serrors.IsNotFound(err) {
+			missing = append(missing, ref.Name)
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "while getting image pull secret %s", ref.Name)
+		}
+		if secret.Type != corev1.SecretTypeDockerConfigJson {
+			invalid = append(invalid, ref.Name)
+		}
+	}
+
+	if len(missing) != 0 {
+		msg := fmt.Sprintf("image pull secrets %s not found in the %s namespace", strings.Join(missing, ", "), s.instance.Namespace)
+		s.warningBuilder.With(msg)
+		s.instance.UpdateConditionTrue(
+			v1alpha1.ConditionTypeImagePullSecretMissing,
+			v1alpha1.ConditionReasonImagePullSecretNotFound,
+			msg,
+		)
+		return nil
+	}
+
+	if len(invalid) != 0 {
+		msg := fmt.Sprintf("image pull secrets %s are not of the %s type", strings.Join(invalid, ", "), corev1.SecretTypeDockerConfigJson)
+		s.warningBuilder.With(msg)
+		s.instance.UpdateConditionTrue(
+			v1alpha1.ConditionTypeImagePullSecretMissing,
+			v1alpha1.ConditionReasonImagePullSecretInvalid,
+			msg,
+		)
+		return nil
+	}
+
+	s.instance.UpdateConditionFalse(
+		v1alpha1.ConditionTypeImagePullSecretMissing,
+		v1alpha1.ConditionReasonImagePullSecretsFound,
+		errors.New("image pull secrets found"),
+	)
+	return nil
+}
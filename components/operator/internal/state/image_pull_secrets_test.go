@@ -0,0 +1,121 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
+	"github.com/kyma-project/docker-registry/components/operator/internal/warning"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_prepareImagePullSecrets(t *testing.T) {
+	testCases := map[string]struct {
+		givenSecrets      []client.Object
+		givenPullSecrets  []corev1.LocalObjectReference
+		expectedFlags     map[string]interface{}
+		expectedCondition *metav1.Condition
+		expectedWarning   string
+	}{
+		"no image pull secrets": {
+			expectedFlags: map[string]interface{}{},
+		},
+		"image pull secrets found": {
+			givenSecrets:     []client.Object{fixImagePullSecret("mirror", corev1.SecretTypeDockerConfigJson)},
+			givenPullSecrets: []corev1.LocalObjectReference{{Name: "mirror"}},
+			expectedFlags: map[string]interface{}{
+				"imagePullSecrets": []interface{}{
+					map[string]interface{}{"name": "mirror"},
+				},
+			},
+			expectedCondition: &metav1.Condition{
+				Status:  metav1.ConditionFalse,
+				Reason:  string(v1alpha1.ConditionReasonImagePullSecretsFound),
+				Message: "image pull secrets found",
+			},
+		},
+		"image pull secret not found": {
+			givenSecrets:     []client.Object{fixImagePullSecret("mirror", corev1.SecretTypeDockerConfigJson)},
+			givenPullSecrets: []corev1.LocalObjectReference{{Name: "mirror"}, {Name: "missing"}},
+			expectedFlags: map[string]interface{}{
+				"imagePullSecrets": []interface{}{
+					map[string]interface{}{"name": "mirror"},
+					map[string]interface{}{"name": "missing"},
+				},
+			},
+			expectedCondition: &metav1.Condition{
+				Status:  metav1.ConditionTrue,
+				Reason:  string(v1alpha1.ConditionReasonImagePullSecretNotFound),
+				Message: "image pull secrets missing not found in the kyma-system namespace",
+			},
+			expectedWarning: "Warning: image pull secrets missing not found in the kyma-system namespace",
+		},
+		"image pull secret of wrong type": {
+			givenSecrets:     []client.Object{fixImagePullSecret("mirror", corev1.SecretTypeOpaque)},
+			givenPullSecrets: []corev1.LocalObjectReference{{Name: "mirror"}},
+			expectedFlags: map[string]interface{}{
+				"imagePullSecrets": []interface{}{
+					map[string]interface{}{"name": "mirror"},
+				},
+			},
+			expectedCondition: &metav1.Condition{
+				Status:  metav1.ConditionTrue,
+				Reason:  string(v1alpha1.ConditionReasonImagePullSecretInvalid),
+				Message: "image pull secrets mirror are not of the kubernetes.io/dockerconfigjson type",
+			},
+			expectedWarning: "Warning: image pull secrets mirror are not of the kubernetes.io/dockerconfigjson type",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			s := &systemState{
+				instance: v1alpha1.DockerRegistry{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "kyma-system"},
+					Spec:       v1alpha1.DockerRegistrySpec{ImagePullSecrets: testCase.givenPullSecrets},
+				},
+				flagsBuilder:   flags.NewBuilder(),
+				warningBuilder: warning.NewBuilder(),
+			}
+			r := &reconciler{
+				k8s: k8s{client: fake.NewClientBuilder().WithObjects(testCase.givenSecrets...).Build()},
+				log: zap.NewNop().Sugar(),
+			}
+
+			err := prepareImagePullSecrets(context.Background(), r, s)
+			require.NoError(t, err)
+
+			flags, err := s.flagsBuilder.Build()
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedFlags, flags)
+			require.Equal(t, testCase.expectedWarning, s.warningBuilder.Build())
+
+			condition := meta.FindStatusCondition(s.instance.Status.Conditions, string(v1alpha1.ConditionTypeImagePullSecretMissing))
+			if testCase.expectedCondition == nil {
+				require.Nil(t, condition)
+				return
+			}
+			require.NotNil(t, condition)
+			require.Equal(t, testCase.expectedCondition.Status, condition.Status)
+			require.Equal(t, testCase.expectedCondition.Reason, condition.Reason)
+			require.Equal(t, testCase.expectedCondition.Message, condition.Message)
+		})
+	}
+}
+
+func fixImagePullSecret(name string, secretType corev1.SecretType) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "kyma-system",
+		},
+		Type: secretType,
+	}
+}
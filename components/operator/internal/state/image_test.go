@@ -0,0 +1,93 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func Test_prepareImage(t *testing.T) {
+	testCases := map[string]struct {
+		givenOverrideImage *v1alpha1.OverrideImage
+		expectedFlags      map[string]interface{}
+	}{
+		"keep chart image": {
+			expectedFlags: map[string]interface{}{},
+		},
+		"override repository": {
+			givenOverrideImage: &v1alpha1.OverrideImage{Repository: "mirror.local/registry"},
+			expectedFlags: map[string]interface{}{
+				"image": map[string]interface{}{
+					"repository": "mirror.local/registry",
+				},
+			},
+		},
+		"override whole image": {
+			givenOverrideImage: &v1alpha1.OverrideImage{
+				Repository: "mirror.local/registry",
+				Tag:        "3.0.1-rc.1",
+				PullPolicy: corev1.PullAlways,
+			},
+			expectedFlags: map[string]interface{}{
+				"image": map[string]interface{}{
+					"repository": "mirror.local/registry",
+					"tag":        "3.0.1-rc.1",
+					"pullPolicy": "Always",
+				},
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			s := &systemState{
+				instance: v1alpha1.DockerRegistry{
+					Spec: v1alpha1.DockerRegistrySpec{OverrideImage: testCase.givenOverrideImage},
+				},
+				flagsBuilder: flags.NewBuilder(),
+			}
+
+			prepareImage(s)
+
+			flags, err := s.flagsBuilder.Build()
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedFlags, flags)
+		})
+	}
+}
+
+func Test_overrideImageRendering(t *testing.T) {
+	s := &systemState{
+		instance: v1alpha1.DockerRegistry{
+			Spec: v1alpha1.DockerRegistrySpec{OverrideImage: &v1alpha1.OverrideImage{
+				Repository: "mirror.local/registry",
+				Tag:        "3.0.1-rc.1",
+				PullPolicy: corev1.PullAlways,
+			}},
+		},
+		flagsBuilder: flags.NewBuilder(),
+	}
+	s.flagsBuilder.WithGarbageCollection("0 3 * * *", false, false)
+
+	prepareImage(s)
+
+	flags, err := s.flagsBuilder.Build()
+	require.NoError(t, err)
+	manifests := renderRegistryChart(t, flags)
+
+	deployment := &appsv1.Deployment{}
+	require.NoError(t, yaml.Unmarshal([]byte(manifests["docker-registry/templates/deployment.yaml"]), deployment))
+	require.Equal(t, "mirror.local/registry:3.0.1-rc.1", deployment.Spec.Template.Spec.Containers[0].Image)
+
+	cronJob := &batchv1.CronJob{}
+	require.NoError(t, yaml.Unmarshal([]byte(manifests["docker-registry/templates/garbage-collection-cronjob.yaml"]), cronJob))
+	container := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+	require.Equal(t, "mirror.local/registry:3.0.1-rc.1", container.Image)
+	require.Equal(t, corev1.PullAlways, container.ImagePullPolicy)
+}
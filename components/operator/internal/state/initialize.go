@@ -4,12 +4,14 @@ import (
 	"context"
 
 	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	internalerrors "github.com/kyma-project/docker-registry/components/operator/internal/errors"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
 // choose right scenario to start (installation/deletion)
-func sFnInitialize(_ context.Context, _ *reconciler, s *systemState) (stateFn, *ctrl.Result, error) {
+func sFnInitialize(_ context.Context, r *reconciler, s *systemState) (stateFn, *ctrl.Result, error) {
 	s.setState(v1alpha1.StateProcessing)
+	s.instance.Status.OperatorVersion = r.operatorVersion
 
 	// in case instance is being deleted and has finalizer - delete all resources
 	instanceIsBeingDeleted := !s.instance.GetDeletionTimestamp().IsZero()
@@ -17,5 +19,27 @@ func sFnInitialize(_ context.Context, _ *reconciler, s *systemState) (stateFn, *
 		return nextState(sFnDeleteResources)
 	}
 
+	// the webhook doesn't guard CRs created before it was deployed or while it was unavailable
+	if err := s.instance.Validate(); err != nil {
+		s.setState(invalidSpecState(s))
+		s.instance.UpdateConditionFalse(
+			v1alpha1.ConditionTypeConfigured,
+			v1alpha1.ConditionReasonInvalidSpec,
+			err,
+		)
+		return stopWithEventualError(internalerrors.ErrInvalidSpec.Wrap(err))
+	}
+
+	startChartUpgrade(r, s)
+
 	return nextState(sFnAccessConfiguration)
 }
+
+// invalidSpecState keeps the installed registry, which became invalid after the operator upgrade, running with the
+// last applied resources, the Warning state asks to fix the spec without reporting the working registry as broken
+func invalidSpecState(s *systemState) v1alpha1.State {
+	if s.instance.IsConditionTrue(v1alpha1.ConditionTypeInstalled) {
+		return v1alpha1.StateWarning
+	}
+	return v1alpha1.StateError
+}
@@ -5,8 +5,10 @@ import (
 	"testing"
 
 	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	internalerrors "github.com/kyma-project/docker-registry/components/operator/internal/errors"
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -14,7 +16,9 @@ func Test_sFnInitialize(t *testing.T) {
 	t.Run("setup and return next step sFnRegistryConfiguration", func(t *testing.T) {
 		r := &reconciler{
 			cfg: cfg{
-				finalizer: v1alpha1.Finalizer,
+				finalizer:       v1alpha1.Finalizer,
+				operatorVersion: "1.2.3",
+				chartVersion:    "1.9.1",
 			},
 			k8s: k8s{
 				client: fake.NewClientBuilder().Build(),
@@ -37,6 +41,9 @@ func Test_sFnInitialize(t *testing.T) {
 		requireEqualFunc(t, sFnAccessConfiguration, next)
 
 		require.Equal(t, v1alpha1.StateProcessing, s.instance.Status.State)
+		require.Equal(t, "1.2.3", s.instance.Status.OperatorVersion)
+		// chart version is set after the chart is applied
+		require.Empty(t, s.instance.Status.ChartVersion)
 	})
 
 	t.Run("setup and return next step sFnDeleteResources", func(t *testing.T) {
@@ -68,4 +75,74 @@ func Test_sFnInitialize(t *testing.T) {
 
 		require.Equal(t, v1alpha1.StateProcessing, s.instance.Status.State)
 	})
+
+	t.Run("stop on invalid spec", func(t *testing.T) {
+		r := &reconciler{
+			cfg: cfg{
+				finalizer: v1alpha1.Finalizer,
+			},
+			k8s: k8s{
+				client: fake.NewClientBuilder().Build(),
+			},
+		}
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				ObjectMeta: metav1.ObjectMeta{
+					Finalizers: []string{
+						r.cfg.finalizer,
+					},
+				},
+				Spec: v1alpha1.DockerRegistrySpec{
+					Storage: &v1alpha1.Storage{
+						Azure: &v1alpha1.StorageAzure{SecretName: "azure"},
+						S3:    &v1alpha1.StorageS3{Bucket: "bucket", Region: "region"},
+					},
+				},
+			},
+		}
+
+		next, result, err := sFnInitialize(context.Background(), r, s)
+		require.ErrorIs(t, err, internalerrors.ErrInvalidSpec)
+		require.True(t, internalerrors.IsTerminal(err))
+		require.Nil(t, result)
+		require.Nil(t, next)
+
+		require.Equal(t, v1alpha1.StateError, s.instance.Status.State)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeConfigured,
+			metav1.ConditionFalse,
+			v1alpha1.ConditionReasonInvalidSpec,
+			err.Error(),
+		)
+	})
+
+	t.Run("keep installed registry with invalid spec in warning state", func(t *testing.T) {
+		r := &reconciler{
+			k8s: k8s{
+				client: fake.NewClientBuilder().Build(),
+			},
+		}
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				Spec: v1alpha1.DockerRegistrySpec{
+					Replicas: ptr.To[int32](3),
+				},
+			},
+		}
+		s.instance.UpdateConditionTrue(v1alpha1.ConditionTypeInstalled, v1alpha1.ConditionReasonInstalled, "DockerRegistry installed")
+
+		next, result, err := sFnInitialize(context.Background(), r, s)
+		require.ErrorIs(t, err, internalerrors.ErrInvalidSpec)
+		require.True(t, internalerrors.IsTerminal(err))
+		require.Nil(t, result)
+		require.Nil(t, next)
+
+		require.Equal(t, v1alpha1.StateWarning, s.instance.Status.State)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeConfigured,
+			metav1.ConditionFalse,
+			v1alpha1.ConditionReasonInvalidSpec,
+			err.Error(),
+		)
+	})
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
 	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
@@ -31,6 +32,10 @@ func sFnUpdateFinalStatus(ctx context.Context, r *reconciler, s *systemState) (s
 		return stopWithEventualError(err)
 	}
 
+	updateSecretsCondition(ctx, r, s)
+	updateNetworkingCondition(ctx, r, s)
+	updateReadyCondition(s)
+
 	warning := s.warningBuilder.Build()
 	if warning != "" {
 		s.setState(v1alpha1.StateWarning)
@@ -48,7 +53,32 @@ func sFnUpdateFinalStatus(ctx context.Context, r *reconciler, s *systemState) (s
 		)
 	}
 
-	return stop()
+	if err := addCleanupFinalizer(ctx, r, s); err != nil {
+		return stopWithEventualError(err)
+	}
+
+	// clients compare it with .metadata.generation to know the status reflects the latest spec
+	s.instance.Status.ObservedGeneration = s.instance.GetGeneration()
+
+	// requeue to make sure the configuration is re-applied periodically
+	requeueDuration := syncPeriod(r, s)
+	if s.credentialRotationRequeueAfter > 0 && s.credentialRotationRequeueAfter < requeueDuration {
+		// don't miss the next credentials rotation step
+		requeueDuration = s.credentialRotationRequeueAfter
+	}
+	if s.deploymentUpdateDeferredBy != "" && deploymentUpdateDeferRequeueAfter < requeueDuration {
+		// retry the rollout as soon as the pod disruption budget allows it
+		requeueDuration = deploymentUpdateDeferRequeueAfter
+	}
+	return requeueAfter(requeueDuration)
+}
+
+// syncPeriod returns the sync period of the CR or the operator default one
+func syncPeriod(r *reconciler, s *systemState) time.Duration {
+	if s.instance.Spec.SyncPeriod != nil || r.syncPeriod == 0 {
+		return s.instance.GetSyncPeriod()
+	}
+	return r.syncPeriod
 }
 
 func updateStatus(ctx context.Context, r *reconciler, s *systemState) error {
@@ -102,10 +132,16 @@ func getExternalAccessFields(ctx context.Context, r *reconciler, s *systemState)
 		return nil
 	}
 
+	pushAddress := resolvedAccess.Host
+	if s.instance.Spec.ReadOnly || s.instance.Spec.Proxy != nil {
+		// read-only registry and pull-through cache can't be used to push images
+		pushAddress = ""
+	}
+
 	return fieldsToUpdate{
 		{"True", &s.instance.Status.ExternalAccess.Enabled, "External access enabled", ""},
 		{resolvedAccess.Host, &s.instance.Status.ExternalAccess.PullAddress, "External pull address", ""},
-		{resolvedAccess.Host, &s.instance.Status.ExternalAccess.PushAddress, "External push address", ""},
+		{pushAddress, &s.instance.Status.ExternalAccess.PushAddress, "External push address", ""},
 		{resolvedAccess.Gateway, &s.instance.Status.ExternalAccess.Gateway, "External gateway namespaced name", ""},
 		{registry.ExternalAccessSecretName, &s.instance.Status.ExternalAccess.SecretName, "Name of secret with registry external access data", ""},
 	}
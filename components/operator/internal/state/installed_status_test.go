@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
 	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
@@ -14,8 +15,11 @@ import (
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
@@ -25,8 +29,10 @@ func Test_sFnConfigurationStatus(t *testing.T) {
 		s := &systemState{
 			instance: v1alpha1.DockerRegistry{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:      "test-name",
-					Namespace: "test-namespace",
+					Finalizers: []string{v1alpha1.CleanupFinalizer},
+					Name:       "test-name",
+					Namespace:  "test-namespace",
+					Generation: 4,
 				},
 				Spec: v1alpha1.DockerRegistrySpec{
 					Storage: &v1alpha1.Storage{
@@ -46,12 +52,15 @@ func Test_sFnConfigurationStatus(t *testing.T) {
 			warningBuilder: warning.NewBuilder(),
 		}
 
-		c := fake.NewClientBuilder().Build()
+		c := fake.NewClientBuilder().WithObjects(
+			fixAccessSecret("test-namespace", registry.InternalAccessSecretName),
+			fixAccessSecret("test-namespace", registry.ExternalAccessSecretName),
+		).Build()
 		eventRecorder := record.NewFakeRecorder(11)
 		r := &reconciler{log: zap.NewNop().Sugar(), k8s: k8s{client: c, EventRecorder: eventRecorder}}
 		next, result, err := sFnUpdateFinalStatus(context.TODO(), r, s)
 		require.NoError(t, err)
-		require.Nil(t, result)
+		require.Equal(t, &ctrl.Result{RequeueAfter: v1alpha1.DefaultSyncPeriod}, result)
 		require.Nil(t, next)
 
 		status := s.instance.Status
@@ -66,6 +75,7 @@ func Test_sFnConfigurationStatus(t *testing.T) {
 		require.Equal(t, "True", status.DeleteEnabled)
 
 		require.Equal(t, FilesystemStorageName, status.Storage)
+		require.Equal(t, int64(4), status.ObservedGeneration)
 
 		require.Equal(t, v1alpha1.StateReady, status.State)
 		requireContainsCondition(t, status,
@@ -80,7 +90,8 @@ func Test_sFnConfigurationStatus(t *testing.T) {
 		s := &systemState{
 			instance: v1alpha1.DockerRegistry{
 				ObjectMeta: metav1.ObjectMeta{
-					Namespace: "test-namespace",
+					Finalizers: []string{v1alpha1.CleanupFinalizer},
+					Namespace:  "test-namespace",
 				},
 				Spec: v1alpha1.DockerRegistrySpec{
 					Storage: &v1alpha1.Storage{
@@ -97,12 +108,12 @@ func Test_sFnConfigurationStatus(t *testing.T) {
 		}
 
 		s.warningBuilder.With("test warning")
-		c := fake.NewClientBuilder().Build()
+		c := fake.NewClientBuilder().WithObjects(fixAccessSecret("test-namespace", registry.InternalAccessSecretName)).Build()
 		eventRecorder := record.NewFakeRecorder(11)
 		r := &reconciler{log: zap.NewNop().Sugar(), k8s: k8s{client: c, EventRecorder: eventRecorder}}
 		next, result, err := sFnUpdateFinalStatus(context.TODO(), r, s)
 		require.NoError(t, err)
-		require.Nil(t, result)
+		require.Equal(t, &ctrl.Result{RequeueAfter: v1alpha1.DefaultSyncPeriod}, result)
 		require.Nil(t, next)
 
 		status := s.instance.Status
@@ -123,11 +134,100 @@ func Test_sFnConfigurationStatus(t *testing.T) {
 		)
 	})
 
+	t.Run("skip external push address in read-only mode", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				ObjectMeta: metav1.ObjectMeta{
+					Finalizers: []string{v1alpha1.CleanupFinalizer},
+					Name:       "test-name",
+					Namespace:  "test-namespace",
+				},
+				Spec: v1alpha1.DockerRegistrySpec{
+					ReadOnly: true,
+					ExternalAccess: &v1alpha1.ExternalAccess{
+						Enabled: ptr.To(true),
+					},
+				},
+			},
+			flagsBuilder:     flags.NewBuilder(),
+			nodePortResolver: registry.NewNodePortResolver(registry.RandomNodePort),
+			gatewayHostResolver: &testExternalAddressResolver{expectedAccess: &registry.ResolvedAccess{
+				Host:    "registry-test-name-test-namespace.cluster.local",
+				Gateway: "kyma-system/kyma-gateway",
+			}},
+			warningBuilder: warning.NewBuilder(),
+		}
+
+		c := fake.NewClientBuilder().Build()
+		eventRecorder := record.NewFakeRecorder(11)
+		r := &reconciler{log: zap.NewNop().Sugar(), k8s: k8s{client: c, EventRecorder: eventRecorder}}
+		_, _, err := sFnUpdateFinalStatus(context.TODO(), r, s)
+		require.NoError(t, err)
+
+		status := s.instance.Status
+		require.Equal(t, "registry-test-name-test-namespace.cluster.local", status.ExternalAccess.PullAddress)
+		require.Empty(t, status.ExternalAccess.PushAddress)
+	})
+
+	t.Run("requeue after custom sync period", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				ObjectMeta: metav1.ObjectMeta{
+					Finalizers: []string{v1alpha1.CleanupFinalizer},
+					Namespace:  "test-namespace",
+				},
+				Spec: v1alpha1.DockerRegistrySpec{
+					SyncPeriod: &metav1.Duration{Duration: 5 * time.Minute},
+				},
+			},
+			flagsBuilder:        flags.NewBuilder(),
+			nodePortResolver:    registry.NewNodePortResolver(registry.RandomNodePort),
+			gatewayHostResolver: &testExternalAddressResolver{},
+			warningBuilder:      warning.NewBuilder(),
+		}
+
+		c := fake.NewClientBuilder().Build()
+		eventRecorder := record.NewFakeRecorder(11)
+		r := &reconciler{log: zap.NewNop().Sugar(), k8s: k8s{client: c, EventRecorder: eventRecorder}}
+		next, result, err := sFnUpdateFinalStatus(context.TODO(), r, s)
+		require.NoError(t, err)
+		require.Equal(t, &ctrl.Result{RequeueAfter: 5 * time.Minute}, result)
+		require.Nil(t, next)
+	})
+
+	t.Run("requeue after operator sync period", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				ObjectMeta: metav1.ObjectMeta{
+					Finalizers: []string{v1alpha1.CleanupFinalizer},
+					Namespace:  "test-namespace",
+				},
+			},
+			flagsBuilder:        flags.NewBuilder(),
+			nodePortResolver:    registry.NewNodePortResolver(registry.RandomNodePort),
+			gatewayHostResolver: &testExternalAddressResolver{},
+			warningBuilder:      warning.NewBuilder(),
+		}
+
+		c := fake.NewClientBuilder().Build()
+		eventRecorder := record.NewFakeRecorder(11)
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: c, EventRecorder: eventRecorder},
+			cfg: cfg{syncPeriod: 10 * time.Minute},
+		}
+		next, result, err := sFnUpdateFinalStatus(context.TODO(), r, s)
+		require.NoError(t, err)
+		require.Equal(t, &ctrl.Result{RequeueAfter: 10 * time.Minute}, result)
+		require.Nil(t, next)
+	})
+
 	t.Run("update status pvc storage configuration", func(t *testing.T) {
 		s := &systemState{
 			instance: v1alpha1.DockerRegistry{
 				ObjectMeta: metav1.ObjectMeta{
-					Namespace: "test-namespace",
+					Finalizers: []string{v1alpha1.CleanupFinalizer},
+					Namespace:  "test-namespace",
 				},
 				Spec: v1alpha1.DockerRegistrySpec{
 					Storage: &v1alpha1.Storage{
@@ -148,7 +248,7 @@ func Test_sFnConfigurationStatus(t *testing.T) {
 		r := &reconciler{log: zap.NewNop().Sugar(), k8s: k8s{client: c, EventRecorder: eventRecorder}}
 		next, result, err := sFnUpdateFinalStatus(context.TODO(), r, s)
 		require.NoError(t, err)
-		require.Nil(t, result)
+		require.Equal(t, &ctrl.Result{RequeueAfter: v1alpha1.DefaultSyncPeriod}, result)
 		require.Nil(t, next)
 
 		status := s.instance.Status
@@ -166,7 +266,8 @@ func Test_sFnConfigurationStatus(t *testing.T) {
 		s := &systemState{
 			instance: v1alpha1.DockerRegistry{
 				ObjectMeta: metav1.ObjectMeta{
-					Namespace: "test-namespace",
+					Finalizers: []string{v1alpha1.CleanupFinalizer},
+					Namespace:  "test-namespace",
 				},
 				Status: v1alpha1.DockerRegistryStatus{
 					Conditions: []metav1.Condition{
@@ -197,14 +298,14 @@ func Test_sFnConfigurationStatus(t *testing.T) {
 		r := &reconciler{
 			log: zap.NewNop().Sugar(),
 			k8s: k8s{
-				client:        fake.NewClientBuilder().WithObjects(secret).Build(),
+				client:        fake.NewClientBuilder().WithObjects(secret, fixAccessSecret("test-namespace", registry.InternalAccessSecretName)).Build(),
 				EventRecorder: record.NewFakeRecorder(11),
 			},
 		}
 
 		next, result, err := sFnUpdateFinalStatus(context.Background(), r, s)
 		require.NoError(t, err)
-		require.Nil(t, result)
+		require.Equal(t, &ctrl.Result{RequeueAfter: v1alpha1.DefaultSyncPeriod}, result)
 		require.Nil(t, next)
 		require.Equal(t, v1alpha1.StateReady, s.instance.Status.State)
 		requireContainsCondition(t, s.instance.Status,
@@ -214,6 +315,41 @@ func Test_sFnConfigurationStatus(t *testing.T) {
 			"DockerRegistry installed",
 		)
 	})
+
+	t.Run("register cleanup finalizer after successful installation", func(t *testing.T) {
+		testScheme := runtime.NewScheme()
+		require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+		require.NoError(t, v1alpha1.AddToScheme(testScheme))
+
+		instance := v1alpha1.DockerRegistry{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "test-name",
+				Namespace:       "test-namespace",
+				ResourceVersion: "123",
+			},
+		}
+		s := &systemState{
+			instance:            *instance.DeepCopy(),
+			flagsBuilder:        flags.NewBuilder(),
+			nodePortResolver:    registry.NewNodePortResolver(registry.RandomNodePort),
+			gatewayHostResolver: &testExternalAddressResolver{},
+			warningBuilder:      warning.NewBuilder(),
+		}
+
+		c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+			&instance,
+			fixAccessSecret("test-namespace", registry.InternalAccessSecretName),
+		).Build()
+		r := &reconciler{log: zap.NewNop().Sugar(), k8s: k8s{client: c, EventRecorder: record.NewFakeRecorder(11)}}
+		_, _, err := sFnUpdateFinalStatus(context.TODO(), r, s)
+		require.NoError(t, err)
+		require.Contains(t, s.instance.GetFinalizers(), v1alpha1.CleanupFinalizer)
+		require.Equal(t, v1alpha1.StateReady, s.instance.Status.State)
+
+		obj := v1alpha1.DockerRegistry{}
+		require.NoError(t, c.Get(context.TODO(), client.ObjectKeyFromObject(&instance), &obj))
+		require.Contains(t, obj.GetFinalizers(), v1alpha1.CleanupFinalizer)
+	})
 }
 
 type testExternalAddressResolver struct {
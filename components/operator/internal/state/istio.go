@@ -0,0 +1,169 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	internalerrors "github.com/kyma-project/docker-registry/components/operator/internal/errors"
+	"github.com/kyma-project/docker-registry/components/operator/internal/registry"
+	internalresource "github.com/kyma-project/docker-registry/components/operator/internal/resource"
+	"github.com/kyma-project/docker-registry/components/operator/internal/tracing"
+	"github.com/pkg/errors"
+	networkingv1beta1api "istio.io/api/networking/v1beta1"
+	securityv1beta1api "istio.io/api/security/v1beta1"
+	typev1beta1 "istio.io/api/type/v1beta1"
+	networkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	securityv1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	peerAuthenticationName  = "dockerregistry"
+	authorizationPolicyName = "dockerregistry"
+	gatewayName             = "dockerregistry"
+)
+
+var gatewayTLSModes = map[string]networkingv1beta1api.ServerTLSSettings_TLSmode{
+	"SIMPLE":       networkingv1beta1api.ServerTLSSettings_SIMPLE,
+	"MUTUAL":       networkingv1beta1api.ServerTLSSettings_MUTUAL,
+	"PASSTHROUGH":  networkingv1beta1api.ServerTLSSettings_PASSTHROUGH,
+	"ISTIO_MUTUAL": networkingv1beta1api.ServerTLSSettings_ISTIO_MUTUAL,
+}
+
+// manage Istio resources securing the registry workload
+func sFnIstioConfiguration(ctx context.Context, r *reconciler, s *systemState) (stateFn, *ctrl.Result, error) {
+	resourceClient := newResourceClient(r, s)
+
+	upsertCtx, span := tracing.StartSpan(ctx, "IstioResourcesUpsert")
+	err := reconcilePeerAuthentication(upsertCtx, resourceClient, s)
+	if err == nil {
+		err = reconcileAuthorizationPolicy(upsertCtx, resourceClient, s)
+	}
+	if err == nil {
+		err = reconcileGateway(upsertCtx, resourceClient, s)
+	}
+	err = internalerrors.ErrIstioResourceFailed.Wrap(err)
+	tracing.EndSpan(span, err)
+	if err != nil {
+		r.log.Warnf("error while reconciling istio resources %s: %s",
+			client.ObjectKeyFromObject(&s.instance), err.Error())
+		s.setState(v1alpha1.StateError)
+		s.instance.UpdateConditionFalse(
+			v1alpha1.ConditionTypeInstalled,
+			v1alpha1.ConditionReasonInstallationErr,
+			err,
+		)
+		return stopWithEventualError(err)
+	}
+
+	return nextState(sFnVerifyResources)
+}
+
+func reconcilePeerAuthentication(ctx context.Context, c internalresource.Client, s *systemState) error {
+	peerAuthentication := &securityv1beta1.PeerAuthentication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      peerAuthenticationName,
+			Namespace: s.instance.GetNamespace(),
+		},
+	}
+
+	if !s.instance.IsIstioStrictMTLSEnabled() {
+		return deleteOwnedResource(ctx, c, s, peerAuthentication, "peer authentication")
+	}
+
+	err := c.UpsertWithReference(ctx, &s.instance, peerAuthentication, func() error {
+		peerAuthentication.Spec.Selector = &typev1beta1.WorkloadSelector{
+			MatchLabels: registryPodLabels(s),
+		}
+		peerAuthentication.Spec.Mtls = &securityv1beta1api.PeerAuthentication_MutualTLS{
+			Mode: securityv1beta1api.PeerAuthentication_MutualTLS_STRICT,
+		}
+		return nil
+	})
+	return errors.Wrap(err, "while applying peer authentication")
+}
+
+func reconcileAuthorizationPolicy(ctx context.Context, c internalresource.Client, s *systemState) error {
+	authorizationPolicy := &securityv1beta1.AuthorizationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      authorizationPolicyName,
+			Namespace: s.instance.GetNamespace(),
+		},
+	}
+
+	principals := s.instance.GetIstioAllowedPrincipals()
+	if len(principals) == 0 {
+		// empty list means deny-nothing, the registry stays reachable by all workloads
+		return deleteOwnedResource(ctx, c, s, authorizationPolicy, "authorization policy")
+	}
+
+	err := c.UpsertWithReference(ctx, &s.instance, authorizationPolicy, func() error {
+		authorizationPolicy.Spec.Selector = &typev1beta1.WorkloadSelector{
+			MatchLabels: registryPodLabels(s),
+		}
+		authorizationPolicy.Spec.Action = securityv1beta1api.AuthorizationPolicy_ALLOW
+		authorizationPolicy.Spec.Rules = []*securityv1beta1api.Rule{
+			{
+				From: []*securityv1beta1api.Rule_From{
+					{Source: &securityv1beta1api.Source{Principals: principals}},
+				},
+				To: []*securityv1beta1api.Rule_To{
+					{Operation: &securityv1beta1api.Operation{Ports: []string{strconv.Itoa(registry.ServicePort)}}},
+				},
+			},
+		}
+		return nil
+	})
+	return errors.Wrap(err, "while applying authorization policy")
+}
+
+func reconcileGateway(ctx context.Context, c internalresource.Client, s *systemState) error {
+	gateway := &networkingv1beta1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gatewayName,
+			Namespace: s.instance.GetNamespace(),
+		},
+	}
+
+	if !s.instance.IsIstioGatewayManaged() {
+		return deleteOwnedResource(ctx, c, s, gateway, "gateway")
+	}
+
+	err := c.UpsertWithReference(ctx, &s.instance, gateway, func() error {
+		gateway.Spec.Selector = s.instance.GetIstioGatewaySelector()
+		gateway.Spec.Servers = gatewayServers(s.instance.Spec.Istio.Gateway.Servers)
+		return nil
+	})
+	return errors.Wrap(err, "while applying gateway")
+}
+
+func gatewayServers(servers []v1alpha1.IstioGatewayServer) []*networkingv1beta1api.Server {
+	result := make([]*networkingv1beta1api.Server, 0, len(servers))
+	for _, server := range servers {
+		gatewayServer := &networkingv1beta1api.Server{
+			Port: &networkingv1beta1api.Port{
+				Number:   server.Port.Number,
+				Protocol: server.Port.Protocol,
+				Name:     server.Port.Name,
+			},
+			Hosts: server.Hosts,
+		}
+		if server.TLS != nil {
+			gatewayServer.Tls = &networkingv1beta1api.ServerTLSSettings{
+				Mode:           gatewayTLSModes[server.TLS.Mode],
+				CredentialName: server.TLS.CredentialName,
+			}
+		}
+		result = append(result, gatewayServer)
+	}
+	return result
+}
+
+// managedGatewayReference returns the gateway in the <namespace>/<name> format used by the VirtualService
+func managedGatewayReference(s *systemState) string {
+	return fmt.Sprintf("%s/%s", s.instance.GetNamespace(), gatewayName)
+}
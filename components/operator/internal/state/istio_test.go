@@ -0,0 +1,260 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	networkingv1beta1api "istio.io/api/networking/v1beta1"
+	securityv1beta1api "istio.io/api/security/v1beta1"
+	networkingv1beta1 "istio.io/client-go/pkg/apis/networking/v1beta1"
+	securityv1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func Test_sFnIstioConfiguration(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+	require.NoError(t, v1alpha1.AddToScheme(testScheme))
+	require.NoError(t, networkingv1beta1.AddToScheme(testScheme))
+	require.NoError(t, securityv1beta1.AddToScheme(testScheme))
+
+	peerAuthenticationKey := client.ObjectKey{Name: peerAuthenticationName, Namespace: "kyma-system"}
+	strictMTLS := &v1alpha1.Istio{
+		Enabled: true,
+		MTLS:    &v1alpha1.IstioMTLS{Mode: v1alpha1.IstioMTLSModeStrict},
+	}
+
+	t.Run("create strict peer authentication", func(t *testing.T) {
+		s := fixPDBSystemState(v1alpha1.DockerRegistrySpec{Istio: strictMTLS})
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).Build()},
+		}
+
+		next, result, err := sFnIstioConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnVerifyResources, next)
+
+		peerAuthentication := &securityv1beta1.PeerAuthentication{}
+		require.NoError(t, r.client.Get(context.Background(), peerAuthenticationKey, peerAuthentication))
+		require.Equal(t, securityv1beta1api.PeerAuthentication_MutualTLS_STRICT, peerAuthentication.Spec.Mtls.Mode)
+		require.Equal(t, map[string]string{"app": "docker-registry", "release": "dockerregistry"}, peerAuthentication.Spec.Selector.MatchLabels)
+		require.True(t, metav1.IsControlledBy(peerAuthentication, &s.instance))
+	})
+
+	t.Run("update existing peer authentication", func(t *testing.T) {
+		s := fixPDBSystemState(v1alpha1.DockerRegistrySpec{Istio: strictMTLS})
+		existing := fixOwnedPeerAuthentication(t, testScheme, s)
+		existing.Spec.Mtls = &securityv1beta1api.PeerAuthentication_MutualTLS{
+			Mode: securityv1beta1api.PeerAuthentication_MutualTLS_PERMISSIVE,
+		}
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithReturnManagedFields().Build()},
+		}
+		// created by the operator version updating resources without the server-side apply
+		require.NoError(t, r.client.Create(context.Background(), existing, client.FieldOwner("operator")))
+
+		_, _, err := sFnIstioConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+
+		peerAuthentication := &securityv1beta1.PeerAuthentication{}
+		require.NoError(t, r.client.Get(context.Background(), peerAuthenticationKey, peerAuthentication))
+		require.Equal(t, securityv1beta1api.PeerAuthentication_MutualTLS_STRICT, peerAuthentication.Spec.Mtls.Mode)
+	})
+
+	t.Run("delete peer authentication when istio is disabled", func(t *testing.T) {
+		s := fixPDBSystemState(v1alpha1.DockerRegistrySpec{
+			Istio: &v1alpha1.Istio{
+				Enabled: false,
+				MTLS:    &v1alpha1.IstioMTLS{Mode: v1alpha1.IstioMTLSModeStrict},
+			},
+		})
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(fixOwnedPeerAuthentication(t, testScheme, s)).Build()},
+		}
+
+		_, _, err := sFnIstioConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+
+		err = r.client.Get(context.Background(), peerAuthenticationKey, &securityv1beta1.PeerAuthentication{})
+		require.True(t, k8serrors.IsNotFound(err))
+	})
+
+	t.Run("keep peer authentication created by the user", func(t *testing.T) {
+		s := fixPDBSystemState(v1alpha1.DockerRegistrySpec{})
+		userPeerAuthentication := &securityv1beta1.PeerAuthentication{
+			ObjectMeta: metav1.ObjectMeta{Name: peerAuthenticationName, Namespace: "kyma-system"},
+		}
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(userPeerAuthentication).Build()},
+		}
+
+		_, _, err := sFnIstioConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.NoError(t, r.client.Get(context.Background(), peerAuthenticationKey, &securityv1beta1.PeerAuthentication{}))
+	})
+}
+
+func Test_reconcileAuthorizationPolicy(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+	require.NoError(t, v1alpha1.AddToScheme(testScheme))
+	require.NoError(t, networkingv1beta1.AddToScheme(testScheme))
+	require.NoError(t, securityv1beta1.AddToScheme(testScheme))
+
+	authorizationPolicyKey := client.ObjectKey{Name: authorizationPolicyName, Namespace: "kyma-system"}
+	fixIstio := func(principals ...string) *v1alpha1.Istio {
+		return &v1alpha1.Istio{
+			Enabled: true,
+			AuthorizationPolicy: &v1alpha1.IstioAuthorizationPolicy{
+				AllowedPrincipals: principals,
+			},
+		}
+	}
+
+	t.Run("create authorization policy allowing principals", func(t *testing.T) {
+		s := fixPDBSystemState(v1alpha1.DockerRegistrySpec{
+			Istio: fixIstio("cluster.local/ns/ci/sa/builder"),
+		})
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).Build()},
+		}
+
+		_, _, err := sFnIstioConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+
+		authorizationPolicy := &securityv1beta1.AuthorizationPolicy{}
+		require.NoError(t, r.client.Get(context.Background(), authorizationPolicyKey, authorizationPolicy))
+		require.Equal(t, securityv1beta1api.AuthorizationPolicy_ALLOW, authorizationPolicy.Spec.Action)
+		require.Equal(t, map[string]string{"app": "docker-registry", "release": "dockerregistry"}, authorizationPolicy.Spec.Selector.MatchLabels)
+		require.Len(t, authorizationPolicy.Spec.Rules, 1)
+		require.Equal(t, []string{"cluster.local/ns/ci/sa/builder"}, authorizationPolicy.Spec.Rules[0].From[0].Source.Principals)
+		require.Equal(t, []string{"5000"}, authorizationPolicy.Spec.Rules[0].To[0].Operation.Ports)
+		require.True(t, metav1.IsControlledBy(authorizationPolicy, &s.instance))
+	})
+
+	t.Run("update principals of existing authorization policy", func(t *testing.T) {
+		s := fixPDBSystemState(v1alpha1.DockerRegistrySpec{
+			Istio: fixIstio("cluster.local/ns/ci/sa/builder", "cluster.local/ns/prod/sa/app"),
+		})
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(fixOwnedAuthorizationPolicy(t, testScheme, s)).Build()},
+		}
+
+		_, _, err := sFnIstioConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+
+		authorizationPolicy := &securityv1beta1.AuthorizationPolicy{}
+		require.NoError(t, r.client.Get(context.Background(), authorizationPolicyKey, authorizationPolicy))
+		require.Equal(t, []string{"cluster.local/ns/ci/sa/builder", "cluster.local/ns/prod/sa/app"}, authorizationPolicy.Spec.Rules[0].From[0].Source.Principals)
+	})
+
+	t.Run("delete authorization policy when principals list is empty", func(t *testing.T) {
+		s := fixPDBSystemState(v1alpha1.DockerRegistrySpec{Istio: fixIstio()})
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(fixOwnedAuthorizationPolicy(t, testScheme, s)).Build()},
+		}
+
+		_, _, err := sFnIstioConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+
+		err = r.client.Get(context.Background(), authorizationPolicyKey, &securityv1beta1.AuthorizationPolicy{})
+		require.True(t, k8serrors.IsNotFound(err))
+	})
+}
+
+func Test_reconcileGateway(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+	require.NoError(t, v1alpha1.AddToScheme(testScheme))
+	require.NoError(t, networkingv1beta1.AddToScheme(testScheme))
+	require.NoError(t, securityv1beta1.AddToScheme(testScheme))
+
+	gatewayKey := client.ObjectKey{Name: gatewayName, Namespace: "kyma-system"}
+	fixIstio := func(create bool) *v1alpha1.Istio {
+		return &v1alpha1.Istio{
+			Enabled: true,
+			Gateway: &v1alpha1.IstioGateway{
+				Create: create,
+				Servers: []v1alpha1.IstioGatewayServer{
+					{
+						Port:  v1alpha1.IstioGatewayPort{Number: 443, Name: "https", Protocol: "HTTPS"},
+						Hosts: []string{"registry.example.com"},
+						TLS:   &v1alpha1.IstioGatewayTLS{Mode: "SIMPLE", CredentialName: "registry-cert"},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("create gateway with default selector", func(t *testing.T) {
+		s := fixPDBSystemState(v1alpha1.DockerRegistrySpec{Istio: fixIstio(true)})
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).Build()},
+		}
+
+		_, _, err := sFnIstioConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+
+		gateway := &networkingv1beta1.Gateway{}
+		require.NoError(t, r.client.Get(context.Background(), gatewayKey, gateway))
+		require.Equal(t, map[string]string{"istio": "ingressgateway"}, gateway.Spec.Selector)
+		require.Len(t, gateway.Spec.Servers, 1)
+		require.Equal(t, uint32(443), gateway.Spec.Servers[0].Port.Number)
+		require.Equal(t, []string{"registry.example.com"}, gateway.Spec.Servers[0].Hosts)
+		require.Equal(t, networkingv1beta1api.ServerTLSSettings_SIMPLE, gateway.Spec.Servers[0].Tls.Mode)
+		require.Equal(t, "registry-cert", gateway.Spec.Servers[0].Tls.CredentialName)
+		require.True(t, metav1.IsControlledBy(gateway, &s.instance))
+	})
+
+	t.Run("delete gateway when create flag is removed", func(t *testing.T) {
+		s := fixPDBSystemState(v1alpha1.DockerRegistrySpec{Istio: fixIstio(false)})
+		gateway := &networkingv1beta1.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Name: gatewayName, Namespace: "kyma-system"},
+		}
+		require.NoError(t, controllerutil.SetControllerReference(&s.instance, gateway, testScheme))
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(gateway).Build()},
+		}
+
+		_, _, err := sFnIstioConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+
+		err = r.client.Get(context.Background(), gatewayKey, &networkingv1beta1.Gateway{})
+		require.True(t, k8serrors.IsNotFound(err))
+	})
+}
+
+func fixOwnedAuthorizationPolicy(t *testing.T, scheme *runtime.Scheme, s *systemState) *securityv1beta1.AuthorizationPolicy {
+	authorizationPolicy := &securityv1beta1.AuthorizationPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: authorizationPolicyName, Namespace: "kyma-system"},
+	}
+	require.NoError(t, controllerutil.SetControllerReference(&s.instance, authorizationPolicy, scheme))
+	return authorizationPolicy
+}
+
+func fixOwnedPeerAuthentication(t *testing.T, scheme *runtime.Scheme, s *systemState) *securityv1beta1.PeerAuthentication {
+	peerAuthentication := &securityv1beta1.PeerAuthentication{
+		ObjectMeta: metav1.ObjectMeta{Name: peerAuthenticationName, Namespace: "kyma-system"},
+	}
+	require.NoError(t, controllerutil.SetControllerReference(&s.instance, peerAuthentication, scheme))
+	return peerAuthentication
+}
@@ -0,0 +1,80 @@
+package state
+
+import (
+	"context"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/registry"
+	internalresource "github.com/kyma-project/docker-registry/components/operator/internal/resource"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	networkPolicyName = "dockerregistry"
+)
+
+func prepareNetworkPolicy(s *systemState) {
+	if s.instance.IsNetworkPolicyEnabled() {
+		s.flagsBuilder.WithoutRegistryAPINetworkPolicy()
+	}
+}
+
+// restrict the ingress traffic to the registry port to the configured peers
+func sFnNetworkPolicy(ctx context.Context, r *reconciler, s *systemState) (stateFn, *ctrl.Result, error) {
+	err := reconcileNetworkPolicy(ctx, newResourceClient(r, s), s)
+	if err != nil {
+		r.log.Warnf("error while reconciling network policy %s: %s",
+			client.ObjectKeyFromObject(&s.instance), err.Error())
+		s.setState(v1alpha1.StateError)
+		s.instance.UpdateConditionFalse(
+			v1alpha1.ConditionTypeInstalled,
+			v1alpha1.ConditionReasonInstallationErr,
+			err,
+		)
+		return stopWithEventualError(err)
+	}
+
+	return nextState(sFnServiceMonitor)
+}
+
+func reconcileNetworkPolicy(ctx context.Context, c internalresource.Client, s *systemState) error {
+	networkPolicy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      networkPolicyName,
+			Namespace: s.instance.GetNamespace(),
+		},
+	}
+
+	if !s.instance.IsNetworkPolicyEnabled() {
+		return deleteOwnedResource(ctx, c, s, networkPolicy, "network policy")
+	}
+
+	// without peers the rule allows all sources, the kubelet pulls images through the NodePort
+	// and the gateway traffic comes from other namespaces, only the registry port stays reachable
+	peers := s.instance.Spec.NetworkPolicy.IngressFrom
+
+	err := c.UpsertWithReference(ctx, &s.instance, networkPolicy, func() error {
+		port := intstr.FromInt32(registry.ServicePort)
+		protocol := corev1.ProtocolTCP
+		networkPolicy.Spec = networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{
+				MatchLabels: registryPodLabels(s),
+			},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+			Ingress: []networkingv1.NetworkPolicyIngressRule{
+				{
+					From:  peers,
+					Ports: []networkingv1.NetworkPolicyPort{{Protocol: &protocol, Port: &port}},
+				},
+			},
+		}
+		return nil
+	})
+	return errors.Wrap(err, "while applying network policy")
+}
@@ -0,0 +1,123 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	networkingv1 "k8s.io/api/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func Test_sFnNetworkPolicy(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+	require.NoError(t, v1alpha1.AddToScheme(testScheme))
+
+	networkPolicyKey := client.ObjectKey{Name: networkPolicyName, Namespace: "kyma-system"}
+
+	t.Run("allow all sources to the registry port by default", func(t *testing.T) {
+		s := fixPDBSystemState(v1alpha1.DockerRegistrySpec{
+			NetworkPolicy: &v1alpha1.NetworkPolicy{Enabled: true},
+		})
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).Build()},
+		}
+
+		next, result, err := sFnNetworkPolicy(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnServiceMonitor, next)
+
+		networkPolicy := &networkingv1.NetworkPolicy{}
+		require.NoError(t, r.client.Get(context.Background(), networkPolicyKey, networkPolicy))
+		require.Equal(t, map[string]string{"app": "docker-registry", "release": "dockerregistry"}, networkPolicy.Spec.PodSelector.MatchLabels)
+		require.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}, networkPolicy.Spec.PolicyTypes)
+		require.Len(t, networkPolicy.Spec.Ingress, 1)
+		require.Empty(t, networkPolicy.Spec.Ingress[0].From)
+		require.Equal(t, int32(5000), networkPolicy.Spec.Ingress[0].Ports[0].Port.IntVal)
+		require.True(t, metav1.IsControlledBy(networkPolicy, &s.instance))
+	})
+
+	t.Run("allow only configured peers", func(t *testing.T) {
+		peers := []networkingv1.NetworkPolicyPeer{
+			{
+				NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "ci"}},
+				PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "builder"}},
+			},
+		}
+		s := fixPDBSystemState(v1alpha1.DockerRegistrySpec{
+			NetworkPolicy: &v1alpha1.NetworkPolicy{Enabled: true, IngressFrom: peers},
+		})
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(fixOwnedNetworkPolicy(t, testScheme, s)).Build()},
+		}
+
+		_, _, err := sFnNetworkPolicy(context.Background(), r, s)
+		require.NoError(t, err)
+
+		networkPolicy := &networkingv1.NetworkPolicy{}
+		require.NoError(t, r.client.Get(context.Background(), networkPolicyKey, networkPolicy))
+		require.Equal(t, peers, networkPolicy.Spec.Ingress[0].From)
+	})
+
+	t.Run("delete network policy when disabled", func(t *testing.T) {
+		s := fixPDBSystemState(v1alpha1.DockerRegistrySpec{})
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(fixOwnedNetworkPolicy(t, testScheme, s)).Build()},
+		}
+
+		_, _, err := sFnNetworkPolicy(context.Background(), r, s)
+		require.NoError(t, err)
+
+		err = r.client.Get(context.Background(), networkPolicyKey, &networkingv1.NetworkPolicy{})
+		require.True(t, k8serrors.IsNotFound(err))
+	})
+}
+
+func Test_prepareNetworkPolicy(t *testing.T) {
+	t.Run("remove chart policy allowing all traffic", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{Spec: v1alpha1.DockerRegistrySpec{
+				NetworkPolicy: &v1alpha1.NetworkPolicy{Enabled: true},
+			}},
+			flagsBuilder: flags.NewBuilder(),
+		}
+
+		prepareNetworkPolicy(s)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"allowRegistryAPI": false}, flags["networkPolicy"])
+	})
+
+	t.Run("keep chart policy when disabled", func(t *testing.T) {
+		s := &systemState{flagsBuilder: flags.NewBuilder()}
+
+		prepareNetworkPolicy(s)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.NotContains(t, flags, "networkPolicy")
+	})
+}
+
+func fixOwnedNetworkPolicy(t *testing.T, scheme *runtime.Scheme, s *systemState) *networkingv1.NetworkPolicy {
+	networkPolicy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: networkPolicyName, Namespace: "kyma-system"},
+	}
+	require.NoError(t, controllerutil.SetControllerReference(&s.instance, networkPolicy, scheme))
+	return networkPolicy
+}
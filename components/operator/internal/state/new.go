@@ -3,10 +3,13 @@ package state
 import (
 	"context"
 	"os"
+	"time"
 
 	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/audit"
 	"github.com/kyma-project/manager-toolkit/installation/chart"
 	"go.uber.org/zap"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -17,19 +20,27 @@ type StateReconciler interface {
 	Reconcile(ctx context.Context, v v1alpha1.DockerRegistry) (ctrl.Result, error)
 }
 
-func NewMachine(client client.Client, config *rest.Config, recorder record.EventRecorder, log *zap.SugaredLogger, cache chart.ManifestCache, chartPath string) StateReconciler {
+func NewMachine(client client.Client, config *rest.Config, clientset kubernetes.Interface, recorder record.EventRecorder, log *zap.SugaredLogger, cache chart.ManifestCache, auditLog *audit.Logger, chartPath, operatorVersion, chartVersion string, deletionTimeout, syncPeriod, applyGracePeriod time.Duration) StateReconciler {
 	return &reconciler{
-		fn:    sFnServedFilter,
-		cache: cache,
-		log:   log,
+		fn:       sFnPausedFilter,
+		cache:    cache,
+		log:      log,
+		auditLog: auditLog,
 		cfg: cfg{
-			finalizer:     v1alpha1.Finalizer,
-			chartPath:     chartPath,
-			managerPodUID: os.Getenv("DOCKERREGISTRY_MANAGER_UID"),
+			finalizer:        v1alpha1.Finalizer,
+			chartPath:        chartPath,
+			operatorVersion:  operatorVersion,
+			chartVersion:     chartVersion,
+			deletionTimeout:  deletionTimeout,
+			syncPeriod:       syncPeriod,
+			applyGracePeriod: applyGracePeriod,
+			managerPodUID:    os.Getenv("DOCKERREGISTRY_MANAGER_UID"),
+			operatorImage:    os.Getenv("DOCKERREGISTRY_OPERATOR_IMAGE"),
 		},
 		k8s: k8s{
 			client:        client,
 			config:        config,
+			clientset:     clientset,
 			EventRecorder: recorder,
 		},
 	}
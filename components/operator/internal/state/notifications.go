@@ -0,0 +1,82 @@
+package state
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// prepareNotifications passes the notification endpoints to the registry configuration
+func prepareNotifications(ctx context.Context, r *reconciler, s *systemState) error {
+	notifications := s.instance.Spec.Notifications
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	endpoints := []map[string]interface{}{}
+	for _, notification := range notifications {
+		endpoint, err := notificationEndpoint(ctx, r, s, notification)
+		if err != nil {
+			return err
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	checksum, err := endpointsChecksum(endpoints)
+	if err != nil {
+		return err
+	}
+
+	s.flagsBuilder.WithNotifications(endpoints, checksum)
+	return nil
+}
+
+func notificationEndpoint(ctx context.Context, r *reconciler, s *systemState, notification v1alpha1.RegistryNotification) (map[string]interface{}, error) {
+	endpoint := map[string]interface{}{
+		"name": notification.Name,
+		"url":  notification.URL,
+	}
+	if notification.Timeout != nil {
+		endpoint["timeout"] = notification.Timeout.Duration.String()
+	}
+	if notification.Threshold != nil {
+		endpoint["threshold"] = *notification.Threshold
+	}
+	if notification.Backoff != nil {
+		endpoint["backoff"] = notification.Backoff.Duration.String()
+	}
+
+	if notification.HeadersSecretName == "" {
+		return endpoint, nil
+	}
+
+	secret := corev1.Secret{}
+	err := r.client.Get(ctx, client.ObjectKey{Namespace: s.instance.Namespace, Name: notification.HeadersSecretName}, &secret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "while getting headers secret %s of the %s notification endpoint", notification.HeadersSecretName, notification.Name)
+	}
+
+	headers := map[string]interface{}{}
+	for key, value := range secret.Data {
+		headers[key] = []string{string(value)}
+	}
+	endpoint["headers"] = headers
+
+	return endpoint, nil
+}
+
+// endpointsChecksum returns short checksum of the endpoints configuration including header values
+func endpointsChecksum(endpoints []map[string]interface{}) (string, error) {
+	raw, err := json.Marshal(endpoints)
+	if err != nil {
+		return "", errors.Wrap(err, "while marshalling notification endpoints")
+	}
+	hash := sha256.Sum256(raw)
+	return hex.EncodeToString(hash[:])[:16], nil
+}
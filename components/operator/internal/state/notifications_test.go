@@ -0,0 +1,131 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_prepareNotifications(t *testing.T) {
+	testCases := map[string]struct {
+		givenSecrets       []client.Object
+		givenNotifications []v1alpha1.RegistryNotification
+		expectedEndpoints  interface{}
+		expectedErr        string
+	}{
+		"no notifications": {},
+		"pass endpoints with headers": {
+			givenSecrets: []client.Object{&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "listener-headers", Namespace: "kyma-system"},
+				Data:       map[string][]byte{"Authorization": []byte("Bearer token")},
+			}},
+			givenNotifications: []v1alpha1.RegistryNotification{
+				{
+					Name:              "listener",
+					URL:               "https://listener.local/events",
+					HeadersSecretName: "listener-headers",
+					Timeout:           &metav1.Duration{Duration: 500 * time.Millisecond},
+					Threshold:         ptr.To[int32](5),
+					Backoff:           &metav1.Duration{Duration: 2 * time.Second},
+				},
+				{
+					Name: "audit",
+					URL:  "https://audit.local/events",
+				},
+			},
+			expectedEndpoints: []interface{}{
+				map[string]interface{}{
+					"name":      "listener",
+					"url":       "https://listener.local/events",
+					"timeout":   "500ms",
+					"threshold": float64(5),
+					"backoff":   "2s",
+					"headers": map[string]interface{}{
+						"Authorization": []interface{}{"Bearer token"},
+					},
+				},
+				map[string]interface{}{
+					"name": "audit",
+					"url":  "https://audit.local/events",
+				},
+			},
+		},
+		"headers secret not found": {
+			givenNotifications: []v1alpha1.RegistryNotification{
+				{Name: "listener", URL: "https://listener.local/events", HeadersSecretName: "listener-headers"},
+			},
+			expectedErr: "while getting headers secret listener-headers of the listener notification endpoint: secrets \"listener-headers\" not found",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			s := &systemState{
+				instance: v1alpha1.DockerRegistry{
+					ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "kyma-system"},
+					Spec:       v1alpha1.DockerRegistrySpec{Notifications: testCase.givenNotifications},
+				},
+				flagsBuilder: flags.NewBuilder(),
+			}
+			r := &reconciler{
+				log: zap.NewNop().Sugar(),
+				k8s: k8s{client: fake.NewClientBuilder().WithObjects(testCase.givenSecrets...).Build()},
+			}
+
+			err := prepareNotifications(context.Background(), r, s)
+			if testCase.expectedErr != "" {
+				require.EqualError(t, err, testCase.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			flags, err := s.flagsBuilder.Build()
+			require.NoError(t, err)
+			if testCase.expectedEndpoints == nil {
+				require.NotContains(t, flags, "notifications")
+				return
+			}
+			require.NotContains(t, flags, "configData")
+			require.Equal(t, testCase.expectedEndpoints, flags["notifications"].(map[string]interface{})["endpoints"])
+			require.Contains(t, flags["rollme"], "notificationsChecksum=")
+		})
+	}
+}
+
+func Test_notificationsChart(t *testing.T) {
+	t.Run("pass endpoints from secret", func(t *testing.T) {
+		flags, err := flags.NewBuilder().
+			WithNotifications([]map[string]interface{}{{
+				"name":    "listener",
+				"url":     "https://listener.local/events",
+				"headers": map[string]interface{}{"Authorization": []string{"Bearer token"}},
+			}}, "abc").
+			Build()
+		require.NoError(t, err)
+
+		deployment := renderRegistryDeployment(t, flags)
+
+		var env *corev1.EnvVar
+		for i, envVar := range deployment.Spec.Template.Spec.Containers[0].Env {
+			if envVar.Name == "REGISTRY_NOTIFICATIONS_ENDPOINTS" {
+				env = &deployment.Spec.Template.Spec.Containers[0].Env[i]
+			}
+		}
+		require.NotNil(t, env)
+		require.Empty(t, env.Value)
+		require.Equal(t, &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "dockerregistry-notifications"},
+			Key:                  "endpoints",
+		}, env.ValueFrom.SecretKeyRef)
+	})
+}
@@ -0,0 +1,34 @@
+package state
+
+import (
+	"context"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// sFnPausedFilter skips the whole reconciliation when the DockerRegistry is annotated as paused
+func sFnPausedFilter(_ context.Context, r *reconciler, s *systemState) (stateFn, *ctrl.Result, error) {
+	if !s.instance.IsPaused() {
+		return nextState(sFnServedFilter)
+	}
+
+	if isPausedConditionSet(&s.instance) {
+		return stop()
+	}
+
+	r.log.Infof("reconciliation paused by the %s annotation", v1alpha1.PausedAnnotation)
+	r.Event(&s.instance, "Normal", string(v1alpha1.ConditionReasonPaused), "Reconciliation paused")
+	s.instance.UpdateConditionUnknown(
+		v1alpha1.ConditionTypeReady,
+		v1alpha1.ConditionReasonPaused,
+		"Reconciliation paused, remove the paused annotation to resume it",
+	)
+	return stop()
+}
+
+func isPausedConditionSet(instance *v1alpha1.DockerRegistry) bool {
+	condition := meta.FindStatusCondition(instance.Status.Conditions, string(v1alpha1.ConditionTypeReady))
+	return condition != nil && condition.Reason == string(v1alpha1.ConditionReasonPaused)
+}
@@ -0,0 +1,70 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func Test_sFnPausedFilter(t *testing.T) {
+	t.Run("go to served filter when not paused", func(t *testing.T) {
+		s := &systemState{instance: v1alpha1.DockerRegistry{}}
+
+		next, result, err := sFnPausedFilter(context.Background(), nil, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnServedFilter, next)
+	})
+
+	t.Run("stop and set ready condition when paused", func(t *testing.T) {
+		s := &systemState{instance: fixPausedDockerRegistry()}
+		recorder := record.NewFakeRecorder(1)
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{EventRecorder: recorder},
+		}
+
+		next, result, err := sFnPausedFilter(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		require.Nil(t, next)
+
+		condition := meta.FindStatusCondition(s.instance.Status.Conditions, string(v1alpha1.ConditionTypeReady))
+		require.NotNil(t, condition)
+		require.Equal(t, metav1.ConditionUnknown, condition.Status)
+		require.Equal(t, string(v1alpha1.ConditionReasonPaused), condition.Reason)
+		require.Equal(t, "Normal Paused Reconciliation paused", <-recorder.Events)
+	})
+
+	t.Run("don't emit event again when already paused", func(t *testing.T) {
+		s := &systemState{instance: fixPausedDockerRegistry()}
+		s.instance.UpdateConditionUnknown(v1alpha1.ConditionTypeReady, v1alpha1.ConditionReasonPaused, "paused")
+		recorder := record.NewFakeRecorder(1)
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{EventRecorder: recorder},
+		}
+
+		next, result, err := sFnPausedFilter(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		require.Nil(t, next)
+		require.Empty(t, recorder.Events)
+	})
+}
+
+func fixPausedDockerRegistry() v1alpha1.DockerRegistry {
+	return v1alpha1.DockerRegistry{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Namespace:   "kyma-system",
+			Annotations: map[string]string{v1alpha1.PausedAnnotation: "true"},
+		},
+	}
+}
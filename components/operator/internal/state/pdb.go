@@ -0,0 +1,76 @@
+package state
+
+import (
+	"context"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/pkg/errors"
+	policyv1 "k8s.io/api/policy/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	podDisruptionBudgetName = "dockerregistry"
+)
+
+// make sure not all registry pods are evicted at once when the registry runs more than one replica
+func sFnPodDisruptionBudget(ctx context.Context, r *reconciler, s *systemState) (stateFn, *ctrl.Result, error) {
+	err := reconcilePodDisruptionBudget(ctx, r, s)
+	if err != nil {
+		r.log.Warnf("error while reconciling pod disruption budget %s: %s",
+			client.ObjectKeyFromObject(&s.instance), err.Error())
+		s.setState(v1alpha1.StateError)
+		s.instance.UpdateConditionFalse(
+			v1alpha1.ConditionTypeInstalled,
+			v1alpha1.ConditionReasonInstallationErr,
+			err,
+		)
+		return stopWithEventualError(err)
+	}
+
+	return nextState(sFnNetworkPolicy)
+}
+
+func reconcilePodDisruptionBudget(ctx context.Context, r *reconciler, s *systemState) error {
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      podDisruptionBudgetName,
+			Namespace: s.instance.GetNamespace(),
+		},
+	}
+
+	if s.instance.GetReplicas() <= 1 {
+		return deletePodDisruptionBudget(ctx, r, s, pdb)
+	}
+
+	err := newResourceClient(r, s).UpsertWithReference(ctx, &s.instance, pdb, func() error {
+		minAvailable := intstr.FromInt32(s.instance.GetPodDisruptionBudgetMinAvailable())
+		pdb.Spec.MinAvailable = &minAvailable
+		pdb.Spec.Selector = &metav1.LabelSelector{
+			MatchLabels: registryPodLabels(s),
+		}
+		return nil
+	})
+	return errors.Wrap(err, "while applying pod disruption budget")
+}
+
+func deletePodDisruptionBudget(ctx context.Context, r *reconciler, s *systemState, pdb *policyv1.PodDisruptionBudget) error {
+	err := r.client.Get(ctx, client.ObjectKeyFromObject(pdb), pdb)
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "while getting pod disruption budget")
+	}
+
+	// don't touch pod disruption budget created by the user
+	if !metav1.IsControlledBy(pdb, &s.instance) {
+		return nil
+	}
+
+	return errors.Wrap(client.IgnoreNotFound(r.client.Delete(ctx, pdb)), "while deleting pod disruption budget")
+}
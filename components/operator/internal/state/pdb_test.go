@@ -0,0 +1,144 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/manager-toolkit/installation/chart"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	policyv1 "k8s.io/api/policy/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func Test_sFnPodDisruptionBudget(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+	require.NoError(t, v1alpha1.AddToScheme(testScheme))
+
+	pdbKey := client.ObjectKey{Name: podDisruptionBudgetName, Namespace: "kyma-system"}
+
+	t.Run("create pod disruption budget for many replicas", func(t *testing.T) {
+		s := fixPDBSystemState(v1alpha1.DockerRegistrySpec{Replicas: ptr.To[int32](3)})
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).Build()},
+		}
+
+		next, result, err := sFnPodDisruptionBudget(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnNetworkPolicy, next)
+
+		pdb := &policyv1.PodDisruptionBudget{}
+		require.NoError(t, r.client.Get(context.Background(), pdbKey, pdb))
+		require.Equal(t, ptr.To(intstr.FromInt32(1)), pdb.Spec.MinAvailable)
+		require.Equal(t, map[string]string{"app": "docker-registry", "release": "dockerregistry"}, pdb.Spec.Selector.MatchLabels)
+		require.True(t, metav1.IsControlledBy(pdb, &s.instance))
+	})
+
+	t.Run("add common metadata to pod disruption budget", func(t *testing.T) {
+		s := fixPDBSystemState(v1alpha1.DockerRegistrySpec{
+			Replicas:          ptr.To[int32](3),
+			CommonLabels:      map[string]string{"team": "platform"},
+			CommonAnnotations: map[string]string{"example.com/owner": "platform"},
+		})
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).Build()},
+		}
+
+		_, _, err := sFnPodDisruptionBudget(context.Background(), r, s)
+		require.NoError(t, err)
+
+		pdb := &policyv1.PodDisruptionBudget{}
+		require.NoError(t, r.client.Get(context.Background(), pdbKey, pdb))
+		require.Equal(t, map[string]string{"team": "platform"}, pdb.GetLabels())
+		require.Equal(t, map[string]string{"example.com/owner": "platform"}, pdb.GetAnnotations())
+	})
+
+	t.Run("update min available", func(t *testing.T) {
+		s := fixPDBSystemState(v1alpha1.DockerRegistrySpec{
+			Autoscaling:         &v1alpha1.Autoscaling{MinReplicas: ptr.To[int32](3), MaxReplicas: 5},
+			PodDisruptionBudget: &v1alpha1.PodDisruptionBudget{MinAvailable: ptr.To[int32](2)},
+		})
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithReturnManagedFields().Build()},
+		}
+		// created by the operator versions updating the pod disruption budget without the server-side apply
+		require.NoError(t, r.client.Create(context.Background(), fixOwnedPDB(t, testScheme, s), client.FieldOwner("operator")))
+
+		_, _, err := sFnPodDisruptionBudget(context.Background(), r, s)
+		require.NoError(t, err)
+
+		pdb := &policyv1.PodDisruptionBudget{}
+		require.NoError(t, r.client.Get(context.Background(), pdbKey, pdb))
+		require.Equal(t, ptr.To(intstr.FromInt32(2)), pdb.Spec.MinAvailable)
+	})
+
+	t.Run("delete pod disruption budget for single replica", func(t *testing.T) {
+		s := fixPDBSystemState(v1alpha1.DockerRegistrySpec{})
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(fixOwnedPDB(t, testScheme, s)).Build()},
+		}
+
+		next, _, err := sFnPodDisruptionBudget(context.Background(), r, s)
+		require.NoError(t, err)
+		requireEqualFunc(t, sFnNetworkPolicy, next)
+
+		err = r.client.Get(context.Background(), pdbKey, &policyv1.PodDisruptionBudget{})
+		require.True(t, k8serrors.IsNotFound(err))
+	})
+
+	t.Run("keep pod disruption budget not owned by the CR", func(t *testing.T) {
+		s := fixPDBSystemState(v1alpha1.DockerRegistrySpec{})
+		userPDB := &policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: podDisruptionBudgetName, Namespace: "kyma-system"},
+		}
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(userPDB).Build()},
+		}
+
+		_, _, err := sFnPodDisruptionBudget(context.Background(), r, s)
+		require.NoError(t, err)
+		require.NoError(t, r.client.Get(context.Background(), pdbKey, &policyv1.PodDisruptionBudget{}))
+	})
+}
+
+func fixPDBSystemState(spec v1alpha1.DockerRegistrySpec) *systemState {
+	return &systemState{
+		instance: v1alpha1.DockerRegistry{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "default",
+				Namespace: "kyma-system",
+				UID:       "test-uid",
+			},
+			Spec: spec,
+		},
+		chartConfig: &chart.Config{
+			Release: chart.Release{Name: "dockerregistry", Namespace: "kyma-system"},
+		},
+	}
+}
+
+func fixOwnedPDB(t *testing.T, scheme *runtime.Scheme, s *systemState) *policyv1.PodDisruptionBudget {
+	minAvailable := intstr.FromInt32(1)
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: podDisruptionBudgetName, Namespace: "kyma-system"},
+		Spec:       policyv1.PodDisruptionBudgetSpec{MinAvailable: &minAvailable},
+	}
+	require.NoError(t, controllerutil.SetControllerReference(&s.instance, pdb, scheme))
+	return pdb
+}
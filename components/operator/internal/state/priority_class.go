@@ -0,0 +1,64 @@
+package state
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/registry"
+	"github.com/pkg/errors"
+	appsv1 "k8s.io/api/apps/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// preparePriorityClass passes the priority class to the chart if it exists, otherwise the registry pods keep the previous one
+// so a typo doesn't lower the registry scheduling priority
+func preparePriorityClass(ctx context.Context, r *reconciler, s *systemState) error {
+	name := s.instance.Spec.PriorityClassName
+	if name == "" {
+		s.instance.RemoveCondition(v1alpha1.ConditionTypePriorityClassMissing)
+		return nil
+	}
+
+	err := r.client.Get(ctx, client.ObjectKey{Name: name}, &schedulingv1.PriorityClass{})
+	if err == nil {
+		s.flagsBuilder.WithPriorityClassName(name)
+		s.instance.RemoveCondition(v1alpha1.ConditionTypePriorityClassMissing)
+		return nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return errors.Wrapf(err, "while getting priority class %s", name)
+	}
+
+	previous, err := deployedPriorityClassName(ctx, r, s)
+	if err != nil {
+		return err
+	}
+	if previous != "" {
+		s.flagsBuilder.WithPriorityClassName(previous)
+	}
+
+	msg := fmt.Sprintf("priority class %s not found, registry pods keep the previous priority class", name)
+	s.warningBuilder.With(msg)
+	s.instance.UpdateConditionTrue(
+		v1alpha1.ConditionTypePriorityClassMissing,
+		v1alpha1.ConditionReasonPriorityClassNotFound,
+		msg,
+	)
+	return nil
+}
+
+// deployedPriorityClassName returns the priority class of the running registry deployment or an empty name if it's not deployed
+func deployedPriorityClassName(ctx context.Context, r *reconciler, s *systemState) (string, error) {
+	deployment := &appsv1.Deployment{}
+	err := r.client.Get(ctx, client.ObjectKey{Name: registry.DeploymentName, Namespace: s.instance.Namespace}, deployment)
+	if k8serrors.IsNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "while getting registry deployment")
+	}
+	return deployment.Spec.Template.Spec.PriorityClassName, nil
+}
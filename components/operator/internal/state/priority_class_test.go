@@ -0,0 +1,103 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
+	"github.com/kyma-project/docker-registry/components/operator/internal/warning"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_preparePriorityClass(t *testing.T) {
+	testCases := map[string]struct {
+		givenObjects      []client.Object
+		givenName         string
+		expectedFlags     map[string]interface{}
+		expectedCondition bool
+		expectedWarning   string
+	}{
+		"no priority class": {
+			expectedFlags: map[string]interface{}{},
+		},
+		"priority class found": {
+			givenObjects:  []client.Object{fixPriorityClass("critical")},
+			givenName:     "critical",
+			expectedFlags: map[string]interface{}{"priorityClassName": "critical"},
+		},
+		"keep priority class of deployed registry": {
+			givenObjects:      []client.Object{fixPriorityClass("critical"), fixPriorityClassDeployment("critical")},
+			givenName:         "criticla",
+			expectedFlags:     map[string]interface{}{"priorityClassName": "critical"},
+			expectedCondition: true,
+			expectedWarning:   "Warning: priority class criticla not found, registry pods keep the previous priority class",
+		},
+		"keep default priority class without deployed registry": {
+			givenName:         "criticla",
+			expectedFlags:     map[string]interface{}{},
+			expectedCondition: true,
+			expectedWarning:   "Warning: priority class criticla not found, registry pods keep the previous priority class",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			s := &systemState{
+				instance: v1alpha1.DockerRegistry{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "kyma-system"},
+					Spec:       v1alpha1.DockerRegistrySpec{PriorityClassName: testCase.givenName},
+				},
+				flagsBuilder:   flags.NewBuilder(),
+				warningBuilder: warning.NewBuilder(),
+			}
+			r := &reconciler{
+				k8s: k8s{client: fake.NewClientBuilder().WithObjects(testCase.givenObjects...).Build()},
+				log: zap.NewNop().Sugar(),
+			}
+
+			err := preparePriorityClass(context.Background(), r, s)
+			require.NoError(t, err)
+
+			flags, err := s.flagsBuilder.Build()
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedFlags, flags)
+			require.Equal(t, testCase.expectedWarning, s.warningBuilder.Build())
+
+			condition := meta.FindStatusCondition(s.instance.Status.Conditions, string(v1alpha1.ConditionTypePriorityClassMissing))
+			if !testCase.expectedCondition {
+				require.Nil(t, condition)
+				return
+			}
+			require.NotNil(t, condition)
+			require.Equal(t, metav1.ConditionTrue, condition.Status)
+			require.Equal(t, string(v1alpha1.ConditionReasonPriorityClassNotFound), condition.Reason)
+		})
+	}
+}
+
+func fixPriorityClass(name string) *schedulingv1.PriorityClass {
+	return &schedulingv1.PriorityClass{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Value:      1000000,
+	}
+}
+
+func fixPriorityClassDeployment(priorityClassName string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "dockerregistry", Namespace: "kyma-system"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{PriorityClassName: priorityClassName},
+			},
+		},
+	}
+}
@@ -0,0 +1,15 @@
+package state
+
+func prepareProbes(s *systemState) {
+	probes := s.instance.Spec.Probes
+	if probes == nil {
+		return
+	}
+
+	if probes.Liveness != nil {
+		s.flagsBuilder.WithProbe("livenessProbe", probes.Liveness)
+	}
+	if probes.Readiness != nil {
+		s.flagsBuilder.WithProbe("readinessProbe", probes.Readiness)
+	}
+}
@@ -0,0 +1,60 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func Test_prepareProbes(t *testing.T) {
+	testCases := map[string]struct {
+		givenProbes   *v1alpha1.Probes
+		expectedFlags map[string]interface{}
+	}{
+		"chart defaults": {
+			expectedFlags: map[string]interface{}{},
+		},
+		"custom probes timing": {
+			givenProbes: &v1alpha1.Probes{
+				Liveness: &v1alpha1.ProbeTiming{
+					InitialDelaySeconds: ptr.To[int32](30),
+					FailureThreshold:    ptr.To[int32](5),
+				},
+				Readiness: &v1alpha1.ProbeTiming{
+					PeriodSeconds:  ptr.To[int32](20),
+					TimeoutSeconds: ptr.To[int32](3),
+				},
+			},
+			expectedFlags: map[string]interface{}{
+				"livenessProbe": map[string]interface{}{
+					"initialDelaySeconds": int64(30),
+					"failureThreshold":    int64(5),
+				},
+				"readinessProbe": map[string]interface{}{
+					"periodSeconds":  int64(20),
+					"timeoutSeconds": int64(3),
+				},
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			s := &systemState{
+				instance: v1alpha1.DockerRegistry{
+					Spec: v1alpha1.DockerRegistrySpec{Probes: testCase.givenProbes},
+				},
+				flagsBuilder: flags.NewBuilder(),
+			}
+
+			prepareProbes(s)
+
+			flags, err := s.flagsBuilder.Build()
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedFlags, flags)
+		})
+	}
+}
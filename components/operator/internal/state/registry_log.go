@@ -0,0 +1,10 @@
+package state
+
+func prepareLog(s *systemState) {
+	log := s.instance.Spec.Log
+	if log == nil {
+		return
+	}
+
+	s.flagsBuilder.WithLog(log.Level, log.Formatter)
+}
@@ -0,0 +1,55 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_prepareLog(t *testing.T) {
+	testCases := map[string]struct {
+		givenLog      *v1alpha1.RegistryLog
+		expectedFlags map[string]interface{}
+	}{
+		"chart defaults": {
+			expectedFlags: map[string]interface{}{},
+		},
+		"custom level": {
+			givenLog: &v1alpha1.RegistryLog{Level: "debug"},
+			expectedFlags: map[string]interface{}{
+				"configData": map[string]interface{}{
+					"log": map[string]interface{}{"level": "debug"},
+				},
+				"rollme": "configData.log.level=debug",
+			},
+		},
+		"custom level and formatter": {
+			givenLog: &v1alpha1.RegistryLog{Level: "warn", Formatter: "text"},
+			expectedFlags: map[string]interface{}{
+				"configData": map[string]interface{}{
+					"log": map[string]interface{}{"level": "warn", "formatter": "text"},
+				},
+				"rollme": "configData.log.level=warn,configData.log.formatter=text",
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			s := &systemState{
+				instance: v1alpha1.DockerRegistry{
+					Spec: v1alpha1.DockerRegistrySpec{Log: testCase.givenLog},
+				},
+				flagsBuilder: flags.NewBuilder(),
+			}
+
+			prepareLog(s)
+
+			flags, err := s.flagsBuilder.Build()
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedFlags, flags)
+		})
+	}
+}
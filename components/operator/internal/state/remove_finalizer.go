@@ -3,12 +3,15 @@ package state
 import (
 	"context"
 
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
 func sFnRemoveFinalizer(ctx context.Context, r *reconciler, s *systemState) (stateFn, *ctrl.Result, error) {
-	if !controllerutil.RemoveFinalizer(&s.instance, r.finalizer) {
+	removed := controllerutil.RemoveFinalizer(&s.instance, r.finalizer)
+	removed = controllerutil.RemoveFinalizer(&s.instance, v1alpha1.CleanupFinalizer) || removed
+	if !removed {
 		return requeue()
 	}
 
@@ -22,6 +22,7 @@ func Test_sFnRemoveFinalizer(t *testing.T) {
 				Namespace: "default",
 				Finalizers: []string{
 					v1alpha1.Finalizer,
+					v1alpha1.CleanupFinalizer,
 				},
 			},
 		}
@@ -45,6 +46,7 @@ func Test_sFnRemoveFinalizer(t *testing.T) {
 		require.Nil(t, err)
 		require.Nil(t, result)
 		require.Nil(t, next)
+		require.Empty(t, s.instance.GetFinalizers())
 	})
 
 	t.Run("requeue when is no finalizer", func(t *testing.T) {
@@ -0,0 +1,115 @@
+package state
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/pkg/errors"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	replicationCronJobPrefix    = "dockerregistry-replication"
+	replicationTargetAnnotation = "dockerregistry.kyma-project.io/replication-target"
+	replicationJobInstanceLabel = "app.kubernetes.io/instance"
+)
+
+// prepareReplication passes the replication targets to the chart and reports the result of the most recent
+// replication jobs of each target in the status
+func prepareReplication(ctx context.Context, r *reconciler, s *systemState) error {
+	replication := s.instance.Spec.Replication
+	if replication == nil {
+		s.instance.Status.Replication = nil
+		return nil
+	}
+
+	statuses := make([]v1alpha1.ReplicationStatus, 0, len(replication.Targets))
+	for i, target := range replication.Targets {
+		if err := checkReplicationCredentials(ctx, r, s, target); err != nil {
+			return err
+		}
+
+		status, err := replicationTargetStatus(ctx, r, s, i, target)
+		if err != nil {
+			return err
+		}
+		statuses = append(statuses, status)
+	}
+
+	s.flagsBuilder.WithReplication(replication.Schedule, replication.JobImage, replication.Targets)
+	s.instance.Status.Replication = statuses
+	return nil
+}
+
+// checkReplicationCredentials makes sure the replication job can mount the target credentials
+func checkReplicationCredentials(ctx context.Context, r *reconciler, s *systemState, target v1alpha1.ReplicationTarget) error {
+	secret := corev1.Secret{}
+	err := r.client.Get(ctx, client.ObjectKey{Namespace: s.instance.Namespace, Name: target.CredentialSecretName}, &secret)
+	if err != nil {
+		return errors.Wrapf(err, "while getting credential secret %s of the %s replication target", target.CredentialSecretName, target.URL)
+	}
+	if _, ok := secret.Data[corev1.DockerConfigJsonKey]; !ok {
+		return fmt.Errorf("credential secret %s of the %s replication target doesn't contain the %s key",
+			target.CredentialSecretName, target.URL, corev1.DockerConfigJsonKey)
+	}
+	return nil
+}
+
+// replicationTargetStatus reads the last sync time from the target CronJob and the error from its most recently finished Job,
+// the status is empty until the CronJob of the target is deployed
+func replicationTargetStatus(ctx context.Context, r *reconciler, s *systemState, index int, target v1alpha1.ReplicationTarget) (v1alpha1.ReplicationStatus, error) {
+	status := v1alpha1.ReplicationStatus{URL: target.URL}
+	name := fmt.Sprintf("%s-%d", replicationCronJobPrefix, index)
+
+	cronJob := &batchv1.CronJob{}
+	err := r.client.Get(ctx, client.ObjectKey{Namespace: s.instance.Namespace, Name: name}, cronJob)
+	if k8serrors.IsNotFound(err) {
+		return status, nil
+	}
+	if err != nil {
+		return status, errors.Wrapf(err, "while getting replication cronjob %s", name)
+	}
+	// the CronJob still copies images to the previous target at this index
+	if cronJob.GetAnnotations()[replicationTargetAnnotation] != target.URL {
+		return status, nil
+	}
+	status.LastSyncTime = cronJob.Status.LastSuccessfulTime
+
+	jobs := &batchv1.JobList{}
+	err = r.client.List(ctx, jobs, client.InNamespace(s.instance.Namespace), client.MatchingLabels{replicationJobInstanceLabel: name})
+	if err != nil {
+		return status, errors.Wrapf(err, "while listing replication jobs of cronjob %s", name)
+	}
+
+	var latest *batchv1.Job
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if !isHookJobFinished(job, batchv1.JobComplete) && !isHookJobFinished(job, batchv1.JobFailed) {
+			continue
+		}
+		if latest == nil || job.GetCreationTimestamp().After(latest.GetCreationTimestamp().Time) {
+			latest = job
+		}
+	}
+	if latest != nil {
+		status.Error = replicationJobError(latest)
+	}
+	return status, nil
+}
+
+func replicationJobError(job *batchv1.Job) string {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type != batchv1.JobFailed || condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		if condition.Message != "" {
+			return condition.Message
+		}
+		return condition.Reason
+	}
+	return ""
+}
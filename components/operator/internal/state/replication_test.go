@@ -0,0 +1,166 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_prepareReplication(t *testing.T) {
+	// the fake client decodes the times in the local zone
+	lastSuccess := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Local())
+	target := v1alpha1.ReplicationTarget{
+		URL:                  "registry.example.com/mirror",
+		CredentialSecretName: "mirror-creds",
+		Repositories:         []string{"app", "tools/builder"},
+	}
+	credentials := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "mirror-creds", Namespace: "kyma-system"},
+		Type:       corev1.SecretTypeDockerConfigJson,
+		Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{}}`)},
+	}
+
+	testCases := map[string]struct {
+		givenObjects      []client.Object
+		givenReplication  *v1alpha1.Replication
+		givenStatus       []v1alpha1.ReplicationStatus
+		expectedStatus    []v1alpha1.ReplicationStatus
+		expectedFlagsUsed bool
+		expectedErr       string
+	}{
+		"no replication": {
+			givenStatus: []v1alpha1.ReplicationStatus{{URL: target.URL}},
+		},
+		"target not synced yet": {
+			givenObjects:      []client.Object{credentials},
+			givenReplication:  &v1alpha1.Replication{Schedule: "0 * * * *", Targets: []v1alpha1.ReplicationTarget{target}},
+			expectedStatus:    []v1alpha1.ReplicationStatus{{URL: target.URL}},
+			expectedFlagsUsed: true,
+		},
+		"target synced": {
+			givenObjects: []client.Object{
+				credentials,
+				fixReplicationCronJob("registry.example.com/mirror", &lastSuccess),
+				fixReplicationJob("old", time.Hour, batchv1.JobFailed, "BackoffLimitExceeded"),
+				fixReplicationJob("new", 2*time.Hour, batchv1.JobComplete, ""),
+			},
+			givenReplication:  &v1alpha1.Replication{Schedule: "0 * * * *", Targets: []v1alpha1.ReplicationTarget{target}},
+			expectedStatus:    []v1alpha1.ReplicationStatus{{URL: target.URL, LastSyncTime: &lastSuccess}},
+			expectedFlagsUsed: true,
+		},
+		"target sync failed": {
+			givenObjects: []client.Object{
+				credentials,
+				fixReplicationCronJob("registry.example.com/mirror", &lastSuccess),
+				fixReplicationJob("old", time.Hour, batchv1.JobComplete, ""),
+				fixReplicationJob("new", 2*time.Hour, batchv1.JobFailed, "Job has reached the specified backoff limit"),
+				fixReplicationJob("running", 3*time.Hour, "", ""),
+			},
+			givenReplication: &v1alpha1.Replication{Schedule: "0 * * * *", Targets: []v1alpha1.ReplicationTarget{target}},
+			expectedStatus: []v1alpha1.ReplicationStatus{{
+				URL: target.URL, LastSyncTime: &lastSuccess, Error: "Job has reached the specified backoff limit",
+			}},
+			expectedFlagsUsed: true,
+		},
+		"ignore cronjob of the previous target": {
+			givenObjects: []client.Object{
+				credentials,
+				fixReplicationCronJob("other.example.com", &lastSuccess),
+			},
+			givenReplication:  &v1alpha1.Replication{Schedule: "0 * * * *", Targets: []v1alpha1.ReplicationTarget{target}},
+			expectedStatus:    []v1alpha1.ReplicationStatus{{URL: target.URL}},
+			expectedFlagsUsed: true,
+		},
+		"credential secret not found": {
+			givenReplication: &v1alpha1.Replication{Schedule: "0 * * * *", Targets: []v1alpha1.ReplicationTarget{target}},
+			expectedErr:      "while getting credential secret mirror-creds of the registry.example.com/mirror replication target: secrets \"mirror-creds\" not found",
+		},
+		"credential secret without docker config": {
+			givenObjects: []client.Object{&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "mirror-creds", Namespace: "kyma-system"},
+				Data:       map[string][]byte{"password": []byte("secret")},
+			}},
+			givenReplication: &v1alpha1.Replication{Schedule: "0 * * * *", Targets: []v1alpha1.ReplicationTarget{target}},
+			expectedErr:      "credential secret mirror-creds of the registry.example.com/mirror replication target doesn't contain the .dockerconfigjson key",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			s := &systemState{
+				instance: v1alpha1.DockerRegistry{
+					ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "kyma-system"},
+					Spec:       v1alpha1.DockerRegistrySpec{Replication: testCase.givenReplication},
+					Status:     v1alpha1.DockerRegistryStatus{Replication: testCase.givenStatus},
+				},
+				flagsBuilder: flags.NewBuilder(),
+			}
+			r := &reconciler{
+				log: zap.NewNop().Sugar(),
+				k8s: k8s{client: fake.NewClientBuilder().WithObjects(testCase.givenObjects...).Build()},
+			}
+
+			err := prepareReplication(context.Background(), r, s)
+			if testCase.expectedErr != "" {
+				require.EqualError(t, err, testCase.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedStatus, s.instance.Status.Replication)
+
+			flags, err := s.flagsBuilder.Build()
+			require.NoError(t, err)
+			if !testCase.expectedFlagsUsed {
+				require.NotContains(t, flags, "replication")
+				return
+			}
+			require.Equal(t, map[string]interface{}{
+				"enabled":  true,
+				"schedule": "0 * * * *",
+				"targets": []interface{}{
+					map[string]interface{}{
+						"url":                  "registry.example.com/mirror",
+						"credentialSecretName": "mirror-creds",
+						"repositories":         []interface{}{"app", "tools/builder"},
+					},
+				},
+			}, flags["replication"])
+		})
+	}
+}
+
+func fixReplicationCronJob(url string, lastSuccessfulTime *metav1.Time) *batchv1.CronJob {
+	return &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "dockerregistry-replication-0",
+			Namespace:   "kyma-system",
+			Annotations: map[string]string{replicationTargetAnnotation: url},
+		},
+		Status: batchv1.CronJobStatus{LastSuccessfulTime: lastSuccessfulTime},
+	}
+}
+
+func fixReplicationJob(name string, age time.Duration, conditionType batchv1.JobConditionType, message string) *batchv1.Job {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "kyma-system",
+			Labels:            map[string]string{replicationJobInstanceLabel: "dockerregistry-replication-0"},
+			CreationTimestamp: metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Add(age)),
+		},
+	}
+	if conditionType != "" {
+		job.Status.Conditions = []batchv1.JobCondition{{Type: conditionType, Status: corev1.ConditionTrue, Message: message}}
+	}
+	return job
+}
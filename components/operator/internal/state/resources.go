@@ -0,0 +1,5 @@
+package state
+
+func prepareResources(s *systemState) {
+	s.flagsBuilder.WithResources(s.instance.GetResources())
+}
@@ -0,0 +1,72 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func Test_prepareResources(t *testing.T) {
+	testCases := map[string]struct {
+		givenResources *corev1.ResourceRequirements
+		expectedFlags  map[string]interface{}
+	}{
+		"default resources": {
+			expectedFlags: map[string]interface{}{
+				"resources": map[string]interface{}{
+					"requests": map[string]interface{}{
+						"cpu":    "10m",
+						"memory": "300Mi",
+					},
+					"limits": map[string]interface{}{
+						"cpu":    "400m",
+						"memory": "800Mi",
+					},
+				},
+			},
+		},
+		"custom resources": {
+			givenResources: &corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				},
+				Limits: corev1.ResourceList{
+					corev1.ResourceMemory:                  resource.MustParse("2Gi"),
+					corev1.ResourceName("example.com/foo"): resource.MustParse("1"),
+				},
+			},
+			expectedFlags: map[string]interface{}{
+				"resources": map[string]interface{}{
+					"requests": map[string]interface{}{
+						"memory": "1Gi",
+					},
+					"limits": map[string]interface{}{
+						"memory":          "2Gi",
+						"example.com/foo": int64(1),
+					},
+				},
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			s := &systemState{
+				instance: v1alpha1.DockerRegistry{
+					Spec: v1alpha1.DockerRegistrySpec{Resources: testCase.givenResources},
+				},
+				flagsBuilder: flags.NewBuilder(),
+			}
+
+			prepareResources(s)
+
+			flags, err := s.flagsBuilder.Build()
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedFlags, flags)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/manager-toolkit/installation/chart/action"
+	"github.com/pkg/errors"
+	policyv1 "k8s.io/api/policy/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// deploymentUpdateDeferRequeueAfter is how often the deferred rollout is retried
+	deploymentUpdateDeferRequeueAfter = time.Minute
+	// maxDeploymentUpdateDeferral is how long the rollout is deferred at most, the budget exhausted for longer,
+	// for example by a single replica budget, would keep the registry on the old pod template forever
+	maxDeploymentUpdateDeferral = time.Hour
+)
+
+// deferRolloutPreApplyAction keeps the pod template of the running Deployment when the rendered one would start a rollout
+// and a PodDisruptionBudget selecting the registry pods doesn't allow any disruption
+func deferRolloutPreApplyAction(ctx context.Context, r *reconciler, s *systemState) action.PreApply {
+	return func(u *unstructured.Unstructured) error {
+		current := &unstructured.Unstructured{}
+		current.SetGroupVersionKind(u.GroupVersionKind())
+		err := r.client.Get(ctx, client.ObjectKeyFromObject(u), current)
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "while getting deployment")
+		}
+
+		// rendered manifests can contain plain ints which can't be deep copied
+		desiredField, _, _ := unstructured.NestedFieldNoCopy(u.Object, "spec", "template")
+		desiredTemplate, _ := desiredField.(map[string]interface{})
+		currentTemplate, _, _ := unstructured.NestedMap(current.Object, "spec", "template")
+		// the stored template contains defaulted fields so only the rendered ones are compared
+		if isSubset(desiredTemplate, currentTemplate) {
+			return nil
+		}
+
+		// the rotated secrets are published with this apply, the pods still running the old ones would reject them
+		if isRotationRollout(s) {
+			r.log.Infof("rolling out deployment %s to apply rotated registry secrets", client.ObjectKeyFromObject(u))
+			return nil
+		}
+
+		podLabels, _, _ := unstructured.NestedStringMap(desiredTemplate, "metadata", "labels")
+		pdbName, err := exhaustedPodDisruptionBudget(ctx, r.client, u.GetNamespace(), podLabels)
+		if err != nil || pdbName == "" {
+			return err
+		}
+
+		if deferredFor := deploymentUpdateDeferredFor(s); deferredFor >= maxDeploymentUpdateDeferral {
+			msg := fmt.Sprintf("Registry rollout deferred for %s, rolling out despite pod disruption budget %s",
+				deferredFor.Round(time.Minute), pdbName)
+			r.log.Warnf("rolling out deployment %s: %s", client.ObjectKeyFromObject(u), msg)
+			r.Event(&s.instance, "Warning", "RolloutDeferralExpired", msg)
+			return nil
+		}
+
+		r.log.Infof("deferring rollout of deployment %s, pod disruption budget %s doesn't allow disruptions",
+			client.ObjectKeyFromObject(u), pdbName)
+		s.deploymentUpdateDeferredBy = pdbName
+		return unstructured.SetNestedMap(u.Object, currentTemplate, "spec", "template")
+	}
+}
+
+// isRotationRollout returns true if the rollout applies the rotated http secret or internal registry credentials
+func isRotationRollout(s *systemState) bool {
+	return s.instance.IsHTTPSecretRotationRequested() || s.credentialsRotated
+}
+
+// deploymentUpdateDeferredFor returns how long the rollout has been deferred since the previous reconciliations
+func deploymentUpdateDeferredFor(s *systemState) time.Duration {
+	condition := meta.FindStatusCondition(s.instance.Status.Conditions, string(v1alpha1.ConditionTypeDeploymentUpdateDeferred))
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		return 0
+	}
+	return time.Since(condition.LastTransitionTime.Time)
+}
+
+// exhaustedPodDisruptionBudget returns the name of a PodDisruptionBudget selecting the pods which doesn't allow any disruption
+func exhaustedPodDisruptionBudget(ctx context.Context, c client.Client, namespace string, podLabels map[string]string) (string, error) {
+	pdbs := &policyv1.PodDisruptionBudgetList{}
+	if err := c.List(ctx, pdbs, client.InNamespace(namespace)); err != nil {
+		return "", errors.Wrap(err, "while listing pod disruption budgets")
+	}
+
+	for _, pdb := range pdbs.Items {
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil || selector.Empty() || !selector.Matches(labels.Set(podLabels)) {
+			continue
+		}
+
+		// unhealthy pods exhaust the budget as well but then the rollout is the way to fix them
+		if pdb.Status.DisruptionsAllowed == 0 && pdb.Status.CurrentHealthy >= pdb.Status.DesiredHealthy {
+			return pdb.GetName(), nil
+		}
+	}
+	return "", nil
+}
+
+// isSubset returns true if all fields of the desired object have the same value in the current one
+func isSubset(desired, current interface{}) bool {
+	switch desiredValue := desired.(type) {
+	case map[string]interface{}:
+		currentValue, ok := current.(map[string]interface{})
+		if !ok {
+			// empty rendered objects are dropped by the API server
+			return len(desiredValue) == 0 && current == nil
+		}
+		for key, value := range desiredValue {
+			if !isSubset(value, currentValue[key]) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		currentValue, ok := current.([]interface{})
+		if !ok {
+			return len(desiredValue) == 0 && current == nil
+		}
+		if len(desiredValue) != len(currentValue) {
+			return false
+		}
+		for i := range desiredValue {
+			if !isSubset(desiredValue[i], currentValue[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(desired, current) || fmt.Sprint(desired) == fmt.Sprint(current)
+	}
+}
+
+func updateDeploymentUpdateDeferredCondition(s *systemState) {
+	if s.deploymentUpdateDeferredBy == "" {
+		s.instance.RemoveCondition(v1alpha1.ConditionTypeDeploymentUpdateDeferred)
+		return
+	}
+
+	msg := fmt.Sprintf("Registry rollout deferred, pod disruption budget %s doesn't allow disruptions", s.deploymentUpdateDeferredBy)
+	s.warningBuilder.With(msg)
+	s.instance.UpdateConditionTrue(
+		v1alpha1.ConditionTypeDeploymentUpdateDeferred,
+		v1alpha1.ConditionReasonDisruptionsNotAllowed,
+		msg,
+	)
+}
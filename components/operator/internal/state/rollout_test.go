@@ -0,0 +1,299 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/warning"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_deferRolloutPreApplyAction(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+
+	t.Run("defer rollout when pod disruption budget doesn't allow disruptions", func(t *testing.T) {
+		s := &systemState{}
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+				fixRolloutDeployment("registry:2.8.2"),
+				fixRolloutPodDisruptionBudget(0, 2, 2),
+			).Build()},
+		}
+		desired := fixRolloutUnstructuredDeployment(t, "registry:3.0.0")
+
+		require.NoError(t, deferRolloutPreApplyAction(context.Background(), r, s)(desired))
+		require.Equal(t, "dockerregistry", s.deploymentUpdateDeferredBy)
+		containers, _, _ := unstructured.NestedSlice(desired.Object, "spec", "template", "spec", "containers")
+		require.Equal(t, "registry:2.8.2", containers[0].(map[string]interface{})["image"])
+	})
+
+	t.Run("keep deferring rollout within max deferral", func(t *testing.T) {
+		s := &systemState{}
+		s.instance.Status.Conditions = []metav1.Condition{fixDeploymentUpdateDeferredCondition(time.Now().Add(-time.Minute))}
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+				fixRolloutDeployment("registry:2.8.2"),
+				fixRolloutPodDisruptionBudget(0, 2, 2),
+			).Build()},
+		}
+		desired := fixRolloutUnstructuredDeployment(t, "registry:3.0.0")
+
+		require.NoError(t, deferRolloutPreApplyAction(context.Background(), r, s)(desired))
+		require.Equal(t, "dockerregistry", s.deploymentUpdateDeferredBy)
+	})
+
+	t.Run("roll out when rollout was deferred longer than max deferral", func(t *testing.T) {
+		s := &systemState{}
+		s.instance.Status.Conditions = []metav1.Condition{fixDeploymentUpdateDeferredCondition(time.Now().Add(-2 * maxDeploymentUpdateDeferral))}
+		recorder := record.NewFakeRecorder(1)
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+				fixRolloutDeployment("registry:2.8.2"),
+				fixRolloutPodDisruptionBudget(0, 2, 2),
+			).Build(), EventRecorder: recorder},
+		}
+		desired := fixRolloutUnstructuredDeployment(t, "registry:3.0.0")
+
+		require.NoError(t, deferRolloutPreApplyAction(context.Background(), r, s)(desired))
+		require.Empty(t, s.deploymentUpdateDeferredBy)
+		containers, _, _ := unstructured.NestedSlice(desired.Object, "spec", "template", "spec", "containers")
+		require.Equal(t, "registry:3.0.0", containers[0].(map[string]interface{})["image"])
+		require.Contains(t, <-recorder.Events, "RolloutDeferralExpired")
+	})
+
+	t.Run("roll out rotated http secret despite pod disruption budget", func(t *testing.T) {
+		s := &systemState{}
+		s.instance.SetAnnotations(map[string]string{v1alpha1.RotateHTTPSecretAnnotation: "true"})
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+				fixRolloutDeployment("registry:2.8.2"),
+				fixRolloutPodDisruptionBudget(0, 2, 2),
+			).Build()},
+		}
+		desired := fixRolloutUnstructuredDeployment(t, "registry:3.0.0")
+
+		require.NoError(t, deferRolloutPreApplyAction(context.Background(), r, s)(desired))
+		require.Empty(t, s.deploymentUpdateDeferredBy)
+		containers, _, _ := unstructured.NestedSlice(desired.Object, "spec", "template", "spec", "containers")
+		require.Equal(t, "registry:3.0.0", containers[0].(map[string]interface{})["image"])
+	})
+
+	t.Run("roll out rotated credentials despite pod disruption budget", func(t *testing.T) {
+		s := &systemState{credentialsRotated: true}
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+				fixRolloutDeployment("registry:2.8.2"),
+				fixRolloutPodDisruptionBudget(0, 2, 2),
+			).Build()},
+		}
+		desired := fixRolloutUnstructuredDeployment(t, "registry:3.0.0")
+
+		require.NoError(t, deferRolloutPreApplyAction(context.Background(), r, s)(desired))
+		require.Empty(t, s.deploymentUpdateDeferredBy)
+		containers, _, _ := unstructured.NestedSlice(desired.Object, "spec", "template", "spec", "containers")
+		require.Equal(t, "registry:3.0.0", containers[0].(map[string]interface{})["image"])
+	})
+
+	t.Run("roll out when pod disruption budget allows disruptions", func(t *testing.T) {
+		s := &systemState{}
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+				fixRolloutDeployment("registry:2.8.2"),
+				fixRolloutPodDisruptionBudget(1, 2, 1),
+			).Build()},
+		}
+		desired := fixRolloutUnstructuredDeployment(t, "registry:3.0.0")
+
+		require.NoError(t, deferRolloutPreApplyAction(context.Background(), r, s)(desired))
+		require.Empty(t, s.deploymentUpdateDeferredBy)
+		containers, _, _ := unstructured.NestedSlice(desired.Object, "spec", "template", "spec", "containers")
+		require.Equal(t, "registry:3.0.0", containers[0].(map[string]interface{})["image"])
+	})
+
+	t.Run("roll out when registry pods are not healthy", func(t *testing.T) {
+		s := &systemState{}
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+				fixRolloutDeployment("registry:2.8.2"),
+				fixRolloutPodDisruptionBudget(0, 0, 1),
+			).Build()},
+		}
+
+		require.NoError(t, deferRolloutPreApplyAction(context.Background(), r, s)(fixRolloutUnstructuredDeployment(t, "registry:3.0.0")))
+		require.Empty(t, s.deploymentUpdateDeferredBy)
+	})
+
+	t.Run("don't defer when pod template doesn't change", func(t *testing.T) {
+		s := &systemState{}
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+				fixRolloutDeployment("registry:2.8.2"),
+				fixRolloutPodDisruptionBudget(0, 2, 2),
+			).Build()},
+		}
+
+		require.NoError(t, deferRolloutPreApplyAction(context.Background(), r, s)(fixRolloutUnstructuredDeployment(t, "registry:2.8.2")))
+		require.Empty(t, s.deploymentUpdateDeferredBy)
+	})
+
+	t.Run("compare rendered template with plain int values", func(t *testing.T) {
+		s := &systemState{}
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+				fixRolloutDeployment("registry:2.8.2"),
+				fixRolloutPodDisruptionBudget(0, 2, 2),
+			).Build()},
+		}
+		desired := fixRolloutUnstructuredDeployment(t, "registry:3.0.0")
+		podSpec, _, _ := unstructured.NestedFieldNoCopy(desired.Object, "spec", "template", "spec")
+		podSpec.(map[string]interface{})["terminationGracePeriodSeconds"] = 30
+
+		require.NoError(t, deferRolloutPreApplyAction(context.Background(), r, s)(desired))
+		require.Equal(t, "dockerregistry", s.deploymentUpdateDeferredBy)
+	})
+
+	t.Run("don't defer first installation", func(t *testing.T) {
+		s := &systemState{}
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+				fixRolloutPodDisruptionBudget(0, 2, 2),
+			).Build()},
+		}
+
+		require.NoError(t, deferRolloutPreApplyAction(context.Background(), r, s)(fixRolloutUnstructuredDeployment(t, "registry:3.0.0")))
+		require.Empty(t, s.deploymentUpdateDeferredBy)
+	})
+
+	t.Run("reconcile twice against existing deployment", func(t *testing.T) {
+		s := &systemState{}
+		c := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(
+			fixRolloutDeployment("registry:2.8.2"),
+			fixRolloutPodDisruptionBudget(1, 2, 1),
+		).Build()
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: c},
+		}
+
+		for i := 0; i < 2; i++ {
+			// the chart renderer parses the manifests to plain ints
+			desired := fixRolloutUnstructuredDeployment(t, "registry:3.0.0")
+			podSpec, _, _ := unstructured.NestedFieldNoCopy(desired.Object, "spec", "template", "spec")
+			podSpec.(map[string]interface{})["terminationGracePeriodSeconds"] = 30
+
+			require.NoError(t, deferRolloutPreApplyAction(context.Background(), r, s)(desired))
+			require.Empty(t, s.deploymentUpdateDeferredBy)
+
+			current := &appsv1.Deployment{}
+			require.NoError(t, c.Get(context.Background(), client.ObjectKeyFromObject(desired), current))
+			desired.SetResourceVersion(current.GetResourceVersion())
+			require.NoError(t, c.Update(context.Background(), desired))
+		}
+
+		current := &appsv1.Deployment{}
+		require.NoError(t, c.Get(context.Background(), client.ObjectKey{Name: "dockerregistry", Namespace: "kyma-system"}, current))
+		require.Equal(t, "registry:3.0.0", current.Spec.Template.Spec.Containers[0].Image)
+	})
+}
+
+func Test_updateDeploymentUpdateDeferredCondition(t *testing.T) {
+	t.Run("set condition when rollout is deferred", func(t *testing.T) {
+		s := &systemState{
+			warningBuilder:             warning.NewBuilder(),
+			deploymentUpdateDeferredBy: "dockerregistry",
+		}
+
+		updateDeploymentUpdateDeferredCondition(s)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeDeploymentUpdateDeferred,
+			metav1.ConditionTrue,
+			v1alpha1.ConditionReasonDisruptionsNotAllowed,
+			"Registry rollout deferred, pod disruption budget dockerregistry doesn't allow disruptions",
+		)
+		require.NotEmpty(t, s.warningBuilder.Build())
+	})
+
+	t.Run("remove condition when rollout is not deferred", func(t *testing.T) {
+		s := &systemState{}
+		s.instance.UpdateConditionTrue(v1alpha1.ConditionTypeDeploymentUpdateDeferred, v1alpha1.ConditionReasonDisruptionsNotAllowed, "deferred")
+
+		updateDeploymentUpdateDeferredCondition(s)
+		require.False(t, s.instance.IsCondition(v1alpha1.ConditionTypeDeploymentUpdateDeferred))
+	})
+}
+
+func fixDeploymentUpdateDeferredCondition(since time.Time) metav1.Condition {
+	return metav1.Condition{
+		Type:               string(v1alpha1.ConditionTypeDeploymentUpdateDeferred),
+		Status:             metav1.ConditionTrue,
+		Reason:             string(v1alpha1.ConditionReasonDisruptionsNotAllowed),
+		LastTransitionTime: metav1.NewTime(since),
+	}
+}
+
+func fixRolloutDeployment(image string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "dockerregistry", Namespace: "kyma-system"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "docker-registry"}},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:                     "registry",
+						Image:                    image,
+						TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+					}},
+				},
+			},
+		},
+	}
+}
+
+func fixRolloutUnstructuredDeployment(t *testing.T, image string) *unstructured.Unstructured {
+	deployment := fixRolloutDeployment(image)
+	// rendered manifests don't contain defaulted fields
+	deployment.Spec.Template.Spec.Containers[0].TerminationMessagePolicy = ""
+	deployment.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind("Deployment"))
+
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(deployment)
+	require.NoError(t, err)
+	return &unstructured.Unstructured{Object: obj}
+}
+
+func fixRolloutPodDisruptionBudget(disruptionsAllowed, currentHealthy, desiredHealthy int32) client.Object {
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "dockerregistry", Namespace: "kyma-system"},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "docker-registry"}},
+		},
+		Status: policyv1.PodDisruptionBudgetStatus{
+			DisruptionsAllowed: disruptionsAllowed,
+			CurrentHealthy:     currentHealthy,
+			DesiredHealthy:     desiredHealthy,
+		},
+	}
+}
@@ -0,0 +1,21 @@
+package state
+
+func prepareScaling(s *systemState) {
+	spec := s.instance.Spec
+	if spec.Autoscaling != nil {
+		if spec.Replicas != nil {
+			s.warningBuilder.With("spec.replicas is ignored when spec.autoscaling is set")
+		}
+		autoscaling := spec.Autoscaling
+		s.flagsBuilder.WithAutoscaling(
+			autoscaling.GetMinReplicas(),
+			autoscaling.MaxReplicas,
+			autoscaling.GetTargetCPUUtilizationPercentage(),
+		)
+		return
+	}
+
+	if spec.Replicas != nil {
+		s.flagsBuilder.WithReplicas(*spec.Replicas)
+	}
+}
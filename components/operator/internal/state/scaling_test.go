@@ -0,0 +1,134 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
+	"github.com/kyma-project/docker-registry/components/operator/internal/warning"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/utils/ptr"
+)
+
+func Test_prepareScaling(t *testing.T) {
+	testCases := map[string]struct {
+		givenSpec       v1alpha1.DockerRegistrySpec
+		expectedFlags   map[string]interface{}
+		expectedWarning string
+	}{
+		"keep chart defaults": {
+			givenSpec:     v1alpha1.DockerRegistrySpec{},
+			expectedFlags: map[string]interface{}{},
+		},
+		"static replicas": {
+			givenSpec: v1alpha1.DockerRegistrySpec{Replicas: ptr.To[int32](3)},
+			expectedFlags: map[string]interface{}{
+				"replicaCount": int64(3),
+			},
+		},
+		"autoscaling with defaults": {
+			givenSpec: v1alpha1.DockerRegistrySpec{Autoscaling: &v1alpha1.Autoscaling{MaxReplicas: 5}},
+			expectedFlags: map[string]interface{}{
+				"autoscaling": map[string]interface{}{
+					"enabled":                        true,
+					"minReplicas":                    int64(1),
+					"maxReplicas":                    int64(5),
+					"targetCPUUtilizationPercentage": int64(80),
+				},
+			},
+		},
+		"ignore replicas when autoscaling is set": {
+			givenSpec: v1alpha1.DockerRegistrySpec{
+				Replicas: ptr.To[int32](3),
+				Autoscaling: &v1alpha1.Autoscaling{
+					MinReplicas:                    ptr.To[int32](2),
+					MaxReplicas:                    4,
+					TargetCPUUtilizationPercentage: ptr.To[int32](60),
+				},
+			},
+			expectedFlags: map[string]interface{}{
+				"autoscaling": map[string]interface{}{
+					"enabled":                        true,
+					"minReplicas":                    int64(2),
+					"maxReplicas":                    int64(4),
+					"targetCPUUtilizationPercentage": int64(60),
+				},
+			},
+			expectedWarning: "Warning: spec.replicas is ignored when spec.autoscaling is set",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			s := &systemState{
+				instance:       v1alpha1.DockerRegistry{Spec: testCase.givenSpec},
+				flagsBuilder:   flags.NewBuilder(),
+				warningBuilder: warning.NewBuilder(),
+			}
+
+			prepareScaling(s)
+
+			flags, err := s.flagsBuilder.Build()
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedFlags, flags)
+			require.Equal(t, testCase.expectedWarning, s.warningBuilder.Build())
+		})
+	}
+}
+
+func Test_registryDeploymentStrategy(t *testing.T) {
+	testCases := map[string]struct {
+		givenSpec        v1alpha1.DockerRegistrySpec
+		givenStorage     func(*flags.Builder)
+		expectedStrategy appsv1.DeploymentStrategyType
+	}{
+		"recreate single replica": {
+			givenSpec:        v1alpha1.DockerRegistrySpec{},
+			givenStorage:     func(fb *flags.Builder) { fb.WithAzure(&v1alpha1.StorageAzureSecrets{}).WithPVCDisabled() },
+			expectedStrategy: appsv1.RecreateDeploymentStrategyType,
+		},
+		"recreate replicas on the filesystem volume": {
+			givenSpec:        v1alpha1.DockerRegistrySpec{Replicas: ptr.To[int32](3)},
+			givenStorage:     func(fb *flags.Builder) { fb.WithFilesystem() },
+			expectedStrategy: appsv1.RecreateDeploymentStrategyType,
+		},
+		"roll replicas on the object storage": {
+			givenSpec:        v1alpha1.DockerRegistrySpec{Replicas: ptr.To[int32](3)},
+			givenStorage:     func(fb *flags.Builder) { fb.WithAzure(&v1alpha1.StorageAzureSecrets{}).WithPVCDisabled() },
+			expectedStrategy: appsv1.RollingUpdateDeploymentStrategyType,
+		},
+		"roll autoscaled replicas on the object storage": {
+			givenSpec:        v1alpha1.DockerRegistrySpec{Autoscaling: &v1alpha1.Autoscaling{MaxReplicas: 4}},
+			givenStorage:     func(fb *flags.Builder) { fb.WithAzure(&v1alpha1.StorageAzureSecrets{}).WithPVCDisabled() },
+			expectedStrategy: appsv1.RollingUpdateDeploymentStrategyType,
+		},
+		"roll replicas on the user pvc": {
+			givenSpec:        v1alpha1.DockerRegistrySpec{Replicas: ptr.To[int32](2)},
+			givenStorage:     func(fb *flags.Builder) { fb.WithFilesystem().WithPVC(&v1alpha1.StoragePVC{Name: "shared"}) },
+			expectedStrategy: appsv1.RollingUpdateDeploymentStrategyType,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			s := &systemState{
+				instance:       v1alpha1.DockerRegistry{Spec: testCase.givenSpec},
+				flagsBuilder:   flags.NewBuilder(),
+				warningBuilder: warning.NewBuilder(),
+			}
+			testCase.givenStorage(s.flagsBuilder)
+
+			prepareScaling(s)
+
+			flags, err := s.flagsBuilder.Build()
+			require.NoError(t, err)
+			strategy := renderRegistryDeployment(t, flags).Spec.Strategy
+			require.Equal(t, testCase.expectedStrategy, strategy.Type)
+			if strategy.Type == appsv1.RollingUpdateDeploymentStrategyType {
+				require.NotNil(t, strategy.RollingUpdate)
+				require.Equal(t, 0, strategy.RollingUpdate.MaxUnavailable.IntValue())
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package state
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	zoneTopologyKey = "topology.kubernetes.io/zone"
+)
+
+func prepareScheduling(s *systemState) {
+	if scheduling := s.instance.Spec.Scheduling; scheduling != nil {
+		s.flagsBuilder.WithScheduling(scheduling)
+	}
+
+	prepareTopologySpreadConstraints(s)
+}
+
+// prepareTopologySpreadConstraints spreads many registry replicas across zones by default
+// without blocking the scheduling in single-zone clusters
+func prepareTopologySpreadConstraints(s *systemState) {
+	constraints := s.instance.Spec.TopologySpreadConstraints
+	if constraints == nil && s.instance.GetReplicas() > 1 {
+		constraints = []corev1.TopologySpreadConstraint{
+			{
+				MaxSkew:           1,
+				TopologyKey:       zoneTopologyKey,
+				WhenUnsatisfiable: corev1.ScheduleAnyway,
+				LabelSelector: &metav1.LabelSelector{
+					MatchLabels: registryPodLabels(s),
+				},
+			},
+		}
+	}
+
+	if len(constraints) != 0 {
+		s.flagsBuilder.WithTopologySpreadConstraints(constraints)
+	}
+}
+
+// registryPodLabels returns labels selecting the registry pods created by the chart
+func registryPodLabels(s *systemState) map[string]string {
+	return map[string]string{
+		"app":     "docker-registry",
+		"release": s.chartConfig.Release.Name,
+	}
+}
@@ -0,0 +1,181 @@
+package state
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
+	"github.com/kyma-project/manager-toolkit/installation/chart"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/yaml"
+)
+
+func Test_prepareScheduling(t *testing.T) {
+	t.Run("keep chart defaults", func(t *testing.T) {
+		s := &systemState{
+			instance:     v1alpha1.DockerRegistry{},
+			flagsBuilder: flags.NewBuilder(),
+		}
+
+		prepareScheduling(s)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{}, flags)
+	})
+
+	t.Run("render scheduling in the registry deployment", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				Spec: v1alpha1.DockerRegistrySpec{
+					Scheduling: &v1alpha1.Scheduling{
+						NodeSelector: map[string]string{
+							"node-pool": "infra",
+						},
+						Tolerations: []corev1.Toleration{
+							{
+								Key:      "dedicated",
+								Operator: corev1.TolerationOpEqual,
+								Value:    "true",
+								Effect:   corev1.TaintEffectNoSchedule,
+							},
+						},
+						Affinity: &corev1.Affinity{
+							NodeAffinity: &corev1.NodeAffinity{
+								RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+									NodeSelectorTerms: []corev1.NodeSelectorTerm{
+										{
+											MatchExpressions: []corev1.NodeSelectorRequirement{
+												{
+													Key:      "kubernetes.io/arch",
+													Operator: corev1.NodeSelectorOpIn,
+													Values:   []string{"amd64"},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			flagsBuilder: flags.NewBuilder(),
+		}
+
+		prepareScheduling(s)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+
+		podSpec := renderRegistryDeployment(t, flags).Spec.Template.Spec
+		require.Equal(t, map[string]string{"node-pool": "infra"}, podSpec.NodeSelector)
+		tolerations, err := json.Marshal(podSpec.Tolerations)
+		require.NoError(t, err)
+		require.JSONEq(t, `[{"key":"dedicated","operator":"Equal","value":"true","effect":"NoSchedule"}]`, string(tolerations))
+		require.Equal(t, s.instance.Spec.Scheduling.Affinity, podSpec.Affinity)
+	})
+}
+
+func Test_prepareTopologySpreadConstraints(t *testing.T) {
+	testCases := map[string]struct {
+		givenSpec           v1alpha1.DockerRegistrySpec
+		expectedConstraints []corev1.TopologySpreadConstraint
+	}{
+		"no constraints for single replica": {
+			givenSpec: v1alpha1.DockerRegistrySpec{},
+		},
+		"spread many replicas across zones by default": {
+			givenSpec: v1alpha1.DockerRegistrySpec{Replicas: ptr.To[int32](3)},
+			expectedConstraints: []corev1.TopologySpreadConstraint{
+				{
+					MaxSkew:           1,
+					TopologyKey:       "topology.kubernetes.io/zone",
+					WhenUnsatisfiable: corev1.ScheduleAnyway,
+					LabelSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							"app":     "docker-registry",
+							"release": "dockerregistry",
+						},
+					},
+				},
+			},
+		},
+		"use constraints from spec": {
+			givenSpec: v1alpha1.DockerRegistrySpec{
+				Replicas: ptr.To[int32](3),
+				TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+					{
+						MaxSkew:           2,
+						TopologyKey:       "kubernetes.io/hostname",
+						WhenUnsatisfiable: corev1.DoNotSchedule,
+					},
+				},
+			},
+			expectedConstraints: []corev1.TopologySpreadConstraint{
+				{
+					MaxSkew:           2,
+					TopologyKey:       "kubernetes.io/hostname",
+					WhenUnsatisfiable: corev1.DoNotSchedule,
+				},
+			},
+		},
+		"disable default constraints with empty list": {
+			givenSpec: v1alpha1.DockerRegistrySpec{
+				Replicas:                  ptr.To[int32](3),
+				TopologySpreadConstraints: []corev1.TopologySpreadConstraint{},
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			s := &systemState{
+				instance:     v1alpha1.DockerRegistry{Spec: testCase.givenSpec},
+				flagsBuilder: flags.NewBuilder(),
+				chartConfig: &chart.Config{
+					Release: chart.Release{Name: "dockerregistry"},
+				},
+			}
+
+			prepareScheduling(s)
+
+			flags, err := s.flagsBuilder.Build()
+			require.NoError(t, err)
+			podSpec := renderRegistryDeployment(t, flags).Spec.Template.Spec
+			require.Equal(t, testCase.expectedConstraints, podSpec.TopologySpreadConstraints)
+		})
+	}
+}
+
+// renderRegistryDeployment renders the registry deployment from the docker-registry chart with the given flags
+func renderRegistryDeployment(t *testing.T, flags map[string]interface{}) *appsv1.Deployment {
+	deployment := &appsv1.Deployment{}
+	require.NoError(t, yaml.Unmarshal([]byte(renderRegistryChart(t, flags)["docker-registry/templates/deployment.yaml"]), deployment))
+	return deployment
+}
+
+// renderRegistryChart renders the docker-registry chart with the given flags and returns manifests by the template path
+func renderRegistryChart(t *testing.T, flags map[string]interface{}) map[string]string {
+	registryChart, err := loader.Load(filepath.Join("..", "..", "..", "..", "config", "docker-registry"))
+	require.NoError(t, err)
+
+	values, err := chartutil.ToRenderValues(registryChart, flags, chartutil.ReleaseOptions{
+		Name:      "dockerregistry",
+		Namespace: "kyma-system",
+	}, nil)
+	require.NoError(t, err)
+
+	manifests, err := engine.Render(registryChart, values)
+	require.NoError(t, err)
+	return manifests
+}
@@ -0,0 +1,85 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	internalresource "github.com/kyma-project/docker-registry/components/operator/internal/resource"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	serviceMonitorName = "dockerregistry"
+	// metricsServiceInstanceLabel selects the registry metrics service created by the chart
+	metricsServiceInstanceLabel = "dockerregistry-metrics"
+)
+
+var serviceMonitorGVK = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Version: "v1",
+	Kind:    "ServiceMonitor",
+}
+
+// let the Prometheus Operator scrape the registry metrics
+func sFnServiceMonitor(ctx context.Context, r *reconciler, s *systemState) (stateFn, *ctrl.Result, error) {
+	err := reconcileServiceMonitor(ctx, newResourceClient(r, s), s)
+	if meta.IsNoMatchError(err) {
+		s.warningBuilder.With("monitoring is enabled but the Prometheus Operator ServiceMonitor CRD is not installed")
+		return nextState(sFnIstioConfiguration)
+	}
+	if err != nil {
+		r.log.Warnf("error while reconciling service monitor %s: %s",
+			client.ObjectKeyFromObject(&s.instance), err.Error())
+		s.setState(v1alpha1.StateError)
+		s.instance.UpdateConditionFalse(
+			v1alpha1.ConditionTypeInstalled,
+			v1alpha1.ConditionReasonInstallationErr,
+			err,
+		)
+		return stopWithEventualError(err)
+	}
+
+	return nextState(sFnIstioConfiguration)
+}
+
+func reconcileServiceMonitor(ctx context.Context, c internalresource.Client, s *systemState) error {
+	serviceMonitor := &unstructured.Unstructured{}
+	serviceMonitor.SetGroupVersionKind(serviceMonitorGVK)
+	serviceMonitor.SetName(serviceMonitorName)
+	serviceMonitor.SetNamespace(s.instance.GetNamespace())
+
+	if !s.instance.IsMonitoringEnabled() {
+		return deleteOwnedResource(ctx, c, s, serviceMonitor, "service monitor")
+	}
+
+	err := c.UpsertWithReference(ctx, &s.instance, serviceMonitor, func() error {
+		spec := map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{
+					"app.kubernetes.io/instance": metricsServiceInstanceLabel,
+				},
+			},
+			"endpoints": []interface{}{
+				map[string]interface{}{
+					"port":     "http",
+					"path":     "/metrics",
+					"interval": prometheusDuration(s.instance.GetMonitoringScrapeInterval()),
+				},
+			},
+		}
+		return unstructured.SetNestedMap(serviceMonitor.Object, spec, "spec")
+	})
+	return errors.Wrap(err, "while applying service monitor")
+}
+
+// prometheusDuration returns the duration in the whole seconds accepted by the Prometheus Operator
+func prometheusDuration(d time.Duration) string {
+	return fmt.Sprintf("%ds", int64(d.Seconds()))
+}
@@ -0,0 +1,118 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/warning"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func Test_sFnServiceMonitor(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(testScheme))
+	require.NoError(t, v1alpha1.AddToScheme(testScheme))
+
+	t.Run("create service monitor for the metrics service", func(t *testing.T) {
+		s := fixServiceMonitorSystemState(&v1alpha1.Monitoring{
+			Enabled:        true,
+			ScrapeInterval: &metav1.Duration{Duration: time.Minute},
+		})
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).Build()},
+		}
+
+		next, result, err := sFnServiceMonitor(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnIstioConfiguration, next)
+
+		serviceMonitor := fixEmptyServiceMonitor()
+		require.NoError(t, r.client.Get(context.Background(), client.ObjectKeyFromObject(serviceMonitor), serviceMonitor))
+		spec, _, _ := unstructured.NestedMap(serviceMonitor.Object, "spec")
+		require.Equal(t, map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{
+					"app.kubernetes.io/instance": "dockerregistry-metrics",
+				},
+			},
+			"endpoints": []interface{}{
+				map[string]interface{}{
+					"port":     "http",
+					"path":     "/metrics",
+					"interval": "60s",
+				},
+			},
+		}, spec)
+		require.Len(t, serviceMonitor.GetOwnerReferences(), 1)
+		require.Equal(t, "default", serviceMonitor.GetOwnerReferences()[0].Name)
+	})
+
+	t.Run("warn when the ServiceMonitor CRD is not installed", func(t *testing.T) {
+		s := fixServiceMonitorSystemState(&v1alpha1.Monitoring{Enabled: true})
+		c := fake.NewClientBuilder().WithScheme(testScheme).WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(_ context.Context, _ client.WithWatch, _ client.ObjectKey, _ client.Object, _ ...client.GetOption) error {
+				return &meta.NoKindMatchError{GroupKind: serviceMonitorGVK.GroupKind()}
+			},
+		}).Build()
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: c},
+		}
+
+		next, result, err := sFnServiceMonitor(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnIstioConfiguration, next)
+		require.Contains(t, s.warningBuilder.Build(), "ServiceMonitor CRD is not installed")
+	})
+
+	t.Run("delete service monitor when monitoring is disabled", func(t *testing.T) {
+		s := fixServiceMonitorSystemState(nil)
+		serviceMonitor := fixEmptyServiceMonitor()
+		serviceMonitor.SetOwnerReferences([]metav1.OwnerReference{
+			*metav1.NewControllerRef(&s.instance, v1alpha1.GroupVersion.WithKind("DockerRegistry")),
+		})
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(serviceMonitor).Build()},
+		}
+
+		_, _, err := sFnServiceMonitor(context.Background(), r, s)
+		require.NoError(t, err)
+
+		err = r.client.Get(context.Background(), client.ObjectKeyFromObject(serviceMonitor), fixEmptyServiceMonitor())
+		require.True(t, k8serrors.IsNotFound(err))
+	})
+}
+
+func fixServiceMonitorSystemState(monitoring *v1alpha1.Monitoring) *systemState {
+	return &systemState{
+		instance: v1alpha1.DockerRegistry{
+			ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "kyma-system"},
+			Spec:       v1alpha1.DockerRegistrySpec{Monitoring: monitoring},
+		},
+		warningBuilder: warning.NewBuilder(),
+	}
+}
+
+func fixEmptyServiceMonitor() *unstructured.Unstructured {
+	serviceMonitor := &unstructured.Unstructured{}
+	serviceMonitor.SetGroupVersionKind(serviceMonitorGVK)
+	serviceMonitor.SetName(serviceMonitorName)
+	serviceMonitor.SetNamespace("kyma-system")
+	return serviceMonitor
+}
@@ -4,36 +4,99 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"strings"
 
 	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	internalerrors "github.com/kyma-project/docker-registry/components/operator/internal/errors"
 	"github.com/kyma-project/docker-registry/components/operator/internal/registry"
 	"github.com/pkg/errors"
 	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
 func sFnStorageConfiguration(ctx context.Context, r *reconciler, s *systemState) (stateFn, *ctrl.Result, error) {
+	prepareGarbageCollection(s)
+	prepareBackup(s)
+	preparePruning(r, s)
+	if s.instance.Spec.ReadOnly {
+		s.flagsBuilder.WithReadOnly()
+	}
+
 	err := prepareStorage(ctx, r, s)
 	if err != nil {
 		s.warningBuilder.With("failed to set storage configuration: " + err.Error())
+		s.setConfigurationFailed(v1alpha1.ConditionReasonConfigurationErr, err)
 		s.instance.UpdateConditionFalse(
-			v1alpha1.ConditionTypeConfigured,
-			v1alpha1.ConditionReasonConfigurationErr,
+			v1alpha1.ConditionTypeStorageReady,
+			storageErrorReason(s.instance.Spec.Storage, err),
 			err,
 		)
+		return nextState(sFnUpdateConfigurationStatus)
 	}
 
+	s.instance.UpdateConditionTrue(
+		v1alpha1.ConditionTypeStorageReady,
+		v1alpha1.ConditionReasonStorageConfigured,
+		"Storage ready",
+	)
+	updateEncryptionCondition(s)
+	checkStoragePressure(ctx, r, s)
+	recordGarbageCollectionDryRun(ctx, r, s)
 	return nextState(sFnUpdateConfigurationStatus)
 }
 
+// updateEncryptionCondition reflects whether the registry encrypts the stored objects,
+// only the S3 storage driver supports the encryption at rest
+func updateEncryptionCondition(s *systemState) {
+	storage := s.instance.Spec.Storage
+	if storage == nil || storage.S3 == nil || !storage.S3.Encrypt {
+		s.instance.UpdateConditionFalse(
+			v1alpha1.ConditionTypeEncryptionEnabled,
+			v1alpha1.ConditionReasonStorageNotEncrypted,
+			errors.New("Storage encryption disabled"),
+		)
+		return
+	}
+
+	msg := "S3 server-side encryption enabled"
+	if storage.S3.KMSKeyID != "" {
+		msg = fmt.Sprintf("S3 server-side encryption enabled with the KMS key %s", storage.S3.KMSKeyID)
+	}
+	s.instance.UpdateConditionTrue(
+		v1alpha1.ConditionTypeEncryptionEnabled,
+		v1alpha1.ConditionReasonStorageEncrypted,
+		msg,
+	)
+}
+
+func storageErrorReason(storage *v1alpha1.Storage, err error) v1alpha1.ConditionReason {
+	if k8serrors.IsNotFound(err) && storage != nil && storage.GCS != nil {
+		return v1alpha1.ConditionReasonGCSSecretMissing
+	}
+	if k8serrors.IsNotFound(err) {
+		return v1alpha1.ConditionReasonStorageSecretMissing
+	}
+	return v1alpha1.ConditionReasonStorageConfigurationErr
+}
+
 func prepareStorage(ctx context.Context, r *reconciler, s *systemState) error {
+	if s.instance.Spec.Storage == nil || s.instance.Spec.Storage.Filesystem == nil {
+		s.instance.RemoveCondition(v1alpha1.ConditionTypeStorageClassImmutable)
+	}
+
 	if s.instance.Spec.Storage != nil {
 		s.flagsBuilder.WithDeleteEnabled(s.instance.Spec.Storage.DeleteEnabled)
 
 		if err := prepareStorageUnique(s); err != nil {
 			return err
 		}
+		if s.instance.Spec.Storage.Filesystem != nil {
+			return prepareFilesystemStorage(ctx, r, s)
+		}
 		s.flagsBuilder.WithPVCDisabled()
 		if s.instance.Spec.Storage.Azure != nil {
 			return prepareAzureStorage(ctx, r, s)
@@ -52,26 +115,193 @@ func prepareStorage(ctx context.Context, r *reconciler, s *systemState) error {
 	return nil
 }
 
-func prepareStorageUnique(s *systemState) error {
-	// make sure only one of the storage options is used
-	storages := 0
-	if s.instance.Spec.Storage.Azure != nil {
-		storages++
+func prepareFilesystemStorage(ctx context.Context, r *reconciler, s *systemState) error {
+	s.flagsBuilder.WithFilesystem()
+
+	filesystem := s.instance.Spec.Storage.Filesystem
+	if filesystem.PVCSize == nil && filesystem.StorageClassName == "" {
+		s.instance.RemoveCondition(v1alpha1.ConditionTypeStorageClassImmutable)
+		return nil
+	}
+
+	pvc, err := registry.GetDockerRegistryPVC(ctx, r.client, s.instance.Namespace)
+	if err != nil {
+		return err
+	}
+
+	prepareStorageClass(s, filesystem.StorageClassName, pvc)
+
+	if filesystem.PVCSize == nil {
+		return nil
+	}
+	return preparePVCSize(ctx, r, s, *filesystem.PVCSize, pvc)
+}
+
+// prepareStorageClass keeps the storage class of the existing pvc because it can't be changed in place
+func prepareStorageClass(s *systemState, storageClassName string, pvc *v1.PersistentVolumeClaim) {
+	if storageClassName == "" {
+		s.instance.RemoveCondition(v1alpha1.ConditionTypeStorageClassImmutable)
+		return
+	}
+
+	if pvc == nil || ptr.Deref(pvc.Spec.StorageClassName, "") == storageClassName {
+		s.flagsBuilder.WithPVCStorageClass(storageClassName)
+		s.instance.RemoveCondition(v1alpha1.ConditionTypeStorageClassImmutable)
+		return
+	}
+
+	currentStorageClassName := ptr.Deref(pvc.Spec.StorageClassName, "")
+	if currentStorageClassName != "" {
+		s.flagsBuilder.WithPVCStorageClass(currentStorageClassName)
+	}
+	msg := fmt.Sprintf(".spec.storage.filesystem.storageClassName %s differs from the current pvc storage class %s, storage class of the existing pvc can't be changed, migrate the registry data to a new pvc manually", storageClassName, currentStorageClassName)
+	s.warningBuilder.With(msg)
+	s.instance.UpdateConditionTrue(
+		v1alpha1.ConditionTypeStorageClassImmutable,
+		v1alpha1.ConditionReasonStorageClassChanged,
+		msg,
+	)
+}
+
+func preparePVCSize(ctx context.Context, r *reconciler, s *systemState, pvcSize resource.Quantity, pvc *v1.PersistentVolumeClaim) error {
+	s.flagsBuilder.WithPVCSize(pvcSize.String())
+	if pvc == nil {
+		// pvc will be created by the chart with the requested size
+		return nil
+	}
+
+	currentSize := pvc.Spec.Resources.Requests.Storage()
+	switch currentSize.Cmp(pvcSize) {
+	case 1:
+		s.warningBuilder.With(fmt.Sprintf(".spec.storage.filesystem.pvcSize %s is lower than the current pvc size %s, pvc can't be shrunk", pvcSize.String(), currentSize.String()))
+	case -1:
+		// chart keeps the pvc size from the cluster so the resize has to be requested directly
+		r.log.Infof("resizing docker registry pvc from %s to %s", currentSize.String(), pvcSize.String())
+		pvc.Spec.Resources.Requests[v1.ResourceStorage] = pvcSize
+		if err := r.client.Update(ctx, pvc); err != nil {
+			return errors.Wrap(err, "while resizing docker registry pvc")
+		}
+	}
+
+	return nil
+}
+
+func checkStoragePressure(ctx context.Context, r *reconciler, s *systemState) {
+	threshold := s.instance.GetStorageAlertThresholdPercent()
+	if threshold == nil {
+		s.instance.RemoveCondition(v1alpha1.ConditionTypeStoragePressure)
+		return
 	}
-	if s.instance.Spec.Storage.S3 != nil {
-		storages++
+
+	usage, err := registry.GetDockerRegistryPVCUsage(ctx, r.client, s.volumeUsageReader, s.instance.Namespace)
+	if err != nil {
+		r.log.Warnf("while reading docker registry pvc usage: %s", err.Error())
+		s.instance.UpdateConditionUnknown(
+			v1alpha1.ConditionTypeStoragePressure,
+			v1alpha1.ConditionReasonStorageUsageUnknown,
+			err.Error(),
+		)
+		return
+	}
+	if usage == nil {
+		// registry is not running yet
+		return
+	}
+
+	msg := fmt.Sprintf("pvc usage is %d%%, alert threshold is %d%%", usage.Percent(), *threshold)
+	if usage.Percent() >= *threshold {
+		s.warningBuilder.With(msg)
+		s.instance.UpdateConditionTrue(
+			v1alpha1.ConditionTypeStoragePressure,
+			v1alpha1.ConditionReasonStorageUsageHigh,
+			msg,
+		)
+		return
+	}
+
+	s.instance.UpdateConditionFalse(
+		v1alpha1.ConditionTypeStoragePressure,
+		v1alpha1.ConditionReasonStorageUsageNormal,
+		errors.New(msg),
+	)
+}
+
+func prepareGarbageCollection(s *systemState) {
+	gc := s.instance.Spec.GarbageCollection
+	if gc == nil {
+		return
+	}
+
+	s.flagsBuilder.WithGarbageCollection(gc.Schedule, gc.DeleteUntagged, gc.DryRun)
+}
+
+// maxGCDryRunLogsBytes limits the garbage collection output stored in the CR annotation
+const maxGCDryRunLogsBytes = 10 * 1024
+
+// recordGarbageCollectionDryRun stores the output of the last garbage collection dry run in the CR annotation,
+// the output is cut from the beginning because the summary is printed at the end
+func recordGarbageCollectionDryRun(ctx context.Context, r *reconciler, s *systemState) {
+	logs := ""
+	if gc := s.instance.Spec.GarbageCollection; gc != nil && gc.DryRun {
+		var err error
+		logs, err = s.gcLogReader.GetLastGCLogs(ctx, r.client, s.instance.Namespace)
+		if err != nil {
+			r.log.Warnf("while reading garbage collection logs: %s", err.Error())
+			return
+		}
+		if len(logs) > maxGCDryRunLogsBytes {
+			logs = logs[len(logs)-maxGCDryRunLogsBytes:]
+		}
+	}
+
+	if s.instance.GetAnnotations()[v1alpha1.LastGCDryRunAnnotation] == logs {
+		return
+	}
+
+	if logs == "" {
+		delete(s.instance.Annotations, v1alpha1.LastGCDryRunAnnotation)
+	} else {
+		if s.instance.Annotations == nil {
+			s.instance.Annotations = map[string]string{}
+		}
+		s.instance.Annotations[v1alpha1.LastGCDryRunAnnotation] = logs
 	}
-	if s.instance.Spec.Storage.GCS != nil {
-		storages++
+
+	// keep calculated status, update overrides it with the one from the cluster
+	status := s.instance.Status.DeepCopy()
+	if err := updateDockerRegistryWithoutStatus(ctx, r, s); err != nil {
+		r.log.Warnf("while storing garbage collection dry run output: %s", err.Error())
 	}
-	if s.instance.Spec.Storage.BTPObjectStore != nil {
-		storages++
+	s.instance.Status = *status
+}
+
+func prepareBackup(s *systemState) {
+	backup := s.instance.Spec.Backup
+	if backup == nil {
+		return
 	}
-	if s.instance.Spec.Storage.PVC != nil {
-		storages++
+
+	s.flagsBuilder.WithBackup(backup.Schedule, backup.VolumeSnapshotClassName, backup.GetRetainCount())
+}
+
+func preparePruning(r *reconciler, s *systemState) {
+	pruning := s.instance.Spec.Pruning
+	if pruning == nil {
+		return
 	}
-	if storages > 1 {
-		return errors.New("only one storage option can be used")
+
+	jobImage := pruning.JobImage
+	if jobImage == "" {
+		jobImage = r.operatorImage
+	}
+	s.flagsBuilder.WithPruning(pruning.Schedule, jobImage,
+		ptr.Deref(pruning.MaxAgeDays, 0), ptr.Deref(pruning.MaxTagsPerRepository, 0))
+}
+
+func prepareStorageUnique(s *systemState) error {
+	// make sure only one of the storage options is used
+	if len(s.instance.Spec.Storage.ConfiguredBackends()) > 1 {
+		return internalerrors.ErrInvalidSpec.Wrap(errors.New("only one storage option can be used"))
 	}
 	return nil
 }
@@ -81,6 +311,9 @@ func prepareAzureStorage(ctx context.Context, r *reconciler, s *systemState) err
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("while fetching azure storage secret from %s", s.instance.Namespace))
 	}
+	if err := requireSecretKeys(azureSecret, "accountName", "accountKey", "container"); err != nil {
+		return errors.Wrap(err, "while validating azure storage secret")
+	}
 	storageAzureSecret := &v1alpha1.StorageAzureSecrets{
 		AccountName: string(azureSecret.Data["accountName"]),
 		AccountKey:  string(azureSecret.Data["accountKey"]),
@@ -90,6 +323,20 @@ func prepareAzureStorage(ctx context.Context, r *reconciler, s *systemState) err
 	return nil
 }
 
+// requireSecretKeys returns error if any of the given keys is missing or empty in the secret
+func requireSecretKeys(secret *v1.Secret, keys ...string) error {
+	missing := []string{}
+	for _, key := range keys {
+		if len(secret.Data[key]) == 0 {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return errors.Errorf("secret %s/%s is missing keys: %s", secret.Namespace, secret.Name, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
 func prepareS3Storage(ctx context.Context, r *reconciler, s *systemState) error {
 	s3Secret, err := registry.GetSecret(ctx, r.client, s.instance.Spec.Storage.S3.SecretName, s.instance.Namespace)
 	if err != nil {
@@ -108,6 +355,9 @@ func prepareGCSStorage(ctx context.Context, r *reconciler, s *systemState) error
 	if err != nil {
 		return errors.Wrap(err, fmt.Sprintf("while fetching gcs storage secret from %s", s.instance.Namespace))
 	}
+	if err := requireSecretKeys(gcsSecret, "accountkey"); err != nil {
+		return errors.Wrap(err, "while validating gcs storage secret")
+	}
 	storageGCSSecret := &v1alpha1.StorageGCSSecrets{
 		AccountKey: string(gcsSecret.Data["accountkey"]),
 	}
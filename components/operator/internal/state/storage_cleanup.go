@@ -0,0 +1,67 @@
+package state
+
+import (
+	"context"
+	"time"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/registry"
+	"github.com/pkg/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// make sure the registry PVC is deleted even if the chart uninstallation left it behind
+func sFnCleanupStorage(ctx context.Context, r *reconciler, s *systemState) (stateFn, *ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(&s.instance, v1alpha1.CleanupFinalizer) {
+		return nextState(sFnRemoveFinalizer)
+	}
+
+	pvc, err := registry.GetDockerRegistryPVC(ctx, r.client, s.instance.GetNamespace())
+	if err != nil {
+		return storageCleanupError(r, s, err)
+	}
+
+	if pvc == nil {
+		r.Event(&s.instance, "Normal", "StorageCleaned", "Registry storage deleted")
+		return nextState(sFnRemoveFinalizer)
+	}
+
+	if pvc.GetDeletionTimestamp().IsZero() {
+		if err := r.client.Delete(ctx, pvc); client.IgnoreNotFound(err) != nil {
+			return storageCleanupError(r, s, errors.Wrap(err, "while deleting pvc"))
+		}
+		return awaitingStorageRemoval(s)
+	}
+
+	// the pvc is deleted after all pods using it are gone
+	if time.Since(pvc.GetDeletionTimestamp().Time) > r.deletionTimeout {
+		return storageCleanupError(r, s, errors.Errorf("pvc %s is not released after %s", pvc.GetName(), r.deletionTimeout))
+	}
+
+	return awaitingStorageRemoval(s)
+}
+
+func storageCleanupError(r *reconciler, s *systemState, err error) (stateFn, *ctrl.Result, error) {
+	r.log.Warnf("error while cleaning up storage of %s: %s",
+		client.ObjectKeyFromObject(&s.instance), err.Error())
+	s.setState(v1alpha1.StateError)
+	s.instance.UpdateConditionFalse(
+		v1alpha1.ConditionTypeDeleted,
+		v1alpha1.ConditionReasonStorageCleanupErr,
+		err,
+	)
+	return stopWithEventualError(err)
+}
+
+func awaitingStorageRemoval(s *systemState) (stateFn, *ctrl.Result, error) {
+	s.setState(v1alpha1.StateDeleting)
+	s.instance.UpdateConditionUnknown(
+		v1alpha1.ConditionTypeDeleted,
+		v1alpha1.ConditionReasonDeletion,
+		"Deleting registry storage",
+	)
+
+	return requeueAfter(requeueDuration)
+}
@@ -0,0 +1,140 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_sFnCleanupStorage(t *testing.T) {
+	t.Run("skip cleanup without cleanup finalizer", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{},
+		}
+
+		next, result, err := sFnCleanupStorage(context.Background(), nil, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnRemoveFinalizer, next)
+	})
+
+	t.Run("remove finalizers when pvc is gone", func(t *testing.T) {
+		s := &systemState{
+			instance: fixCleanupDockerRegistry(),
+		}
+		eventRecorder := record.NewFakeRecorder(1)
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{
+				client:        fake.NewClientBuilder().Build(),
+				EventRecorder: eventRecorder,
+			},
+		}
+
+		next, result, err := sFnCleanupStorage(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnRemoveFinalizer, next)
+		require.Equal(t, "Normal StorageCleaned Registry storage deleted", <-eventRecorder.Events)
+	})
+
+	t.Run("delete pvc left by chart uninstallation", func(t *testing.T) {
+		s := &systemState{
+			instance: fixCleanupDockerRegistry(),
+		}
+		pvc := fixRegistryPVC("20Gi")
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			k8s: k8s{
+				client: fake.NewClientBuilder().WithObjects(pvc).Build(),
+			},
+		}
+
+		next, result, err := sFnCleanupStorage(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Equal(t, &ctrl.Result{RequeueAfter: requeueDuration}, result)
+		require.Nil(t, next)
+
+		err = r.client.Get(context.Background(), client.ObjectKeyFromObject(pvc), &corev1.PersistentVolumeClaim{})
+		require.Error(t, err)
+
+		require.Equal(t, v1alpha1.StateDeleting, s.instance.Status.State)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeDeleted,
+			metav1.ConditionUnknown,
+			v1alpha1.ConditionReasonDeletion,
+			"Deleting registry storage",
+		)
+	})
+
+	t.Run("wait until pvc is released", func(t *testing.T) {
+		s := &systemState{
+			instance: fixCleanupDockerRegistry(),
+		}
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			cfg: cfg{deletionTimeout: time.Minute},
+			k8s: k8s{
+				client: fake.NewClientBuilder().WithObjects(fixDeletingRegistryPVC(time.Now())).Build(),
+			},
+		}
+
+		next, result, err := sFnCleanupStorage(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Equal(t, &ctrl.Result{RequeueAfter: requeueDuration}, result)
+		require.Nil(t, next)
+	})
+
+	t.Run("keep finalizers when pvc is not released in time", func(t *testing.T) {
+		s := &systemState{
+			instance: fixCleanupDockerRegistry(),
+		}
+		r := &reconciler{
+			log: zap.NewNop().Sugar(),
+			cfg: cfg{deletionTimeout: time.Minute},
+			k8s: k8s{
+				client: fake.NewClientBuilder().WithObjects(fixDeletingRegistryPVC(time.Now().Add(-2 * time.Minute))).Build(),
+			},
+		}
+
+		next, result, err := sFnCleanupStorage(context.Background(), r, s)
+		require.EqualError(t, err, "pvc dockerregistry is not released after 1m0s")
+		require.Nil(t, result)
+		require.Nil(t, next)
+
+		require.Equal(t, v1alpha1.StateError, s.instance.Status.State)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeDeleted,
+			metav1.ConditionFalse,
+			v1alpha1.ConditionReasonStorageCleanupErr,
+			"pvc dockerregistry is not released after 1m0s",
+		)
+	})
+}
+
+func fixCleanupDockerRegistry() v1alpha1.DockerRegistry {
+	return v1alpha1.DockerRegistry{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "default",
+			Namespace:  "kyma-system",
+			Finalizers: []string{v1alpha1.CleanupFinalizer},
+		},
+	}
+}
+
+func fixDeletingRegistryPVC(deletedAt time.Time) *corev1.PersistentVolumeClaim {
+	pvc := fixRegistryPVC("20Gi")
+	pvc.Finalizers = []string{"kubernetes.io/pvc-protection"}
+	pvc.DeletionTimestamp = &metav1.Time{Time: deletedAt}
+	return pvc
+}
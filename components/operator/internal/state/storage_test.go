@@ -2,15 +2,23 @@ package state
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
 	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
+	"github.com/kyma-project/docker-registry/components/operator/internal/registry"
 	"github.com/kyma-project/docker-registry/components/operator/internal/warning"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
@@ -47,6 +55,385 @@ func Test_sFnStorageConfiguration(t *testing.T) {
 		require.EqualValues(t, expectedFlags, flags)
 	})
 
+	t.Run("internal registry with garbage collection", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				Spec: v1alpha1.DockerRegistrySpec{
+					GarbageCollection: &v1alpha1.GarbageCollection{
+						Schedule:       "0,30 3 * * 0",
+						DeleteUntagged: true,
+					},
+				},
+			},
+			statusSnapshot: v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().Build()},
+			log: zap.NewNop().Sugar(),
+		}
+		expectedFlags := map[string]interface{}{
+			"configData": map[string]interface{}{
+				"storage": map[string]interface{}{
+					"filesystem": map[string]interface{}{
+						"rootdirectory": "/var/lib/registry",
+					},
+				},
+			},
+			"garbageCollection": map[string]interface{}{
+				"enabled":        true,
+				"schedule":       "0,30 3 * * 0",
+				"deleteUntagged": true,
+			},
+			"storage": "filesystem",
+		}
+
+		next, result, err := sFnStorageConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnUpdateConfigurationStatus, next)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.EqualValues(t, expectedFlags, flags)
+	})
+
+	t.Run("internal registry with backup", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				Spec: v1alpha1.DockerRegistrySpec{
+					Backup: &v1alpha1.Backup{
+						Schedule:                "0 2 * * *",
+						VolumeSnapshotClassName: "csi-snapclass",
+					},
+				},
+			},
+			statusSnapshot: v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		_, _, err := sFnStorageConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			"enabled":                 true,
+			"schedule":                "0 2 * * *",
+			"volumeSnapshotClassName": "csi-snapclass",
+			"retainCount":             int64(7),
+		}, flags["backup"])
+	})
+
+	t.Run("internal registry with pruning", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				Spec: v1alpha1.DockerRegistrySpec{
+					Pruning: &v1alpha1.Pruning{
+						Schedule:             "0 1 * * 0",
+						MaxTagsPerRepository: ptr.To[int32](10),
+						JobImage:             "registry.example.com/dockerregistry-operator:1.0.0",
+					},
+				},
+			},
+			statusSnapshot: v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		_, _, err := sFnStorageConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			"enabled":              true,
+			"schedule":             "0 1 * * 0",
+			"maxTagsPerRepository": int64(10),
+			"jobImage":             "registry.example.com/dockerregistry-operator:1.0.0",
+		}, flags["pruning"])
+	})
+
+	t.Run("internal registry with pruning by the operator image", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				Spec: v1alpha1.DockerRegistrySpec{
+					Pruning: &v1alpha1.Pruning{Schedule: "0 1 * * 0"},
+				},
+			},
+			statusSnapshot: v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().Build()},
+			log: zap.NewNop().Sugar(),
+			cfg: cfg{operatorImage: "europe-docker.pkg.dev/kyma-project/prod/dockerregistry-operator:1.2.0"},
+		}
+
+		_, _, err := sFnStorageConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			"enabled":  true,
+			"schedule": "0 1 * * 0",
+			"jobImage": "europe-docker.pkg.dev/kyma-project/prod/dockerregistry-operator:1.2.0",
+		}, flags["pruning"])
+	})
+
+	t.Run("internal registry in read-only mode", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				Spec: v1alpha1.DockerRegistrySpec{
+					ReadOnly: true,
+				},
+			},
+			statusSnapshot: v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().Build()},
+			log: zap.NewNop().Sugar(),
+		}
+		expectedFlags := map[string]interface{}{
+			"rollme": "configData.storage.maintenance.readonly.enabled=true",
+			"configData": map[string]interface{}{
+				"storage": map[string]interface{}{
+					"filesystem": map[string]interface{}{
+						"rootdirectory": "/var/lib/registry",
+					},
+					"maintenance": map[string]interface{}{
+						"readonly": map[string]interface{}{
+							"enabled": true,
+						},
+					},
+				},
+			},
+			"readOnly": true,
+			"storage":  "filesystem",
+		}
+
+		next, result, err := sFnStorageConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnUpdateConfigurationStatus, next)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.EqualValues(t, expectedFlags, flags)
+	})
+
+	t.Run("internal registry using explicit filesystem storage", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				Spec: v1alpha1.DockerRegistrySpec{
+					Storage: &v1alpha1.Storage{
+						Filesystem: &v1alpha1.StorageFilesystem{},
+					},
+				},
+			},
+			statusSnapshot: v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().Build()},
+			log: zap.NewNop().Sugar(),
+		}
+		expectedFlags := map[string]interface{}{
+			"rollme": "configData.storage.delete.enabled=false",
+			"configData": map[string]interface{}{
+				"storage": map[string]interface{}{
+					"delete": map[string]interface{}{
+						"enabled": false,
+					},
+					"filesystem": map[string]interface{}{
+						"rootdirectory": "/var/lib/registry",
+					},
+				},
+			},
+			"storage": "filesystem",
+		}
+
+		next, result, err := sFnStorageConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnUpdateConfigurationStatus, next)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.EqualValues(t, expectedFlags, flags)
+	})
+
+	t.Run("resize filesystem pvc when pvcSize is increased", func(t *testing.T) {
+		pvcSize := resource.MustParse("30Gi")
+		s := &systemState{
+			instance:       fixFilesystemDockerRegistry(&pvcSize, nil),
+			statusSnapshot: v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().WithObjects(fixRegistryPVC("20Gi")).Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		next, result, err := sFnStorageConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnUpdateConfigurationStatus, next)
+		require.Empty(t, s.warningBuilder.Build())
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"size": "30Gi"}, flags["persistence"])
+
+		pvc := corev1.PersistentVolumeClaim{}
+		require.NoError(t, r.client.Get(context.Background(), client.ObjectKey{Namespace: "kyma-system", Name: "dockerregistry"}, &pvc))
+		require.Equal(t, "30Gi", pvc.Spec.Resources.Requests.Storage().String())
+	})
+
+	t.Run("don't shrink filesystem pvc", func(t *testing.T) {
+		pvcSize := resource.MustParse("10Gi")
+		s := &systemState{
+			instance:       fixFilesystemDockerRegistry(&pvcSize, nil),
+			statusSnapshot: v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().WithObjects(fixRegistryPVC("20Gi")).Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		_, _, err := sFnStorageConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Equal(t, "Warning: .spec.storage.filesystem.pvcSize 10Gi is lower than the current pvc size 20Gi, pvc can't be shrunk", s.warningBuilder.Build())
+
+		pvc := corev1.PersistentVolumeClaim{}
+		require.NoError(t, r.client.Get(context.Background(), client.ObjectKey{Namespace: "kyma-system", Name: "dockerregistry"}, &pvc))
+		require.Equal(t, "20Gi", pvc.Spec.Resources.Requests.Storage().String())
+	})
+
+	t.Run("create filesystem pvc with storage class", func(t *testing.T) {
+		instance := fixFilesystemDockerRegistry(nil, nil)
+		instance.Spec.Storage.Filesystem.StorageClassName = "fast"
+		s := &systemState{
+			instance:       instance,
+			statusSnapshot: v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		_, _, err := sFnStorageConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Empty(t, s.warningBuilder.Build())
+		require.Nil(t, meta.FindStatusCondition(s.instance.Status.Conditions, string(v1alpha1.ConditionTypeStorageClassImmutable)))
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"storageClass": "fast"}, flags["persistence"])
+	})
+
+	t.Run("don't change storage class of existing filesystem pvc", func(t *testing.T) {
+		instance := fixFilesystemDockerRegistry(nil, nil)
+		instance.Spec.Storage.Filesystem.StorageClassName = "fast"
+		s := &systemState{
+			instance:       instance,
+			statusSnapshot: v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		pvc := fixRegistryPVC("20Gi")
+		pvc.Spec.StorageClassName = ptr.To("standard")
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().WithObjects(pvc).Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		_, _, err := sFnStorageConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		msg := ".spec.storage.filesystem.storageClassName fast differs from the current pvc storage class standard, storage class of the existing pvc can't be changed, migrate the registry data to a new pvc manually"
+		require.Equal(t, "Warning: "+msg, s.warningBuilder.Build())
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeStorageClassImmutable,
+			metav1.ConditionTrue,
+			v1alpha1.ConditionReasonStorageClassChanged,
+			msg,
+		)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"storageClass": "standard"}, flags["persistence"])
+	})
+
+	t.Run("set storage pressure when pvc usage exceeds threshold", func(t *testing.T) {
+		threshold := int32(80)
+		s := &systemState{
+			instance:          fixFilesystemDockerRegistry(nil, &threshold),
+			statusSnapshot:    v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:      flags.NewBuilder(),
+			warningBuilder:    warning.NewBuilder(),
+			volumeUsageReader: &fixedVolumeUsageReader{usage: &registry.VolumeUsage{UsedBytes: 85, CapacityBytes: 100}},
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		_, _, err := sFnStorageConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeStoragePressure,
+			metav1.ConditionTrue,
+			v1alpha1.ConditionReasonStorageUsageHigh,
+			"pvc usage is 85%, alert threshold is 80%",
+		)
+		require.Equal(t, "Warning: pvc usage is 85%, alert threshold is 80%", s.warningBuilder.Build())
+	})
+
+	t.Run("clear storage pressure when pvc usage is below threshold", func(t *testing.T) {
+		threshold := int32(80)
+		s := &systemState{
+			instance:          fixFilesystemDockerRegistry(nil, &threshold),
+			statusSnapshot:    v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:      flags.NewBuilder(),
+			warningBuilder:    warning.NewBuilder(),
+			volumeUsageReader: &fixedVolumeUsageReader{usage: &registry.VolumeUsage{UsedBytes: 40, CapacityBytes: 100}},
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		_, _, err := sFnStorageConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeStoragePressure,
+			metav1.ConditionFalse,
+			v1alpha1.ConditionReasonStorageUsageNormal,
+			"pvc usage is 40%, alert threshold is 80%",
+		)
+		require.Empty(t, s.warningBuilder.Build())
+	})
+
 	t.Run("internal registry using azure storage with deleteEnabled", func(t *testing.T) {
 		azureSecret := &corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
@@ -109,11 +496,133 @@ func Test_sFnStorageConfiguration(t *testing.T) {
 		require.NoError(t, err)
 		require.Nil(t, result)
 		requireEqualFunc(t, sFnUpdateConfigurationStatus, next)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeStorageReady,
+			metav1.ConditionTrue,
+			v1alpha1.ConditionReasonStorageConfigured,
+			"Storage ready",
+		)
 
 		flags, err := s.flagsBuilder.Build()
 		require.NoError(t, err)
 		require.EqualValues(t, expectedFlags, flags)
 	})
+
+	t.Run("internal registry using azure storage without secret", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "kyma-system",
+				},
+				Spec: v1alpha1.DockerRegistrySpec{
+					Storage: &v1alpha1.Storage{
+						Azure: &v1alpha1.StorageAzure{
+							SecretName: "azureSecret",
+						},
+					},
+				},
+			},
+			statusSnapshot: v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		next, result, err := sFnStorageConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnUpdateConfigurationStatus, next)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeStorageReady,
+			metav1.ConditionFalse,
+			v1alpha1.ConditionReasonStorageSecretMissing,
+			"while fetching azure storage secret from kyma-system: secrets \"azureSecret\" not found",
+		)
+	})
+
+	t.Run("internal registry using azure storage with incomplete secret", func(t *testing.T) {
+		azureSecret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "azureSecret",
+				Namespace: "kyma-system",
+			},
+			Data: map[string][]byte{
+				"accountName": []byte("accountName"),
+			},
+		}
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "kyma-system",
+				},
+				Spec: v1alpha1.DockerRegistrySpec{
+					Storage: &v1alpha1.Storage{
+						Azure: &v1alpha1.StorageAzure{
+							SecretName: "azureSecret",
+						},
+					},
+				},
+			},
+			statusSnapshot: v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().WithObjects(azureSecret).Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		next, result, err := sFnStorageConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnUpdateConfigurationStatus, next)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeStorageReady,
+			metav1.ConditionFalse,
+			v1alpha1.ConditionReasonStorageConfigurationErr,
+			"while validating azure storage secret: secret kyma-system/azureSecret is missing keys: accountKey, container",
+		)
+	})
+
+	t.Run("internal registry using gcs storage without secret", func(t *testing.T) {
+		s := &systemState{
+			instance: v1alpha1.DockerRegistry{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "kyma-system",
+				},
+				Spec: v1alpha1.DockerRegistrySpec{
+					Storage: &v1alpha1.Storage{
+						GCS: &v1alpha1.StorageGCS{
+							Bucket:     "bucket",
+							SecretName: "gcsSecret",
+						},
+					},
+				},
+			},
+			statusSnapshot: v1alpha1.DockerRegistryStatus{},
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		next, result, err := sFnStorageConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnUpdateConfigurationStatus, next)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeStorageReady,
+			metav1.ConditionFalse,
+			v1alpha1.ConditionReasonGCSSecretMissing,
+			"while fetching gcs storage secret from kyma-system: secrets \"gcsSecret\" not found",
+		)
+	})
+
 	t.Run("internal registry using s3 storage", func(t *testing.T) {
 		s3Secret := &corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
@@ -572,3 +1081,185 @@ func Test_sFnStorageConfiguration(t *testing.T) {
 	})
 
 }
+
+type fixedVolumeUsageReader struct {
+	usage *registry.VolumeUsage
+	err   error
+}
+
+func (r *fixedVolumeUsageReader) GetPVCUsage(_ context.Context, _ client.Client, _, _ string) (*registry.VolumeUsage, error) {
+	return r.usage, r.err
+}
+
+func fixFilesystemDockerRegistry(pvcSize *resource.Quantity, alertThresholdPercent *int32) v1alpha1.DockerRegistry {
+	return v1alpha1.DockerRegistry{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "kyma-system",
+		},
+		Spec: v1alpha1.DockerRegistrySpec{
+			Storage: &v1alpha1.Storage{
+				Filesystem: &v1alpha1.StorageFilesystem{
+					PVCSize:               pvcSize,
+					AlertThresholdPercent: alertThresholdPercent,
+				},
+			},
+		},
+	}
+}
+
+func fixRegistryPVC(size string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dockerregistry",
+			Namespace: "kyma-system",
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse(size),
+				},
+			},
+		},
+	}
+}
+
+func Test_recordGarbageCollectionDryRun(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	t.Run("store last dry run output", func(t *testing.T) {
+		dockerRegistry := fixGCDockerRegistry(true, nil)
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&dockerRegistry).Build()
+		s := &systemState{
+			instance:    dockerRegistry,
+			gcLogReader: &fixedGCLogReader{logs: strings.Repeat("a", 100) + strings.Repeat("b", maxGCDryRunLogsBytes)},
+		}
+		r := &reconciler{
+			k8s: k8s{client: k8sClient},
+			log: zap.NewNop().Sugar(),
+		}
+
+		recordGarbageCollectionDryRun(context.Background(), r, s)
+
+		stored := v1alpha1.DockerRegistry{}
+		require.NoError(t, k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&dockerRegistry), &stored))
+		require.Equal(t, strings.Repeat("b", maxGCDryRunLogsBytes), stored.Annotations[v1alpha1.LastGCDryRunAnnotation])
+		require.Equal(t, stored.ResourceVersion, s.instance.ResourceVersion)
+	})
+
+	t.Run("remove dry run output when dry run is disabled", func(t *testing.T) {
+		dockerRegistry := fixGCDockerRegistry(false, map[string]string{v1alpha1.LastGCDryRunAnnotation: "1 blobs eligible for deletion"})
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&dockerRegistry).Build()
+		s := &systemState{
+			instance:    dockerRegistry,
+			gcLogReader: &fixedGCLogReader{logs: "1 blobs eligible for deletion"},
+		}
+		r := &reconciler{
+			k8s: k8s{client: k8sClient},
+			log: zap.NewNop().Sugar(),
+		}
+
+		recordGarbageCollectionDryRun(context.Background(), r, s)
+
+		stored := v1alpha1.DockerRegistry{}
+		require.NoError(t, k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&dockerRegistry), &stored))
+		require.NotContains(t, stored.Annotations, v1alpha1.LastGCDryRunAnnotation)
+	})
+
+	t.Run("keep annotation when logs can't be read", func(t *testing.T) {
+		dockerRegistry := fixGCDockerRegistry(true, map[string]string{v1alpha1.LastGCDryRunAnnotation: "1 blobs eligible for deletion"})
+		k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&dockerRegistry).Build()
+		s := &systemState{
+			instance:    dockerRegistry,
+			gcLogReader: &fixedGCLogReader{err: errors.New("forbidden")},
+		}
+		r := &reconciler{
+			k8s: k8s{client: k8sClient},
+			log: zap.NewNop().Sugar(),
+		}
+
+		recordGarbageCollectionDryRun(context.Background(), r, s)
+
+		stored := v1alpha1.DockerRegistry{}
+		require.NoError(t, k8sClient.Get(context.Background(), client.ObjectKeyFromObject(&dockerRegistry), &stored))
+		require.Equal(t, "1 blobs eligible for deletion", stored.Annotations[v1alpha1.LastGCDryRunAnnotation])
+	})
+}
+
+type fixedGCLogReader struct {
+	logs string
+	err  error
+}
+
+func (r *fixedGCLogReader) GetLastGCLogs(_ context.Context, _ client.Client, _ string) (string, error) {
+	return r.logs, r.err
+}
+
+func fixGCDockerRegistry(dryRun bool, annotations map[string]string) v1alpha1.DockerRegistry {
+	return v1alpha1.DockerRegistry{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "default",
+			Namespace:   "kyma-system",
+			Annotations: annotations,
+		},
+		Spec: v1alpha1.DockerRegistrySpec{
+			GarbageCollection: &v1alpha1.GarbageCollection{
+				Schedule: "0 3 * * 0",
+				DryRun:   dryRun,
+			},
+		},
+	}
+}
+
+func Test_updateEncryptionCondition(t *testing.T) {
+	testCases := map[string]struct {
+		storage         *v1alpha1.Storage
+		expectedStatus  metav1.ConditionStatus
+		expectedReason  v1alpha1.ConditionReason
+		expectedMessage string
+	}{
+		"default storage": {
+			storage:         nil,
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  v1alpha1.ConditionReasonStorageNotEncrypted,
+			expectedMessage: "Storage encryption disabled",
+		},
+		"s3 storage without encryption": {
+			storage:         &v1alpha1.Storage{S3: &v1alpha1.StorageS3{Bucket: "registry"}},
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  v1alpha1.ConditionReasonStorageNotEncrypted,
+			expectedMessage: "Storage encryption disabled",
+		},
+		"s3 storage with encryption": {
+			storage:         &v1alpha1.Storage{S3: &v1alpha1.StorageS3{Bucket: "registry", Encrypt: true}},
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  v1alpha1.ConditionReasonStorageEncrypted,
+			expectedMessage: "S3 server-side encryption enabled",
+		},
+		"s3 storage with kms key": {
+			storage:         &v1alpha1.Storage{S3: &v1alpha1.StorageS3{Bucket: "registry", Encrypt: true, KMSKeyID: "key"}},
+			expectedStatus:  metav1.ConditionTrue,
+			expectedReason:  v1alpha1.ConditionReasonStorageEncrypted,
+			expectedMessage: "S3 server-side encryption enabled with the KMS key key",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			s := &systemState{
+				instance: v1alpha1.DockerRegistry{
+					Spec: v1alpha1.DockerRegistrySpec{Storage: testCase.storage},
+				},
+			}
+
+			updateEncryptionCondition(s)
+
+			requireContainsCondition(t, s.instance.Status,
+				v1alpha1.ConditionTypeEncryptionEnabled,
+				testCase.expectedStatus,
+				testCase.expectedReason,
+				testCase.expectedMessage,
+			)
+		})
+	}
+}
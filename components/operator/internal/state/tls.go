@@ -0,0 +1,45 @@
+package state
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/registry"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func sFnTLSConfiguration(ctx context.Context, r *reconciler, s *systemState) (stateFn, *ctrl.Result, error) {
+	if s.instance.Spec.TLS != nil && s.instance.Spec.TLS.CertManager != nil {
+		return nextState(sFnCertManagerConfiguration)
+	}
+	s.instance.RemoveCondition(v1alpha1.ConditionTypeTLSReady)
+
+	err := prepareTLS(ctx, r, s)
+	if err != nil {
+		s.warningBuilder.With("failed to set tls configuration: " + err.Error())
+		s.setConfigurationFailed(v1alpha1.ConditionReasonConfigurationErr, err)
+	}
+
+	return nextState(sFnStorageConfiguration)
+}
+
+func prepareTLS(ctx context.Context, r *reconciler, s *systemState) error {
+	tls := s.instance.Spec.TLS
+	if tls == nil || tls.SecretName == "" {
+		return nil
+	}
+
+	tlsSecret, err := registry.GetSecret(ctx, r.client, tls.SecretName, s.instance.Namespace)
+	if err != nil {
+		return errors.Wrap(err, fmt.Sprintf("while fetching tls secret from %s", s.instance.Namespace))
+	}
+	if err := requireSecretKeys(tlsSecret, corev1.TLSCertKey, corev1.TLSPrivateKeyKey); err != nil {
+		return errors.Wrap(err, "while validating tls secret")
+	}
+
+	s.flagsBuilder.WithTLS(tls.SecretName, secretChecksum(tlsSecret, corev1.TLSCertKey, corev1.TLSPrivateKeyKey))
+	return nil
+}
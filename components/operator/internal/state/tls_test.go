@@ -0,0 +1,161 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/flags"
+	"github.com/kyma-project/docker-registry/components/operator/internal/warning"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func Test_sFnTLSConfiguration(t *testing.T) {
+	t.Run("skip when tls is not configured", func(t *testing.T) {
+		s := &systemState{
+			instance:       v1alpha1.DockerRegistry{},
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		next, result, err := sFnTLSConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnStorageConfiguration, next)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Empty(t, flags)
+	})
+
+	t.Run("mount custom tls secret", func(t *testing.T) {
+		s := &systemState{
+			instance:       fixTLSDockerRegistry("registry-tls"),
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().WithObjects(fixTLSSecret("cert", "key")).Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		next, result, err := sFnTLSConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnStorageConfiguration, next)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.Equal(t, "registry-tls", flags["tlsSecretName"])
+		require.Contains(t, flags["rollme"], "tlsSecretChecksum=")
+		require.Empty(t, s.warningBuilder.Build())
+	})
+
+	t.Run("change rollme when certificate changes", func(t *testing.T) {
+		rollme := func(cert string) interface{} {
+			s := &systemState{
+				instance:       fixTLSDockerRegistry("registry-tls"),
+				flagsBuilder:   flags.NewBuilder(),
+				warningBuilder: warning.NewBuilder(),
+			}
+			r := &reconciler{
+				k8s: k8s{client: fake.NewClientBuilder().WithObjects(fixTLSSecret(cert, "key")).Build()},
+				log: zap.NewNop().Sugar(),
+			}
+
+			_, _, err := sFnTLSConfiguration(context.Background(), r, s)
+			require.NoError(t, err)
+
+			flags, err := s.flagsBuilder.Build()
+			require.NoError(t, err)
+			return flags["rollme"]
+		}
+
+		require.Equal(t, rollme("cert"), rollme("cert"))
+		require.NotEqual(t, rollme("cert"), rollme("renewed-cert"))
+	})
+
+	t.Run("tls secret not found", func(t *testing.T) {
+		s := &systemState{
+			instance:       fixTLSDockerRegistry("registry-tls"),
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		next, result, err := sFnTLSConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		require.Nil(t, result)
+		requireEqualFunc(t, sFnStorageConfiguration, next)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeConfigured,
+			metav1.ConditionFalse,
+			v1alpha1.ConditionReasonConfigurationErr,
+			"while fetching tls secret from kyma-system: secrets \"registry-tls\" not found",
+		)
+
+		flags, err := s.flagsBuilder.Build()
+		require.NoError(t, err)
+		require.NotContains(t, flags, "tlsSecretName")
+	})
+
+	t.Run("tls secret without private key", func(t *testing.T) {
+		s := &systemState{
+			instance:       fixTLSDockerRegistry("registry-tls"),
+			flagsBuilder:   flags.NewBuilder(),
+			warningBuilder: warning.NewBuilder(),
+		}
+		r := &reconciler{
+			k8s: k8s{client: fake.NewClientBuilder().WithObjects(fixTLSSecret("cert", "")).Build()},
+			log: zap.NewNop().Sugar(),
+		}
+
+		_, _, err := sFnTLSConfiguration(context.Background(), r, s)
+		require.NoError(t, err)
+		requireContainsCondition(t, s.instance.Status,
+			v1alpha1.ConditionTypeConfigured,
+			metav1.ConditionFalse,
+			v1alpha1.ConditionReasonConfigurationErr,
+			"while validating tls secret: secret kyma-system/registry-tls is missing keys: tls.key",
+		)
+	})
+}
+
+func fixTLSDockerRegistry(secretName string) v1alpha1.DockerRegistry {
+	return v1alpha1.DockerRegistry{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "kyma-system",
+		},
+		Spec: v1alpha1.DockerRegistrySpec{
+			TLS: &v1alpha1.TLS{
+				SecretName: secretName,
+			},
+		},
+	}
+}
+
+func fixTLSSecret(cert, key string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "registry-tls",
+			Namespace: "kyma-system",
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       []byte(cert),
+			corev1.TLSPrivateKeyKey: []byte(key),
+		},
+	}
+}
@@ -2,11 +2,17 @@ package state
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 
 	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	internalresource "github.com/kyma-project/docker-registry/components/operator/internal/resource"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -62,3 +68,40 @@ func getBTPStorageHyperscaler(secretData map[string][]byte) string {
 	}
 	return storageType
 }
+
+// secretChecksum returns short checksum of the given secret keys
+func secretChecksum(secret *corev1.Secret, keys ...string) string {
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write(secret.Data[key])
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// configMapChecksum returns short checksum of the given config map keys
+func configMapChecksum(configMap *corev1.ConfigMap, keys ...string) string {
+	hash := sha256.New()
+	for _, key := range keys {
+		hash.Write([]byte(configMap.Data[key]))
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
+
+// deleteOwnedResource removes the resource created for the DockerRegistry CR if it exists
+func deleteOwnedResource(ctx context.Context, c internalresource.Client, s *systemState, obj internalresource.Object, kind string) error {
+	err := c.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+	if k8serrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		// nothing to delete or the resource CRD (e.g. Istio) is not installed
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "while getting %s", kind)
+	}
+
+	// don't touch resources created by the user
+	if !metav1.IsControlledBy(obj, &s.instance) {
+		return nil
+	}
+
+	return errors.Wrapf(client.IgnoreNotFound(c.Delete(ctx, obj)), "while deleting %s", kind)
+}
@@ -22,10 +22,22 @@ func sFnVerifyResources(_ context.Context, r *reconciler, s *systemState) (state
 			v1alpha1.ConditionReasonInstallationErr,
 			err,
 		)
+		s.instance.UpdateConditionFalse(
+			v1alpha1.ConditionTypeDeploymentReady,
+			v1alpha1.ConditionReasonDeploymentErr,
+			err,
+		)
+		updateReadyCondition(s)
 		return stopWithEventualError(err)
 	}
 
 	if !result.Ready && result.Reason == chart.DeploymentVerificationProcessing {
+		s.instance.UpdateConditionUnknown(
+			v1alpha1.ConditionTypeDeploymentReady,
+			v1alpha1.ConditionReasonDeploymentProgressing,
+			"Waiting for the registry deployment rollout",
+		)
+		updateReadyCondition(s)
 		return requeueAfter(requeueDuration)
 	}
 
@@ -37,11 +49,22 @@ func sFnVerifyResources(_ context.Context, r *reconciler, s *systemState) (state
 			v1alpha1.ConditionReasonDeploymentReplicaFailure,
 			result.Reason,
 		)
+		s.instance.UpdateConditionFalse(
+			v1alpha1.ConditionTypeDeploymentReady,
+			v1alpha1.ConditionReasonDeploymentReplicaFailure,
+			errors.New(result.Reason),
+		)
+		updateReadyCondition(s)
 		return stopWithEventualError(errors.New(result.Reason))
 	}
 
 	// remove possible previous DeploymentFailure condition
 	s.instance.RemoveCondition(v1alpha1.ConditionTypeDeploymentFailure)
+	s.instance.UpdateConditionTrue(
+		v1alpha1.ConditionTypeDeploymentReady,
+		v1alpha1.ConditionReasonDeploymentAvailable,
+		"Registry deployment available",
+	)
 
-	return nextState(sFnUpdateFinalStatus)
+	return nextState(sFnPostReconcileHook)
 }
@@ -67,7 +67,7 @@ func Test_sFnVerifyResources(t *testing.T) {
 		next, result, err := sFnVerifyResources(context.Background(), r, s)
 		require.Nil(t, err)
 		require.Nil(t, result)
-		requireEqualFunc(t, sFnUpdateFinalStatus, next)
+		requireEqualFunc(t, sFnPostReconcileHook, next)
 	})
 
 	t.Run("warning", func(t *testing.T) {
@@ -90,7 +90,7 @@ func Test_sFnVerifyResources(t *testing.T) {
 		next, result, err := sFnVerifyResources(context.Background(), r, s)
 		require.Nil(t, err)
 		require.Nil(t, result)
-		requireEqualFunc(t, sFnUpdateFinalStatus, next)
+		requireEqualFunc(t, sFnPostReconcileHook, next)
 	})
 
 	t.Run("verify error", func(t *testing.T) {
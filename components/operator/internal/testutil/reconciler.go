@@ -0,0 +1,95 @@
+package testutil
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/controllers"
+	"github.com/kyma-project/docker-registry/components/operator/internal/dryrun"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	istionetworking "istio.io/client-go/pkg/apis/networking/v1beta1"
+	istiosecurity "istio.io/client-go/pkg/apis/security/v1beta1"
+	apiruntime "k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// TestReconciler runs the DockerRegistry reconciler against a fake cluster
+// so table-driven tests don't need the envtest control plane
+type TestReconciler struct {
+	Client   client.Client
+	Recorder *record.FakeRecorder
+
+	reconciler reconcile.Reconciler
+}
+
+// NewTestReconciler returns the reconciler backed by the fake client seeded with the objects,
+// the discovery server needed by helm is closed when the test ends
+func NewTestReconciler(t testing.TB, objects []client.Object) *TestReconciler {
+	t.Helper()
+
+	scheme := NewScheme(t)
+	fakeClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&v1alpha1.DockerRegistry{}).
+		Build()
+
+	discoveryServer := dryrun.NewDiscoveryServer(scheme)
+	t.Cleanup(discoveryServer.Close)
+
+	recorder := record.NewFakeRecorder(100)
+	reconciler := controllers.NewDockerRegistryReconciler(fakeClient, &rest.Config{Host: discoveryServer.URL}, k8sfake.NewClientset(), recorder,
+		zap.NewNop().Sugar(), nil, ChartPath(), 0, 0, 0)
+
+	return &TestReconciler{
+		Client:     fakeClient,
+		Recorder:   recorder,
+		reconciler: reconciler,
+	}
+}
+
+// Reconcile runs a single reconciliation of the DockerRegistry CR
+func (tr *TestReconciler) Reconcile(name, namespace string) (ctrl.Result, error) {
+	return tr.reconciler.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: client.ObjectKey{Name: name, Namespace: namespace},
+	})
+}
+
+// GetCR returns the DockerRegistry CR stored in the fake cluster
+func (tr *TestReconciler) GetCR(name, namespace string) (*v1alpha1.DockerRegistry, error) {
+	instance := &v1alpha1.DockerRegistry{}
+	err := tr.Client.Get(context.Background(), client.ObjectKey{Name: name, Namespace: namespace}, instance)
+	if err != nil {
+		return nil, err
+	}
+	return instance, nil
+}
+
+// NewScheme returns the scheme with all types the operator manages
+func NewScheme(t testing.TB) *apiruntime.Scheme {
+	t.Helper()
+
+	scheme := apiruntime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	require.NoError(t, istionetworking.AddToScheme(scheme))
+	require.NoError(t, istiosecurity.AddToScheme(scheme))
+	return scheme
+}
+
+// ChartPath returns the path to the docker-registry chart independent of the test working directory
+func ChartPath() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "..", "..", "config", "docker-registry")
+}
@@ -0,0 +1,63 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestTestReconciler(t *testing.T) {
+	testCases := map[string]struct {
+		givenObjects      []client.Object
+		reconciles        int
+		expectedFinalizer bool
+		expectedState     v1alpha1.State
+		expectNotFound    bool
+	}{
+		"add finalizer on the first reconciliation": {
+			givenObjects:      []client.Object{fixDockerRegistry()},
+			reconciles:        1,
+			expectedFinalizer: true,
+			expectedState:     v1alpha1.StateProcessing,
+		},
+		"keep processing until registry deployment is ready": {
+			givenObjects:      []client.Object{fixDockerRegistry()},
+			reconciles:        3,
+			expectedFinalizer: true,
+			expectedState:     v1alpha1.StateProcessing,
+		},
+		"ignore missing dockerregistry": {
+			reconciles:     1,
+			expectNotFound: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Run(testName, func(t *testing.T) {
+			tr := NewTestReconciler(t, testCase.givenObjects)
+
+			for i := 0; i < testCase.reconciles; i++ {
+				_, err := tr.Reconcile("default", "kyma-system")
+				require.NoError(t, err)
+			}
+
+			instance, err := tr.GetCR("default", "kyma-system")
+			if testCase.expectNotFound {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, testCase.expectedFinalizer, len(instance.GetFinalizers()) > 0)
+			require.Equal(t, testCase.expectedState, instance.Status.State)
+		})
+	}
+}
+
+func fixDockerRegistry() *v1alpha1.DockerRegistry {
+	return &v1alpha1.DockerRegistry{
+		ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "kyma-system"},
+	}
+}
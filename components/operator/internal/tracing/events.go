@@ -0,0 +1,50 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// TraceIDAnnotation is added to the events recorded during the traced reconciliation
+// to correlate them with traces
+const TraceIDAnnotation = "dockerregistry.operator.kyma-project.io/trace-id"
+
+type eventRecorder struct {
+	record.EventRecorder
+	traceID string
+}
+
+// NewEventRecorder returns recorder annotating events with the trace ID of the span stored in ctx,
+// the recorder is returned as it is when ctx is not traced
+func NewEventRecorder(ctx context.Context, recorder record.EventRecorder) record.EventRecorder {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.HasTraceID() {
+		return recorder
+	}
+
+	return &eventRecorder{
+		EventRecorder: recorder,
+		traceID:       spanContext.TraceID().String(),
+	}
+}
+
+func (r *eventRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	r.AnnotatedEventf(object, nil, eventtype, reason, "%s", message)
+}
+
+func (r *eventRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.AnnotatedEventf(object, nil, eventtype, reason, messageFmt, args...)
+}
+
+func (r *eventRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	withTraceID := map[string]string{TraceIDAnnotation: r.traceID}
+	for key, value := range annotations {
+		withTraceID[key] = value
+	}
+
+	r.EventRecorder.AnnotatedEventf(object, withTraceID, eventtype, reason, "%s", fmt.Sprintf(messageFmt, args...))
+}
@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+type annotationsRecorder struct {
+	record.EventRecorder
+	annotations map[string]string
+	message     string
+}
+
+func (r *annotationsRecorder) AnnotatedEventf(_ runtime.Object, annotations map[string]string, _, _, messageFmt string, args ...interface{}) {
+	r.annotations = annotations
+	r.message = messageFmt
+	if len(args) > 0 {
+		r.message = args[0].(string)
+	}
+}
+
+func TestNewEventRecorder(t *testing.T) {
+	t.Run("annotate events with the trace ID", func(t *testing.T) {
+		traceID := trace.TraceID{0x0a, 0x0b}
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: traceID,
+			SpanID:  trace.SpanID{0x01},
+		}))
+		base := &annotationsRecorder{}
+
+		recorder := NewEventRecorder(ctx, base)
+		recorder.Eventf(&corev1.Pod{}, "Normal", "Reason", "registry %s", "ready")
+
+		require.Equal(t, map[string]string{TraceIDAnnotation: traceID.String()}, base.annotations)
+		require.Equal(t, "registry ready", base.message)
+	})
+
+	t.Run("return recorder as it is when context is not traced", func(t *testing.T) {
+		base := record.NewFakeRecorder(1)
+
+		recorder := NewEventRecorder(context.Background(), base)
+
+		require.Equal(t, base, recorder)
+	})
+}
@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	tracerName  = "github.com/kyma-project/docker-registry/components/operator"
+	serviceName = "dockerregistry-operator"
+)
+
+// SetupTracerProvider configures the global tracer provider exporting spans over OTLP gRPC to the given endpoint,
+// when endpoint is empty spans are not recorded at all
+func SetupTracerProvider(ctx context.Context, endpoint string) (func(context.Context) error, error) {
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracegrpc.New(ctx,
+		otlptracegrpc.WithEndpoint(endpoint),
+		otlptracegrpc.WithInsecure(),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "while creating OTLP trace exporter")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// StartSpan starts the child span of the span stored in ctx
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartReconcileSpan starts the root span of the given object reconciliation
+func StartReconcileSpan(ctx context.Context, obj client.Object) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, "Reconcile",
+		trace.WithNewRoot(),
+		trace.WithAttributes(
+			attribute.String("dockerregistry.name", obj.GetName()),
+			attribute.String("dockerregistry.namespace", obj.GetNamespace()),
+			attribute.Int64("dockerregistry.generation", obj.GetGeneration()),
+		),
+	)
+}
+
+// EndSpan marks the span as failed when err is not nil and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
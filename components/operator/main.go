@@ -20,6 +20,7 @@ import (
 	"context"
 	"flag"
 	"os"
+	"strings"
 	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
@@ -27,10 +28,12 @@ import (
 	"github.com/pkg/errors"
 	uberzap "go.uber.org/zap"
 	istionetworking "istio.io/client-go/pkg/apis/networking/v1beta1"
+	istiosecurity "istio.io/client-go/pkg/apis/security/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	apiextensionsscheme "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/scheme"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -43,28 +46,42 @@ import (
 	"github.com/kyma-project/manager-toolkit/logging/logger"
 
 	operatorv1alpha1 "github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	operatorv1beta1 "github.com/kyma-project/docker-registry/components/operator/api/v1beta1"
 	"github.com/kyma-project/docker-registry/components/operator/controllers"
+	"github.com/kyma-project/docker-registry/components/operator/internal/audit"
+	"github.com/kyma-project/docker-registry/components/operator/internal/backoff"
 	internalconfig "github.com/kyma-project/docker-registry/components/operator/internal/config"
 	k8s "github.com/kyma-project/docker-registry/components/operator/internal/controllers/kubernetes"
+	"github.com/kyma-project/docker-registry/components/operator/internal/conversion"
+	"github.com/kyma-project/docker-registry/components/operator/internal/dryrun"
+	"github.com/kyma-project/docker-registry/components/operator/internal/events"
 	"github.com/kyma-project/docker-registry/components/operator/internal/gitrepository"
+	"github.com/kyma-project/docker-registry/components/operator/internal/metrics"
+	"github.com/kyma-project/docker-registry/components/operator/internal/pruning"
 	"github.com/kyma-project/docker-registry/components/operator/internal/registry"
 	internalresource "github.com/kyma-project/docker-registry/components/operator/internal/resource"
+	"github.com/kyma-project/docker-registry/components/operator/internal/tracing"
 	//+kubebuilder:scaffold:imports
 )
 
 var (
-	scheme         = runtime.NewScheme()
-	cleanupTimeout = time.Second * 10
+	scheme = runtime.NewScheme()
 )
 
+// kubernetesConfigPrefix prefixes the environment variables of the secret propagation config,
+// for example DOCKERREGISTRY_BASE_NAMESPACES
+const kubernetesConfigPrefix = "DOCKERREGISTRY"
+
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(operatorv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(operatorv1beta1.AddToScheme(scheme))
 
 	utilruntime.Must(apiextensionsscheme.AddToScheme(scheme))
 
 	utilruntime.Must(istionetworking.AddToScheme(scheme))
+	utilruntime.Must(istiosecurity.AddToScheme(scheme))
 
 	//+kubebuilder:scaffold:scheme
 }
@@ -72,15 +89,82 @@ func init() {
 func main() {
 	var metricsAddr string
 	var probeAddr string
+	var cleanupTimeout time.Duration
 	var configPath string
+	var configFile string
 	var syncPeriod time.Duration
+	var enableLeaderElection bool
+	var leaderElectionNamespace string
+	var leaseDuration time.Duration
+	var renewDeadline time.Duration
+	var retryPeriod time.Duration
+	var enableWebhook bool
+	var reconcileBaseDelay time.Duration
+	var reconcileMaxDelay time.Duration
+	var registryMaxConcurrentReconciles int
+	var namespaceMaxConcurrentReconciles int
+	var secretMaxConcurrentReconciles int
+	var propagateExternalSecret bool
+	var propagatedConfigMaps string
+	var configMapMaxConcurrentReconciles int
+	var deletionTimeout time.Duration
+	var otelEndpoint string
+	var auditLogPath string
+	var registrySyncPeriod time.Duration
+	var chartApplyGracePeriod time.Duration
+	var dryRun bool
+	var dryRunInput string
+	var dryRunFixtures string
+	var prune bool
+	var pruneRegistryURL string
+	var pruneCAFile string
+	var pruneMaxAgeDays int
+	var pruneMaxTagsPerRepository int
+	var watchNamespaces internalconfig.StringList
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
+	flag.DurationVar(&cleanupTimeout, "cleanup-timeout", 10*time.Second, "Timeout of the orphan deprecated resources cleanup run at startup.")
 	flag.StringVar(&configPath, "config-path", "", "Path to config file for dynamic reconfiguration.")
-	flag.DurationVar(&syncPeriod, "sync-period", 30*time.Minute, "Sync period for controller cache.")
+	flag.StringVar(&configFile, "config-file", "", "Path to the YAML file with values of the operator flags keyed by the flag names. Flags given on the command line take precedence.")
+	flag.DurationVar(&syncPeriod, "sync-period", operatorv1alpha1.DefaultSyncPeriod, "Sync period for controller cache.")
+	flag.DurationVar(&registrySyncPeriod, "registry-sync-period", operatorv1alpha1.DefaultSyncPeriod, "Period of the DockerRegistry CRs reconciliation used when the CR doesn't set spec.syncPeriod.")
+	flag.DurationVar(&chartApplyGracePeriod, "chart-apply-grace-period", 0, "How long the registry chart isn't re-applied when its values didn't change. Opt-in, the default zero re-applies the chart on every reconciliation.")
+	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
+		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&leaderElectionNamespace, "leader-election-namespace", "", "Namespace where the leader election lease is created. Defaults to the operator namespace.")
+	flag.DurationVar(&leaseDuration, "leader-election-lease-duration", 15*time.Second, "Duration that non-leader candidates will wait to force acquire leadership.")
+	flag.DurationVar(&renewDeadline, "leader-election-renew-deadline", 10*time.Second, "Duration that the acting leader will retry refreshing leadership before giving up.")
+	flag.DurationVar(&retryPeriod, "leader-election-retry-period", 2*time.Second, "Duration the leader election clients should wait between tries of actions.")
+	flag.BoolVar(&enableWebhook, "webhook-enabled", false, "Enable the DockerRegistry validating and defaulting webhooks. Requires serving certificates.")
+	flag.DurationVar(&reconcileBaseDelay, "reconcile-base-delay", backoff.DefaultBaseDelay, "Initial delay of the controller work queue retrying failed reconciliations, doubled after each failure.")
+	flag.DurationVar(&reconcileMaxDelay, "reconcile-max-delay", backoff.DefaultMaxDelay, "Maximum delay of the controller work queue retrying failed reconciliations.")
+	flag.IntVar(&registryMaxConcurrentReconciles, "registry-max-concurrent-reconciles", 1, "Maximum number of DockerRegistry CRs reconciled at once.")
+	flag.IntVar(&namespaceMaxConcurrentReconciles, "namespace-max-concurrent-reconciles", 1, "Maximum number of namespaces the registry secrets are propagated to at once.")
+	flag.IntVar(&secretMaxConcurrentReconciles, "secret-max-concurrent-reconciles", 1, "Maximum number of registry secrets reconciled at once.")
+	flag.IntVar(&configMapMaxConcurrentReconciles, "configmap-max-concurrent-reconciles", 1, "Maximum number of propagated ConfigMaps reconciled at once.")
+	flag.StringVar(&propagatedConfigMaps, "propagated-configmaps", "", "Comma-separated names of ConfigMaps from the kyma-system namespace copied to the namespaces receiving the registry secrets. No ConfigMap is copied when empty.")
+	flag.BoolVar(&propagateExternalSecret, "propagate-external-secret", true, "Copy the external registry access secret to all not excluded namespaces.")
+	flag.DurationVar(&deletionTimeout, "deletion-timeout", 5*time.Minute, "Duration the operator waits for the registry PVC to be released after the DockerRegistry CR is deleted.")
+	flag.StringVar(&otelEndpoint, "otel-endpoint", "", "The OTLP gRPC endpoint (host:port) the reconciliation traces are exported to. Tracing is disabled when empty.")
+	flag.StringVar(&auditLogPath, "audit-log-path", "", "Path to the file the DockerRegistry reconciliation audit records are written to. Audit log is disabled when empty.")
+	flag.BoolVar(&dryRun, "dry-run", false, "Reconcile the DockerRegistry CR from --dry-run-input against a fake cluster, print the resulting manifests and exit.")
+	flag.StringVar(&dryRunInput, "dry-run-input", "", "Path to the file with the DockerRegistry CR reconciled in the dry run.")
+	flag.StringVar(&dryRunFixtures, "dry-run-fixtures", "", "Path to the file with objects, for example Secrets, the fake cluster of the dry run is seeded with.")
+	flag.BoolVar(&prune, "prune", false, "Delete images selected by --prune-max-age-days and --prune-max-tags-per-repository from the registry and exit. Credentials are read from the REGISTRY_USERNAME and REGISTRY_PASSWORD environment variables.")
+	flag.StringVar(&pruneRegistryURL, "prune-registry-url", "", "The address of the registry API pruned by --prune.")
+	flag.StringVar(&pruneCAFile, "prune-ca-file", "", "Path to the PEM encoded certificate the registry serving certificate is verified with.")
+	flag.IntVar(&pruneMaxAgeDays, "prune-max-age-days", 0, "Delete images built more days ago. Zero means no limit.")
+	flag.IntVar(&pruneMaxTagsPerRepository, "prune-max-tags-per-repository", 0, "Keep only this number of most recently built tags in each repository. Zero means no limit.")
+	flag.Var(&watchNamespaces, "watch-namespace", "Namespace the operator watches, can be repeated. All namespaces are watched when not set.")
 	flag.Parse()
 
+	if configFile != "" {
+		if err := internalconfig.LoadFlags(flag.CommandLine, configFile); err != nil {
+			panic(errors.Wrapf(err, "unable to load flags from file: %s", configFile))
+		}
+	}
+
 	// Load ChartPath from environment
 	appCfg, err := internalconfig.GetConfig("")
 	if err != nil {
@@ -124,6 +208,23 @@ func main() {
 
 	zapLog := log.WithContext()
 
+	if dryRun {
+		os.Exit(runDryRun(zapLog, appCfg.ChartPath, dryRunInput, dryRunFixtures))
+	}
+
+	if prune {
+		os.Exit(runPrune(zapLog, pruning.Options{
+			RegistryURL: pruneRegistryURL,
+			Username:    os.Getenv("REGISTRY_USERNAME"),
+			Password:    os.Getenv("REGISTRY_PASSWORD"),
+			CAFile:      pruneCAFile,
+			Policy: pruning.Policy{
+				MaxAge:  time.Duration(pruneMaxAgeDays) * 24 * time.Hour,
+				MaxTags: pruneMaxTagsPerRepository,
+			},
+		}))
+	}
+
 	// Setup signal handler
 	signalCtx := ctrl.SetupSignalHandler()
 
@@ -132,11 +233,42 @@ func main() {
 		go config.ReconfigureOnConfigChange(signalCtx, zapLog, atomicLevel, configPath)
 	}
 
+	shutdownTracing, err := tracing.SetupTracerProvider(signalCtx, otelEndpoint)
+	if err != nil {
+		zapLog.Error("unable to set up tracing", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			zapLog.Error("while flushing traces", "error", err)
+		}
+	}()
+
+	var auditLog *audit.Logger
+	if auditLogPath != "" {
+		auditWriter := audit.NewFileWriter(auditLogPath)
+		defer auditWriter.Close()
+		auditLog = audit.New(auditWriter)
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
 	defer cancel()
 
+	configKubernetes, err := k8s.GetConfig(kubernetesConfigPrefix)
+	if err != nil {
+		zapLog.Error("unable to load secret propagation config from environment", "error", err)
+		os.Exit(1)
+	}
+	// flags given explicitly take precedence over the environment
+	if isFlagSet("propagated-configmaps") {
+		configKubernetes.BaseConfigMapNames = splitNames(propagatedConfigMaps)
+	}
+	if isFlagSet("propagate-external-secret") {
+		configKubernetes.PropagateExternalSecret = propagateExternalSecret
+	}
+
 	zapLog.Info("cleaning orphan deprecated resources")
-	err = cleanupOrphanDeprecatedResources(ctx)
+	err = cleanupOrphanDeprecatedResources(ctx, zapLog, configKubernetes)
 	if err != nil {
 		zapLog.Error("while removing orphan resources", "error", err)
 		os.Exit(1)
@@ -147,15 +279,23 @@ func main() {
 		Metrics: ctrlmetrics.Options{
 			BindAddress: metricsAddr,
 		},
-		HealthProbeBindAddress: probeAddr,
-		Cache: ctrlcache.Options{
-			SyncPeriod: &syncPeriod,
-		},
+		HealthProbeBindAddress:  probeAddr,
+		LeaderElection:          enableLeaderElection,
+		LeaderElectionID:        "dockerregistry-operator.kyma-project.io",
+		LeaderElectionNamespace: leaderElectionNamespace,
+		LeaseDuration:           &leaseDuration,
+		RenewDeadline:           &renewDeadline,
+		RetryPeriod:             &retryPeriod,
+		Cache:                   cacheOptions(syncPeriod, watchNamespaces),
+		NewCache:                metrics.NewCache,
 		Client: ctrlclient.Options{
 			Cache: &ctrlclient.CacheOptions{
+				// read from the API server, with --watch-namespace the operator Role limits the reads to the watched namespaces
 				DisableFor: []ctrlclient.Object{
 					&corev1.Secret{},
 					&corev1.ConfigMap{},
+					// only the registry and garbage collection pods are listed, caching would watch every pod in the cluster
+					&corev1.Pod{},
 				},
 			},
 		},
@@ -165,42 +305,82 @@ func main() {
 		os.Exit(1)
 	}
 
+	metrics.Register(registry.NewAPICollector(mgr.GetClient(), registry.APICheckTimeout))
+
+	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		zapLog.Error("unable to create kubernetes clientset", "error", err)
+		os.Exit(1)
+	}
+
 	reconciler := controllers.NewDockerRegistryReconciler(
-		mgr.GetClient(), mgr.GetConfig(),
-		mgr.GetEventRecorderFor("dockerregistry-operator"),
+		mgr.GetClient(), mgr.GetConfig(), clientset,
+		events.NewRateLimitedRecorder(mgr.GetEventRecorderFor("dockerregistry-operator"), events.DefaultWarningInterval),
 		zapLog,
+		auditLog,
 		appCfg.ChartPath,
+		deletionTimeout,
+		registrySyncPeriod,
+		chartApplyGracePeriod,
 	)
 
-	configKubernetes := k8s.Config{
-		BaseNamespace:                 "kyma-system",
-		BaseInternalSecretName:        registry.InternalAccessSecretName,
-		BaseExternalSecretName:        registry.ExternalAccessSecretName,
-		ExcludedNamespaces:            []string{"kyma-system"},
-		ConfigMapRequeueDuration:      time.Minute,
-		SecretRequeueDuration:         time.Minute,
-		ServiceAccountRequeueDuration: time.Minute,
-	}
-
 	resourceClient := internalresource.New(mgr.GetClient(), scheme)
 	secretSvc := k8s.NewSecretService(resourceClient, configKubernetes)
+	serviceAccountSvc := k8s.NewServiceAccountService(mgr.GetClient(), configKubernetes)
 
-	if err = reconciler.SetupWithManager(mgr); err != nil {
+	if err = reconciler.WithRateLimiter(backoff.NewRateLimiter(reconcileBaseDelay, reconcileMaxDelay)).
+		WithMaxConcurrentReconciles(registryMaxConcurrentReconciles).
+		SetupWithManager(mgr); err != nil {
 		zapLog.Error("unable to create controller", "controller", "DockerRegistry", "error", err)
 		os.Exit(1)
 	}
 
-	if err := k8s.NewNamespace(mgr.GetClient(), zapLog, configKubernetes, secretSvc).
+	if enableWebhook {
+		isExcludedNamespace, err := configKubernetes.ExcludedNamespaceMatcher()
+		if err != nil {
+			zapLog.Error("unable to create webhook", "webhook", "DockerRegistry", "error", err)
+			os.Exit(1)
+		}
+		deletionProtection := operatorv1alpha1.WithDeletionProtection(configKubernetes.GetPropagatedSecretNames(), isExcludedNamespace)
+		if err = (&operatorv1alpha1.DockerRegistry{}).SetupWebhookWithManager(mgr, deletionProtection); err != nil {
+			zapLog.Error("unable to create webhook", "webhook", "DockerRegistry", "error", err)
+			os.Exit(1)
+		}
+		conversion.SetupWebhookWithManager(mgr)
+	}
+
+	if err := k8s.NewNamespace(mgr.GetClient(), zapLog, configKubernetes, secretSvc, serviceAccountSvc).
+		WithRateLimiter(backoff.NewRateLimiter(reconcileBaseDelay, reconcileMaxDelay)).
+		WithMaxConcurrentReconciles(namespaceMaxConcurrentReconciles).
 		SetupWithManager(mgr); err != nil {
 		zapLog.Error("unable to create Namespace controller", "error", err)
 		os.Exit(1)
 	}
 
 	if err := k8s.NewSecret(mgr.GetClient(), zapLog, configKubernetes, secretSvc).
+		WithRateLimiter(backoff.NewRateLimiter(reconcileBaseDelay, reconcileMaxDelay)).
+		WithMaxConcurrentReconciles(secretMaxConcurrentReconciles).
 		SetupWithManager(mgr); err != nil {
 		zapLog.Error("unable to create Secret controller", "error", err)
 		os.Exit(1)
 	}
+
+	if len(configKubernetes.BaseConfigMapNames) != 0 {
+		configMapSvc := k8s.NewConfigMapService(resourceClient, configKubernetes)
+		if err := k8s.NewConfigMap(mgr.GetClient(), zapLog, configKubernetes, configMapSvc).
+			WithRateLimiter(backoff.NewRateLimiter(reconcileBaseDelay, reconcileMaxDelay)).
+			WithMaxConcurrentReconciles(configMapMaxConcurrentReconciles).
+			SetupWithManager(mgr); err != nil {
+			zapLog.Error("unable to create ConfigMap controller", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := k8s.NewDeployment(mgr.GetClient(), zapLog).
+		SetupWithManager(mgr); err != nil {
+		zapLog.Error("unable to create Deployment controller", "error", err)
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -219,7 +399,89 @@ func main() {
 	}
 }
 
-func cleanupOrphanDeprecatedResources(ctx context.Context) error {
+// runDryRun returns the exit code of the dry run, 1 means the DockerRegistry CR would not reconcile successfully
+func runDryRun(log *uberzap.SugaredLogger, chartPath, inputPath, fixturesPath string) int {
+	if inputPath == "" {
+		log.Error("--dry-run-input is required in the dry run")
+		return 1
+	}
+
+	chartVersion, err := internalconfig.GetChartVersion(chartPath)
+	if err != nil {
+		log.Warnf("while reading chart version: %s", err.Error())
+	}
+
+	err = dryrun.Run(context.Background(), log, scheme, dryrun.Options{
+		InputPath:       inputPath,
+		FixturesPath:    fixturesPath,
+		ChartPath:       chartPath,
+		OperatorVersion: controllers.OperatorVersion,
+		ChartVersion:    chartVersion,
+	}, os.Stdout)
+	if err != nil {
+		log.Error("dry run failed", "error", err)
+		return 1
+	}
+	return 0
+}
+
+func runPrune(log *uberzap.SugaredLogger, opts pruning.Options) int {
+	if opts.RegistryURL == "" {
+		log.Error("--prune-registry-url is required to prune the registry")
+		return 1
+	}
+	if opts.Policy == (pruning.Policy{}) {
+		log.Error("--prune-max-age-days or --prune-max-tags-per-repository is required to prune the registry")
+		return 1
+	}
+
+	if err := pruning.Run(ctrl.SetupSignalHandler(), log, opts); err != nil {
+		log.Error("pruning failed", "error", err)
+		return 1
+	}
+	return 0
+}
+
+// cacheOptions limits the informers of namespaced objects to the watched namespaces,
+// all namespaces are watched when none is given
+func cacheOptions(syncPeriod time.Duration, watchNamespaces []string) ctrlcache.Options {
+	opts := ctrlcache.Options{
+		SyncPeriod: &syncPeriod,
+	}
+	if len(watchNamespaces) == 0 {
+		return opts
+	}
+
+	opts.DefaultNamespaces = map[string]ctrlcache.Config{}
+	for _, namespace := range watchNamespaces {
+		opts.DefaultNamespaces[namespace] = ctrlcache.Config{}
+	}
+	return opts
+}
+
+// isFlagSet returns true if the flag was given on the command line or in the config file
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// splitNames returns not empty names from the comma-separated list
+func splitNames(list string) []string {
+	names := []string{}
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func cleanupOrphanDeprecatedResources(ctx context.Context, logger *uberzap.SugaredLogger, config k8s.Config) error {
 	// We are going to talk to the API server _before_ we start the manager.
 	// Since the default manager client reads from cache, we will get an error.
 	// So, we create a "serverClient" that would read from the API directly.
@@ -231,5 +493,23 @@ func cleanupOrphanDeprecatedResources(ctx context.Context) error {
 		return errors.Wrap(err, "failed to create a server client")
 	}
 
-	return gitrepository.Cleanup(ctx, serverClient)
+	if err := gitrepository.Cleanup(ctx, serverClient); err != nil {
+		return err
+	}
+
+	// pull secrets could be left in namespaces excluded since the previous run, the leftovers don't break
+	// the registry so the operator starts anyway and the cleanup is retried on its next start
+	if err := cleanupOrphanPullSecrets(ctx, logger, serverClient, config); err != nil {
+		logger.Warnf("while removing orphan pull secrets: %s", err.Error())
+	}
+	return nil
+}
+
+func cleanupOrphanPullSecrets(ctx context.Context, logger *uberzap.SugaredLogger, serverClient ctrlclient.Client, config k8s.Config) error {
+	selected, err := k8s.GetNamespaceFilter(ctx, serverClient)
+	if err != nil {
+		return err
+	}
+	secretSvc := k8s.NewSecretService(internalresource.New(serverClient, scheme), config)
+	return errors.Wrap(secretSvc.CleanupOrphanSecrets(ctx, logger, selected), "failed to clean up orphan pull secrets")
 }
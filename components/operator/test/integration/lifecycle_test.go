@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/internal/registry"
+)
+
+const (
+	namespaceName = "kyma-system"
+	crName        = "default"
+	resourceName  = "dockerregistry"
+
+	timeout  = 30 * time.Second
+	interval = time.Second
+)
+
+var _ = Describe("DockerRegistry lifecycle", Ordered, func() {
+	ctx := context.Background()
+	crKey := client.ObjectKey{Name: crName, Namespace: namespaceName}
+
+	BeforeAll(func() {
+		namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName}}
+		Expect(k8sClient.Create(ctx, namespace)).To(Succeed())
+	})
+
+	It("installs the registry", func() {
+		dockerRegistry := &v1alpha1.DockerRegistry{
+			ObjectMeta: metav1.ObjectMeta{Name: crName, Namespace: namespaceName},
+		}
+		Expect(k8sClient.Create(ctx, dockerRegistry)).To(Succeed())
+
+		Eventually(getObjectFunc(ctx, client.ObjectKey{Name: registry.DeploymentName, Namespace: namespaceName}, &appsv1.Deployment{})).
+			WithTimeout(timeout).WithPolling(interval).Should(Succeed())
+		Eventually(getObjectFunc(ctx, client.ObjectKey{Name: resourceName, Namespace: namespaceName}, &corev1.Service{})).
+			WithTimeout(timeout).WithPolling(interval).Should(Succeed())
+		Eventually(getObjectFunc(ctx, client.ObjectKey{Name: registry.InternalAccessSecretName, Namespace: namespaceName}, &corev1.Secret{})).
+			WithTimeout(timeout).WithPolling(interval).Should(Succeed())
+
+		Eventually(func(g Gomega) {
+			stored := &v1alpha1.DockerRegistry{}
+			g.Expect(k8sClient.Get(ctx, crKey, stored)).To(Succeed())
+			g.Expect(stored.GetFinalizers()).NotTo(BeEmpty())
+			g.Expect(stored.Status.State).NotTo(BeEmpty())
+		}).WithTimeout(timeout).WithPolling(interval).Should(Succeed())
+	})
+
+	It("applies the spec change", func() {
+		stored := &v1alpha1.DockerRegistry{}
+		Expect(k8sClient.Get(ctx, crKey, stored)).To(Succeed())
+		stored.Spec.CommonLabels = map[string]string{"cost-center": "1234"}
+		Expect(k8sClient.Update(ctx, stored)).To(Succeed())
+
+		Eventually(func(g Gomega) {
+			deployment := &appsv1.Deployment{}
+			g.Expect(k8sClient.Get(ctx, client.ObjectKey{Name: registry.DeploymentName, Namespace: namespaceName}, deployment)).To(Succeed())
+			g.Expect(deployment.GetLabels()).To(HaveKeyWithValue("cost-center", "1234"))
+		}).WithTimeout(timeout).WithPolling(interval).Should(Succeed())
+	})
+
+	It("uninstalls the registry", func() {
+		stored := &v1alpha1.DockerRegistry{}
+		Expect(k8sClient.Get(ctx, crKey, stored)).To(Succeed())
+		Expect(k8sClient.Delete(ctx, stored)).To(Succeed())
+
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, crKey, &v1alpha1.DockerRegistry{})
+			return k8serrors.IsNotFound(err)
+		}).WithTimeout(timeout).WithPolling(interval).Should(BeTrue())
+		Eventually(func() bool {
+			err := k8sClient.Get(ctx, client.ObjectKey{Name: registry.DeploymentName, Namespace: namespaceName}, &appsv1.Deployment{})
+			return k8serrors.IsNotFound(err)
+		}).WithTimeout(timeout).WithPolling(interval).Should(BeTrue())
+	})
+})
+
+func getObjectFunc(ctx context.Context, key client.ObjectKey, obj client.Object) func() error {
+	return func() error {
+		return k8sClient.Get(ctx, key, obj)
+	}
+}
@@ -0,0 +1,110 @@
+package integration
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	uberzap "go.uber.org/zap"
+	istionetworking "istio.io/client-go/pkg/apis/networking/v1beta1"
+	istiosecurity "istio.io/client-go/pkg/apis/security/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
+	"github.com/kyma-project/docker-registry/components/operator/controllers"
+)
+
+// The suite runs the operator manager against the envtest API server to cover what the fake client can't,
+// like watches, predicates and the status subresource
+
+var (
+	k8sClient client.Client
+	testEnv   *envtest.Environment
+
+	cancelSuiteCtx context.CancelFunc
+)
+
+func TestIntegration(t *testing.T) {
+	RegisterFailHandler(Fail)
+
+	RunSpecs(t, "Integration Suite")
+}
+
+var _ = BeforeSuite(func() {
+	logf.SetLogger(zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)))
+
+	By("bootstrapping test environment")
+	testEnv = &envtest.Environment{
+		CRDDirectoryPaths: []string{
+			filepath.Join("..", "..", "..", "..", "config", "operator", "base", "crd", "bases"),
+		},
+		BinaryAssetsDirectory: filepath.Join("..", "..", "..", "..", "bin", "k8s", "kubebuilder_assets"),
+		ErrorIfCRDPathMissing: true,
+	}
+
+	config, err := testEnv.Start()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(config).NotTo(BeNil())
+
+	scheme := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	Expect(v1alpha1.AddToScheme(scheme)).To(Succeed())
+	Expect(istionetworking.AddToScheme(scheme)).To(Succeed())
+	Expect(istiosecurity.AddToScheme(scheme)).To(Succeed())
+
+	k8sClient, err = client.New(config, client.Options{Scheme: scheme})
+	Expect(err).NotTo(HaveOccurred())
+
+	k8sManager, err := ctrl.NewManager(config, ctrl.Options{
+		Scheme:  scheme,
+		Metrics: metricsserver.Options{BindAddress: "0"},
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	reconcilerLogger, err := uberzap.NewDevelopment()
+	Expect(err).NotTo(HaveOccurred())
+
+	chartPath := filepath.Join("..", "..", "..", "..", "config", "docker-registry")
+	err = controllers.NewDockerRegistryReconciler(
+		k8sManager.GetClient(),
+		k8sManager.GetConfig(),
+		kubernetes.NewForConfigOrDie(k8sManager.GetConfig()),
+		record.NewFakeRecorder(1000),
+		reconcilerLogger.Sugar(),
+		nil,
+		chartPath,
+		time.Minute,
+		v1alpha1.DefaultSyncPeriod,
+		0).
+		SetupWithManager(k8sManager)
+	Expect(err).NotTo(HaveOccurred())
+
+	var suiteCtx context.Context
+	suiteCtx, cancelSuiteCtx = context.WithCancel(context.Background())
+	go func() {
+		defer GinkgoRecover()
+
+		Expect(k8sManager.Start(suiteCtx)).To(Succeed(), "failed to run manager")
+	}()
+})
+
+var _ = AfterSuite(func() {
+	if cancelSuiteCtx != nil {
+		cancelSuiteCtx()
+	}
+
+	By("tearing down the test environment")
+	Expect(testEnv.Stop()).To(Succeed())
+})
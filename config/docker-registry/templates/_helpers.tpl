@@ -79,3 +79,90 @@ Create a URL for container images
 {{- $version := ternary (print ":" $.img.version) (print "@sha256:" $.img.sha) (empty $.img.sha) -}}
 {{- print $path "/" $.img.name $version -}}
 {{- end -}}
+
+{{/*
+Create the registry image, image.repository overrides images.registry
+*/}}
+{{- define "registryimage" -}}
+{{- if .Values.image.repository -}}
+{{- print .Values.image.repository ":" (default .Values.images.registry.version .Values.image.tag) -}}
+{{- else -}}
+{{- include "imageurl" (dict "reg" .Values.containerRegistry "img" .Values.images.registry) -}}
+{{- end -}}
+{{- end -}}
+
+{{/*
+Renders registry storage environment variables for the configured storage backend.
+Usage:
+{{- include "docker-registry.storageEnv" . | trim | nindent 12 }}
+*/}}
+{{- define "docker-registry.storageEnv" -}}
+{{- if eq .Values.storage "filesystem" }}
+- name: REGISTRY_STORAGE_FILESYSTEM_ROOTDIRECTORY
+  value: "/var/lib/registry"
+{{- else if eq .Values.storage "azure" }}
+- name: REGISTRY_STORAGE_AZURE_ACCOUNTNAME
+  valueFrom:
+    secretKeyRef:
+      name: {{ template "docker-registry.fullname" . }}-secret
+      key: azureAccountName
+- name: REGISTRY_STORAGE_AZURE_ACCOUNTKEY
+  valueFrom:
+    secretKeyRef:
+      name: {{ template "docker-registry.fullname" . }}-secret
+      key: azureAccountKey
+- name: REGISTRY_STORAGE_AZURE_CONTAINER
+  valueFrom:
+    secretKeyRef:
+      name: {{ template "docker-registry.fullname" . }}-secret
+      key: azureContainer
+{{- else if eq .Values.storage "s3" }}
+{{- if and .Values.secrets.s3.secretKey .Values.secrets.s3.accessKey }}
+- name: REGISTRY_STORAGE_S3_ACCESSKEY
+  valueFrom:
+    secretKeyRef:
+      name: {{ template "docker-registry.fullname" . }}-secret
+      key: s3AccessKey
+- name: REGISTRY_STORAGE_S3_SECRETKEY
+  valueFrom:
+    secretKeyRef:
+      name: {{ template "docker-registry.fullname" . }}-secret
+      key: s3SecretKey
+{{- end }}
+- name: REGISTRY_STORAGE_S3_REGION
+  value: {{ required ".Values.s3.region is required" .Values.s3.region }}
+{{- if .Values.s3.regionEndpoint }}
+- name: REGISTRY_STORAGE_S3_REGIONENDPOINT
+  value: {{ .Values.s3.regionEndpoint }}
+{{- end }}
+- name: REGISTRY_STORAGE_S3_BUCKET
+  value: {{ required ".Values.s3.bucket is required" .Values.s3.bucket }}
+{{- if .Values.s3.encrypt }}
+- name: REGISTRY_STORAGE_S3_ENCRYPT
+  value: {{ .Values.s3.encrypt | quote }}
+{{- if .Values.s3.keyID }}
+- name: REGISTRY_STORAGE_S3_KEYID
+  value: {{ .Values.s3.keyID | quote }}
+{{- end }}
+{{- end }}
+{{- if .Values.s3.secure }}
+- name: REGISTRY_STORAGE_S3_SECURE
+  value: {{ .Values.s3.secure | quote }}
+{{- end }}
+{{- else if eq .Values.storage "gcs" }}
+{{- if .Values.secrets.gcs.accountkey }}
+- name: REGISTRY_STORAGE_GCS_KEYFILE
+  value: /gcs_secret/keyfile.json
+{{- end }}
+- name: REGISTRY_STORAGE_GCS_BUCKET
+  value: {{ required ".Values.gcs.bucket is required" .Values.gcs.bucket }}
+{{- if .Values.gcs.rootdirectory }}
+- name: REGISTRY_STORAGE_GCS_ROOTDIRECTORY
+  value: {{ .Values.gcs.rootdirectory }}
+{{- end }}
+{{- if .Values.gcs.chunkSize }}
+- name: REGISTRY_STORAGE_GCS_CHUNKSIZE
+  value: {{ .Values.gcs.chunkSize}}
+{{- end }}
+{{- end }}
+{{- end -}}
@@ -7,3 +7,13 @@ images:
 - name: europe-docker.pkg.dev/kyma-project/prod/dockerregistry-operator
   newName: local-registry
   newTag: local
+# copy the manager image to the env of the operator, it runs the pruning jobs
+replacements:
+- source:
+    kind: Deployment
+    fieldPath: spec.template.spec.containers.[name=manager].image
+  targets:
+  - select:
+      kind: Deployment
+    fieldPaths:
+    - spec.template.spec.containers.[name=manager].env.[name=DOCKERREGISTRY_OPERATOR_IMAGE].value
@@ -0,0 +1,125 @@
+package image
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// registryClient implements the part of the registry HTTP API needed to push and pull a single image
+type registryClient struct {
+	ctx      context.Context
+	baseURL  *url.URL
+	username string
+	password string
+	repo     string
+}
+
+func (c *registryClient) pushBlob(data []byte) error {
+	resp, err := c.do(http.MethodPost, c.url(fmt.Sprintf("/v2/%s/blobs/uploads/", c.repo)), nil, "")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("unexpected status '%s' while starting blob upload", resp.Status)
+	}
+
+	location, err := c.baseURL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return fmt.Errorf("while parsing upload location: %w", err)
+	}
+	query := location.Query()
+	query.Set("digest", digest(data))
+	location.RawQuery = query.Encode()
+
+	resp, err = c.do(http.MethodPut, location, data, "application/octet-stream")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected status '%s' while uploading blob", resp.Status)
+	}
+	return nil
+}
+
+// pushManifest uploads the manifest under the tag and returns the digest computed by the registry
+func (c *registryClient) pushManifest(tag string, manifest []byte) (string, error) {
+	resp, err := c.do(http.MethodPut, c.url(fmt.Sprintf("/v2/%s/manifests/%s", c.repo, tag)), manifest, manifestMediaType)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("unexpected status '%s' while uploading manifest", resp.Status)
+	}
+	return resp.Header.Get("Docker-Content-Digest"), nil
+}
+
+func (c *registryClient) pullManifest(reference string) ([]byte, error) {
+	return c.get(fmt.Sprintf("/v2/%s/manifests/%s", c.repo, reference), manifestMediaType)
+}
+
+func (c *registryClient) pullBlob(digest string) ([]byte, error) {
+	return c.get(fmt.Sprintf("/v2/%s/blobs/%s", c.repo, digest), "")
+}
+
+// deleteManifest returns false if the registry doesn't allow deleting images
+func (c *registryClient) deleteManifest(digest string) (bool, error) {
+	resp, err := c.do(http.MethodDelete, c.url(fmt.Sprintf("/v2/%s/manifests/%s", c.repo, digest)), nil, "")
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusAccepted:
+		return true, nil
+	case http.StatusMethodNotAllowed:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected status '%s' while deleting manifest", resp.Status)
+	}
+}
+
+func (c *registryClient) get(path, accept string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(c.ctx, http.MethodGet, c.url(path).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	req.SetBasicAuth(c.username, c.password)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status '%s' while getting '%s'", resp.Status, path)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (c *registryClient) do(method string, u *url.URL, body []byte, contentType string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(c.ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.SetBasicAuth(c.username, c.password)
+
+	return http.DefaultClient.Do(req)
+}
+
+func (c *registryClient) url(path string) *url.URL {
+	return c.baseURL.JoinPath(path)
+}
@@ -0,0 +1,107 @@
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	manifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	configMediaType   = "application/vnd.oci.image.config.v1+json"
+	layerMediaType    = "application/vnd.oci.image.layer.v1.tar+gzip"
+)
+
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int    `json:"size"`
+}
+
+type manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	Config        descriptor   `json:"config"`
+	Layers        []descriptor `json:"layers"`
+}
+
+// testImage is a single layer OCI image built in memory, so the test doesn't need any image source
+type testImage struct {
+	config   []byte
+	layer    []byte
+	manifest []byte
+}
+
+func newTestImage(content string) (*testImage, error) {
+	layerTar, err := tarFile("test.txt", []byte(content))
+	if err != nil {
+		return nil, err
+	}
+
+	layer, err := gzipData(layerTar)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := json.Marshal(map[string]interface{}{
+		"architecture": "amd64",
+		"os":           "linux",
+		"rootfs": map[string]interface{}{
+			"type":     "layers",
+			"diff_ids": []string{digest(layerTar)},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := json.Marshal(manifest{
+		SchemaVersion: 2,
+		MediaType:     manifestMediaType,
+		Config:        descriptor{MediaType: configMediaType, Digest: digest(config), Size: len(config)},
+		Layers:        []descriptor{{MediaType: layerMediaType, Digest: digest(layer), Size: len(layer)}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &testImage{
+		config:   config,
+		layer:    layer,
+		manifest: manifest,
+	}, nil
+}
+
+func tarFile(name string, content []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := tar.NewWriter(buf)
+	if err := w.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gzipData(data []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func digest(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
@@ -0,0 +1,153 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+
+	"github.com/kyma-project/docker-registry/tests/operator/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	internalAccessSecretName = "dockerregistry-config"
+	registryPort             = 5000
+	testRepository           = "e2e/smoke"
+	testTag                  = "latest"
+)
+
+// VerifyPushAndPull pushes the test image to the registry through the port-forward to the registry pod
+// using the credentials from the internal access secret, pulls it back and compares the digests
+func VerifyPushAndPull(testutils *utils.TestUtils) error {
+	username, password, err := getCredentials(testutils)
+	if err != nil {
+		return err
+	}
+
+	podName, err := getRegistryPodName(testutils)
+	if err != nil {
+		return err
+	}
+
+	localPort, stop, err := utils.PortForward(testutils, podName, registryPort)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	img, err := newTestImage(testutils.Namespace)
+	if err != nil {
+		return err
+	}
+
+	c := &registryClient{
+		ctx:      testutils.Ctx,
+		baseURL:  &url.URL{Scheme: "http", Host: fmt.Sprintf("127.0.0.1:%d", localPort)},
+		username: username,
+		password: password,
+		repo:     testRepository,
+	}
+
+	testutils.Logger.Infof("Pushing image '%s:%s'", testRepository, testTag)
+	pushedDigest, err := push(c, img)
+	if err != nil {
+		return err
+	}
+
+	testutils.Logger.Infof("Pulling image '%s:%s'", testRepository, testTag)
+	if err := pull(c, pushedDigest, img); err != nil {
+		return err
+	}
+
+	deleted, err := c.deleteManifest(pushedDigest)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		testutils.Logger.Infof("Registry doesn't allow deleting images, image '%s' is removed with the registry", pushedDigest)
+	}
+	return nil
+}
+
+func push(c *registryClient, img *testImage) (string, error) {
+	if err := c.pushBlob(img.layer); err != nil {
+		return "", err
+	}
+	if err := c.pushBlob(img.config); err != nil {
+		return "", err
+	}
+
+	pushedDigest, err := c.pushManifest(testTag, img.manifest)
+	if err != nil {
+		return "", err
+	}
+	if pushedDigest != digest(img.manifest) {
+		return "", fmt.Errorf("registry computed manifest digest '%s', expected '%s'", pushedDigest, digest(img.manifest))
+	}
+	return pushedDigest, nil
+}
+
+func pull(c *registryClient, pushedDigest string, img *testImage) error {
+	pulledManifest, err := c.pullManifest(testTag)
+	if err != nil {
+		return err
+	}
+	if pulledDigest := digest(pulledManifest); pulledDigest != pushedDigest {
+		return fmt.Errorf("pulled manifest digest '%s', pushed '%s'", pulledDigest, pushedDigest)
+	}
+
+	pulledLayer, err := c.pullBlob(digest(img.layer))
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(pulledLayer, img.layer) {
+		return fmt.Errorf("pulled layer digest '%s', pushed '%s'", digest(pulledLayer), digest(img.layer))
+	}
+	return nil
+}
+
+func getCredentials(testutils *utils.TestUtils) (string, string, error) {
+	var secret corev1.Secret
+	objectKey := client.ObjectKey{
+		Name:      internalAccessSecretName,
+		Namespace: testutils.Namespace,
+	}
+
+	if err := testutils.Client.Get(testutils.Ctx, objectKey, &secret); err != nil {
+		return "", "", err
+	}
+
+	return string(secret.Data["username"]), string(secret.Data["password"]), nil
+}
+
+func getRegistryPodName(testutils *utils.TestUtils) (string, error) {
+	var deploy appsv1.Deployment
+	objectKey := client.ObjectKey{
+		Name:      testutils.DockerregistryDeployName,
+		Namespace: testutils.Namespace,
+	}
+	if err := testutils.Client.Get(testutils.Ctx, objectKey, &deploy); err != nil {
+		return "", err
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(deploy.Spec.Selector)
+	if err != nil {
+		return "", err
+	}
+
+	var pods corev1.PodList
+	if err := testutils.Client.List(testutils.Ctx, &pods,
+		client.InNamespace(testutils.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return "", err
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodRunning && pod.DeletionTimestamp == nil {
+			return pod.GetName(), nil
+		}
+	}
+	return "", fmt.Errorf("no running pod of deployment '%s' found", testutils.DockerregistryDeployName)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
 	"github.com/kyma-project/docker-registry/tests/operator/dockerregistry"
+	"github.com/kyma-project/docker-registry/tests/operator/image"
 	"github.com/kyma-project/docker-registry/tests/operator/logger"
 	"github.com/kyma-project/docker-registry/tests/operator/namespace"
 	"github.com/kyma-project/docker-registry/tests/operator/utils"
@@ -35,12 +36,19 @@ func main() {
 		os.Exit(1)
 	}
 
+	restConfig, err := utils.LoadRestConfig("")
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+
 	log.Info("Start scenario")
 	err = runScenario(&utils.TestUtils{
-		Namespace: fmt.Sprintf("dockerregistry-test-%s", uuid.New().String()),
-		Ctx:       ctx,
-		Client:    client,
-		Logger:    log,
+		Namespace:  fmt.Sprintf("dockerregistry-test-%s", uuid.New().String()),
+		Ctx:        ctx,
+		Client:     client,
+		RestConfig: restConfig,
+		Logger:     log,
 
 		Name:                     "default-test",
 		DockerregistryDeployName: "dockerregistry",
@@ -72,6 +80,12 @@ func runScenario(testutil *utils.TestUtils) error {
 		return err
 	}
 
+	// push and pull image
+	testutil.Logger.Infof("Verifying image push and pull to dockerregistry '%s'", testutil.Name)
+	if err := image.VerifyPushAndPull(testutil); err != nil {
+		return err
+	}
+
 	// update Docker Registry with other spec
 	testutil.Logger.Infof("Updating dockerregistry '%s'", testutil.Name)
 	if err := dockerregistry.Update(testutil); err != nil {
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// PortForward forwards a random local port to the pod port and returns the local port,
+// the returned stop function closes the forwarding
+func PortForward(utils *TestUtils, podName string, podPort int) (uint16, func(), error) {
+	clientset, err := kubernetes.NewForConfig(utils.RestConfig)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	transport, upgrader, err := spdy.RoundTripperFor(utils.RestConfig)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	url := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(utils.Namespace).
+		Name(podName).
+		SubResource("portforward").
+		URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, url)
+
+	stopCh := make(chan struct{})
+	readyCh := make(chan struct{})
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{fmt.Sprintf("0:%d", podPort)},
+		stopCh, readyCh, io.Discard, io.Discard)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- forwarder.ForwardPorts()
+	}()
+
+	select {
+	case <-readyCh:
+	case err := <-errCh:
+		return 0, nil, fmt.Errorf("while forwarding port of pod '%s': %w", podName, err)
+	case <-utils.Ctx.Done():
+		close(stopCh)
+		return 0, nil, utils.Ctx.Err()
+	}
+
+	ports, err := forwarder.GetPorts()
+	if err != nil {
+		close(stopCh)
+		return 0, nil, err
+	}
+
+	return ports[0].Local, func() { close(stopCh) }, nil
+}
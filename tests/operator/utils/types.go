@@ -5,6 +5,7 @@ import (
 
 	"github.com/kyma-project/docker-registry/components/operator/api/v1alpha1"
 	"go.uber.org/zap"
+	"k8s.io/client-go/rest"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -12,6 +13,8 @@ type TestUtils struct {
 	Ctx    context.Context
 	Logger *zap.SugaredLogger
 	Client client.Client
+	// RestConfig is used to forward ports of the registry pods
+	RestConfig *rest.Config
 
 	Namespace                string
 	Name                     string